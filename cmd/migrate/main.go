@@ -90,11 +90,45 @@ func main() {
 		&domain.LeasingRequest{},
 		&domain.Review{},
 		&domain.ReviewImage{},
+		&domain.ReviewHelpfulVote{},
+		&domain.ReviewReport{},
+		&domain.ReviewModeration{},
+		&domain.RecoveryCode{},
+		&domain.UserIdentity{},
+		&domain.DormManager{},
+		&domain.DelegatedAction{},
+		&domain.Notification{},
+		&domain.NotificationPreference{},
+		&domain.Thread{},
+		&domain.ThreadMessage{},
+		&domain.ThreadRead{},
+		&domain.ThreadReport{},
+		&domain.UserBlock{},
+		&domain.EmailOutbox{},
 		&domain.Receipt{},
 		&domain.SupportRequest{},
+		&domain.SavedSearch{},
+		&domain.SavedSearchMatch{},
+		&domain.Shortlist{},
+		&domain.ShortlistItem{},
+		&domain.DormPriceHistory{},
+		&domain.ReminderLog{},
+		&domain.Webhook{},
+		&domain.WebhookDelivery{},
+		&domain.LineAccount{},
+		&domain.DormView{},
+		&domain.LessorReportLog{},
+		&domain.Announcement{},
+		&domain.AnnouncementAttachment{},
+		&domain.AnnouncementRecipient{},
 	); err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 
+	// Seed price history with the current price of dorms created before it was recorded
+	db.Exec(`INSERT INTO dorm_price_histories (dorm_id, price, create_at)
+		SELECT id, price, create_at FROM dorms
+		WHERE NOT EXISTS (SELECT 1 FROM dorm_price_histories WHERE dorm_price_histories.dorm_id = dorms.id)`)
+
 	fmt.Println("Migration completed")
 }
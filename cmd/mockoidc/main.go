@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/PitiNarak/condormhub-backend/pkg/oidc/oidctest"
+)
+
+// A local OpenID Connect provider to develop "Sign in with Google" against.
+// Point GOOGLE_ISSUER at the printed URL and use the same client id.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9000", "address to listen on")
+	clientID := flag.String("client-id", "condormhub-local", "client id to accept")
+	subject := flag.String("sub", "mock-user-1", "subject of the logged in user")
+	email := flag.String("email", "student@example.com", "email of the logged in user")
+	name := flag.String("name", "Mock Student", "name of the logged in user")
+	verified := flag.Bool("email-verified", true, "whether the email is verified")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Unable to listen on %s: %v", *addr, err)
+	}
+
+	server := oidctest.NewUnstartedServer(*clientID, oidctest.User{
+		Subject:       *subject,
+		Email:         *email,
+		EmailVerified: *verified,
+		Name:          *name,
+	})
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	log.Printf("Mock OIDC provider running at %s for client %s", server.URL, *clientID)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook/webhooktest"
+)
+
+// A local endpoint to register as a webhook while developing. Run the server
+// with WEBHOOK_ALLOW_LOCAL=true so it accepts the http:// URL printed here.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9001", "address to listen on")
+	secret := flag.String("secret", "", "secret to check signatures with, empty accepts every delivery")
+	status := flag.Int("status", 204, "status to answer deliveries with")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Unable to listen on %s: %v", *addr, err)
+	}
+
+	server := webhooktest.NewUnstartedServer(*secret)
+	server.RespondWith(*status)
+	server.OnDelivery(func(d webhooktest.Delivery) {
+		log.Printf("%s delivery %s (signature valid: %t)\n%s", d.Event, d.ID, d.Valid, d.Body)
+	})
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	log.Printf("Mock webhook endpoint running at %s", server.URL)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+}
@@ -3,25 +3,36 @@ package config
 import (
 	"log"
 
+	"github.com/PitiNarak/condormhub-backend/internal/core/services"
 	"github.com/PitiNarak/condormhub-backend/internal/database"
 	"github.com/PitiNarak/condormhub-backend/internal/server"
 	"github.com/PitiNarak/condormhub-backend/pkg/email"
 	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
+	"github.com/PitiNarak/condormhub-backend/pkg/line"
+	"github.com/PitiNarak/condormhub-backend/pkg/oidc"
 	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/PitiNarak/condormhub-backend/pkg/sms"
 	"github.com/PitiNarak/condormhub-backend/pkg/storage"
 	"github.com/PitiNarak/condormhub-backend/pkg/stripe"
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook"
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
 )
 
 type AppConfig struct {
-	SMTP         email.SMTPConfig `envPrefix:"SMTP_"`
-	JWT          jwt.JWTConfig    `envPrefix:"JWT_"`
-	Server       server.Config    `envPrefix:"SERVER_"`
-	Database     database.Config  `envPrefix:"DB_"`
-	Storage      storage.Config   `envPrefix:"STORAGE_"`
-	StripeConfig stripe.Config    `envPrefix:"STRIPE_"`
-	Redis        redis.Config     `envPrefix:"REDIS_"`
+	SMTP         email.SMTPConfig        `envPrefix:"SMTP_"`
+	Mailer       email.MailerConfig      `envPrefix:"MAILER_"`
+	JWT          jwt.JWTConfig           `envPrefix:"JWT_"`
+	Server       server.Config           `envPrefix:"SERVER_"`
+	Database     database.Config         `envPrefix:"DB_"`
+	Storage      storage.Config          `envPrefix:"STORAGE_"`
+	StripeConfig stripe.Config           `envPrefix:"STRIPE_"`
+	Redis        redis.Config            `envPrefix:"REDIS_"`
+	Google       oidc.Config             `envPrefix:"GOOGLE_"`
+	SMS          sms.Config              `envPrefix:"SMS_"`
+	Line         line.Config             `envPrefix:"LINE_"`
+	Reminder     services.ReminderConfig `envPrefix:"REMINDER_"`
+	Webhook      webhook.Config          `envPrefix:"WEBHOOK_"`
 }
 
 // Load configs from .env file
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DormPriceHistory records every price a dorm has been listed at.
+type DormPriceHistory struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt time.Time `gorm:"autoCreateTime;index"`
+	DormID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Price    float64   `gorm:"not null"`
+}
+
+// RentPrice is a price a dorm was listed or leased at from At. RemovedAt is
+// when the dorm was deleted, nil while it is listed.
+type RentPrice struct {
+	DormID    uuid.UUID
+	Price     float64
+	At        time.Time
+	RemovedAt *time.Time
+}
+
+// RentStat is the rent distribution of a group of prices.
+type RentStat struct {
+	P25    float64
+	Median float64
+	P75    float64
+	Count  int
+}
+
+type RentFilter struct {
+	Province string
+	District string
+	Bedrooms int // -1 for any
+	Interval string
+	From     time.Time
+	To       time.Time
+}
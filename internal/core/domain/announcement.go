@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"io"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Announcement is a message from a lessor to every current tenant of a dorm,
+// e.g. a water shutoff. It goes out at PublishAt, PublishedAt is set once it did.
+type Announcement struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt    time.Time `gorm:"autoCreateTime"`
+	UpdateAt    time.Time `gorm:"autoUpdateTime"`
+	DormID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	Dorm        Dorm      `gorm:"foreignKey:DormID;references:ID"`
+	AuthorID    uuid.UUID `gorm:"type:uuid;not null"`
+	Title       string    `gorm:"not null"`
+	Body        string    `gorm:"type:text;not null"`
+	PublishAt   time.Time `gorm:"not null;index"`
+	PublishedAt *time.Time
+	Attachments []AnnouncementAttachment `gorm:"foreignKey:AnnouncementID"`
+}
+
+type AnnouncementAttachment struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt       time.Time `gorm:"autoCreateTime"`
+	AnnouncementID uuid.UUID `gorm:"type:uuid;not null;index"`
+	FileKey        string    `gorm:"type:text;not null"`
+	FileName       string    `gorm:"not null"`
+	ContentType    string    `gorm:"not null"`
+}
+
+// AnnouncementRecipient is a tenant an announcement was sent to, through the
+// lease they had when it was published. ReadAt is set once they read it.
+type AnnouncementRecipient struct {
+	AnnouncementID   uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	Announcement     Announcement `gorm:"foreignKey:AnnouncementID;references:ID"`
+	UserID           uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	LeasingHistoryID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	CreateAt         time.Time    `gorm:"autoCreateTime"`
+	ReadAt           *time.Time
+}
+
+// AnnouncementStats is how many tenants received an announcement and read it.
+type AnnouncementStats struct {
+	Recipients int64
+	Read       int64
+}
+
+// AnnouncementFile is an attachment uploaded with a new announcement.
+type AnnouncementFile struct {
+	Name        string
+	ContentType string
+	Data        io.Reader
+}
+
+func (a *Announcement) IsPublished() bool {
+	return a.PublishedAt != nil
+}
+
+func (a *Announcement) ToDTO(attachments []dto.AnnouncementAttachmentResponseBody) dto.AnnouncementResponseBody {
+	return dto.AnnouncementResponseBody{
+		ID:          a.ID,
+		CreateAt:    a.CreateAt,
+		DormID:      a.DormID,
+		DormName:    a.Dorm.Name,
+		Title:       a.Title,
+		Body:        a.Body,
+		PublishAt:   a.PublishAt,
+		PublishedAt: a.PublishedAt,
+		Published:   a.IsPublished(),
+		Attachments: attachments,
+	}
+}
+
+func (a *Announcement) BeforeDelete(tx *gorm.DB) (err error) {
+	err = tx.Where("announcement_id = ?", a.ID).Delete(&AnnouncementRecipient{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Where("announcement_id = ?", a.ID).Delete(&AnnouncementAttachment{}).Error
+}
@@ -25,6 +25,8 @@ type Contract struct {
 	LessorStatus ContractStatus `gorm:"default:WAITING"`
 	LesseeStatus ContractStatus `gorm:"default:WAITING"`
 	Status       ContractStatus `gorm:"default:WAITING"`
+	// TermMonths is the length of the lease, 0 when open-ended.
+	TermMonths int `gorm:"default:0"`
 }
 
 func (ct *Contract) ToDTO(urls []string) dto.ContractResponseBody {
@@ -37,5 +39,6 @@ func (ct *Contract) ToDTO(urls []string) dto.ContractResponseBody {
 		LessorStatus:   dto.ContractStatus(ct.LessorStatus),
 		LesseeStatus:   dto.ContractStatus(ct.LesseeStatus),
 		ContractStatus: dto.ContractStatus(ct.Status),
+		TermMonths:     ct.TermMonths,
 	}
 }
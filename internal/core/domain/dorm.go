@@ -80,6 +80,10 @@ func updateDormsOwnedCount(tx *gorm.DB, lessorID uuid.UUID) error {
 }
 
 func (d *Dorm) AfterCreate(tx *gorm.DB) (err error) {
+	if err := tx.Create(&DormPriceHistory{DormID: d.ID, Price: d.Price}).Error; err != nil {
+		return err
+	}
+
 	return updateDormsOwnedCount(tx, d.OwnerID)
 }
 
@@ -100,6 +104,23 @@ func (d *Dorm) BeforeDelete(tx *gorm.DB) (err error) {
 		return err
 	}
 
+	// Scheduled announcements are not sent for a deleted dorm
+	scheduled := tx.Model(&Announcement{}).Select("id").Where("dorm_id = ? AND published_at IS NULL", d.ID)
+	err = tx.Where("announcement_id IN (?)", scheduled).Delete(&AnnouncementAttachment{}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Where("dorm_id = ? AND published_at IS NULL", d.ID).Delete(&Announcement{}).Error
+	if err != nil {
+		return err
+	}
+
+	// Managers lose access to a deleted dorm
+	err = tx.Where("dorm_id = ?", d.ID).Delete(&DormManager{}).Error
+	if err != nil {
+		return err
+	}
+
 	// Soft delete Ownership proof when deleting dorm
 	err = tx.Model(&OwnershipProof{}).Where("dorm_id = ?", d.ID).Delete(&OwnershipProof{}).Error
 	if err != nil {
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+)
+
+type ManagerStatus string
+
+const (
+	ManagerInvited  ManagerStatus = "INVITED"
+	ManagerAccepted ManagerStatus = "ACCEPTED"
+)
+
+// ManagerScope is something a lessor can let a manager do for a dorm. Payouts are never delegated.
+// ScopeMeterReadings covers the monthly bills, which are issued once the meters are read.
+type ManagerScope string
+
+const (
+	ScopeLeasingRequests ManagerScope = "LEASING_REQUESTS"
+	ScopeContracts       ManagerScope = "CONTRACTS"
+	ScopeMeterReadings   ManagerScope = "METER_READINGS"
+	ScopeReviewReplies   ManagerScope = "REVIEW_REPLIES"
+)
+
+type ManagerScopes struct {
+	LeasingRequests bool `gorm:"not null;default:false"`
+	Contracts       bool `gorm:"not null;default:false"`
+	MeterReadings   bool `gorm:"not null;default:false"`
+	ReviewReplies   bool `gorm:"not null;default:false"`
+}
+
+func (s ManagerScopes) Has(scope ManagerScope) bool {
+	switch scope {
+	case ScopeLeasingRequests:
+		return s.LeasingRequests
+	case ScopeContracts:
+		return s.Contracts
+	case ScopeMeterReadings:
+		return s.MeterReadings
+	case ScopeReviewReplies:
+		return s.ReviewReplies
+	}
+	return false
+}
+
+// DormManager lets another user, e.g. a caretaker, act on a dorm on behalf of its owner.
+type DormManager struct {
+	ID         uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt   time.Time     `gorm:"autoCreateTime"`
+	UpdateAt   time.Time     `gorm:"autoUpdateTime"`
+	DormID     uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_dorm_manager"`
+	Dorm       Dorm          `gorm:"foreignKey:DormID;references:ID"`
+	ManagerID  uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_dorm_manager;index"`
+	Manager    User          `gorm:"foreignKey:ManagerID;references:ID"`
+	Status     ManagerStatus `gorm:"not null"`
+	Scopes     ManagerScopes `gorm:"embedded;embeddedPrefix:can_"`
+	AcceptedAt *time.Time    `gorm:"default:null"`
+}
+
+// DelegatedAction records an action a manager took on behalf of the dorm owner.
+type DelegatedAction struct {
+	ID         uuid.UUID    `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt   time.Time    `gorm:"autoCreateTime"`
+	DormID     uuid.UUID    `gorm:"type:uuid;not null;index"`
+	OwnerID    uuid.UUID    `gorm:"type:uuid;not null"`
+	ManagerID  uuid.UUID    `gorm:"type:uuid;not null"`
+	Manager    User         `gorm:"foreignKey:ManagerID;references:ID"`
+	Scope      ManagerScope `gorm:"not null"`
+	Action     string       `gorm:"not null"`
+	ResourceID uuid.UUID    `gorm:"type:uuid"`
+}
+
+func NewManagerScopes(scopes dto.DormManagerScopes) ManagerScopes {
+	return ManagerScopes{
+		LeasingRequests: scopes.LeasingRequests,
+		Contracts:       scopes.Contracts,
+		MeterReadings:   scopes.MeterReadings,
+		ReviewReplies:   scopes.ReviewReplies,
+	}
+}
+
+func (s ManagerScopes) ToDTO() dto.DormManagerScopes {
+	return dto.DormManagerScopes{
+		LeasingRequests: s.LeasingRequests,
+		Contracts:       s.Contracts,
+		MeterReadings:   s.MeterReadings,
+		ReviewReplies:   s.ReviewReplies,
+	}
+}
+
+func (m *DormManager) ToDTO() dto.DormManagerResponseBody {
+	return dto.DormManagerResponseBody{
+		ID:         m.ID,
+		CreateAt:   m.CreateAt,
+		DormID:     m.DormID,
+		DormName:   m.Dorm.Name,
+		Manager:    m.Manager.ToDTO(),
+		Status:     string(m.Status),
+		Scopes:     m.Scopes.ToDTO(),
+		AcceptedAt: m.AcceptedAt,
+	}
+}
+
+func (a *DelegatedAction) ToDTO() dto.DelegatedActionResponseBody {
+	return dto.DelegatedActionResponseBody{
+		ID:         a.ID,
+		CreateAt:   a.CreateAt,
+		DormID:     a.DormID,
+		Manager:    a.Manager.ToDTO(),
+		Scope:      string(a.Scope),
+		Action:     a.Action,
+		ResourceID: a.ResourceID,
+	}
+}
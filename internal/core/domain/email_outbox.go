@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "PENDING"
+	OutboxSent    OutboxStatus = "SENT"
+	// OutboxFailed is set once every attempt failed, the email is not retried again.
+	OutboxFailed OutboxStatus = "FAILED"
+)
+
+// EmailOutbox is a rendered email waiting to be delivered by the outbox worker.
+type EmailOutbox struct {
+	ID            uuid.UUID    `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt      time.Time    `gorm:"autoCreateTime"`
+	UpdateAt      time.Time    `gorm:"autoUpdateTime"`
+	To            string       `gorm:"not null"`
+	Subject       string       `gorm:"not null"`
+	HTML          string       `gorm:"type:text"`
+	Text          string       `gorm:"type:text"`
+	Status        OutboxStatus `gorm:"default:'PENDING';index:idx_email_outbox_due,priority:1"`
+	Attempts      int          `gorm:"default:0"`
+	NextAttemptAt time.Time    `gorm:"index:idx_email_outbox_due,priority:2"`
+	SentAt        *time.Time
+	LastError     string `gorm:"type:text"`
+}
@@ -9,18 +9,22 @@ import (
 )
 
 type LeasingHistory struct {
-	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	DormID     uuid.UUID `gorm:"type:uuid;not null"`
-	Dorm       Dorm      `gorm:"foreignKey:DormID;references:ID"`
-	LesseeID   uuid.UUID `gorm:"type:uuid;not null"`
-	Lessee     User      `gorm:"foreignKey:LesseeID;references:ID"`
-	Orders     []Order   `gorm:"foreignKey:LeasingHistoryID"`
-	Start      time.Time
-	End        time.Time `gorm:"default:null"`
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	DormID   uuid.UUID `gorm:"type:uuid;not null"`
+	Dorm     Dorm      `gorm:"foreignKey:DormID;references:ID"`
+	LesseeID uuid.UUID `gorm:"type:uuid;not null"`
+	Lessee   User      `gorm:"foreignKey:LesseeID;references:ID"`
+	Orders   []Order   `gorm:"foreignKey:LeasingHistoryID"`
+	Start    time.Time
+	End      time.Time `gorm:"default:null"`
+	// PlannedEnd is when the term of the lease is over, nil when open-ended.
+	// End is only set once the lease is actually ended.
+	PlannedEnd *time.Time `gorm:"index"`
 	Price      float64
 	ReviewFlag bool
 	Review     *Review        `gorm:"embedded"`
 	Images     []ReviewImage  `gorm:"foreignKey:HistoryID"` // Link to ReviewImage
+	Reports    []ReviewReport `gorm:"foreignKey:HistoryID"`
 	DeletedAt  gorm.DeletedAt `gorm:"index"`
 }
 
@@ -41,6 +45,7 @@ func (l *LeasingHistory) ToDTO(urls []string) dto.LeasingHistory {
 		Orders:     orders,
 		Start:      l.Start,
 		End:        l.End,
+		PlannedEnd: l.PlannedEnd,
 		Price:      l.Price,
 		Review:     review,
 		ReviewFlag: l.ReviewFlag,
@@ -48,10 +53,44 @@ func (l *LeasingHistory) ToDTO(urls []string) dto.LeasingHistory {
 }
 
 type Review struct {
-	Message    string     `gorm:"default:null"`
-	Rate       int        `gorm:"default:null"`
-	CreateAt   *time.Time `gorm:"autoUpdateTime;default:null"`
-	ReportFlag bool       `gorm:"default:false"`
+	Message      string        `gorm:"default:null"`
+	Rate         int           `gorm:"default:null"`
+	Ratings      ReviewRatings `gorm:"embedded"`
+	CreateAt     *time.Time    `gorm:"autoUpdateTime;default:null"`
+	ReportFlag   bool          `gorm:"default:false"`
+	Hidden       bool          `gorm:"default:false"`
+	Removed      bool          `gorm:"default:false"`
+	Reply        string        `gorm:"default:null"`
+	ReplyAt      *time.Time    `gorm:"default:null"`
+	HelpfulCount int           `gorm:"default:0"`
+}
+
+// ReviewRatings holds the optional per-dimension scores of a review, 1 to 5 each.
+type ReviewRatings struct {
+	CleanlinessRate   int `gorm:"default:null"`
+	LocationRate      int `gorm:"default:null"`
+	ValueRate         int `gorm:"default:null"`
+	CommunicationRate int `gorm:"default:null"`
+	AccuracyRate      int `gorm:"default:null"`
+}
+
+type ReviewHelpfulVote struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt  time.Time `gorm:"autoCreateTime"`
+	HistoryID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_review_helpful_vote"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_review_helpful_vote"`
+}
+
+// ReviewSummary aggregates the reviews of a dorm. Dimension averages only count reviews that rated the dimension.
+type ReviewSummary struct {
+	Count         int
+	AverageRate   float64
+	Cleanliness   float64
+	Location      float64
+	Value         float64
+	Communication float64
+	Accuracy      float64
+	Histogram     map[int]int
 }
 
 type ReviewImage struct {
@@ -63,32 +102,75 @@ type ReviewImage struct {
 
 func (r *Review) ToDTO(urls []string, reviewer dto.UserResponse, historyID uuid.UUID) dto.Review {
 
+	var reply *dto.ReviewReply
+	if r.Reply != "" && r.ReplyAt != nil {
+		reply = &dto.ReviewReply{Message: r.Reply, CreateAt: *r.ReplyAt}
+	}
+
 	return dto.Review{
+		HistoryID:    historyID,
+		Message:      r.Message,
+		Rate:         r.Rate,
+		Ratings:      r.Ratings.ToDTO(),
+		CreateAt:     *r.CreateAt,
+		Images:       urls,
+		Reviewer:     reviewer,
+		ReportFlag:   r.ReportFlag,
+		Hidden:       r.Hidden,
+		Reply:        reply,
+		HelpfulCount: r.HelpfulCount,
+	}
+}
+
+func (r *ReviewRatings) ToDTO() dto.ReviewRatings {
+	return dto.ReviewRatings{
+		Cleanliness:   r.CleanlinessRate,
+		Location:      r.LocationRate,
+		Value:         r.ValueRate,
+		Communication: r.CommunicationRate,
+		Accuracy:      r.AccuracyRate,
+	}
+}
+
+func (s *ReviewSummary) ToDTO() dto.ReviewSummary {
+	return dto.ReviewSummary{
+		Count:       s.Count,
+		AverageRate: s.AverageRate,
+		Averages: dto.ReviewRatingAverages{
+			Cleanliness:   s.Cleanliness,
+			Location:      s.Location,
+			Value:         s.Value,
+			Communication: s.Communication,
+			Accuracy:      s.Accuracy,
+		},
+		Histogram: s.Histogram,
+	}
+}
+
+func (r *Review) ToReportedReviewDTO(urls []string, reviewer dto.UserResponse, historyID uuid.UUID, reports []ReviewReport) dto.ReportedReview {
+	categories := map[string]int{}
+	reportsDTO := make([]dto.ReviewReportResponseBody, len(reports))
+	for i, report := range reports {
+		categories[string(report.Category)]++
+		reportsDTO[i] = report.ToDTO()
+	}
+
+	return dto.ReportedReview{
 		HistoryID:  historyID,
 		Message:    r.Message,
 		Rate:       r.Rate,
+		Reviewer:   reviewer,
 		CreateAt:   *r.CreateAt,
 		Images:     urls,
-		Reviewer:   reviewer,
-		ReportFlag: r.ReportFlag,
-	}
-}
-
-func (r *Review) ToReportedReviewDTO(urls []string, reviewer dto.UserResponse, historyID uuid.UUID) dto.ReportedReview {
-	return dto.ReportedReview{
-		HistoryID: historyID,
-		Message:   r.Message,
-		Rate:      r.Rate,
-		Reviewer:  reviewer,
-		CreateAt:  *r.CreateAt,
-		Images:    urls,
+		Categories: categories,
+		Reports:    reportsDTO,
 	}
 }
 
 func (l *LeasingHistory) AfterUpdate(tx *gorm.DB) (err error) {
-	// Calculate the new average rating after an update to leasingHistory
+	// Calculate the new average rating after an update to leasingHistory, hidden reviews do not count
 	var avgRating float64
-	err = tx.Model(&LeasingHistory{}).Select("COALESCE(avg(rate), 0)").Where("dorm_id = ?", l.DormID).Where("review_flag = ?", true).Scan(&avgRating).Error
+	err = tx.Model(&LeasingHistory{}).Select("COALESCE(avg(rate), 0)").Where("dorm_id = ?", l.DormID).Where("review_flag = ?", true).Where("hidden = ?", false).Scan(&avgRating).Error
 	if err != nil {
 		return err
 	}
@@ -108,7 +190,7 @@ func (l *LeasingHistory) AfterUpdate(tx *gorm.DB) (err error) {
 
 	// Count the amount of review a lessor has after an update to leasingHistory
 	var count int64
-	err = tx.Model(&LeasingHistory{}).Joins("JOIN dorms ON dorms.id = leasing_histories.dorm_id").Where("dorms.owner_id = ?", dorm.OwnerID).Where("leasing_histories.review_flag = ?", true).Count(&count).Error
+	err = tx.Model(&LeasingHistory{}).Joins("JOIN dorms ON dorms.id = leasing_histories.dorm_id").Where("dorms.owner_id = ?", dorm.OwnerID).Where("leasing_histories.review_flag = ?", true).Where("leasing_histories.hidden = ?", false).Count(&count).Error
 	if err != nil {
 		return err
 	}
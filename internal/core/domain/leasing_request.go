@@ -26,16 +26,19 @@ type LeasingRequest struct {
 	Start    time.Time `gorm:"autoCreateTime"`
 	End      time.Time `gorm:"default:null"`
 	Message  string
+	// TermMonths is the length of the lease asked for, 0 when open-ended.
+	TermMonths int `gorm:"default:0"`
 }
 
 func (l *LeasingRequest) ToDTO() dto.LeasingRequest {
 	return dto.LeasingRequest{
-		ID:      l.ID,
-		Status:  dto.Status(l.Status),
-		Dorm:    l.Dorm.ToDTO(),
-		Lessee:  l.Lessee.ToDTO(),
-		Start:   l.Start,
-		End:     l.End,
-		Message: l.Message,
+		ID:         l.ID,
+		Status:     dto.Status(l.Status),
+		Dorm:       l.Dorm.ToDTO(),
+		Lessee:     l.Lessee.ToDTO(),
+		Start:      l.Start,
+		End:        l.End,
+		Message:    l.Message,
+		TermMonths: l.TermMonths,
 	}
 }
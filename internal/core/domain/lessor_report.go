@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DormView counts the views of a dorm page per day.
+type DormView struct {
+	DormID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Day    time.Time `gorm:"type:date;primaryKey"`
+	Count  int64     `gorm:"not null;default:0"`
+}
+
+// LessorReportLog records a weekly report that was sent, so every lessor gets
+// one per week even with several instances running the scheduler.
+type LessorReportLog struct {
+	OwnerID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WeekStart time.Time `gorm:"type:date;primaryKey"`
+	CreateAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// DormReport is the activity of one dorm over the period of a report.
+type DormReport struct {
+	DormID uuid.UUID
+	Name   string
+	// NewRequests were made during the period, AnsweredRequests were approved
+	// or rejected during it after ResponseTime in total.
+	NewRequests      int64
+	AnsweredRequests int64
+	PendingRequests  int64
+	ResponseTime     time.Duration
+	// Occupancy is the share of the period the dorm was leased, from 0 to 1.
+	Occupancy         float64
+	PaidBills         int64
+	PaidAmount        int64
+	OutstandingBills  int64
+	OutstandingAmount int64
+	NewReviews        int64
+	// Rating is the average rate of the visible reviews at the end of the
+	// period, PreviousRating at its start. Both are 0 without reviews.
+	Rating         float64
+	PreviousRating float64
+	Views          int64
+}
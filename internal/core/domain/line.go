@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+)
+
+// LineAccount links a user to the LINE user who sent their link code to the bot.
+type LineAccount struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	LineUserID string    `gorm:"not null;uniqueIndex"`
+	CreateAt   time.Time `gorm:"autoCreateTime"`
+}
+
+func (a *LineAccount) ToDTO() dto.LineAccountResponseBody {
+	return dto.LineAccountResponseBody{
+		Linked:   true,
+		LinkedAt: &a.CreateAt,
+	}
+}
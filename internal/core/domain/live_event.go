@@ -0,0 +1,11 @@
+package domain
+
+// LiveEventType is what a live event pushed to connected clients is about.
+type LiveEventType string
+
+const (
+	LiveNotification     LiveEventType = "notification"
+	LiveContractStatus   LiveEventType = "contract.status"
+	LivePaymentCompleted LiveEventType = "payment.completed"
+	LiveMessage          LiveEventType = "message"
+)
@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationLeasingRequestCreated     NotificationType = "leasing_request.created"
+	NotificationLeasingRequestApproved    NotificationType = "leasing_request.approved"
+	NotificationLeasingRequestRejected    NotificationType = "leasing_request.rejected"
+	NotificationContractAwaitingSignature NotificationType = "contract.awaiting_signature"
+	NotificationContractSigned            NotificationType = "contract.signed"
+	NotificationOrderCreated              NotificationType = "order.created"
+	NotificationPaymentCompleted          NotificationType = "payment.completed"
+	NotificationSupportUpdated            NotificationType = "support.updated"
+	NotificationAnnouncementPublished     NotificationType = "announcement.published"
+
+	// Reminders are sent by the scheduler, see ReminderLog.
+	NotificationPaymentDueSoon        NotificationType = "reminder.payment_due_soon"
+	NotificationPaymentDue            NotificationType = "reminder.payment_due"
+	NotificationPaymentOverdue        NotificationType = "reminder.payment_overdue"
+	NotificationContractUnsigned      NotificationType = "reminder.contract_unsigned"
+	NotificationLeasingRequestPending NotificationType = "reminder.leasing_request_pending"
+	NotificationLeaseEnding           NotificationType = "reminder.lease_ending"
+
+	// NotificationWeeklyReport is the weekly email summing up the dorms of a
+	// lessor. It is only sent by email, turning that off opts out of it.
+	NotificationWeeklyReport NotificationType = "report.weekly"
+)
+
+var NotificationTypes = []NotificationType{
+	NotificationLeasingRequestCreated,
+	NotificationLeasingRequestApproved,
+	NotificationLeasingRequestRejected,
+	NotificationContractAwaitingSignature,
+	NotificationContractSigned,
+	NotificationOrderCreated,
+	NotificationPaymentCompleted,
+	NotificationSupportUpdated,
+	NotificationAnnouncementPublished,
+	NotificationPaymentDueSoon,
+	NotificationPaymentDue,
+	NotificationPaymentOverdue,
+	NotificationContractUnsigned,
+	NotificationLeasingRequestPending,
+	NotificationLeaseEnding,
+	NotificationWeeklyReport,
+}
+
+// notificationEmailByDefault are the types that need the user to act, so they are
+// also sent by email until the user turns it off.
+var notificationEmailByDefault = map[NotificationType]bool{
+	NotificationLeasingRequestCreated:     true,
+	NotificationContractAwaitingSignature: true,
+	NotificationOrderCreated:              true,
+	NotificationPaymentCompleted:          true,
+	NotificationSupportUpdated:            true,
+	NotificationAnnouncementPublished:     true,
+	NotificationPaymentDueSoon:            true,
+	NotificationPaymentDue:                true,
+	NotificationPaymentOverdue:            true,
+	NotificationContractUnsigned:          true,
+	NotificationLeasingRequestPending:     true,
+	NotificationLeaseEnding:               true,
+	NotificationWeeklyReport:              true,
+}
+
+// notificationLineByDefault are sent on LINE once the user linked an account:
+// new requests, contracts to sign, bills due, payment confirmations and
+// announcements of the dorm.
+var notificationLineByDefault = map[NotificationType]bool{
+	NotificationLeasingRequestCreated:     true,
+	NotificationContractAwaitingSignature: true,
+	NotificationContractUnsigned:          true,
+	NotificationOrderCreated:              true,
+	NotificationPaymentDueSoon:            true,
+	NotificationPaymentDue:                true,
+	NotificationPaymentOverdue:            true,
+	NotificationPaymentCompleted:          true,
+	NotificationAnnouncementPublished:     true,
+}
+
+func (t NotificationType) IsValid() bool {
+	for _, v := range NotificationTypes {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationEvent is the domain event services publish on key transitions.
+type NotificationEvent struct {
+	Type   NotificationType
+	UserID uuid.UUID
+	Title  string
+	Body   string
+	// Link is the path in the web app the notification opens, e.g. /contracts/<id>.
+	Link string
+	// DormName, Amount, Status and Days fill in the translated email of the event.
+	DormName string
+	Amount   float64
+	Status   string
+	Days     int
+}
+
+type Notification struct {
+	ID       uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt time.Time        `gorm:"autoCreateTime"`
+	UserID   uuid.UUID        `gorm:"type:uuid;not null;index"`
+	Type     NotificationType `gorm:"not null"`
+	Title    string           `gorm:"not null"`
+	Body     string
+	Link     string
+	ReadAt   *time.Time
+}
+
+// NotificationPreference is where a user wants events of one type delivered.
+// Types without a row use DefaultNotificationPreference.
+type NotificationPreference struct {
+	UserID uuid.UUID        `gorm:"type:uuid;primaryKey"`
+	Type   NotificationType `gorm:"primaryKey"`
+	InApp  bool             `gorm:"not null"`
+	Email  bool             `gorm:"not null"`
+	Line   bool             `gorm:"not null;default:false"`
+}
+
+func DefaultNotificationPreference(userID uuid.UUID, t NotificationType) NotificationPreference {
+	return NotificationPreference{
+		UserID: userID,
+		Type:   t,
+		InApp:  true,
+		Email:  notificationEmailByDefault[t],
+		Line:   notificationLineByDefault[t],
+	}
+}
+
+func (n *Notification) ToDTO() dto.NotificationResponseBody {
+	return dto.NotificationResponseBody{
+		ID:       n.ID,
+		CreateAt: n.CreateAt,
+		Type:     string(n.Type),
+		Title:    n.Title,
+		Body:     n.Body,
+		Link:     n.Link,
+		Read:     n.ReadAt != nil,
+		ReadAt:   n.ReadAt,
+	}
+}
+
+func (p *NotificationPreference) ToDTO() dto.NotificationPreferenceBody {
+	return dto.NotificationPreferenceBody{
+		Type:  string(p.Type),
+		InApp: p.InApp,
+		Email: p.Email,
+		Line:  p.Line,
+	}
+}
@@ -26,6 +26,7 @@ type Order struct {
 	PaidTransactionID string         `gorm:"default:null"`
 	LeasingHistory    LeasingHistory `gorm:"foreignKey:LeasingHistoryID"`
 	LeasingHistoryID  uuid.UUID
+	DueAt             time.Time      `gorm:"default:null;index"`
 	DeletedAt         gorm.DeletedAt `gorm:"index"`
 }
 
@@ -34,6 +35,7 @@ func (o *Order) ToDTO() dto.OrderResponseBody {
 		ID:              o.ID,
 		Type:            string(o.Type),
 		Price:           o.Price,
+		DueAt:           o.DueAt,
 		PaidTransaction: o.PaidTransaction.ToDTO(),
 	}
 }
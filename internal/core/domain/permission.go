@@ -0,0 +1,78 @@
+package domain
+
+// Permission lets a user do something, e.g. list a dorm, or act on resources
+// they do not own, e.g. edit any dorm.
+type Permission string
+
+const (
+	PermissionDormCreate     Permission = "dorm:create"
+	PermissionDormUpdate     Permission = "dorm:update"
+	PermissionDormInsights   Permission = "dorm:insights"
+	PermissionDormAnalytics  Permission = "dorm:analytics"
+	PermissionIncomeRead     Permission = "income:read"
+	PermissionSavedSearch    Permission = "saved_search:create"
+	PermissionShortlist      Permission = "shortlist:create"
+	PermissionWebhookManage  Permission = "webhook:manage"
+	PermissionLeasingManage  Permission = "leasing:manage"
+	PermissionReviewModerate Permission = "review:moderate"
+	PermissionUserBan        Permission = "user:ban"
+	PermissionUserVerify     Permission = "user:verify"
+	PermissionUserRoles      Permission = "user:roles"
+	PermissionOwnershipProof Permission = "ownership:review"
+	PermissionSupportManage  Permission = "support:manage"
+	PermissionFinanceRead    Permission = "finance:read"
+	PermissionFinanceRefund  Permission = "finance:refund"
+)
+
+var rolePermissions = map[Role][]Permission{
+	AdminRole: {
+		PermissionDormCreate,
+		PermissionDormUpdate,
+		PermissionDormInsights,
+		PermissionLeasingManage,
+		PermissionReviewModerate,
+		PermissionUserBan,
+		PermissionUserVerify,
+		PermissionUserRoles,
+		PermissionOwnershipProof,
+		PermissionSupportManage,
+		PermissionFinanceRead,
+		PermissionFinanceRefund,
+	},
+	SupportAgentRole: {
+		PermissionLeasingManage,
+		PermissionReviewModerate,
+		PermissionUserBan,
+		PermissionUserVerify,
+		PermissionSupportManage,
+	},
+	FinanceRole: {
+		PermissionFinanceRead,
+		PermissionFinanceRefund,
+	},
+	LessorRole: {
+		PermissionDormCreate,
+		PermissionDormAnalytics,
+		PermissionIncomeRead,
+		PermissionWebhookManage,
+	},
+	LesseeRole: {
+		PermissionSavedSearch,
+		PermissionShortlist,
+	},
+}
+
+// Can reports whether the role grants permission.
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether the role is the admin or a sub-admin role rather than a tenant or lessor.
+func (r Role) IsStaff() bool {
+	return r == AdminRole || r == SupportAgentRole || r == FinanceRole
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReminderLog records a reminder that was sent. Its key makes every step of a
+// reminder schedule go out once per subject and recipient, even with several
+// instances running the scheduler.
+type ReminderLog struct {
+	Type      NotificationType `gorm:"primaryKey"`
+	SubjectID uuid.UUID        `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID        `gorm:"type:uuid;primaryKey"`
+	// Step is the offset of the reminder in days from its anchor, e.g. -3 for
+	// three days before an order is due.
+	Step     int       `gorm:"primaryKey;autoIncrement:false"`
+	CreateAt time.Time `gorm:"autoCreateTime"`
+}
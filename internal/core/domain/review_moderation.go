@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+)
+
+type ReportCategory string
+
+const (
+	ReportSpam         ReportCategory = "SPAM"
+	ReportHarassment   ReportCategory = "HARASSMENT"
+	ReportFalseInfo    ReportCategory = "FALSE_INFO"
+	ReportPersonalData ReportCategory = "PERSONAL_DATA"
+)
+
+type ModerationDecision string
+
+const (
+	ModerationDismiss ModerationDecision = "DISMISS"
+	ModerationHide    ModerationDecision = "HIDE"
+	ModerationRemove  ModerationDecision = "REMOVE"
+)
+
+type AppealStatus string
+
+const (
+	AppealPending    AppealStatus = "PENDING"
+	AppealUpheld     AppealStatus = "UPHELD"
+	AppealOverturned AppealStatus = "OVERTURNED"
+)
+
+type ReviewReport struct {
+	ID           uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt     time.Time      `gorm:"autoCreateTime"`
+	HistoryID    uuid.UUID      `gorm:"type:uuid;not null;index"`
+	ReporterID   uuid.UUID      `gorm:"type:uuid;not null"`
+	Reporter     User           `gorm:"foreignKey:ReporterID;references:ID"`
+	Category     ReportCategory `gorm:"not null"`
+	Reason       string         `gorm:"type:text"`
+	ModerationID *uuid.UUID     `gorm:"type:uuid;index"`
+}
+
+// ReviewModeration records an admin decision on a reported review and the reviewer's appeal against it.
+type ReviewModeration struct {
+	ID             uuid.UUID          `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt       time.Time          `gorm:"autoCreateTime"`
+	HistoryID      uuid.UUID          `gorm:"type:uuid;not null;index"`
+	History        LeasingHistory     `gorm:"foreignKey:HistoryID;references:ID"`
+	AdminID        uuid.UUID          `gorm:"type:uuid;not null"`
+	Decision       ModerationDecision `gorm:"not null"`
+	Reason         string             `gorm:"type:text"`
+	AppealStatus   AppealStatus       `gorm:"default:null"`
+	AppealMessage  string             `gorm:"type:text;default:null"`
+	AppealAt       *time.Time         `gorm:"default:null"`
+	AppealAdminID  *uuid.UUID         `gorm:"type:uuid;default:null"`
+	AppealReason   string             `gorm:"type:text;default:null"`
+	AppealDecideAt *time.Time         `gorm:"default:null"`
+}
+
+func (r *ReviewReport) ToDTO() dto.ReviewReportResponseBody {
+	return dto.ReviewReportResponseBody{
+		ID:       r.ID,
+		CreateAt: r.CreateAt,
+		Reporter: r.Reporter.ToDTO(),
+		Category: string(r.Category),
+		Reason:   r.Reason,
+	}
+}
+
+func (m *ReviewModeration) ToDTO() dto.ReviewModerationResponseBody {
+	return dto.ReviewModerationResponseBody{
+		ID:             m.ID,
+		CreateAt:       m.CreateAt,
+		HistoryID:      m.HistoryID,
+		Decision:       string(m.Decision),
+		Reason:         m.Reason,
+		AppealStatus:   string(m.AppealStatus),
+		AppealMessage:  m.AppealMessage,
+		AppealAt:       m.AppealAt,
+		AppealReason:   m.AppealReason,
+		AppealDecideAt: m.AppealDecideAt,
+	}
+}
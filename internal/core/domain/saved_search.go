@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AlertFrequency string
+
+const (
+	AlertImmediate AlertFrequency = "IMMEDIATE"
+	AlertDaily     AlertFrequency = "DAILY"
+)
+
+type SavedSearch struct {
+	ID             uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt       time.Time      `gorm:"autoCreateTime"`
+	UpdateAt       time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	UserID         uuid.UUID      `gorm:"type:uuid;not null;index"`
+	User           User           `gorm:"foreignKey:UserID;references:ID"`
+	Name           string         `gorm:"not null"`
+	Search         string
+	MinPrice       int `gorm:"default:0"`
+	MaxPrice       int `gorm:"default:0"`
+	District       string
+	Subdistrict    string
+	Province       string
+	Zipcode        string
+	AlertFrequency AlertFrequency `gorm:"default:IMMEDIATE"`
+	LastDigestAt   time.Time      `gorm:"default:null"`
+}
+
+// SavedSearchMatch records a dorm that matched a saved search, so each dorm is only
+// announced once per search and daily digests know what is still unsent.
+type SavedSearchMatch struct {
+	ID            uuid.UUID   `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt      time.Time   `gorm:"autoCreateTime"`
+	SavedSearchID uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_saved_search_dorm"`
+	SavedSearch   SavedSearch `gorm:"foreignKey:SavedSearchID;references:ID"`
+	DormID        uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_saved_search_dorm"`
+	Dorm          Dorm        `gorm:"foreignKey:DormID;references:ID"`
+	Notified      bool        `gorm:"default:false"`
+}
+
+// Matches applies the same filters as GET /dorms to a single dorm.
+func (s *SavedSearch) Matches(d *Dorm) bool {
+	if d == nil {
+		return false
+	}
+	if s.Search != "" {
+		fields := []string{d.Name, d.Address.Province, d.Address.District, d.Address.Subdistrict, d.Address.Zipcode}
+		found := false
+		for _, f := range fields {
+			if containsFold(f, s.Search) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if s.MinPrice > 0 && d.Price < float64(s.MinPrice) {
+		return false
+	}
+	if s.MaxPrice > 0 && d.Price > float64(s.MaxPrice) {
+		return false
+	}
+	if s.District != "" && !containsFold(d.Address.District, s.District) {
+		return false
+	}
+	if s.Subdistrict != "" && !containsFold(d.Address.Subdistrict, s.Subdistrict) {
+		return false
+	}
+	if s.Province != "" && !containsFold(d.Address.Province, s.Province) {
+		return false
+	}
+	if s.Zipcode != "" && !containsFold(d.Address.Zipcode, s.Zipcode) {
+		return false
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (s *SavedSearch) ToDTO() dto.SavedSearchResponseBody {
+	return dto.SavedSearchResponseBody{
+		ID:             s.ID,
+		CreateAt:       s.CreateAt,
+		UpdateAt:       s.UpdateAt,
+		Name:           s.Name,
+		Search:         s.Search,
+		MinPrice:       s.MinPrice,
+		MaxPrice:       s.MaxPrice,
+		District:       s.District,
+		Subdistrict:    s.Subdistrict,
+		Province:       s.Province,
+		Zipcode:        s.Zipcode,
+		AlertFrequency: string(s.AlertFrequency),
+	}
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+)
+
+// Session is a device the user is logged in on. It is stored in redis, not in the database.
+type Session struct {
+	ID         string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	Current    bool
+}
+
+func (s *Session) ToDTO() dto.SessionResponseBody {
+	return dto.SessionResponseBody{
+		ID:         s.ID,
+		Device:     s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		Current:    s.Current,
+	}
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Shortlist struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt  time.Time      `gorm:"autoCreateTime"`
+	UpdateAt  time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index"`
+	User      User           `gorm:"foreignKey:UserID;references:ID"`
+	Name      string         `gorm:"not null"`
+	Items     []ShortlistItem
+}
+
+type ShortlistItem struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt    time.Time `gorm:"autoCreateTime"`
+	ShortlistID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_shortlist_dorm"`
+	DormID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_shortlist_dorm;index"`
+	Dorm        Dorm      `gorm:"foreignKey:DormID;references:ID"`
+	PriceAtSave float64
+}
+
+func (s *Shortlist) ToDTO() dto.ShortlistResponseBody {
+	items := make([]dto.ShortlistItemResponseBody, len(s.Items))
+	for i, item := range s.Items {
+		items[i] = dto.ShortlistItemResponseBody{
+			CreateAt:    item.CreateAt,
+			PriceAtSave: item.PriceAtSave,
+			Dorm:        item.Dorm.ToDTO(),
+		}
+	}
+
+	return dto.ShortlistResponseBody{
+		ID:       s.ID,
+		CreateAt: s.CreateAt,
+		UpdateAt: s.UpdateAt,
+		Name:     s.Name,
+		Dorms:    items,
+	}
+}
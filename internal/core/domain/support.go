@@ -22,6 +22,8 @@ type SupportRequest struct {
 	UserID   uuid.UUID
 	Message  string        `gorn:"type:text;not null"`
 	Status   SupportStatus `gorm:"default:'OPEN'"`
+	// ThreadID lets staff read the conversation the request is about.
+	ThreadID *uuid.UUID `gorm:"type:uuid;index"`
 }
 
 func (s *SupportRequest) ToDTO() dto.SupportResponseBody {
@@ -32,5 +34,6 @@ func (s *SupportRequest) ToDTO() dto.SupportResponseBody {
 		UserID:   s.UserID,
 		Message:  s.Message,
 		Status:   string(s.Status),
+		ThreadID: s.ThreadID,
 	}
 }
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+)
+
+type ThreadSubject string
+
+const (
+	ThreadDorm           ThreadSubject = "DORM"
+	ThreadLeasingRequest ThreadSubject = "LEASING_REQUEST"
+	// ThreadLease is about a LeasingHistory, i.e. a tenancy.
+	ThreadLease ThreadSubject = "LEASE"
+)
+
+// Thread is a conversation between a lessee and the lessor of a dorm. There is at
+// most one thread per subject and lessee.
+type Thread struct {
+	ID            uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt      time.Time     `gorm:"autoCreateTime"`
+	Subject       ThreadSubject `gorm:"not null;uniqueIndex:idx_thread_subject_lessee"`
+	SubjectID     uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_thread_subject_lessee"`
+	DormID        uuid.UUID     `gorm:"type:uuid;not null;index"`
+	Dorm          Dorm          `gorm:"foreignKey:DormID;references:ID"`
+	LesseeID      uuid.UUID     `gorm:"type:uuid;not null;index;uniqueIndex:idx_thread_subject_lessee"`
+	Lessee        User          `gorm:"foreignKey:LesseeID;references:ID"`
+	LessorID      uuid.UUID     `gorm:"type:uuid;not null;index"`
+	Lessor        User          `gorm:"foreignKey:LessorID;references:ID"`
+	LastMessageAt *time.Time
+}
+
+type ThreadMessage struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt time.Time `gorm:"autoCreateTime;index"`
+	ThreadID uuid.UUID `gorm:"type:uuid;not null;index"`
+	SenderID uuid.UUID `gorm:"type:uuid;not null"`
+	Sender   User      `gorm:"foreignKey:SenderID;references:ID"`
+	Body     string    `gorm:"type:text"`
+	ImageKey string
+}
+
+// ThreadRead is how far a participant has read a thread, for read receipts and unread counts.
+type ThreadRead struct {
+	ThreadID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	LastReadAt time.Time `gorm:"not null"`
+}
+
+// ThreadReport lets staff read a thread a participant reported.
+type ThreadReport struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt   time.Time      `gorm:"autoCreateTime"`
+	ThreadID   uuid.UUID      `gorm:"type:uuid;not null;index"`
+	ReporterID uuid.UUID      `gorm:"type:uuid;not null"`
+	Category   ReportCategory `gorm:"not null"`
+	Reason     string         `gorm:"type:text"`
+}
+
+// UserBlock stops two users from messaging each other, whoever blocked whom.
+type UserBlock struct {
+	BlockerID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	BlockedID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Blocked   User      `gorm:"foreignKey:BlockedID;references:ID"`
+	CreateAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// IsParticipant reports whether the user is the lessee or the lessor of the thread.
+func (t *Thread) IsParticipant(userID uuid.UUID) bool {
+	return userID == t.LesseeID || userID == t.LessorID
+}
+
+// Other returns the participant that is not userID.
+func (t *Thread) Other(userID uuid.UUID) uuid.UUID {
+	if userID == t.LesseeID {
+		return t.LessorID
+	}
+	return t.LesseeID
+}
+
+func (t *Thread) ToDTO() dto.ThreadResponseBody {
+	return dto.ThreadResponseBody{
+		ID:            t.ID,
+		CreateAt:      t.CreateAt,
+		Subject:       string(t.Subject),
+		SubjectID:     t.SubjectID,
+		DormID:        t.DormID,
+		DormName:      t.Dorm.Name,
+		Lessee:        t.Lessee.ToDTO(),
+		Lessor:        t.Lessor.ToDTO(),
+		LastMessageAt: t.LastMessageAt,
+	}
+}
+
+func (m *ThreadMessage) ToDTO(imageURL string, read bool) dto.ThreadMessageResponseBody {
+	return dto.ThreadMessageResponseBody{
+		ID:       m.ID,
+		CreateAt: m.CreateAt,
+		ThreadID: m.ThreadID,
+		SenderID: m.SenderID,
+		Body:     m.Body,
+		ImageURL: imageURL,
+		Read:     read,
+	}
+}
+
+func (b *UserBlock) ToDTO() dto.UserBlockResponseBody {
+	return dto.UserBlockResponseBody{
+		User:     b.Blocked.ToDTO(),
+		CreateAt: b.CreateAt,
+	}
+}
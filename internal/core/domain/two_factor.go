@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCode is a one-time code that replaces the authenticator app, stored as a SHA-256 hash.
+type RecoveryCode struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt time.Time `gorm:"autoCreateTime"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash string    `gorm:"not null"`
+	UsedAt   *time.Time
+}
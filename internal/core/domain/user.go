@@ -18,6 +18,10 @@ const (
 	AdminRole  Role = "ADMIN"
 	LesseeRole Role = "LESSEE"
 	LessorRole Role = "LESSOR"
+
+	// Sub-admin roles with some of the admin permissions, see rolePermissions.
+	SupportAgentRole Role = "SUPPORT_AGENT"
+	FinanceRole      Role = "FINANCE"
 )
 
 type Lifestyle string
@@ -182,6 +186,7 @@ type User struct {
 	FilledPersonalInfo bool           `gorm:"default:false"`
 	Lifestyles         LifestyleArray `validate:"lifestyle" gorm:"type:lifestyle_tag[]"`
 	PhoneNumber        string
+	PhoneVerified      bool `gorm:"default:false"`
 	StudentEvidence    string
 	IsStudentVerified  VerificationStatus
 	ProfilePicKey      string
@@ -189,6 +194,11 @@ type User struct {
 	DormsOwned         int64 `gorm:"default:0"`
 	DormsLeased        int64 `gorm:"default:0"`
 	Banned             bool  `gorm:"default:false"`
+	TwoFactorEnabled   bool  `gorm:"default:false"`
+	TwoFactorSecret    string
+	TwoFactorLastStep  int64 `gorm:"default:0"`
+	// Language of the emails sent to the user, "th" or "en".
+	Language string `gorm:"default:'en'"`
 }
 
 func (u *User) ToDTO() dto.UserResponse {
@@ -210,11 +220,14 @@ func (u *User) ToDTO() dto.UserResponse {
 		FilledPersonalInfo: u.FilledPersonalInfo,
 		Lifestyles:         lifestyles,
 		PhoneNumber:        u.PhoneNumber,
+		PhoneVerified:      u.PhoneVerified,
 		IsStudentVerified:  string(u.IsStudentVerified),
 		ReviewCount:        u.ReviewCount,
 		DormsOwned:         u.DormsOwned,
 		DormsLeased:        u.DormsLeased,
 		Banned:             u.Banned,
+		TwoFactorEnabled:   u.TwoFactorEnabled,
+		Language:           u.Language,
 	}
 }
 
@@ -231,6 +244,38 @@ func (u *User) BeforeDelete(tx *gorm.DB) (err error) {
 		return err
 	}
 
+	// Stop managing dorms for other lessors
+	err = tx.Where("manager_id = ?", u.ID).Delete(&DormManager{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Where("user_id = ?", u.ID).Delete(&Notification{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Where("user_id = ?", u.ID).Delete(&NotificationPreference{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Where("blocker_id = ? OR blocked_id = ?", u.ID, u.ID).Delete(&UserBlock{}).Error
+	if err != nil {
+		return err
+	}
+
+	// Unlink provider accounts so they can sign up again
+	err = tx.Where("user_id = ?", u.ID).Delete(&UserIdentity{}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Where("user_id = ?", u.ID).Delete(&LineAccount{}).Error
+	if err != nil {
+		return err
+	}
+
 	// Mark Waiting Contract as Canceled if a lessee delete their account
 	err = tx.Model(&Contract{}).Where("lessee_id = ? AND status = ?", u.ID, Waiting).Update("status", Cancelled).Error
 	if err != nil {
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to an account at an OpenID Connect provider.
+type UserIdentity struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt time.Time `gorm:"autoCreateTime"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_identity_user_provider"`
+	Provider string    `gorm:"not null;uniqueIndex:idx_identity_subject;uniqueIndex:idx_identity_user_provider"`
+	Subject  string    `gorm:"not null;uniqueIndex:idx_identity_subject"`
+	Email    string
+}
+
+func (i *UserIdentity) ToDTO() dto.UserIdentityResponseBody {
+	return dto.UserIdentityResponseBody{
+		Provider: i.Provider,
+		Email:    i.Email,
+		CreateAt: i.CreateAt,
+	}
+}
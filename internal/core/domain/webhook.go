@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookRequestCreated WebhookEvent = "request.created"
+	WebhookContractSigned WebhookEvent = "contract.signed"
+	WebhookOrderPaid      WebhookEvent = "order.paid"
+	WebhookLeaseEnded     WebhookEvent = "lease.ended"
+)
+
+var WebhookEvents = []WebhookEvent{
+	WebhookRequestCreated,
+	WebhookContractSigned,
+	WebhookOrderPaid,
+	WebhookLeaseEnded,
+}
+
+// Webhook is an endpoint a lessor registered to receive the events of their dorms.
+type Webhook struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt    time.Time      `gorm:"autoCreateTime"`
+	UpdateAt    time.Time      `gorm:"autoUpdateTime"`
+	OwnerID     uuid.UUID      `gorm:"type:uuid;not null;index"`
+	Owner       User           `gorm:"foreignKey:OwnerID;references:ID"`
+	URL         string         `gorm:"not null"`
+	Secret      string         `gorm:"not null"`
+	Events      []WebhookEvent `gorm:"serializer:json;type:text;not null"`
+	Description string
+	Active      bool `gorm:"not null"`
+}
+
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Webhook) BeforeDelete(tx *gorm.DB) (err error) {
+	return tx.Where("webhook_id = ?", w.ID).Delete(&WebhookDelivery{}).Error
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	// WebhookDeliveryFailed is set once every attempt failed, it can still be redelivered by hand.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "FAILED"
+)
+
+// WebhookDelivery is one event sent to one webhook, and the log of its attempts.
+// A redelivery is a new delivery of the same EventID.
+type WebhookDelivery struct {
+	ID             uuid.UUID             `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreateAt       time.Time             `gorm:"autoCreateTime"`
+	UpdateAt       time.Time             `gorm:"autoUpdateTime"`
+	WebhookID      uuid.UUID             `gorm:"type:uuid;not null;index"`
+	EventID        uuid.UUID             `gorm:"type:uuid;not null"`
+	Event          WebhookEvent          `gorm:"not null"`
+	Payload        string                `gorm:"type:text;not null"`
+	RedeliveryOf   *uuid.UUID            `gorm:"type:uuid"`
+	Status         WebhookDeliveryStatus `gorm:"default:'PENDING';index:idx_webhook_delivery_due,priority:1"`
+	Attempts       int                   `gorm:"default:0"`
+	NextAttemptAt  time.Time             `gorm:"index:idx_webhook_delivery_due,priority:2"`
+	DeliveredAt    *time.Time
+	ResponseStatus int
+	ResponseBody   string `gorm:"type:text"`
+	LastError      string `gorm:"type:text"`
+}
+
+func (w *Webhook) ToDTO() dto.WebhookResponseBody {
+	events := make([]string, len(w.Events))
+	for i, e := range w.Events {
+		events[i] = string(e)
+	}
+	return dto.WebhookResponseBody{
+		ID:          w.ID,
+		CreateAt:    w.CreateAt,
+		UpdateAt:    w.UpdateAt,
+		URL:         w.URL,
+		Events:      events,
+		Description: w.Description,
+		Active:      w.Active,
+	}
+}
+
+func (d *WebhookDelivery) ToDTO() dto.WebhookDeliveryResponseBody {
+	return dto.WebhookDeliveryResponseBody{
+		ID:             d.ID,
+		CreateAt:       d.CreateAt,
+		EventID:        d.EventID,
+		Event:          string(d.Event),
+		Payload:        d.Payload,
+		RedeliveryOf:   d.RedeliveryOf,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		DeliveredAt:    d.DeliveredAt,
+		ResponseStatus: d.ResponseStatus,
+		ResponseBody:   d.ResponseBody,
+		LastError:      d.LastError,
+	}
+}
@@ -0,0 +1,117 @@
+// Package policy decides whether a user may act on a resource and logs every denial.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+var ErrPermissionDenied = errors.New("permission denied")
+
+// ErrTwoFactorRequired denies staff who act through their role without two-factor authentication.
+var ErrTwoFactorRequired = errors.New("two-factor authentication required")
+
+// Can reports whether the role of user grants permission, for checks that fall
+// back to something else rather than fail. Staff only get the permissions of
+// their role once they use two-factor authentication.
+func Can(user *domain.User, permission domain.Permission) bool {
+	return user.Role.Can(permission) && (!user.Role.IsStaff() || user.TwoFactorEnabled)
+}
+
+// Authorize allows users whose role grants permission.
+func Authorize(user *domain.User, permission domain.Permission) error {
+	if user.Role.Can(permission) {
+		return requireTwoFactor(user, permission)
+	}
+	return deny(user, permission, uuid.Nil)
+}
+
+// AuthorizeOwner allows the owner of the resource and users whose role grants permission.
+func AuthorizeOwner(user *domain.User, ownerID uuid.UUID, permission domain.Permission) error {
+	if user.ID == ownerID {
+		return nil
+	}
+	if user.Role.Can(permission) {
+		return requireTwoFactor(user, permission)
+	}
+	return deny(user, permission, ownerID)
+}
+
+// AuthorizeManager allows the dorm owner, users whose role grants permission and
+// the dorm manager if the owner gave them scope. An empty permission grants no role
+// access, for things only the owner's side may do. manager may be nil.
+func AuthorizeManager(user *domain.User, ownerID uuid.UUID, manager *domain.DormManager, scope domain.ManagerScope, permission domain.Permission) error {
+	if user.ID == ownerID {
+		return nil
+	}
+	if permission != "" && user.Role.Can(permission) {
+		return requireTwoFactor(user, permission)
+	}
+	if manager != nil && manager.ManagerID == user.ID && manager.Status == domain.ManagerAccepted && manager.Scopes.Has(scope) {
+		return nil
+	}
+	if permission == "" {
+		log.Printf("permission denied: user %s (%s) lacks %s scope on resource of %s\n", user.ID, user.Role, scope, ownerID)
+		return fmt.Errorf("%w: %s", ErrPermissionDenied, scope)
+	}
+	return deny(user, permission, ownerID)
+}
+
+// AuthorizeOverUser allows users whose role grants permission to act on target,
+// e.g. ban them, as long as the role of target ranks below their own. Support
+// agents cannot act on staff and admins cannot act on other admins.
+func AuthorizeOverUser(user *domain.User, target *domain.User, permission domain.Permission) error {
+	if err := Authorize(user, permission); err != nil {
+		return err
+	}
+	if rank(target.Role) >= rank(user.Role) {
+		log.Printf("permission denied: user %s (%s) cannot use %s on user %s (%s)\n", user.ID, user.Role, permission, target.ID, target.Role)
+		return fmt.Errorf("%w: %s", ErrPermissionDenied, permission)
+	}
+	return nil
+}
+
+// AuthorizeRoleChange allows users to give target role if they may act on target
+// and role does not rank above their own.
+func AuthorizeRoleChange(user *domain.User, target *domain.User, role domain.Role) error {
+	if err := AuthorizeOverUser(user, target, domain.PermissionUserRoles); err != nil {
+		return err
+	}
+	if rank(role) > rank(user.Role) {
+		log.Printf("permission denied: user %s (%s) cannot grant %s\n", user.ID, user.Role, role)
+		return fmt.Errorf("%w: %s", ErrPermissionDenied, domain.PermissionUserRoles)
+	}
+	return nil
+}
+
+// rank orders the roles by their authority over other users.
+func rank(role domain.Role) int {
+	switch {
+	case role == domain.AdminRole:
+		return 2
+	case role.IsStaff():
+		return 1
+	}
+	return 0
+}
+
+func requireTwoFactor(user *domain.User, permission domain.Permission) error {
+	if !user.Role.IsStaff() || user.TwoFactorEnabled {
+		return nil
+	}
+	log.Printf("permission denied: staff %s (%s) without two-factor used %s\n", user.ID, user.Role, permission)
+	return fmt.Errorf("%w: %s", ErrTwoFactorRequired, permission)
+}
+
+func deny(user *domain.User, permission domain.Permission, ownerID uuid.UUID) error {
+	if ownerID == uuid.Nil {
+		log.Printf("permission denied: user %s (%s) lacks %s\n", user.ID, user.Role, permission)
+	} else {
+		log.Printf("permission denied: user %s (%s) lacks %s on resource of %s\n", user.ID, user.Role, permission, ownerID)
+	}
+	return fmt.Errorf("%w: %s", ErrPermissionDenied, permission)
+}
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaffTwoFactor(t *testing.T) {
+	ownerID := uuid.New()
+	admin := &domain.User{ID: uuid.New(), Role: domain.AdminRole}
+	secured := &domain.User{ID: uuid.New(), Role: domain.AdminRole, TwoFactorEnabled: true}
+
+	t.Run("staff without two-factor", func(t *testing.T) {
+		assert.ErrorIs(t, Authorize(admin, domain.PermissionUserBan), ErrTwoFactorRequired)
+		assert.ErrorIs(t, AuthorizeOwner(admin, ownerID, domain.PermissionDormUpdate), ErrTwoFactorRequired)
+		assert.ErrorIs(t, AuthorizeManager(admin, ownerID, nil, domain.ScopeContracts, domain.PermissionLeasingManage), ErrTwoFactorRequired)
+		assert.False(t, Can(admin, domain.PermissionSupportManage))
+	})
+
+	t.Run("staff with two-factor", func(t *testing.T) {
+		assert.NoError(t, Authorize(secured, domain.PermissionUserBan))
+		assert.NoError(t, AuthorizeOwner(secured, ownerID, domain.PermissionDormUpdate))
+		assert.NoError(t, AuthorizeManager(secured, ownerID, nil, domain.ScopeContracts, domain.PermissionLeasingManage))
+		assert.True(t, Can(secured, domain.PermissionSupportManage))
+	})
+
+	t.Run("owners and lessors need no two-factor", func(t *testing.T) {
+		owner := &domain.User{ID: ownerID, Role: domain.AdminRole}
+		assert.NoError(t, AuthorizeOwner(owner, ownerID, domain.PermissionDormUpdate))
+
+		lessor := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+		assert.NoError(t, Authorize(lessor, domain.PermissionDormCreate))
+		assert.ErrorIs(t, AuthorizeOwner(lessor, ownerID, domain.PermissionDormUpdate), ErrPermissionDenied)
+	})
+}
+
+func TestAuthorizeOverUser(t *testing.T) {
+	admin := &domain.User{ID: uuid.New(), Role: domain.AdminRole, TwoFactorEnabled: true}
+	agent := &domain.User{ID: uuid.New(), Role: domain.SupportAgentRole, TwoFactorEnabled: true}
+	finance := &domain.User{ID: uuid.New(), Role: domain.FinanceRole, TwoFactorEnabled: true}
+	lessor := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+
+	t.Run("support agent", func(t *testing.T) {
+		assert.NoError(t, AuthorizeOverUser(agent, lessor, domain.PermissionUserBan))
+		assert.ErrorIs(t, AuthorizeOverUser(agent, admin, domain.PermissionUserBan), ErrPermissionDenied)
+		assert.ErrorIs(t, AuthorizeOverUser(agent, finance, domain.PermissionUserBan), ErrPermissionDenied)
+		assert.ErrorIs(t, AuthorizeOverUser(agent, &domain.User{ID: uuid.New(), Role: domain.SupportAgentRole}, domain.PermissionUserBan), ErrPermissionDenied)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		assert.NoError(t, AuthorizeOverUser(admin, agent, domain.PermissionUserBan))
+		assert.ErrorIs(t, AuthorizeOverUser(admin, admin, domain.PermissionUserBan), ErrPermissionDenied)
+		assert.ErrorIs(t, AuthorizeOverUser(admin, &domain.User{ID: uuid.New(), Role: domain.AdminRole}, domain.PermissionUserBan), ErrPermissionDenied)
+	})
+
+	t.Run("without the permission", func(t *testing.T) {
+		assert.ErrorIs(t, AuthorizeOverUser(finance, lessor, domain.PermissionUserBan), ErrPermissionDenied)
+	})
+
+	t.Run("role change", func(t *testing.T) {
+		assert.NoError(t, AuthorizeRoleChange(admin, lessor, domain.SupportAgentRole))
+		assert.NoError(t, AuthorizeRoleChange(admin, finance, domain.LesseeRole))
+		assert.NoError(t, AuthorizeRoleChange(admin, lessor, domain.AdminRole))
+		assert.ErrorIs(t, AuthorizeRoleChange(admin, &domain.User{ID: uuid.New(), Role: domain.AdminRole}, domain.LesseeRole), ErrPermissionDenied)
+		assert.ErrorIs(t, AuthorizeRoleChange(agent, lessor, domain.LesseeRole), ErrPermissionDenied)
+	})
+}
+
+func TestRolePermissions(t *testing.T) {
+	lessee := &domain.User{ID: uuid.New(), Role: domain.LesseeRole}
+	lessor := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+
+	for _, permission := range []domain.Permission{domain.PermissionSavedSearch, domain.PermissionShortlist} {
+		assert.NoError(t, Authorize(lessee, permission))
+		assert.ErrorIs(t, Authorize(lessor, permission), ErrPermissionDenied)
+	}
+	for _, permission := range []domain.Permission{domain.PermissionDormAnalytics, domain.PermissionIncomeRead, domain.PermissionWebhookManage} {
+		assert.NoError(t, Authorize(lessor, permission))
+		assert.ErrorIs(t, Authorize(lessee, permission), ErrPermissionDenied)
+	}
+}
@@ -0,0 +1,24 @@
+package ports
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AnalyticsRepository interface {
+	GetListingPrices(filter domain.RentFilter) ([]domain.RentPrice, error)
+	GetLeasePrices(filter domain.RentFilter) ([]domain.RentPrice, error)
+	GetAreaListingRent(province string, district string, bedrooms int, excludeDormID uuid.UUID) (*domain.RentStat, error)
+}
+
+type AnalyticsService interface {
+	GetRentTrend(filter domain.RentFilter) (*dto.RentTrendResponseBody, error)
+	GetMarketPositionByOwnerID(user *domain.User, limit int, page int) ([]dto.DormMarketPositionResponseBody, int, int, error)
+}
+
+type AnalyticsHandler interface {
+	GetRentTrend(c *fiber.Ctx) error
+	GetMyMarketPosition(c *fiber.Ctx) error
+}
@@ -0,0 +1,48 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AnnouncementRepository interface {
+	Create(announcement *domain.Announcement) error
+	GetByID(id uuid.UUID) (*domain.Announcement, error)
+	GetByDormID(dormID uuid.UUID, limit int, page int) ([]domain.Announcement, int, int, error)
+	Delete(announcement *domain.Announcement) error
+	// ClaimDue marks the scheduled announcements whose time has come as
+	// published and returns them, so each one is sent once.
+	ClaimDue(now time.Time, limit int) ([]domain.Announcement, error)
+	// GetActiveLeases returns the leases of the dorm running at the given time.
+	GetActiveLeases(dormID uuid.UUID, at time.Time) ([]domain.LeasingHistory, error)
+	CreateRecipients(recipients []domain.AnnouncementRecipient) error
+	// GetRecipient returns nil without an error when the user did not receive the announcement.
+	GetRecipient(announcementID uuid.UUID, userID uuid.UUID) (*domain.AnnouncementRecipient, error)
+	GetByLeasingHistoryID(historyID uuid.UUID, limit int, page int) ([]domain.AnnouncementRecipient, int, int, error)
+	MarkRead(announcementID uuid.UUID, userID uuid.UUID, at time.Time) error
+	GetStats(announcementIDs []uuid.UUID) (map[uuid.UUID]domain.AnnouncementStats, error)
+}
+
+type AnnouncementService interface {
+	Create(ctx context.Context, user *domain.User, dormID uuid.UUID, body dto.AnnouncementRequestBody, publishAt time.Time, files []domain.AnnouncementFile) (*dto.AnnouncementResponseBody, error)
+	GetByID(ctx context.Context, user *domain.User, id uuid.UUID) (*dto.AnnouncementResponseBody, error)
+	GetByDormID(ctx context.Context, user *domain.User, dormID uuid.UUID, limit int, page int) ([]dto.AnnouncementResponseBody, int, int, error)
+	GetByLeasingHistoryID(ctx context.Context, user *domain.User, historyID uuid.UUID, limit int, page int) ([]dto.AnnouncementResponseBody, int, int, error)
+	MarkRead(user *domain.User, id uuid.UUID) error
+	Delete(ctx context.Context, user *domain.User, id uuid.UUID) error
+	PublishDue() error
+}
+
+type AnnouncementHandler interface {
+	Create(c *fiber.Ctx) error
+	GetByID(c *fiber.Ctx) error
+	GetByDormID(c *fiber.Ctx) error
+	GetByLeasingHistoryID(c *fiber.Ctx) error
+	MarkRead(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+}
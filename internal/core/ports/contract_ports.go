@@ -21,7 +21,7 @@ type ContractRepository interface {
 type ContractService interface {
 	GetContractByContractID(contractID uuid.UUID) (*dto.ContractResponseBody, error)
 	GetByUserID(userID uuid.UUID, limit, page int) (*[]dto.ContractResponseBody, int, int, error)
-	GetByDormID(lesseeID uuid.UUID, limit, page int) (*[]dto.ContractResponseBody, int, int, error)
+	GetByDormID(user *domain.User, dormID uuid.UUID, limit, page int) (*[]dto.ContractResponseBody, int, int, error)
 	DeleteContract(contractID uuid.UUID) error
 	UpdateStatus(contractID uuid.UUID, lessorStatus domain.ContractStatus, userID uuid.UUID) error
 }
@@ -0,0 +1,43 @@
+package ports
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DormManagerRepository interface {
+	Create(manager *domain.DormManager) error
+	GetByID(id uuid.UUID) (*domain.DormManager, error)
+	GetByDormID(dormID uuid.UUID) ([]domain.DormManager, error)
+	GetByManagerID(managerID uuid.UUID) ([]domain.DormManager, error)
+	GetAccepted(dormID uuid.UUID, managerID uuid.UUID) (*domain.DormManager, error)
+	Update(manager *domain.DormManager) error
+	Delete(id uuid.UUID) error
+	CreateAction(action *domain.DelegatedAction) error
+	GetActionsByDormID(dormID uuid.UUID, limit int, page int) ([]domain.DelegatedAction, int, int, error)
+}
+
+type DormManagerService interface {
+	Invite(user *domain.User, dormID uuid.UUID, email string, scopes domain.ManagerScopes) (*domain.DormManager, error)
+	GetByDormID(user *domain.User, dormID uuid.UUID) ([]domain.DormManager, error)
+	GetMine(userID uuid.UUID) ([]domain.DormManager, error)
+	UpdateScopes(user *domain.User, dormID uuid.UUID, id uuid.UUID, scopes domain.ManagerScopes) (*domain.DormManager, error)
+	Remove(user *domain.User, dormID uuid.UUID, id uuid.UUID) error
+	Accept(userID uuid.UUID, id uuid.UUID) (*domain.DormManager, error)
+	Leave(userID uuid.UUID, id uuid.UUID) error
+	GetActions(user *domain.User, dormID uuid.UUID, limit int, page int) ([]domain.DelegatedAction, int, int, error)
+	Authorize(user *domain.User, dorm *domain.Dorm, scope domain.ManagerScope, permission domain.Permission) (*domain.DormManager, error)
+	RecordAction(manager *domain.DormManager, scope domain.ManagerScope, action string, resourceID uuid.UUID) error
+}
+
+type DormManagerHandler interface {
+	Invite(c *fiber.Ctx) error
+	GetByDormID(c *fiber.Ctx) error
+	GetMine(c *fiber.Ctx) error
+	UpdateScopes(c *fiber.Ctx) error
+	Remove(c *fiber.Ctx) error
+	Accept(c *fiber.Ctx) error
+	Leave(c *fiber.Ctx) error
+	GetActions(c *fiber.Ctx) error
+}
@@ -23,14 +23,14 @@ type DormRepository interface {
 }
 
 type DormService interface {
-	Create(userRole domain.Role, dorm *domain.Dorm) error
+	Create(user *domain.User, dorm *domain.Dorm) error
 	GetAll(limit int, page int, search string, min_price int, max_price int, district string, subdistrict string, province string, zipcode string) ([]dto.DormResponseBody, int, int, error)
 	GetByID(id uuid.UUID) (*dto.DormResponseBody, error)
-	Update(userID uuid.UUID, isAdmin bool, dormID uuid.UUID, dorm *dto.DormUpdateRequestBody) (*dto.DormResponseBody, error)
-	Delete(ctx context.Context, userID uuid.UUID, isAdmin bool, dormID uuid.UUID) error
-	UploadDormImage(ctx context.Context, dormID uuid.UUID, filename string, contentType string, fileData io.Reader, userID uuid.UUID, isAdmin bool) (string, error)
+	Update(user *domain.User, dormID uuid.UUID, dorm *dto.DormUpdateRequestBody) (*dto.DormResponseBody, error)
+	Delete(ctx context.Context, user *domain.User, dormID uuid.UUID) error
+	UploadDormImage(ctx context.Context, dormID uuid.UUID, filename string, contentType string, fileData io.Reader, user *domain.User) (string, error)
 	GetByOwnerID(ownerID uuid.UUID, limit int, page int) ([]dto.DormResponseBody, int, int, error)
-	DeleteImageByURL(ctx context.Context, imageURL string, userID uuid.UUID, isAdmin bool) error
+	DeleteImageByURL(ctx context.Context, imageURL string, user *domain.User) error
 	GetImageUrl(dormImage []domain.DormImage) []string
 }
 
@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+)
+
+type EmailOutboxRepository interface {
+	email.Outbox
+	// Claim returns due emails and hides them from other workers for lease.
+	Claim(now time.Time, lease time.Duration, limit int) ([]domain.EmailOutbox, error)
+	Update(entry *domain.EmailOutbox) error
+}
+
+type EmailOutboxService interface {
+	// ProcessDue delivers the due emails and schedules a retry for failed ones.
+	ProcessDue() error
+}
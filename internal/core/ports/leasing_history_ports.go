@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/gofiber/fiber/v2"
@@ -17,29 +18,34 @@ type LeasingHistoryRepository interface {
 	GetByUserID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error)
 	GetReviewByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error)
 	GetByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error)
+	HasActiveLease(dormID uuid.UUID) (bool, error)
 	DeleteReview(leasingHistory *domain.LeasingHistory) error
 	SaveReviewImage(reviewImage *domain.ReviewImage) error
 	DeleteImageByKey(imageKey string) error
 	GetImageByKey(imageKey string) (*domain.ReviewImage, error)
-	GetReportedReviews(limit int, page int) ([]domain.LeasingHistory, int, int, error)
+	UpdateReviewReply(id uuid.UUID, reply string, replyAt *time.Time) error
+	SetHelpfulVote(historyID uuid.UUID, userID uuid.UUID, helpful bool) error
+	GetReviewSummaryByDormID(id uuid.UUID) (*domain.ReviewSummary, error)
 }
 
 type LeasingHistoryService interface {
-	Create(userID uuid.UUID, dormID uuid.UUID) (*domain.LeasingHistory, error)
-	CreateReview(user *domain.User, id uuid.UUID, Message string, Rate int) (*domain.Review, error)
+	Create(userID uuid.UUID, dormID uuid.UUID, termMonths int) (*domain.LeasingHistory, error)
+	CreateReview(user *domain.User, id uuid.UUID, Message string, Rate int, ratings domain.ReviewRatings) (*domain.Review, error)
 	GetReviewByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error)
-	UpdateReview(user *domain.User, id uuid.UUID, Message string, Rate int) (*domain.Review, error)
+	GetReviewSummaryByDormID(id uuid.UUID) (*domain.ReviewSummary, error)
+	UpdateReview(user *domain.User, id uuid.UUID, Message string, Rate int, ratings domain.ReviewRatings) (*domain.Review, error)
 	DeleteReview(user *domain.User, id uuid.UUID) error
-	Delete(id uuid.UUID) error
+	Delete(user *domain.User, id uuid.UUID) error
 	GetByID(id uuid.UUID) (*domain.LeasingHistory, error)
 	GetByUserID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error)
 	GetByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error)
-	SetEndTimestamp(id uuid.UUID) error
-	UploadReviewImage(ctx context.Context, historyID uuid.UUID, filename string, contentType string, fileData io.Reader, userID uuid.UUID, isAdmin bool) (string, error)
-	DeleteImageByURL(ctx context.Context, imageURL string, userID uuid.UUID, isAdmin bool) error
+	SetEndTimestamp(user *domain.User, id uuid.UUID) error
+	UploadReviewImage(ctx context.Context, historyID uuid.UUID, filename string, contentType string, fileData io.Reader, user *domain.User) (string, error)
+	DeleteImageByURL(ctx context.Context, imageURL string, user *domain.User) error
 	GetImageUrl(reviewImage []domain.ReviewImage) []string
-	GetReportedReviews(limit int, page int) ([]domain.LeasingHistory, int, int, error)
-	ReportReview(id uuid.UUID) (*domain.LeasingHistory, error)
+	ReplyToReview(user *domain.User, id uuid.UUID, message string) (*domain.LeasingHistory, error)
+	DeleteReviewReply(user *domain.User, id uuid.UUID) error
+	SetHelpfulVote(user *domain.User, id uuid.UUID, helpful bool) (*domain.LeasingHistory, error)
 }
 
 type LeasingHistoryHandler interface {
@@ -54,6 +60,8 @@ type LeasingHistoryHandler interface {
 	SetEndTimestamp(c *fiber.Ctx) error
 	UploadReviewImage(c *fiber.Ctx) error
 	DeleteReviewImageByURL(c *fiber.Ctx) error
-	GetReportedReviews(c *fiber.Ctx) error
-	ReportReview(c *fiber.Ctx) error
+	ReplyToReview(c *fiber.Ctx) error
+	DeleteReviewReply(c *fiber.Ctx) error
+	VoteReviewHelpful(c *fiber.Ctx) error
+	UnvoteReviewHelpful(c *fiber.Ctx) error
 }
@@ -13,16 +13,17 @@ type LeasingRequestRepository interface {
 	GetByID(id uuid.UUID) (*domain.LeasingRequest, error)
 	GetByUserID(id uuid.UUID, limit, page int, role domain.Role) ([]domain.LeasingRequest, int, int, error)
 	GetByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingRequest, int, int, error)
+	GetAverageResponseTime(ownerID uuid.UUID) (float64, int, error)
 }
 
 type LeasingRequestService interface {
-	Create(leeseeID uuid.UUID, dormID uuid.UUID, message string) (*domain.LeasingRequest, error)
+	Create(leeseeID uuid.UUID, dormID uuid.UUID, message string, termMonths int) (*domain.LeasingRequest, error)
 	Delete(id uuid.UUID) error
 	GetByUserID(id uuid.UUID, role domain.Role, limit, page int) ([]domain.LeasingRequest, int, int, error)
-	Approve(id, userId uuid.UUID, isAdmin bool) error
-	Reject(id, userId uuid.UUID, isAdmin bool) error
-	Cancel(id, userId uuid.UUID, isAdmin bool) error
-	GetByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingRequest, int, int, error)
+	Approve(id uuid.UUID, user *domain.User) error
+	Reject(id uuid.UUID, user *domain.User) error
+	Cancel(id uuid.UUID, user *domain.User) error
+	GetByDormID(user *domain.User, id uuid.UUID, limit, page int) ([]domain.LeasingRequest, int, int, error)
 }
 
 type LeasingRequestHandler interface {
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+type LessorReportRepository interface {
+	// GetRecipients returns the lessors owning dorms who have not opted out of
+	// the weekly report and have not received the one of the week yet.
+	GetRecipients(weekStart time.Time) ([]domain.User, error)
+	GetDormReports(ownerID uuid.UUID, from time.Time, to time.Time) ([]domain.DormReport, error)
+	Claim(log *domain.LessorReportLog) (bool, error)
+	AddDormView(dormID uuid.UUID, day time.Time) error
+}
+
+// DormViewRecorder counts the views of dorm pages. Failures are logged, they
+// never fail the request.
+type DormViewRecorder interface {
+	RecordView(dormID uuid.UUID)
+}
+
+type LessorReportService interface {
+	DormViewRecorder
+	SendWeekly() error
+}
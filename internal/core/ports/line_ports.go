@@ -0,0 +1,33 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type LineAccountRepository interface {
+	// GetByUserID returns nil without an error when the user has not linked LINE.
+	GetByUserID(userID uuid.UUID) (*domain.LineAccount, error)
+	// Link replaces any previous link of the user or of the LINE user.
+	Link(account *domain.LineAccount) error
+	DeleteByUserID(userID uuid.UUID) error
+	DeleteByLineUserID(lineUserID string) error
+}
+
+type LineService interface {
+	CreateLinkCode(ctx context.Context, userID uuid.UUID) (string, time.Time, error)
+	GetAccount(userID uuid.UUID) (*domain.LineAccount, error)
+	Unlink(userID uuid.UUID) error
+	HandleWebhook(ctx context.Context, body []byte, signature string) error
+}
+
+type LineHandler interface {
+	CreateLinkCode(c *fiber.Ctx) error
+	GetAccount(c *fiber.Ctx) error
+	Unlink(c *fiber.Ctx) error
+	Webhook(c *fiber.Ctx) error
+}
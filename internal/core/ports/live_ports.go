@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// LivePublisher pushes events to every connected session of a user. Pushing is
+// best effort, clients that are offline catch up by fetching as before.
+type LivePublisher interface {
+	Push(userID uuid.UUID, eventType domain.LiveEventType, data interface{})
+}
+
+type LiveService interface {
+	LivePublisher
+	Subscribe(ctx context.Context, userID uuid.UUID) (<-chan string, error)
+	Active(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error)
+}
+
+type LiveHandler interface {
+	Stream(c *fiber.Ctx) error
+}
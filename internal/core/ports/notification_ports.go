@@ -0,0 +1,50 @@
+package ports
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type NotificationRepository interface {
+	Create(notification *domain.Notification) error
+	GetByUserID(userID uuid.UUID, unreadOnly bool, limit int, page int) ([]domain.Notification, int, int, error)
+	CountUnread(userID uuid.UUID) (int64, error)
+	MarkRead(userID uuid.UUID, id uuid.UUID) error
+	MarkAllRead(userID uuid.UUID) error
+	GetPreferences(userID uuid.UUID) ([]domain.NotificationPreference, error)
+	SavePreferences(preferences []domain.NotificationPreference) error
+}
+
+// NotificationPublisher is what services use to tell users about key transitions.
+// Delivery failures are logged, they never fail the transition itself.
+type NotificationPublisher interface {
+	Publish(event domain.NotificationEvent)
+}
+
+// NotificationChannel delivers notifications outside the app, e.g. by email.
+// The NotificationPreference of the user turns each channel on per type.
+type NotificationChannel interface {
+	Name() string
+	Enabled(preference domain.NotificationPreference) bool
+	Send(user *domain.User, event domain.NotificationEvent) error
+}
+
+type NotificationService interface {
+	NotificationPublisher
+	GetByUserID(userID uuid.UUID, unreadOnly bool, limit int, page int) ([]domain.Notification, int, int, error)
+	CountUnread(userID uuid.UUID) (int64, error)
+	MarkRead(userID uuid.UUID, id uuid.UUID) error
+	MarkAllRead(userID uuid.UUID) error
+	GetPreferences(userID uuid.UUID) ([]domain.NotificationPreference, error)
+	UpdatePreferences(userID uuid.UUID, preferences []domain.NotificationPreference) ([]domain.NotificationPreference, error)
+}
+
+type NotificationHandler interface {
+	GetAll(c *fiber.Ctx) error
+	GetUnreadCount(c *fiber.Ctx) error
+	MarkRead(c *fiber.Ctx) error
+	MarkAllRead(c *fiber.Ctx) error
+	GetPreferences(c *fiber.Ctx) error
+	UpdatePreferences(c *fiber.Ctx) error
+}
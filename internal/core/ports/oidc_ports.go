@@ -0,0 +1,35 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type OIDCRepository interface {
+	Create(identity *domain.UserIdentity) error
+	// GetByProviderSubject returns nil without an error when the provider account is not linked.
+	GetByProviderSubject(provider string, subject string) (*domain.UserIdentity, error)
+	GetByUserID(userID uuid.UUID) ([]domain.UserIdentity, error)
+	Delete(userID uuid.UUID, provider string) error
+}
+
+type OIDCService interface {
+	GetAuthorizationURL(ctx context.Context, provider string, linkUserID uuid.UUID) (string, error)
+	Login(ctx context.Context, provider string, code string, state string, client dto.ClientInfo) (*domain.User, string, string, string, error)
+	Link(ctx context.Context, userID uuid.UUID, provider string, code string, state string) (*domain.UserIdentity, error)
+	Unlink(user *domain.User, provider string) error
+	GetIdentities(userID uuid.UUID) ([]domain.UserIdentity, error)
+}
+
+type OIDCHandler interface {
+	GetLoginURL(c *fiber.Ctx) error
+	Callback(c *fiber.Ctx) error
+	GetLinkURL(c *fiber.Ctx) error
+	Link(c *fiber.Ctx) error
+	Unlink(c *fiber.Ctx) error
+	GetIdentities(c *fiber.Ctx) error
+}
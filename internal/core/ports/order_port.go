@@ -15,7 +15,7 @@ type OrderRepository interface {
 }
 
 type OrderService interface {
-	CreateOrder(leasingHistoryID uuid.UUID) (*domain.Order, error)
+	CreateOrder(user *domain.User, leasingHistoryID uuid.UUID) (*domain.Order, error)
 	GetOrderByID(orderID uuid.UUID) (*domain.Order, error)
 	GetUnpaidOrderByUserID(userID uuid.UUID, limit int, page int) ([]domain.Order, int, int, error)
 	UpdateOrder(order *domain.Order) error
@@ -0,0 +1,18 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PhoneVerificationService interface {
+	SendCode(ctx context.Context, user *domain.User) error
+	Verify(ctx context.Context, user *domain.User, code string) (*domain.User, error)
+}
+
+type PhoneVerificationHandler interface {
+	SendCode(c *fiber.Ctx) error
+	Verify(c *fiber.Ctx) error
+}
@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+)
+
+// ReminderRepository finds what needs a reminder. Each query returns the rows
+// whose anchor time is in [from, to).
+type ReminderRepository interface {
+	GetUnpaidOrders(from time.Time, to time.Time) ([]domain.Order, error)
+	GetWaitingContracts(from time.Time, to time.Time) ([]domain.Contract, error)
+	GetPendingLeasingRequests(from time.Time, to time.Time) ([]domain.LeasingRequest, error)
+	GetEndingLeases(from time.Time, to time.Time) ([]domain.LeasingHistory, error)
+	// Claim records the reminder and reports false when it was already sent.
+	Claim(log *domain.ReminderLog) (bool, error)
+}
+
+type ReminderService interface {
+	SendDue() error
+}
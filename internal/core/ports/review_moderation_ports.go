@@ -0,0 +1,38 @@
+package ports
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReviewModerationRepository interface {
+	CreateReport(report *domain.ReviewReport) error
+	HasOpenReport(historyID uuid.UUID, reporterID uuid.UUID) (bool, error)
+	GetQueue(limit int, page int) ([]domain.LeasingHistory, int, int, error)
+	CreateModeration(moderation *domain.ReviewModeration, history *domain.LeasingHistory, reviewValues map[string]interface{}) error
+	GetModerationByID(id uuid.UUID) (*domain.ReviewModeration, error)
+	GetLatestModerationByHistoryID(historyID uuid.UUID) (*domain.ReviewModeration, error)
+	HasAppeal(historyID uuid.UUID) (bool, error)
+	SaveAppeal(moderation *domain.ReviewModeration) error
+	GetPendingAppeals(limit int, page int) ([]domain.ReviewModeration, int, int, error)
+	DecideAppeal(moderation *domain.ReviewModeration, history *domain.LeasingHistory, reviewValues map[string]interface{}) error
+}
+
+type ReviewModerationService interface {
+	Report(user *domain.User, historyID uuid.UUID, category domain.ReportCategory, reason string) (*domain.LeasingHistory, error)
+	GetQueue(limit int, page int) ([]domain.LeasingHistory, int, int, error)
+	Moderate(adminID uuid.UUID, historyID uuid.UUID, decision domain.ModerationDecision, reason string) (*domain.ReviewModeration, error)
+	Appeal(user *domain.User, historyID uuid.UUID, message string) (*domain.ReviewModeration, error)
+	GetPendingAppeals(limit int, page int) ([]domain.ReviewModeration, int, int, error)
+	DecideAppeal(adminID uuid.UUID, moderationID uuid.UUID, status domain.AppealStatus, reason string) (*domain.ReviewModeration, error)
+}
+
+type ReviewModerationHandler interface {
+	Report(c *fiber.Ctx) error
+	GetQueue(c *fiber.Ctx) error
+	Moderate(c *fiber.Ctx) error
+	Appeal(c *fiber.Ctx) error
+	GetPendingAppeals(c *fiber.Ctx) error
+	DecideAppeal(c *fiber.Ctx) error
+}
@@ -0,0 +1,43 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SavedSearchRepository interface {
+	Create(search *domain.SavedSearch) error
+	GetByID(id uuid.UUID) (*domain.SavedSearch, error)
+	GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.SavedSearch, int, int, error)
+	Update(search *domain.SavedSearch) error
+	Delete(id uuid.UUID) error
+	GetCandidatesForDorm(dorm *domain.Dorm) ([]domain.SavedSearch, error)
+	CreateMatch(match *domain.SavedSearchMatch) (bool, error)
+	ReopenMatch(match *domain.SavedSearchMatch) (bool, error)
+	MarkMatchesNotified(ids []uuid.UUID) error
+	GetPendingDigestMatches(before time.Time) ([]domain.SavedSearchMatch, error)
+	ClaimDigest(id uuid.UUID, before time.Time, at time.Time) (bool, error)
+}
+
+type SavedSearchService interface {
+	Create(user *domain.User, body dto.SavedSearchRequestBody) (*domain.SavedSearch, error)
+	GetByID(userID uuid.UUID, id uuid.UUID) (*domain.SavedSearch, error)
+	GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.SavedSearch, int, int, error)
+	Update(userID uuid.UUID, id uuid.UUID, body dto.SavedSearchRequestBody) (*domain.SavedSearch, error)
+	Delete(userID uuid.UUID, id uuid.UUID) error
+	MatchDorm(dorm *domain.Dorm, previous *domain.Dorm)
+	MatchAvailableDorm(dorm *domain.Dorm)
+	SendDailyDigest() error
+}
+
+type SavedSearchHandler interface {
+	Create(c *fiber.Ctx) error
+	GetMine(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+	GetDorms(c *fiber.Ctx) error
+}
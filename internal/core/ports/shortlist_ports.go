@@ -0,0 +1,47 @@
+package ports
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShortlistRepository interface {
+	Create(shortlist *domain.Shortlist) error
+	GetByID(id uuid.UUID) (*domain.Shortlist, error)
+	GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.Shortlist, int, int, error)
+	UpdateName(id uuid.UUID, name string) error
+	Delete(id uuid.UUID) error
+	AddItem(item *domain.ShortlistItem) error
+	RemoveItem(shortlistID uuid.UUID, dormID uuid.UUID) error
+	RemoveItemsByDormID(dormID uuid.UUID) error
+	GetUsersByDormID(dormID uuid.UUID) ([]domain.User, error)
+	CountUsersByDormID(dormID uuid.UUID) (int, error)
+}
+
+type ShortlistService interface {
+	Create(user *domain.User, name string) (*domain.Shortlist, error)
+	GetByID(userID uuid.UUID, id uuid.UUID) (*domain.Shortlist, error)
+	GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.Shortlist, int, int, error)
+	Rename(userID uuid.UUID, id uuid.UUID, name string) (*domain.Shortlist, error)
+	Delete(userID uuid.UUID, id uuid.UUID) error
+	AddDorm(userID uuid.UUID, id uuid.UUID, dormID uuid.UUID) (*domain.Shortlist, error)
+	RemoveDorm(userID uuid.UUID, id uuid.UUID, dormID uuid.UUID) error
+	Compare(dormIDs []uuid.UUID) ([]dto.DormComparisonResponseBody, error)
+	GetSavedCount(user *domain.User, dormID uuid.UUID) (int, error)
+	NotifyDormUpdated(dorm *domain.Dorm, previous *domain.Dorm)
+	NotifyDormDeleted(dorm *domain.Dorm)
+}
+
+type ShortlistHandler interface {
+	Create(c *fiber.Ctx) error
+	GetMine(c *fiber.Ctx) error
+	GetByID(c *fiber.Ctx) error
+	Rename(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+	AddDorm(c *fiber.Ctx) error
+	RemoveDorm(c *fiber.Ctx) error
+	Compare(c *fiber.Ctx) error
+	GetSavedCount(c *fiber.Ctx) error
+}
@@ -0,0 +1,58 @@
+package ports
+
+import (
+	"context"
+	"io"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ThreadRepository interface {
+	Create(thread *domain.Thread) error
+	GetByID(id uuid.UUID) (*domain.Thread, error)
+	GetBySubject(subject domain.ThreadSubject, subjectID uuid.UUID, lesseeID uuid.UUID) (*domain.Thread, error)
+	GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.Thread, int, int, error)
+	CreateMessage(thread *domain.Thread, message *domain.ThreadMessage) error
+	GetMessages(threadID uuid.UUID, limit int, page int) ([]domain.ThreadMessage, int, int, error)
+	GetRead(threadID uuid.UUID, userID uuid.UUID) (*domain.ThreadRead, error)
+	SaveRead(read *domain.ThreadRead) error
+	CountUnread(threadID uuid.UUID, userID uuid.UUID) (int64, error)
+	CountAllUnread(userID uuid.UUID) (int64, error)
+	CreateReport(report *domain.ThreadReport) error
+	IsAttached(threadID uuid.UUID) (bool, error)
+	Block(block *domain.UserBlock) error
+	Unblock(blockerID uuid.UUID, blockedID uuid.UUID) error
+	IsBlocked(userID uuid.UUID, otherID uuid.UUID) (bool, error)
+	GetBlocks(blockerID uuid.UUID) ([]domain.UserBlock, error)
+}
+
+type ThreadService interface {
+	Open(user *domain.User, subject domain.ThreadSubject, subjectID uuid.UUID) (*dto.ThreadResponseBody, error)
+	GetMine(user *domain.User, limit int, page int) ([]dto.ThreadResponseBody, int, int, error)
+	GetByID(user *domain.User, id uuid.UUID) (*dto.ThreadResponseBody, error)
+	GetMessages(ctx context.Context, user *domain.User, id uuid.UUID, limit int, page int) ([]dto.ThreadMessageResponseBody, int, int, error)
+	Send(ctx context.Context, user *domain.User, id uuid.UUID, body string, filename string, contentType string, image io.Reader) (*dto.ThreadMessageResponseBody, error)
+	MarkRead(user *domain.User, id uuid.UUID) error
+	CountUnread(userID uuid.UUID) (int64, error)
+	Report(user *domain.User, id uuid.UUID, category domain.ReportCategory, reason string) error
+	Block(user *domain.User, blockedID uuid.UUID) error
+	Unblock(user *domain.User, blockedID uuid.UUID) error
+	GetBlocks(userID uuid.UUID) ([]domain.UserBlock, error)
+}
+
+type ThreadHandler interface {
+	Open(c *fiber.Ctx) error
+	GetMine(c *fiber.Ctx) error
+	GetByID(c *fiber.Ctx) error
+	GetMessages(c *fiber.Ctx) error
+	Send(c *fiber.Ctx) error
+	MarkRead(c *fiber.Ctx) error
+	GetUnreadCount(c *fiber.Ctx) error
+	Report(c *fiber.Ctx) error
+	Block(c *fiber.Ctx) error
+	Unblock(c *fiber.Ctx) error
+	GetBlocks(c *fiber.Ctx) error
+}
@@ -0,0 +1,36 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TwoFactorRepository interface {
+	ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+	DeleteRecoveryCodes(userID uuid.UUID) error
+}
+
+type TwoFactorService interface {
+	Setup(user *domain.User) (string, string, error)
+	Enable(user *domain.User, code string) ([]string, error)
+	Disable(user *domain.User, code string) error
+	RegenerateRecoveryCodes(user *domain.User, code string) ([]string, error)
+	Verify(ctx context.Context, token string, code string, client dto.ClientInfo) (*domain.User, string, string, error)
+	SetupPending(ctx context.Context, token string) (string, string, error)
+	Enroll(ctx context.Context, token string, code string, client dto.ClientInfo) (*domain.User, []string, string, string, error)
+}
+
+type TwoFactorHandler interface {
+	Setup(c *fiber.Ctx) error
+	Enable(c *fiber.Ctx) error
+	Disable(c *fiber.Ctx) error
+	RegenerateRecoveryCodes(c *fiber.Ctx) error
+	Verify(c *fiber.Ctx) error
+	SetupPending(c *fiber.Ctx) error
+	Enroll(c *fiber.Ctx) error
+}
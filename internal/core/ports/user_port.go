@@ -16,6 +16,8 @@ type UserRepository interface {
 	UpdateInformation(userID uuid.UUID, data domain.User) error
 	UpdateUser(user *domain.User) error
 	GetUserByEmail(email string) (*domain.User, error)
+	// FindUserByEmail returns nil without an error when no user has the email.
+	FindUserByEmail(email string) (*domain.User, error)
 	DeleteAccount(userID uuid.UUID) error
 	GetLessorIncome(lessorID uuid.UUID) (float64, error)
 	GetPending(limit int, page int) ([]domain.User, int, int, error)
@@ -24,23 +26,30 @@ type UserRepository interface {
 type UserService interface {
 	ConvertToDTO(user domain.User) dto.UserResponse
 	GetStudentEvidenceDTO(c context.Context, studentEvidence string) (*dto.StudentEvidenceUploadResponseBody, error)
-	Create(ctx context.Context, user *domain.User) (string, string, error)
+	Create(ctx context.Context, user *domain.User, client dto.ClientInfo) (string, string, error)
 	GetUserByEmail(email string) (*domain.User, error)
 	GetUserByID(id uuid.UUID) (*domain.User, error)
 	FirstFillInformation(userID uuid.UUID, data dto.UserFirstFillRequestBody) (*domain.User, error)
-	UpdateInformation(userID uuid.UUID, data dto.UserInformationRequestBody) (*domain.User, error)
-	Login(context.Context, string, string) (*domain.User, string, string, error)
+	UpdateInformation(ctx context.Context, userID uuid.UUID, sessionID string, data dto.UserInformationRequestBody) (*domain.User, error)
+	Login(ctx context.Context, email string, password string, client dto.ClientInfo) (user *domain.User, accessToken string, refreshToken string, twoFactorToken string, err error)
 	RefreshToken(ctx context.Context, refreshToken string) (string, string, error)
-	VerifyUser(ctx context.Context, token string) (*domain.User, string, string, error)
+	GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) error
+	Logout(ctx context.Context, userID uuid.UUID, sessionID string, all bool) error
+	VerifyUser(ctx context.Context, token string, client dto.ClientInfo) (*domain.User, string, string, error)
 	ResetPasswordCreate(context.Context, string) error
-	ResetPassword(context.Context, string, string) (*domain.User, string, string, error)
-	DeleteAccount(userID uuid.UUID) error
+	ResetPassword(ctx context.Context, token string, password string, client dto.ClientInfo) (*domain.User, string, string, error)
+	DeleteAccount(ctx context.Context, userID uuid.UUID) error
 	UploadStudentEvidence(ctx context.Context, filename string, contentType string, fileData io.Reader, userID uuid.UUID) (string, error)
-	GetStudentEvidenceByID(ctx context.Context, id uuid.UUID, isSelf bool, isAdmin bool) (*dto.StudentEvidenceUploadResponseBody, error)
+	GetStudentEvidenceByID(ctx context.Context, id uuid.UUID, viewer *domain.User) (*dto.StudentEvidenceUploadResponseBody, error)
 	ResendVerificationEmailService(ctx context.Context, email string) error
+	RequestEmailChange(ctx context.Context, user *domain.User, newEmail string, password string) error
+	ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error)
 	UploadProfilePicture(ctx context.Context, filename string, contentType string, fileData io.Reader, userID uuid.UUID) (string, error)
-	GetLessorIncome(lessorID uuid.UUID, userRole domain.Role) (float64, error)
-	UpdateUserBanStatus(id uuid.UUID, ban bool) (*domain.User, error)
+	GetLessorIncome(user *domain.User) (float64, error)
+	UpdateUserBanStatus(ctx context.Context, admin *domain.User, id uuid.UUID, ban bool) (*domain.User, error)
+	UpdateUserRole(admin *domain.User, id uuid.UUID, role domain.Role) (*domain.User, error)
 	GetPending(limit int, page int) ([]domain.User, int, int, error)
 	UpdateVerificationStatus(lesseeID uuid.UUID, status domain.VerificationStatus) (*domain.User, error)
 }
@@ -49,6 +58,10 @@ type UserHandler interface {
 	Register(c *fiber.Ctx) error
 	Login(c *fiber.Ctx) error
 	RefreshToken(c *fiber.Ctx) error
+	GetSessions(c *fiber.Ctx) error
+	RevokeSession(c *fiber.Ctx) error
+	RevokeOtherSessions(c *fiber.Ctx) error
+	Logout(c *fiber.Ctx) error
 	FirstFillInformation(c *fiber.Ctx) error
 	UpdateUserInformation(c *fiber.Ctx) error
 	VerifyEmail(c *fiber.Ctx) error
@@ -60,10 +73,13 @@ type UserHandler interface {
 	UploadStudentEvidence(c *fiber.Ctx) error
 	GetStudentEvidenceByID(c *fiber.Ctx) error
 	ResendVerificationEmailHandler(c *fiber.Ctx) error
+	RequestEmailChange(c *fiber.Ctx) error
+	ConfirmEmailChange(c *fiber.Ctx) error
 	UploadProfilePicture(c *fiber.Ctx) error
 	GetLessorIncome(c *fiber.Ctx) error
 	BanUser(c *fiber.Ctx) error
 	UnbanUser(c *fiber.Ctx) error
+	SetUserRole(c *fiber.Ctx) error
 	GetPending(c *fiber.Ctx) error
 	VerifyStudentVerification(c *fiber.Ctx) error
 	RejectStudentVerification(c *fiber.Ctx) error
@@ -0,0 +1,52 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WebhookRepository interface {
+	Create(webhook *domain.Webhook) error
+	GetByID(id uuid.UUID) (*domain.Webhook, error)
+	GetByOwnerID(ownerID uuid.UUID, limit int, page int) ([]domain.Webhook, int, int, error)
+	GetSubscribers(ownerID uuid.UUID, event domain.WebhookEvent) ([]domain.Webhook, error)
+	Update(webhook *domain.Webhook) error
+	Delete(id uuid.UUID) error
+	CreateDelivery(delivery *domain.WebhookDelivery) error
+	GetDeliveryByID(id uuid.UUID) (*domain.WebhookDelivery, error)
+	GetDeliveriesByWebhookID(webhookID uuid.UUID, limit int, page int) ([]domain.WebhookDelivery, int, int, error)
+	ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error)
+	UpdateDelivery(delivery *domain.WebhookDelivery) error
+}
+
+// WebhookPublisher is what services use to tell a lessor's webhooks about an event
+// of their dorms. Like NotificationPublisher, failures never fail the transition.
+type WebhookPublisher interface {
+	Publish(ownerID uuid.UUID, event domain.WebhookEvent, data any)
+}
+
+type WebhookService interface {
+	WebhookPublisher
+	Create(owner *domain.User, body dto.WebhookRequestBody) (*domain.Webhook, error)
+	GetByID(ownerID uuid.UUID, id uuid.UUID) (*domain.Webhook, error)
+	GetByOwnerID(ownerID uuid.UUID, limit int, page int) ([]domain.Webhook, int, int, error)
+	Update(ownerID uuid.UUID, id uuid.UUID, body dto.WebhookRequestBody) (*domain.Webhook, error)
+	Delete(ownerID uuid.UUID, id uuid.UUID) error
+	GetDeliveries(ownerID uuid.UUID, id uuid.UUID, limit int, page int) ([]domain.WebhookDelivery, int, int, error)
+	Redeliver(ownerID uuid.UUID, id uuid.UUID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error)
+	ProcessDue() error
+}
+
+type WebhookHandler interface {
+	Create(c *fiber.Ctx) error
+	GetMine(c *fiber.Ctx) error
+	GetByID(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+	GetDeliveries(c *fiber.Ctx) error
+	Redeliver(c *fiber.Ctx) error
+}
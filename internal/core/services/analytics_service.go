@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// medianTolerancePercent is how far from the area median a price may be and still count as at the median.
+const medianTolerancePercent = 5.0
+
+// maxRentPeriods is the most periods a rent trend has, e.g. five years of weeks.
+const maxRentPeriods = 260
+
+type AnalyticsService struct {
+	analyticsRepo ports.AnalyticsRepository
+	dormRepo      ports.DormRepository
+}
+
+func NewAnalyticsService(analyticsRepo ports.AnalyticsRepository, dormRepo ports.DormRepository) ports.AnalyticsService {
+	return &AnalyticsService{analyticsRepo: analyticsRepo, dormRepo: dormRepo}
+}
+
+func toRentStatDTO(stat domain.RentStat) dto.RentStat {
+	return dto.RentStat{P25: stat.P25, Median: stat.Median, P75: stat.P75, Count: stat.Count}
+}
+
+func (s *AnalyticsService) GetRentTrend(filter domain.RentFilter) (*dto.RentTrendResponseBody, error) {
+	switch filter.Interval {
+	case "week", "month", "quarter", "year":
+	default:
+		return nil, apperror.BadRequestError(errors.New("invalid interval"), "interval must be one of week, month, quarter or year")
+	}
+	if filter.From.After(filter.To) {
+		return nil, apperror.BadRequestError(errors.New("invalid date range"), "from cannot be after to")
+	}
+	periods := 0
+	for period := rentPeriod(filter.From, filter.Interval); !period.After(filter.To); period = nextRentPeriod(period, filter.Interval) {
+		periods++
+	}
+	if periods > maxRentPeriods {
+		return nil, apperror.BadRequestError(errors.New("too many periods"), "Select a shorter date range or a longer interval")
+	}
+
+	listing, err := s.analyticsRepo.GetListingPrices(filter)
+	if err != nil {
+		return nil, err
+	}
+	lease, err := s.analyticsRepo.GetLeasePrices(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.RentTrendResponseBody{
+		Province: filter.Province,
+		District: filter.District,
+		Interval: filter.Interval,
+		From:     filter.From,
+		To:       filter.To,
+		Points:   []dto.RentTrendPoint{},
+	}
+	if filter.Bedrooms != -1 {
+		res.Bedrooms = &filter.Bedrooms
+	}
+
+	for period := rentPeriod(filter.From, filter.Interval); !period.After(filter.To); period = nextRentPeriod(period, filter.Interval) {
+		end := nextRentPeriod(period, filter.Interval)
+		point := dto.RentTrendPoint{
+			Period:  period,
+			Listing: toRentStatDTO(rentStat(listedPrices(listing, period, end))),
+			Lease:   toRentStatDTO(rentStat(leasedPrices(lease, period, end))),
+		}
+		if point.Listing.Count > 0 || point.Lease.Count > 0 {
+			res.Points = append(res.Points, point)
+		}
+	}
+
+	return res, nil
+}
+
+// rentPeriod returns the start of the week (Monday), month, quarter or year t is in.
+func rentPeriod(t time.Time, interval string) time.Time {
+	year, month, day := t.Date()
+	switch interval {
+	case "week":
+		return time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case "quarter":
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+}
+
+func nextRentPeriod(period time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return period.AddDate(0, 0, 7)
+	case "month":
+		return period.AddDate(0, 1, 0)
+	case "quarter":
+		return period.AddDate(0, 3, 0)
+	}
+	return period.AddDate(1, 0, 0)
+}
+
+// listedPrices returns the price each dorm was listed at when the period ended,
+// so a dorm whose price did not change still counts in the period. Dorms deleted
+// before the period are left out. prices must be ordered by dorm, then oldest first.
+func listedPrices(prices []domain.RentPrice, from time.Time, to time.Time) []float64 {
+	res := []float64{}
+	for i, price := range prices {
+		if !price.At.Before(to) {
+			continue
+		}
+		current := i+1 == len(prices) || prices[i+1].DormID != price.DormID || !prices[i+1].At.Before(to)
+		if current && (price.RemovedAt == nil || !price.RemovedAt.Before(from)) {
+			res = append(res, price.Price)
+		}
+	}
+	return res
+}
+
+func leasedPrices(prices []domain.RentPrice, from time.Time, to time.Time) []float64 {
+	res := []float64{}
+	for _, price := range prices {
+		if !price.At.Before(from) && price.At.Before(to) {
+			res = append(res, price.Price)
+		}
+	}
+	return res
+}
+
+// rentStat computes percentiles the way percentile_cont does, so the series
+// agrees with GetAreaListingRent.
+func rentStat(prices []float64) domain.RentStat {
+	stat := domain.RentStat{Count: len(prices)}
+	if len(prices) == 0 {
+		return stat
+	}
+
+	sort.Float64s(prices)
+	stat.P25 = percentile(prices, 0.25)
+	stat.Median = percentile(prices, 0.5)
+	stat.P75 = percentile(prices, 0.75)
+	return stat
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower+1 == len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*(pos-float64(lower))
+}
+
+// GetMarketPositionByOwnerID compares each of a lessor's dorms with the median price of
+// other dorms with the same bedroom count in the same district.
+func (s *AnalyticsService) GetMarketPositionByOwnerID(user *domain.User, limit int, page int) ([]dto.DormMarketPositionResponseBody, int, int, error) {
+	if err := policy.Authorize(user, domain.PermissionDormAnalytics); err != nil {
+		return nil, 0, 0, apperror.ForbiddenError(err, "Only lessors can view their market position")
+	}
+
+	dorms, totalPages, totalRows, err := s.dormRepo.GetByOwnerID(user.ID, limit, page)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	res := make([]dto.DormMarketPositionResponseBody, len(dorms))
+	for i, dorm := range dorms {
+		area, err := s.analyticsRepo.GetAreaListingRent(dorm.Address.Province, dorm.Address.District, dorm.Bedrooms, dorm.ID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		res[i] = dto.DormMarketPositionResponseBody{
+			DormID:         dorm.ID,
+			Name:           dorm.Name,
+			Price:          dorm.Price,
+			AreaMedian:     area.Median,
+			AreaSampleSize: area.Count,
+			Position:       dto.NoMarketData,
+		}
+		if area.Count == 0 || area.Median <= 0 {
+			continue
+		}
+
+		diff := (dorm.Price - area.Median) / area.Median * 100
+		res[i].DifferencePercent = math.Round(diff*100) / 100
+		switch {
+		case math.Abs(diff) <= medianTolerancePercent:
+			res[i].Position = dto.AtMedian
+		case diff > 0:
+			res[i].Position = dto.AboveMedian
+		default:
+			res[i].Position = dto.BelowMedian
+		}
+	}
+
+	return res, totalPages, totalRows, nil
+}
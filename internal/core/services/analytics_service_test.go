@@ -0,0 +1,122 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAnalyticsRepo struct {
+	ports.AnalyticsRepository
+	listing []domain.RentPrice
+	lease   []domain.RentPrice
+}
+
+func (m *mockAnalyticsRepo) GetListingPrices(filter domain.RentFilter) ([]domain.RentPrice, error) {
+	return m.listing, nil
+}
+
+func (m *mockAnalyticsRepo) GetLeasePrices(filter domain.RentFilter) ([]domain.RentPrice, error) {
+	return m.lease, nil
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func monthlyFilter(from time.Time, to time.Time) domain.RentFilter {
+	return domain.RentFilter{Interval: "month", Bedrooms: -1, From: from, To: to}
+}
+
+func TestRentTrendCarriesListingPricesForward(t *testing.T) {
+	steady, changed, removed, late := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	removedAt := day(2025, time.February, 10)
+	repo := &mockAnalyticsRepo{listing: []domain.RentPrice{
+		// set before the range and never changed
+		{DormID: steady, Price: 5000, At: day(2024, time.June, 1)},
+		{DormID: changed, Price: 6000, At: day(2024, time.December, 1)},
+		{DormID: changed, Price: 6500, At: day(2025, time.February, 5)},
+		{DormID: changed, Price: 7000, At: day(2025, time.February, 20)},
+		{DormID: removed, Price: 9000, At: day(2024, time.January, 1), RemovedAt: &removedAt},
+		{DormID: late, Price: 8000, At: day(2025, time.March, 15)},
+	}}
+	service := &AnalyticsService{analyticsRepo: repo}
+
+	res, err := service.GetRentTrend(monthlyFilter(day(2025, time.January, 1), day(2025, time.April, 30)))
+	if !assert.NoError(t, err) || !assert.Len(t, res.Points, 4) {
+		return
+	}
+
+	jan, feb, mar, apr := res.Points[0], res.Points[1], res.Points[2], res.Points[3]
+	assert.Equal(t, day(2025, time.January, 1), jan.Period)
+	assert.Equal(t, 3, jan.Listing.Count)
+	assert.Equal(t, 6000.0, jan.Listing.Median)
+
+	// the last price of the month counts, the dorm removed during February still does
+	assert.Equal(t, 3, feb.Listing.Count)
+	assert.Equal(t, 7000.0, feb.Listing.Median)
+
+	assert.Equal(t, 3, mar.Listing.Count)
+	assert.Equal(t, 7000.0, mar.Listing.Median)
+	assert.Equal(t, 6000.0, mar.Listing.P25)
+	assert.Equal(t, 7500.0, mar.Listing.P75)
+
+	assert.Equal(t, apr.Listing, mar.Listing)
+	assert.Zero(t, apr.Lease.Count)
+}
+
+func TestRentTrendLeasePrices(t *testing.T) {
+	dormID := uuid.New()
+	repo := &mockAnalyticsRepo{lease: []domain.RentPrice{
+		{DormID: dormID, Price: 4000, At: day(2025, time.January, 3)},
+		{DormID: dormID, Price: 5000, At: day(2025, time.January, 31)},
+		{DormID: dormID, Price: 6000, At: day(2025, time.March, 1)},
+	}}
+	service := &AnalyticsService{analyticsRepo: repo}
+
+	res, err := service.GetRentTrend(monthlyFilter(day(2025, time.January, 1), day(2025, time.March, 31)))
+	if !assert.NoError(t, err) || !assert.Len(t, res.Points, 2, "February has no lease and no listing") {
+		return
+	}
+
+	assert.Equal(t, 2, res.Points[0].Lease.Count)
+	assert.Equal(t, 4500.0, res.Points[0].Lease.Median)
+	assert.Equal(t, 4250.0, res.Points[0].Lease.P25)
+	assert.Equal(t, day(2025, time.March, 1), res.Points[1].Period)
+	assert.Equal(t, 6000.0, res.Points[1].Lease.Median)
+	assert.Zero(t, res.Points[1].Listing.Count)
+}
+
+func TestRentPeriod(t *testing.T) {
+	// a Thursday
+	at := time.Date(2025, time.May, 15, 13, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, day(2025, time.May, 12), rentPeriod(at, "week"))
+	assert.Equal(t, day(2025, time.May, 1), rentPeriod(at, "month"))
+	assert.Equal(t, day(2025, time.April, 1), rentPeriod(at, "quarter"))
+	assert.Equal(t, day(2025, time.January, 1), rentPeriod(at, "year"))
+	assert.Equal(t, day(2025, time.May, 12), rentPeriod(day(2025, time.May, 18), "week"), "Sunday ends the week")
+}
+
+func TestRentTrendFilter(t *testing.T) {
+	service := &AnalyticsService{analyticsRepo: &mockAnalyticsRepo{}}
+
+	_, err := service.GetRentTrend(domain.RentFilter{Interval: "day", From: day(2025, time.January, 1), To: day(2025, time.February, 1)})
+	assert.ErrorContains(t, err, "interval must be one of week, month, quarter or year")
+
+	_, err = service.GetRentTrend(monthlyFilter(day(2025, time.February, 1), day(2025, time.January, 1)))
+	assert.ErrorContains(t, err, "from cannot be after to")
+
+	_, err = service.GetRentTrend(domain.RentFilter{Interval: "week", From: day(2015, time.January, 1), To: day(2025, time.January, 1)})
+	assert.ErrorContains(t, err, "Select a shorter date range or a longer interval")
+
+	res, err := service.GetRentTrend(domain.RentFilter{Interval: "year", Bedrooms: 2, From: day(2015, time.January, 1), To: day(2025, time.January, 1)})
+	if assert.NoError(t, err) {
+		assert.Empty(t, res.Points)
+		assert.Equal(t, 2, *res.Bedrooms)
+	}
+}
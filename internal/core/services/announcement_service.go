@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/storage"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+const (
+	maxAnnouncementAttachments = 5
+	announcementBatchSize      = 20
+	// announcementMaxSchedule is how far ahead an announcement can be scheduled.
+	announcementMaxSchedule          = 90 * 24 * time.Hour
+	announcementAttachmentExpiration = time.Hour
+)
+
+type AnnouncementService struct {
+	announcementRepo ports.AnnouncementRepository
+	dormRepo         ports.DormRepository
+	historyRepo      ports.LeasingHistoryRepository
+	storage          *storage.Storage
+	notifier         ports.NotificationPublisher
+}
+
+func NewAnnouncementService(announcementRepo ports.AnnouncementRepository, dormRepo ports.DormRepository, historyRepo ports.LeasingHistoryRepository, storage *storage.Storage, notifier ports.NotificationPublisher) ports.AnnouncementService {
+	return &AnnouncementService{
+		announcementRepo: announcementRepo,
+		dormRepo:         dormRepo,
+		historyRepo:      historyRepo,
+		storage:          storage,
+		notifier:         notifier,
+	}
+}
+
+func (s *AnnouncementService) ownedDorm(user *domain.User, dormID uuid.UUID) (*domain.Dorm, error) {
+	dorm, err := s.dormRepo.GetByID(dormID)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.AuthorizeOwner(user, dorm.OwnerID, domain.PermissionDormUpdate); err != nil {
+		return nil, apperror.ForbiddenError(err, "You do not have permission to manage the announcements of this dorm")
+	}
+	return dorm, nil
+}
+
+// Create posts an announcement to the current tenants of the dorm. A zero or
+// past publishAt publishes it right away, a later one schedules it.
+func (s *AnnouncementService) Create(ctx context.Context, user *domain.User, dormID uuid.UUID, body dto.AnnouncementRequestBody, publishAt time.Time, files []domain.AnnouncementFile) (*dto.AnnouncementResponseBody, error) {
+	dorm, err := s.ownedDorm(user, dormID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) > maxAnnouncementAttachments {
+		return nil, apperror.BadRequestError(errors.New("too many attachments"), fmt.Sprintf("An announcement cannot have more than %d attachments", maxAnnouncementAttachments))
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.ContentType, "image/") && file.ContentType != "application/pdf" {
+			return nil, apperror.BadRequestError(errors.New("unsupported attachment type"), "Attachments must be images or PDF files")
+		}
+	}
+
+	now := time.Now()
+	if publishAt.After(now.Add(announcementMaxSchedule)) {
+		return nil, apperror.BadRequestError(errors.New("publish time too far"), "An announcement cannot be scheduled more than 90 days ahead")
+	}
+	if publishAt.Before(now) {
+		publishAt = now
+	}
+
+	announcement := &domain.Announcement{
+		DormID:    dorm.ID,
+		Dorm:      *dorm,
+		AuthorID:  user.ID,
+		Title:     strings.TrimSpace(body.Title),
+		Body:      strings.TrimSpace(body.Body),
+		PublishAt: publishAt,
+	}
+	if !publishAt.After(now) {
+		announcement.PublishedAt = &now
+	}
+
+	for _, file := range files {
+		name := strings.ReplaceAll(file.Name, " ", "-")
+		key := fmt.Sprintf("announcements/%s/%s-%s", dorm.ID, uuid.New().String(), name)
+		if err := s.storage.UploadFile(ctx, key, file.ContentType, file.Data, storage.PrivateBucket); err != nil {
+			return nil, apperror.InternalServerError(err, "error uploading file")
+		}
+		announcement.Attachments = append(announcement.Attachments, domain.AnnouncementAttachment{
+			FileKey:     key,
+			FileName:    file.Name,
+			ContentType: file.ContentType,
+		})
+	}
+
+	if err := s.announcementRepo.Create(announcement); err != nil {
+		return nil, err
+	}
+
+	if announcement.IsPublished() {
+		s.deliver(announcement)
+	}
+
+	return s.toOwnerDTO(ctx, announcement)
+}
+
+// deliver sends the announcement to the tenant of every lease running at its publication.
+func (s *AnnouncementService) deliver(announcement *domain.Announcement) {
+	leases, err := s.announcementRepo.GetActiveLeases(announcement.DormID, *announcement.PublishedAt)
+	if err != nil {
+		log.Errorf("announcement: cannot load tenants of dorm %s: %v", announcement.DormID, err)
+		return
+	}
+
+	recipients := make([]domain.AnnouncementRecipient, 0, len(leases))
+	seen := make(map[uuid.UUID]bool, len(leases))
+	for _, lease := range leases {
+		if seen[lease.LesseeID] {
+			continue
+		}
+		seen[lease.LesseeID] = true
+		recipients = append(recipients, domain.AnnouncementRecipient{
+			AnnouncementID:   announcement.ID,
+			UserID:           lease.LesseeID,
+			LeasingHistoryID: lease.ID,
+		})
+	}
+
+	if err := s.announcementRepo.CreateRecipients(recipients); err != nil {
+		log.Errorf("announcement: cannot record recipients of %s: %v", announcement.ID, err)
+		return
+	}
+
+	for _, recipient := range recipients {
+		s.notifier.Publish(domain.NotificationEvent{
+			Type:     domain.NotificationAnnouncementPublished,
+			UserID:   recipient.UserID,
+			Title:    fmt.Sprintf("%s: %s", announcement.Dorm.Name, announcement.Title),
+			Body:     announcement.Body,
+			Link:     "/announcements/" + announcement.ID.String(),
+			DormName: announcement.Dorm.Name,
+		})
+	}
+}
+
+// PublishDue sends the scheduled announcements whose time has come.
+func (s *AnnouncementService) PublishDue() error {
+	claimed, err := s.announcementRepo.ClaimDue(time.Now(), announcementBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range claimed {
+		announcement, err := s.announcementRepo.GetByID(v.ID)
+		if err != nil {
+			log.Errorf("announcement: cannot load announcement %s: %v", v.ID, err)
+			continue
+		}
+		s.deliver(announcement)
+	}
+	return nil
+}
+
+// GetByID returns the announcement to its lessor, with read stats, or to a
+// tenant who received it.
+func (s *AnnouncementService) GetByID(ctx context.Context, user *domain.User, id uuid.UUID) (*dto.AnnouncementResponseBody, error) {
+	announcement, err := s.announcementRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if announcement.Dorm.OwnerID == user.ID || policy.Can(user, domain.PermissionDormUpdate) {
+		return s.toOwnerDTO(ctx, announcement)
+	}
+
+	recipient, err := s.announcementRepo.GetRecipient(id, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if recipient == nil {
+		return nil, apperror.ForbiddenError(errors.New("not a recipient"), "You do not have permission to view this announcement")
+	}
+	return s.toTenantDTO(ctx, announcement, recipient.ReadAt)
+}
+
+// GetByDormID returns every announcement of the dorm, scheduled ones included, to its lessor.
+func (s *AnnouncementService) GetByDormID(ctx context.Context, user *domain.User, dormID uuid.UUID, limit int, page int) ([]dto.AnnouncementResponseBody, int, int, error) {
+	if _, err := s.ownedDorm(user, dormID); err != nil {
+		return nil, 0, 0, err
+	}
+
+	announcements, totalPages, totalRows, err := s.announcementRepo.GetByDormID(dormID, limit, page)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	ids := make([]uuid.UUID, len(announcements))
+	for i, v := range announcements {
+		ids[i] = v.ID
+	}
+	stats, err := s.announcementRepo.GetStats(ids)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	resData := make([]dto.AnnouncementResponseBody, len(announcements))
+	for i, v := range announcements {
+		attachments, err := s.attachmentsToDTO(ctx, v.Attachments)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		res := v.ToDTO(attachments)
+		res.Stats = &dto.AnnouncementStats{Recipients: stats[v.ID].Recipients, Read: stats[v.ID].Read}
+		resData[i] = res
+	}
+	return resData, totalPages, totalRows, nil
+}
+
+// GetByLeasingHistoryID returns the announcements sent to the tenant of the lease.
+func (s *AnnouncementService) GetByLeasingHistoryID(ctx context.Context, user *domain.User, historyID uuid.UUID, limit int, page int) ([]dto.AnnouncementResponseBody, int, int, error) {
+	history, err := s.historyRepo.GetByID(historyID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if user.ID != history.LesseeID && user.ID != history.Dorm.OwnerID && !policy.Can(user, domain.PermissionLeasingManage) {
+		return nil, 0, 0, apperror.ForbiddenError(errors.New("unauthorized action"), "You do not have permission to view the announcements of this lease")
+	}
+
+	recipients, totalPages, totalRows, err := s.announcementRepo.GetByLeasingHistoryID(historyID, limit, page)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	resData := make([]dto.AnnouncementResponseBody, len(recipients))
+	for i, v := range recipients {
+		res, err := s.toTenantDTO(ctx, &v.Announcement, v.ReadAt)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		resData[i] = *res
+	}
+	return resData, totalPages, totalRows, nil
+}
+
+func (s *AnnouncementService) MarkRead(user *domain.User, id uuid.UUID) error {
+	recipient, err := s.announcementRepo.GetRecipient(id, user.ID)
+	if err != nil {
+		return err
+	}
+	if recipient == nil {
+		return apperror.NotFoundError(errors.New("not a recipient"), "Announcement not found")
+	}
+	return s.announcementRepo.MarkRead(id, user.ID, time.Now())
+}
+
+// Delete cancels a scheduled announcement, or removes a published one for its tenants.
+func (s *AnnouncementService) Delete(ctx context.Context, user *domain.User, id uuid.UUID) error {
+	announcement, err := s.announcementRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := policy.AuthorizeOwner(user, announcement.Dorm.OwnerID, domain.PermissionDormUpdate); err != nil {
+		return apperror.ForbiddenError(err, "You do not have permission to manage the announcements of this dorm")
+	}
+
+	if err := s.announcementRepo.Delete(announcement); err != nil {
+		return err
+	}
+
+	for _, attachment := range announcement.Attachments {
+		if err := s.storage.DeleteFile(ctx, attachment.FileKey, storage.PrivateBucket); err != nil {
+			log.Errorf("announcement: cannot delete attachment %s: %v", attachment.FileKey, err)
+		}
+	}
+	return nil
+}
+
+func (s *AnnouncementService) attachmentsToDTO(ctx context.Context, attachments []domain.AnnouncementAttachment) ([]dto.AnnouncementAttachmentResponseBody, error) {
+	resData := make([]dto.AnnouncementAttachmentResponseBody, len(attachments))
+	for i, v := range attachments {
+		url, err := s.storage.GetSignedUrl(ctx, v.FileKey, announcementAttachmentExpiration)
+		if err != nil {
+			return nil, apperror.InternalServerError(err, "Failed to get attachment url")
+		}
+		resData[i] = dto.AnnouncementAttachmentResponseBody{Name: v.FileName, ContentType: v.ContentType, URL: url}
+	}
+	return resData, nil
+}
+
+func (s *AnnouncementService) toOwnerDTO(ctx context.Context, announcement *domain.Announcement) (*dto.AnnouncementResponseBody, error) {
+	attachments, err := s.attachmentsToDTO(ctx, announcement.Attachments)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.announcementRepo.GetStats([]uuid.UUID{announcement.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	res := announcement.ToDTO(attachments)
+	res.Stats = &dto.AnnouncementStats{Recipients: stats[announcement.ID].Recipients, Read: stats[announcement.ID].Read}
+	return &res, nil
+}
+
+func (s *AnnouncementService) toTenantDTO(ctx context.Context, announcement *domain.Announcement, readAt *time.Time) (*dto.AnnouncementResponseBody, error) {
+	attachments, err := s.attachmentsToDTO(ctx, announcement.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	read := readAt != nil
+	res := announcement.ToDTO(attachments)
+	res.Read = &read
+	res.ReadAt = readAt
+	return &res, nil
+}
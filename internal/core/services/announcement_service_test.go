@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAnnouncementRepo struct {
+	ports.AnnouncementRepository
+	announcements map[uuid.UUID]*domain.Announcement
+	leases        []domain.LeasingHistory
+	recipients    []domain.AnnouncementRecipient
+}
+
+func (m *mockAnnouncementRepo) Create(announcement *domain.Announcement) error {
+	announcement.ID = uuid.New()
+	m.announcements[announcement.ID] = announcement
+	return nil
+}
+
+func (m *mockAnnouncementRepo) GetByID(id uuid.UUID) (*domain.Announcement, error) {
+	announcement, ok := m.announcements[id]
+	if !ok {
+		return nil, errors.New("announcement not found")
+	}
+	return announcement, nil
+}
+
+func (m *mockAnnouncementRepo) ClaimDue(now time.Time, limit int) ([]domain.Announcement, error) {
+	var claimed []domain.Announcement
+	for _, announcement := range m.announcements {
+		if announcement.PublishedAt == nil && !announcement.PublishAt.After(now) && len(claimed) < limit {
+			announcement.PublishedAt = &now
+			claimed = append(claimed, *announcement)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *mockAnnouncementRepo) GetActiveLeases(dormID uuid.UUID, at time.Time) ([]domain.LeasingHistory, error) {
+	var leases []domain.LeasingHistory
+	for _, lease := range m.leases {
+		if lease.DormID == dormID && !lease.Start.After(at) && (lease.End.IsZero() || lease.End.After(at)) {
+			leases = append(leases, lease)
+		}
+	}
+	return leases, nil
+}
+
+func (m *mockAnnouncementRepo) CreateRecipients(recipients []domain.AnnouncementRecipient) error {
+	m.recipients = append(m.recipients, recipients...)
+	return nil
+}
+
+func (m *mockAnnouncementRepo) GetRecipient(announcementID uuid.UUID, userID uuid.UUID) (*domain.AnnouncementRecipient, error) {
+	for i, recipient := range m.recipients {
+		if recipient.AnnouncementID == announcementID && recipient.UserID == userID {
+			return &m.recipients[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockAnnouncementRepo) MarkRead(announcementID uuid.UUID, userID uuid.UUID, at time.Time) error {
+	for i, recipient := range m.recipients {
+		if recipient.AnnouncementID == announcementID && recipient.UserID == userID && recipient.ReadAt == nil {
+			m.recipients[i].ReadAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *mockAnnouncementRepo) GetStats(announcementIDs []uuid.UUID) (map[uuid.UUID]domain.AnnouncementStats, error) {
+	stats := map[uuid.UUID]domain.AnnouncementStats{}
+	for _, recipient := range m.recipients {
+		stat := stats[recipient.AnnouncementID]
+		stat.Recipients++
+		if recipient.ReadAt != nil {
+			stat.Read++
+		}
+		stats[recipient.AnnouncementID] = stat
+	}
+	return stats, nil
+}
+
+type announcementFixture struct {
+	service  *AnnouncementService
+	repo     *mockAnnouncementRepo
+	notifier *mockNotifier
+	dorm     *domain.Dorm
+	owner    *domain.User
+}
+
+func newAnnouncementFixture() *announcementFixture {
+	owner := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+	dorm := &domain.Dorm{ID: uuid.New(), OwnerID: owner.ID, Name: "Baan Suan"}
+	repo := &mockAnnouncementRepo{announcements: map[uuid.UUID]*domain.Announcement{}}
+	notifier := &mockNotifier{}
+
+	return &announcementFixture{
+		service:  &AnnouncementService{announcementRepo: repo, dormRepo: newMockDormStore(dorm), notifier: notifier},
+		repo:     repo,
+		notifier: notifier,
+		dorm:     dorm,
+		owner:    owner,
+	}
+}
+
+// lease adds a lease of the dorm from start, ended at end unless it is zero.
+func (f *announcementFixture) lease(lesseeID uuid.UUID, start time.Time, end time.Time) domain.LeasingHistory {
+	lease := domain.LeasingHistory{ID: uuid.New(), DormID: f.dorm.ID, LesseeID: lesseeID, Start: start, End: end}
+	f.repo.leases = append(f.repo.leases, lease)
+	return lease
+}
+
+func (f *announcementFixture) post(t *testing.T, publishAt time.Time) *dto.AnnouncementResponseBody {
+	res, err := f.service.Create(context.Background(), f.owner, f.dorm.ID, dto.AnnouncementRequestBody{Title: " Water outage ", Body: "No water on Monday."}, publishAt, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return res
+}
+
+func recipientIDs(recipients []domain.AnnouncementRecipient) []uuid.UUID {
+	ids := make([]uuid.UUID, len(recipients))
+	for i, recipient := range recipients {
+		ids[i] = recipient.UserID
+	}
+	return ids
+}
+
+func TestAnnouncementRecipients(t *testing.T) {
+	f := newAnnouncementFixture()
+	now := time.Now()
+	current, moved, previous := uuid.New(), uuid.New(), uuid.New()
+	f.lease(current, now.AddDate(0, -3, 0), time.Time{})
+	// renewed: an ended lease and the running one of the same tenant
+	f.lease(moved, now.AddDate(-1, 0, 0), now.AddDate(0, -1, 0))
+	renewed := f.lease(moved, now.AddDate(0, -1, 0), time.Time{})
+	f.lease(previous, now.AddDate(-1, 0, 0), now.AddDate(0, -2, 0))
+	f.lease(uuid.New(), now.AddDate(0, 1, 0), time.Time{})
+
+	res := f.post(t, time.Time{})
+
+	assert.ElementsMatch(t, []uuid.UUID{current, moved}, recipientIDs(f.repo.recipients))
+	for _, recipient := range f.repo.recipients {
+		if recipient.UserID == moved {
+			assert.Equal(t, renewed.ID, recipient.LeasingHistoryID)
+		}
+	}
+	if assert.Len(t, f.notifier.events, 2) {
+		assert.Equal(t, domain.NotificationAnnouncementPublished, f.notifier.events[0].Type)
+		assert.Equal(t, "Baan Suan: Water outage", f.notifier.events[0].Title)
+		assert.Equal(t, "/announcements/"+res.ID.String(), f.notifier.events[0].Link)
+	}
+	if assert.NotNil(t, res.Stats) {
+		assert.Equal(t, int64(2), res.Stats.Recipients)
+	}
+}
+
+func TestAnnouncementSchedule(t *testing.T) {
+	f := newAnnouncementFixture()
+	now := time.Now()
+	f.lease(uuid.New(), now.AddDate(0, -3, 0), time.Time{})
+
+	res := f.post(t, now.Add(time.Hour))
+	assert.Empty(t, f.repo.recipients, "scheduled announcements are not sent yet")
+
+	assert.NoError(t, f.service.PublishDue())
+	assert.Empty(t, f.repo.recipients)
+
+	// recipients are the tenants at publication, not when it was scheduled
+	late := uuid.New()
+	f.lease(late, now.Add(-time.Minute), time.Time{})
+	f.repo.announcements[res.ID].PublishAt = now.Add(-time.Minute)
+
+	assert.NoError(t, f.service.PublishDue())
+	assert.Len(t, f.repo.recipients, 2)
+	assert.Contains(t, recipientIDs(f.repo.recipients), late)
+
+	assert.NoError(t, f.service.PublishDue())
+	assert.Len(t, f.repo.recipients, 2, "published once")
+	assert.Len(t, f.notifier.events, 2)
+
+	_, err := f.service.Create(context.Background(), f.owner, f.dorm.ID, dto.AnnouncementRequestBody{Title: "Later", Body: "Later"}, now.AddDate(0, 4, 0), nil)
+	assert.ErrorContains(t, err, "An announcement cannot be scheduled more than 90 days ahead")
+}
+
+func TestAnnouncementCreate(t *testing.T) {
+	f := newAnnouncementFixture()
+
+	_, err := f.service.Create(context.Background(), &domain.User{ID: uuid.New(), Role: domain.LessorRole}, f.dorm.ID, dto.AnnouncementRequestBody{Title: "Hi", Body: "Hi"}, time.Time{}, nil)
+	assert.ErrorContains(t, err, "You do not have permission to manage the announcements of this dorm")
+
+	_, err = f.service.Create(context.Background(), f.owner, f.dorm.ID, dto.AnnouncementRequestBody{Title: "Hi", Body: "Hi"}, time.Time{}, []domain.AnnouncementFile{{Name: "run.sh", ContentType: "text/x-shellscript"}})
+	assert.ErrorContains(t, err, "Attachments must be images or PDF files")
+
+	files := make([]domain.AnnouncementFile, maxAnnouncementAttachments+1)
+	_, err = f.service.Create(context.Background(), f.owner, f.dorm.ID, dto.AnnouncementRequestBody{Title: "Hi", Body: "Hi"}, time.Time{}, files)
+	assert.ErrorContains(t, err, "An announcement cannot have more than 5 attachments")
+
+	assert.Empty(t, f.repo.announcements)
+}
+
+func TestAnnouncementGetByID(t *testing.T) {
+	ctx := context.Background()
+	f := newAnnouncementFixture()
+	tenant := &domain.User{ID: uuid.New(), Role: domain.LesseeRole}
+	f.lease(tenant.ID, time.Now().AddDate(0, -1, 0), time.Time{})
+	res := f.post(t, time.Time{})
+
+	t.Run("owner sees read stats", func(t *testing.T) {
+		announcement, err := f.service.GetByID(ctx, f.owner, res.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, announcement.Stats) {
+			assert.Equal(t, int64(1), announcement.Stats.Recipients)
+			assert.Nil(t, announcement.Read)
+		}
+	})
+
+	t.Run("admin sees read stats", func(t *testing.T) {
+		admin := &domain.User{ID: uuid.New(), Role: domain.AdminRole, TwoFactorEnabled: true}
+		announcement, err := f.service.GetByID(ctx, admin, res.ID)
+		if assert.NoError(t, err) {
+			assert.NotNil(t, announcement.Stats)
+		}
+	})
+
+	t.Run("recipient sees whether they read it", func(t *testing.T) {
+		announcement, err := f.service.GetByID(ctx, tenant, res.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, announcement.Read) {
+			assert.False(t, *announcement.Read)
+			assert.Nil(t, announcement.Stats)
+		}
+
+		assert.NoError(t, f.service.MarkRead(tenant, res.ID))
+		announcement, err = f.service.GetByID(ctx, tenant, res.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, announcement.Read) {
+			assert.True(t, *announcement.Read)
+			assert.NotNil(t, announcement.ReadAt)
+		}
+	})
+
+	t.Run("other users", func(t *testing.T) {
+		for _, user := range []*domain.User{
+			{ID: uuid.New(), Role: domain.LesseeRole},
+			{ID: uuid.New(), Role: domain.LessorRole},
+			// staff without two-factor authentication get no permission
+			{ID: uuid.New(), Role: domain.AdminRole},
+		} {
+			_, err := f.service.GetByID(ctx, user, res.ID)
+			assert.ErrorContains(t, err, "You do not have permission to view this announcement", user.Role)
+			assert.ErrorContains(t, f.service.MarkRead(user, res.ID), "Announcement not found")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
@@ -16,15 +17,23 @@ type ContractService struct {
 	dormRepo              ports.DormRepository
 	leasingHistoryService ports.LeasingHistoryService
 	dormService           ports.DormService
+	managerService        ports.DormManagerService
+	notifier              ports.NotificationPublisher
+	live                  ports.LivePublisher
+	webhooks              ports.WebhookPublisher
 }
 
-func NewContractService(contractRepo ports.ContractRepository, userRepo ports.UserRepository, dormRepo ports.DormRepository, leasingHistoryService ports.LeasingHistoryService, dormService ports.DormService) ports.ContractService {
+func NewContractService(contractRepo ports.ContractRepository, userRepo ports.UserRepository, dormRepo ports.DormRepository, leasingHistoryService ports.LeasingHistoryService, dormService ports.DormService, managerService ports.DormManagerService, notifier ports.NotificationPublisher, live ports.LivePublisher, webhooks ports.WebhookPublisher) ports.ContractService {
 	return &ContractService{
 		contractRepo:          contractRepo,
 		userRepo:              userRepo,
 		dormRepo:              dormRepo,
 		leasingHistoryService: leasingHistoryService,
 		dormService:           dormService,
+		managerService:        managerService,
+		notifier:              notifier,
+		live:                  live,
+		webhooks:              webhooks,
 	}
 }
 
@@ -50,7 +59,7 @@ func (ct *ContractService) GetByUserID(userID uuid.UUID, limit, page int) (*[]dt
 	if user == nil || user.Role == "" {
 		return nil, 0, 0, apperror.BadRequestError(errors.New("invalid user"), "user not found or role is missing")
 	}
-	if user.Role == domain.AdminRole {
+	if user.Role.IsStaff() {
 		return nil, 0, 0, apperror.BadRequestError(errors.New("invalid user"), "role mismatch")
 	}
 	contracts, totalPage, totalRows, err := ct.getContractsByRole(user.Role, userID, limit, page)
@@ -70,8 +79,19 @@ func (ct *ContractService) getContractsByRole(role domain.Role, userID uuid.UUID
 
 }
 
-func (ct *ContractService) GetByDormID(dormID uuid.UUID, limit, page int) (*[]dto.ContractResponseBody, int, int, error) {
-	contracts, totalPage, totalRows, err := ct.contractRepo.GetContractByLessorID(dormID, limit, page)
+func (ct *ContractService) GetByDormID(user *domain.User, dormID uuid.UUID, limit, page int) (*[]dto.ContractResponseBody, int, int, error) {
+	dorm, err := ct.dormRepo.GetByID(dormID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if _, err := ct.managerService.Authorize(user, dorm, domain.ScopeContracts, domain.PermissionLeasingManage); err != nil {
+		if apperror.IsAppError(err) {
+			return nil, 0, 0, err
+		}
+		return nil, 0, 0, apperror.ForbiddenError(err, "You do not have permission to view this dorm's contracts")
+	}
+
+	contracts, totalPage, totalRows, err := ct.contractRepo.GetContractByDormID(dormID, limit, page)
 	if err != nil {
 		return nil, totalPage, totalRows, err
 	}
@@ -93,15 +113,42 @@ func (ct *ContractService) UpdateStatus(contractID uuid.UUID, status domain.Cont
 	if user == nil || user.Role == "" {
 		return apperror.BadRequestError(errors.New("invalid user"), "user not found or role is missing")
 	}
-	if user.Role == domain.AdminRole {
+	if user.Role.IsStaff() {
 		return apperror.BadRequestError(errors.New("invalid user"), "role mismatch")
 	}
 
-	if err := ct.contractRepo.UpdateStatus(contractID, status, &user.Role); err != nil {
+	contract, err := ct.contractRepo.GetContractByContractID(contractID)
+	if err != nil {
 		return err
 	}
 
-	contract, err := ct.contractRepo.GetContractByContractID(contractID)
+	// a manager of the dorm signs for the lessor
+	role := user.Role
+	var manager *domain.DormManager
+	if user.ID != contract.LesseeID && user.ID != contract.Dorm.OwnerID {
+		manager, err = ct.managerService.Authorize(user, &contract.Dorm, domain.ScopeContracts, "")
+		if err != nil {
+			if apperror.IsAppError(err) {
+				return err
+			}
+			return apperror.ForbiddenError(err, "You do not have permission to sign this contract")
+		}
+		role = domain.LessorRole
+	}
+
+	if err := ct.contractRepo.UpdateStatus(contractID, status, &role); err != nil {
+		return err
+	}
+
+	action := "contract.sign"
+	if status == domain.Cancelled {
+		action = "contract.cancel"
+	}
+	if err := ct.managerService.RecordAction(manager, domain.ScopeContracts, action, contractID); err != nil {
+		return err
+	}
+
+	contract, err = ct.contractRepo.GetContractByContractID(contractID)
 	if err != nil {
 		return err
 	}
@@ -110,9 +157,39 @@ func (ct *ContractService) UpdateStatus(contractID uuid.UUID, status domain.Cont
 		if err := ct.contractRepo.UpdateStatus(contractID, domain.Signed, nil); err != nil {
 			return err
 		}
-		if _, err := ct.leasingHistoryService.Create(contract.LesseeID, contract.DormID); err != nil {
+		if _, err := ct.leasingHistoryService.Create(contract.LesseeID, contract.DormID, contract.TermMonths); err != nil {
 			return err
 		}
+		for _, userID := range []uuid.UUID{contract.LesseeID, contract.Dorm.OwnerID} {
+			ct.notifier.Publish(domain.NotificationEvent{
+				Type:     domain.NotificationContractSigned,
+				UserID:   userID,
+				Title:    "Contract signed",
+				Body:     fmt.Sprintf("The contract for %s was signed by both parties.", contract.Dorm.Name),
+				Link:     "/contracts/" + contractID.String(),
+				DormName: contract.Dorm.Name,
+			})
+		}
+		ct.webhooks.Publish(contract.Dorm.OwnerID, domain.WebhookContractSigned, dto.WebhookContractSignedData{
+			ContractID: contractID,
+			DormID:     contract.DormID,
+			DormName:   contract.Dorm.Name,
+			LesseeID:   contract.LesseeID,
+		})
+	} else if status == domain.Signed {
+		// tell the other party it is their turn
+		waitingID := contract.LesseeID
+		if role == domain.LesseeRole {
+			waitingID = contract.Dorm.OwnerID
+		}
+		ct.notifier.Publish(domain.NotificationEvent{
+			Type:     domain.NotificationContractAwaitingSignature,
+			UserID:   waitingID,
+			Title:    "Contract waiting for your signature",
+			Body:     fmt.Sprintf("The contract for %s was signed by the other party and is waiting for your signature.", contract.Dorm.Name),
+			Link:     "/contracts/" + contractID.String(),
+			DormName: contract.Dorm.Name,
+		})
 	}
 
 	if contract.LesseeStatus == domain.Cancelled || contract.LessorStatus == domain.Cancelled {
@@ -120,5 +197,19 @@ func (ct *ContractService) UpdateStatus(contractID uuid.UUID, status domain.Cont
 			return err
 		}
 	}
+
+	contract, err = ct.contractRepo.GetContractByContractID(contractID)
+	if err != nil {
+		return err
+	}
+	event := dto.ContractStatusEventBody{
+		ContractID:   contract.ID,
+		Status:       string(contract.Status),
+		LesseeStatus: string(contract.LesseeStatus),
+		LessorStatus: string(contract.LessorStatus),
+	}
+	ct.live.Push(contract.LesseeID, domain.LiveContractStatus, event)
+	ct.live.Push(contract.Dorm.OwnerID, domain.LiveContractStatus, event)
+
 	return nil
 }
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type DormManagerService struct {
+	managerRepo ports.DormManagerRepository
+	dormRepo    ports.DormRepository
+	userRepo    ports.UserRepository
+}
+
+func NewDormManagerService(managerRepo ports.DormManagerRepository, dormRepo ports.DormRepository, userRepo ports.UserRepository) ports.DormManagerService {
+	return &DormManagerService{managerRepo: managerRepo, dormRepo: dormRepo, userRepo: userRepo}
+}
+
+// Invite asks the user with email to manage the dorm. The delegation is active once they accept.
+func (s *DormManagerService) Invite(user *domain.User, dormID uuid.UUID, email string, scopes domain.ManagerScopes) (*domain.DormManager, error) {
+	dorm, err := s.ownedDorm(user, dormID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitee, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if invitee.ID == dorm.OwnerID {
+		return nil, apperror.BadRequestError(errors.New("owner as manager"), "The owner cannot be a manager of their own dorm")
+	}
+
+	manager := &domain.DormManager{
+		DormID:    dormID,
+		ManagerID: invitee.ID,
+		Status:    domain.ManagerInvited,
+		Scopes:    scopes,
+	}
+	if err := s.managerRepo.Create(manager); err != nil {
+		return nil, err
+	}
+
+	return s.managerRepo.GetByID(manager.ID)
+}
+
+func (s *DormManagerService) GetByDormID(user *domain.User, dormID uuid.UUID) ([]domain.DormManager, error) {
+	if _, err := s.ownedDorm(user, dormID); err != nil {
+		return nil, err
+	}
+	return s.managerRepo.GetByDormID(dormID)
+}
+
+// GetMine returns the invitations and delegations of a manager.
+func (s *DormManagerService) GetMine(userID uuid.UUID) ([]domain.DormManager, error) {
+	return s.managerRepo.GetByManagerID(userID)
+}
+
+func (s *DormManagerService) UpdateScopes(user *domain.User, dormID uuid.UUID, id uuid.UUID, scopes domain.ManagerScopes) (*domain.DormManager, error) {
+	manager, err := s.ownedManager(user, dormID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.Scopes = scopes
+	if err := s.managerRepo.Update(manager); err != nil {
+		return nil, err
+	}
+
+	return manager, nil
+}
+
+func (s *DormManagerService) Remove(user *domain.User, dormID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.ownedManager(user, dormID, id); err != nil {
+		return err
+	}
+	return s.managerRepo.Delete(id)
+}
+
+func (s *DormManagerService) Accept(userID uuid.UUID, id uuid.UUID) (*domain.DormManager, error) {
+	manager, err := s.managerRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if manager.ManagerID != userID {
+		return nil, apperror.NotFoundError(errors.New("invitation of another user"), "Manager not found")
+	}
+	if manager.Status != domain.ManagerInvited {
+		return nil, apperror.ConflictError(errors.New("invitation already accepted"), "Invitation already accepted")
+	}
+
+	now := time.Now()
+	manager.Status = domain.ManagerAccepted
+	manager.AcceptedAt = &now
+	if err := s.managerRepo.Update(manager); err != nil {
+		return nil, err
+	}
+
+	return manager, nil
+}
+
+// Leave declines an invitation or stops managing a dorm.
+func (s *DormManagerService) Leave(userID uuid.UUID, id uuid.UUID) error {
+	manager, err := s.managerRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if manager.ManagerID != userID {
+		return apperror.NotFoundError(errors.New("delegation of another user"), "Manager not found")
+	}
+	return s.managerRepo.Delete(id)
+}
+
+func (s *DormManagerService) GetActions(user *domain.User, dormID uuid.UUID, limit int, page int) ([]domain.DelegatedAction, int, int, error) {
+	if _, err := s.ownedDorm(user, dormID); err != nil {
+		return nil, 0, 0, err
+	}
+	return s.managerRepo.GetActionsByDormID(dormID, limit, page)
+}
+
+// Authorize checks that user may act on the dorm for scope. It returns the
+// delegation when the user acts as a manager, so the action can be recorded,
+// and nil when they act as the owner or with permission.
+func (s *DormManagerService) Authorize(user *domain.User, dorm *domain.Dorm, scope domain.ManagerScope, permission domain.Permission) (*domain.DormManager, error) {
+	if user.ID == dorm.OwnerID || (permission != "" && policy.Can(user, permission)) {
+		return nil, nil
+	}
+
+	manager, err := s.managerRepo.GetAccepted(dorm.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := policy.AuthorizeManager(user, dorm.OwnerID, manager, scope, permission); err != nil {
+		return nil, err
+	}
+
+	return manager, nil
+}
+
+// RecordAction records an action taken on behalf of the owner. It does nothing for a nil manager.
+func (s *DormManagerService) RecordAction(manager *domain.DormManager, scope domain.ManagerScope, action string, resourceID uuid.UUID) error {
+	if manager == nil {
+		return nil
+	}
+
+	dorm, err := s.dormRepo.GetByID(manager.DormID)
+	if err != nil {
+		return err
+	}
+
+	return s.managerRepo.CreateAction(&domain.DelegatedAction{
+		DormID:     manager.DormID,
+		OwnerID:    dorm.OwnerID,
+		ManagerID:  manager.ManagerID,
+		Scope:      scope,
+		Action:     action,
+		ResourceID: resourceID,
+	})
+}
+
+// ownedDorm returns the dorm if user owns it; managers cannot manage managers.
+func (s *DormManagerService) ownedDorm(user *domain.User, dormID uuid.UUID) (*domain.Dorm, error) {
+	dorm, err := s.dormRepo.GetByID(dormID)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.AuthorizeOwner(user, dorm.OwnerID, domain.PermissionDormUpdate); err != nil {
+		return nil, apperror.ForbiddenError(err, "You do not have permission to manage this dorm's managers")
+	}
+	return dorm, nil
+}
+
+func (s *DormManagerService) ownedManager(user *domain.User, dormID uuid.UUID, id uuid.UUID) (*domain.DormManager, error) {
+	if _, err := s.ownedDorm(user, dormID); err != nil {
+		return nil, err
+	}
+
+	manager, err := s.managerRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if manager.DormID != dormID {
+		return nil, apperror.NotFoundError(errors.New("manager of another dorm"), "Manager not found")
+	}
+
+	return manager, nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDormManagerRepo struct {
+	ports.DormManagerRepository
+	managers map[uuid.UUID]*domain.DormManager
+	actions  []domain.DelegatedAction
+}
+
+func (m *mockDormManagerRepo) Create(manager *domain.DormManager) error {
+	for _, existing := range m.managers {
+		if existing.DormID == manager.DormID && existing.ManagerID == manager.ManagerID {
+			return errors.New("duplicate manager")
+		}
+	}
+	manager.ID = uuid.New()
+	m.managers[manager.ID] = manager
+	return nil
+}
+
+func (m *mockDormManagerRepo) GetByID(id uuid.UUID) (*domain.DormManager, error) {
+	manager, ok := m.managers[id]
+	if !ok {
+		return nil, errors.New("manager not found")
+	}
+	return manager, nil
+}
+
+func (m *mockDormManagerRepo) GetAccepted(dormID uuid.UUID, managerID uuid.UUID) (*domain.DormManager, error) {
+	for _, manager := range m.managers {
+		if manager.DormID == dormID && manager.ManagerID == managerID && manager.Status == domain.ManagerAccepted {
+			return manager, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDormManagerRepo) Update(manager *domain.DormManager) error {
+	m.managers[manager.ID] = manager
+	return nil
+}
+
+func (m *mockDormManagerRepo) Delete(id uuid.UUID) error {
+	delete(m.managers, id)
+	return nil
+}
+
+func (m *mockDormManagerRepo) CreateAction(action *domain.DelegatedAction) error {
+	m.actions = append(m.actions, *action)
+	return nil
+}
+
+type dormManagerFixture struct {
+	service   *DormManagerService
+	managers  *mockDormManagerRepo
+	dorm      *domain.Dorm
+	owner     *domain.User
+	caretaker *domain.User
+}
+
+func newDormManagerFixture() *dormManagerFixture {
+	owner := &domain.User{ID: uuid.New(), Email: "owner@example.com", Role: domain.LessorRole}
+	caretaker := &domain.User{ID: uuid.New(), Email: "caretaker@example.com", Role: domain.LessorRole}
+	dorm := &domain.Dorm{ID: uuid.New(), OwnerID: owner.ID, Name: "Baan Suan"}
+	managers := &mockDormManagerRepo{managers: map[uuid.UUID]*domain.DormManager{}}
+
+	return &dormManagerFixture{
+		service: &DormManagerService{
+			managerRepo: managers,
+			dormRepo:    newMockDormStore(dorm),
+			userRepo:    &mockUserRepo{users: []*domain.User{owner, caretaker}},
+		},
+		managers:  managers,
+		dorm:      dorm,
+		owner:     owner,
+		caretaker: caretaker,
+	}
+}
+
+func TestDormManagerInvite(t *testing.T) {
+	t.Run("owner invites a manager", func(t *testing.T) {
+		f := newDormManagerFixture()
+
+		manager, err := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+		if assert.NoError(t, err) {
+			assert.Equal(t, f.caretaker.ID, manager.ManagerID)
+			assert.Equal(t, domain.ManagerInvited, manager.Status)
+			assert.Nil(t, manager.AcceptedAt)
+		}
+	})
+
+	t.Run("owner cannot manage their own dorm", func(t *testing.T) {
+		f := newDormManagerFixture()
+
+		_, err := f.service.Invite(f.owner, f.dorm.ID, f.owner.Email, domain.ManagerScopes{Contracts: true})
+		assert.ErrorContains(t, err, "The owner cannot be a manager of their own dorm")
+		assert.Empty(t, f.managers.managers)
+	})
+
+	t.Run("only the owner invites", func(t *testing.T) {
+		f := newDormManagerFixture()
+
+		_, err := f.service.Invite(f.caretaker, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+		assert.ErrorContains(t, err, "You do not have permission to manage this dorm's managers")
+		assert.Empty(t, f.managers.managers)
+	})
+}
+
+func TestDormManagerAccept(t *testing.T) {
+	f := newDormManagerFixture()
+	invitation, err := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+	assert.NoError(t, err)
+
+	_, err = f.service.Accept(f.owner.ID, invitation.ID)
+	assert.ErrorContains(t, err, "Manager not found")
+	assert.Equal(t, domain.ManagerInvited, invitation.Status)
+
+	manager, err := f.service.Accept(f.caretaker.ID, invitation.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, domain.ManagerAccepted, manager.Status)
+		assert.NotNil(t, manager.AcceptedAt)
+	}
+
+	_, err = f.service.Accept(f.caretaker.ID, invitation.ID)
+	assert.ErrorContains(t, err, "Invitation already accepted")
+}
+
+func TestDormManagerRevoke(t *testing.T) {
+	t.Run("owner removes the manager", func(t *testing.T) {
+		f := newDormManagerFixture()
+		invitation, _ := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+		_, _ = f.service.Accept(f.caretaker.ID, invitation.ID)
+
+		assert.ErrorContains(t, f.service.Remove(f.caretaker, f.dorm.ID, invitation.ID), "You do not have permission to manage this dorm's managers")
+		assert.NoError(t, f.service.Remove(f.owner, f.dorm.ID, invitation.ID))
+
+		_, err := f.service.Authorize(f.caretaker, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		assert.Error(t, err)
+	})
+
+	t.Run("manager leaves", func(t *testing.T) {
+		f := newDormManagerFixture()
+		invitation, _ := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+		_, _ = f.service.Accept(f.caretaker.ID, invitation.ID)
+
+		assert.ErrorContains(t, f.service.Leave(f.owner.ID, invitation.ID), "Manager not found")
+		assert.NoError(t, f.service.Leave(f.caretaker.ID, invitation.ID))
+
+		_, err := f.service.Authorize(f.caretaker, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		assert.Error(t, err)
+	})
+
+	t.Run("manager of another dorm", func(t *testing.T) {
+		f := newDormManagerFixture()
+		invitation, _ := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+		other := &domain.Dorm{ID: uuid.New(), OwnerID: f.owner.ID}
+		f.service.dormRepo.(*mockDormStore).dorms[other.ID] = other
+
+		assert.ErrorContains(t, f.service.Remove(f.owner, other.ID, invitation.ID), "Manager not found")
+		assert.Len(t, f.managers.managers, 1)
+	})
+}
+
+func TestDormManagerAuthorize(t *testing.T) {
+	f := newDormManagerFixture()
+	invitation, _ := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+
+	t.Run("owner", func(t *testing.T) {
+		manager, err := f.service.Authorize(f.owner, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		assert.NoError(t, err)
+		assert.Nil(t, manager)
+	})
+
+	t.Run("staff with the permission", func(t *testing.T) {
+		agent := &domain.User{ID: uuid.New(), Role: domain.SupportAgentRole, TwoFactorEnabled: true}
+		manager, err := f.service.Authorize(agent, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		assert.NoError(t, err)
+		assert.Nil(t, manager)
+	})
+
+	t.Run("another lessor", func(t *testing.T) {
+		lessor := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+		_, err := f.service.Authorize(lessor, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		assert.Error(t, err)
+	})
+
+	t.Run("invited manager before accepting", func(t *testing.T) {
+		_, err := f.service.Authorize(f.caretaker, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		assert.Error(t, err)
+	})
+
+	_, err := f.service.Accept(f.caretaker.ID, invitation.ID)
+	assert.NoError(t, err)
+
+	t.Run("accepted manager within scope", func(t *testing.T) {
+		manager, err := f.service.Authorize(f.caretaker, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		if assert.NoError(t, err) {
+			assert.Equal(t, invitation.ID, manager.ID)
+		}
+	})
+
+	t.Run("accepted manager outside scope", func(t *testing.T) {
+		for _, scope := range []domain.ManagerScope{domain.ScopeLeasingRequests, domain.ScopeMeterReadings, domain.ScopeReviewReplies} {
+			_, err := f.service.Authorize(f.caretaker, f.dorm, scope, domain.PermissionLeasingManage)
+			assert.Error(t, err, scope)
+		}
+	})
+
+	t.Run("scopes changed by the owner", func(t *testing.T) {
+		_, err := f.service.UpdateScopes(f.owner, f.dorm.ID, invitation.ID, domain.ManagerScopes{MeterReadings: true})
+		assert.NoError(t, err)
+
+		_, err = f.service.Authorize(f.caretaker, f.dorm, domain.ScopeMeterReadings, domain.PermissionLeasingManage)
+		assert.NoError(t, err)
+		_, err = f.service.Authorize(f.caretaker, f.dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+		assert.Error(t, err)
+	})
+}
+
+func TestDormManagerRecordAction(t *testing.T) {
+	f := newDormManagerFixture()
+	invitation, _ := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{MeterReadings: true})
+	_, _ = f.service.Accept(f.caretaker.ID, invitation.ID)
+
+	assert.NoError(t, f.service.RecordAction(nil, domain.ScopeMeterReadings, "order.create", uuid.New()))
+	assert.Empty(t, f.managers.actions)
+
+	manager, err := f.service.Authorize(f.caretaker, f.dorm, domain.ScopeMeterReadings, domain.PermissionLeasingManage)
+	assert.NoError(t, err)
+	orderID := uuid.New()
+	assert.NoError(t, f.service.RecordAction(manager, domain.ScopeMeterReadings, "order.create", orderID))
+	if assert.Len(t, f.managers.actions, 1) {
+		action := f.managers.actions[0]
+		assert.Equal(t, f.owner.ID, action.OwnerID)
+		assert.Equal(t, f.caretaker.ID, action.ManagerID)
+		assert.Equal(t, domain.ScopeMeterReadings, action.Scope)
+		assert.Equal(t, orderID, action.ResourceID)
+	}
+}
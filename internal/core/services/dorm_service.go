@@ -2,12 +2,12 @@ package services
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"strings"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/PitiNarak/condormhub-backend/pkg/storage"
@@ -16,19 +16,14 @@ import (
 )
 
 type DormService struct {
-	dormRepo ports.DormRepository
-	storage  *storage.Storage
+	dormRepo           ports.DormRepository
+	storage            *storage.Storage
+	savedSearchService ports.SavedSearchService
+	shortlistService   ports.ShortlistService
 }
 
-func NewDormService(repo ports.DormRepository, storage *storage.Storage) ports.DormService {
-	return &DormService{dormRepo: repo, storage: storage}
-}
-
-func checkPermission(ownerID uuid.UUID, userID uuid.UUID, isAdmin bool) error {
-	if ownerID != userID && !isAdmin {
-		return errors.New("unauthorized action")
-	}
-	return nil
+func NewDormService(repo ports.DormRepository, storage *storage.Storage, savedSearchService ports.SavedSearchService, shortlistService ports.ShortlistService) ports.DormService {
+	return &DormService{dormRepo: repo, storage: storage, savedSearchService: savedSearchService, shortlistService: shortlistService}
 }
 
 func (s *DormService) GetImageUrl(dormImage []domain.DormImage) []string {
@@ -39,11 +34,17 @@ func (s *DormService) GetImageUrl(dormImage []domain.DormImage) []string {
 	return urls
 }
 
-func (s *DormService) Create(userRole domain.Role, dorm *domain.Dorm) error {
-	if userRole != domain.AdminRole && userRole != domain.LessorRole {
-		return apperror.ForbiddenError(errors.New("unauthorized action"), "You do not have permission to create a dorm")
+func (s *DormService) Create(user *domain.User, dorm *domain.Dorm) error {
+	if err := policy.Authorize(user, domain.PermissionDormCreate); err != nil {
+		return apperror.ForbiddenError(err, "You do not have permission to create a dorm")
+	}
+	if err := s.dormRepo.Create(dorm); err != nil {
+		return err
 	}
-	return s.dormRepo.Create(dorm)
+
+	go s.savedSearchService.MatchDorm(dorm, nil)
+
+	return nil
 }
 
 func (s *DormService) GetAll(
@@ -77,13 +78,13 @@ func (s *DormService) GetByID(id uuid.UUID) (*dto.DormResponseBody, error) {
 	return &resData, nil
 }
 
-func (s *DormService) Update(userID uuid.UUID, isAdmin bool, dormID uuid.UUID, updateData *dto.DormUpdateRequestBody) (*dto.DormResponseBody, error) {
+func (s *DormService) Update(user *domain.User, dormID uuid.UUID, updateData *dto.DormUpdateRequestBody) (*dto.DormResponseBody, error) {
 	dorm, err := s.dormRepo.GetByID(dormID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = checkPermission(dorm.OwnerID, userID, isAdmin); err != nil {
+	if err = policy.AuthorizeOwner(user, dorm.OwnerID, domain.PermissionDormUpdate); err != nil {
 		return nil, apperror.ForbiddenError(err, "You do not have permission to update this dorm")
 	}
 
@@ -91,16 +92,26 @@ func (s *DormService) Update(userID uuid.UUID, isAdmin bool, dormID uuid.UUID, u
 		return nil, err
 	}
 
-	return s.GetByID(dormID)
+	updatedDorm, err := s.dormRepo.GetByID(dormID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.savedSearchService.MatchDorm(updatedDorm, dorm)
+	go s.shortlistService.NotifyDormUpdated(updatedDorm, dorm)
+
+	resData := updatedDorm.ToDTO()
+	resData.Images = s.GetImageUrl(updatedDorm.Images)
+	return &resData, nil
 }
 
-func (s *DormService) Delete(ctx context.Context, userID uuid.UUID, isAdmin bool, dormID uuid.UUID) error {
+func (s *DormService) Delete(ctx context.Context, user *domain.User, dormID uuid.UUID) error {
 	dorm, err := s.dormRepo.GetByID(dormID)
 	if err != nil {
 		return err
 	}
 
-	if err := checkPermission(dorm.OwnerID, userID, isAdmin); err != nil {
+	if err := policy.AuthorizeOwner(user, dorm.OwnerID, domain.PermissionDormUpdate); err != nil {
 		return apperror.ForbiddenError(err, "You do not have permission to delete this dorm")
 	}
 
@@ -113,16 +124,22 @@ func (s *DormService) Delete(ctx context.Context, userID uuid.UUID, isAdmin bool
 		}
 	}
 
-	return s.dormRepo.Delete(*dorm)
+	if err := s.dormRepo.Delete(*dorm); err != nil {
+		return err
+	}
+
+	go s.shortlistService.NotifyDormDeleted(dorm)
+
+	return nil
 }
 
-func (s *DormService) UploadDormImage(ctx context.Context, dormID uuid.UUID, filename string, contentType string, fileData io.Reader, userID uuid.UUID, isAdmin bool) (string, error) {
+func (s *DormService) UploadDormImage(ctx context.Context, dormID uuid.UUID, filename string, contentType string, fileData io.Reader, user *domain.User) (string, error) {
 	dorm, err := s.dormRepo.GetByID(dormID)
 	if err != nil {
 		return "", err
 	}
 
-	if err = checkPermission(dorm.OwnerID, userID, isAdmin); err != nil {
+	if err = policy.AuthorizeOwner(user, dorm.OwnerID, domain.PermissionDormUpdate); err != nil {
 		return "", apperror.ForbiddenError(err, "You do not have permission to upload image to this dorm")
 	}
 
@@ -158,7 +175,7 @@ func (s *DormService) GetByOwnerID(ownerID uuid.UUID, limit int, page int) ([]dt
 	return resData, totalPages, totalRows, nil
 }
 
-func (s *DormService) DeleteImageByURL(ctx context.Context, imageURL string, userID uuid.UUID, isAdmin bool) error {
+func (s *DormService) DeleteImageByURL(ctx context.Context, imageURL string, user *domain.User) error {
 	imageKey, err := s.storage.GetFileKeyFromPublicUrl(imageURL)
 	if err != nil {
 		return apperror.InternalServerError(err, "Failed to parse URL")
@@ -174,7 +191,7 @@ func (s *DormService) DeleteImageByURL(ctx context.Context, imageURL string, use
 		return err
 	}
 
-	if err := checkPermission(dorm.OwnerID, userID, isAdmin); err != nil {
+	if err := policy.AuthorizeOwner(user, dorm.OwnerID, domain.PermissionDormUpdate); err != nil {
 		return apperror.ForbiddenError(err, "You do not have permission to delete this dorm image")
 	}
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -49,6 +50,16 @@ func (m *mockDormRepo) GetImageByKey(imageKey string) (*domain.DormImage, error)
 	panic("unimplemented")
 }
 
+type mockSavedSearchService struct {
+	ports.SavedSearchService
+}
+
+func (m *mockSavedSearchService) MatchDorm(dorm *domain.Dorm, previous *domain.Dorm) {}
+
+type mockShortlistService struct {
+	ports.ShortlistService
+}
+
 func TestCreateDorm(t *testing.T) {
 	// Success case: User is lessor
 	t.Run("lessor", func(t *testing.T) {
@@ -57,9 +68,9 @@ func TestCreateDorm(t *testing.T) {
 				return nil
 			},
 		}
-		service := NewDormService(repo, nil)
+		service := NewDormService(repo, nil, &mockSavedSearchService{}, &mockShortlistService{})
 
-		err := service.Create(domain.LessorRole, &domain.Dorm{Name: "SpaceDorm"})
+		err := service.Create(&domain.User{Role: domain.LessorRole}, &domain.Dorm{Name: "SpaceDorm"})
 		assert.NoError(t, err)
 	})
 
@@ -70,9 +81,9 @@ func TestCreateDorm(t *testing.T) {
 				return nil
 			},
 		}
-		service := NewDormService(repo, nil)
+		service := NewDormService(repo, nil, &mockSavedSearchService{}, &mockShortlistService{})
 
-		err := service.Create(domain.AdminRole, &domain.Dorm{Name: "SpaceDorm"})
+		err := service.Create(&domain.User{Role: domain.AdminRole, TwoFactorEnabled: true}, &domain.Dorm{Name: "SpaceDorm"})
 		assert.NoError(t, err)
 	})
 
@@ -83,10 +94,10 @@ func TestCreateDorm(t *testing.T) {
 				return nil
 			},
 		}
-		service := NewDormService(repo, nil)
+		service := NewDormService(repo, nil, &mockSavedSearchService{}, &mockShortlistService{})
 
-		err := service.Create(domain.LesseeRole, &domain.Dorm{Name: "SpaceDorm"})
+		err := service.Create(&domain.User{Role: domain.LesseeRole}, &domain.Dorm{Name: "SpaceDorm"})
 		assert.Error(t, err)
-		assert.Equal(t, "You do not have permission to create a dorm - unauthorized action", err.Error())
+		assert.Equal(t, "You do not have permission to create a dorm - permission denied: dorm:create", err.Error())
 	})
 }
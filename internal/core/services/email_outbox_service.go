@@ -0,0 +1,75 @@
+package services
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const (
+	outboxBatchSize   = 50
+	outboxMaxAttempts = 8
+	// outboxClaimLease must be longer than delivering a whole batch takes.
+	outboxClaimLease = 10 * time.Minute
+	outboxBaseDelay  = time.Minute
+	outboxMaxDelay   = 6 * time.Hour
+)
+
+type EmailOutboxService struct {
+	outboxRepo ports.EmailOutboxRepository
+	mailer     email.Mailer
+}
+
+func NewEmailOutboxService(outboxRepo ports.EmailOutboxRepository, mailer email.Mailer) ports.EmailOutboxService {
+	return &EmailOutboxService{outboxRepo: outboxRepo, mailer: mailer}
+}
+
+func (s *EmailOutboxService) ProcessDue() error {
+	now := time.Now()
+	entries, err := s.outboxRepo.Claim(now, outboxClaimLease, outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		err := s.mailer.Send(&email.Message{
+			To:      entry.To,
+			Subject: entry.Subject,
+			HTML:    entry.HTML,
+			Text:    entry.Text,
+		})
+
+		entry.Attempts++
+		if err == nil {
+			sentAt := time.Now()
+			entry.Status = domain.OutboxSent
+			entry.SentAt = &sentAt
+			entry.LastError = ""
+		} else if entry.Attempts >= outboxMaxAttempts {
+			log.Errorf("email outbox: giving up on email %s after %d attempts: %v", entry.ID, entry.Attempts, err)
+			entry.Status = domain.OutboxFailed
+			entry.LastError = err.Error()
+		} else {
+			entry.NextAttemptAt = time.Now().Add(retryBackoff(outboxBaseDelay, outboxMaxDelay, entry.Attempts))
+			entry.LastError = err.Error()
+		}
+
+		if err := s.outboxRepo.Update(entry); err != nil {
+			log.Errorf("email outbox: cannot update email %s: %v", entry.ID, err)
+		}
+	}
+	return nil
+}
+
+// retryBackoff doubles the delay after every failed attempt: base, 2*base, 4*base, ... up to maxDelay.
+func retryBackoff(base time.Duration, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base << (attempts - 1)
+	if delay > maxDelay || delay <= 0 {
+		return maxDelay
+	}
+	return delay
+}
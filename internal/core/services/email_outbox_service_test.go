@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEmailOutboxRepo struct {
+	ports.EmailOutboxRepository
+	entries []*domain.EmailOutbox
+}
+
+func (m *mockEmailOutboxRepo) Enqueue(message *email.Message) error {
+	m.entries = append(m.entries, &domain.EmailOutbox{
+		ID:            uuid.New(),
+		To:            message.To,
+		Subject:       message.Subject,
+		HTML:          message.HTML,
+		Text:          message.Text,
+		Status:        domain.OutboxPending,
+		NextAttemptAt: time.Now(),
+	})
+	return nil
+}
+
+// Claim returns the due pending emails and moves them out of reach for lease.
+func (m *mockEmailOutboxRepo) Claim(now time.Time, lease time.Duration, limit int) ([]domain.EmailOutbox, error) {
+	var claimed []domain.EmailOutbox
+	for _, entry := range m.entries {
+		if len(claimed) < limit && entry.Status == domain.OutboxPending && !entry.NextAttemptAt.After(now) {
+			entry.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, *entry)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *mockEmailOutboxRepo) Update(entry *domain.EmailOutbox) error {
+	for i := range m.entries {
+		if m.entries[i].ID == entry.ID {
+			*m.entries[i] = *entry
+		}
+	}
+	return nil
+}
+
+// flakyMailer fails its first sends, as many as failures, and captures the rest.
+type flakyMailer struct {
+	*email.MemoryMailer
+	failures int
+	attempts int
+}
+
+func (m *flakyMailer) Send(message *email.Message) error {
+	m.attempts++
+	if m.attempts <= m.failures {
+		return errors.New("connection refused")
+	}
+	return m.MemoryMailer.Send(message)
+}
+
+func newEmailOutboxFixture(failures int) (*EmailOutboxService, *mockEmailOutboxRepo, *flakyMailer, *domain.EmailOutbox) {
+	repo := &mockEmailOutboxRepo{}
+	mailer := &flakyMailer{MemoryMailer: email.NewMemoryMailer(), failures: failures}
+	_ = repo.Enqueue(&email.Message{To: "somchai@example.com", Subject: "Verify your email", Text: "Welcome"})
+
+	return &EmailOutboxService{outboxRepo: repo, mailer: mailer}, repo, mailer, repo.entries[0]
+}
+
+func TestEmailOutboxProcessDue(t *testing.T) {
+	t.Run("sent", func(t *testing.T) {
+		service, _, mailer, entry := newEmailOutboxFixture(0)
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.OutboxSent, entry.Status)
+		assert.Equal(t, 1, entry.Attempts)
+		assert.NotNil(t, entry.SentAt)
+		if messages := mailer.Messages(); assert.Len(t, messages, 1) {
+			assert.Equal(t, "somchai@example.com", messages[0].To)
+			assert.Equal(t, "Verify your email", messages[0].Subject)
+		}
+
+		// a sent email is not claimed again
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, 1, mailer.attempts)
+	})
+
+	t.Run("failed attempts back off", func(t *testing.T) {
+		service, _, mailer, entry := newEmailOutboxFixture(2)
+
+		for attempt := 1; attempt <= 2; attempt++ {
+			before := time.Now()
+			assert.NoError(t, service.ProcessDue())
+			assert.Equal(t, domain.OutboxPending, entry.Status)
+			assert.Equal(t, attempt, entry.Attempts)
+			assert.Equal(t, "connection refused", entry.LastError)
+			assert.WithinDuration(t, before.Add(retryBackoff(outboxBaseDelay, outboxMaxDelay, attempt)), entry.NextAttemptAt, time.Second)
+
+			// not due yet
+			assert.NoError(t, service.ProcessDue())
+			assert.Equal(t, attempt, entry.Attempts)
+			entry.NextAttemptAt = time.Now()
+		}
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.OutboxSent, entry.Status)
+		assert.Equal(t, 3, entry.Attempts)
+		assert.Empty(t, entry.LastError)
+		assert.Len(t, mailer.Messages(), 1)
+	})
+
+	t.Run("given up after the last attempt", func(t *testing.T) {
+		service, _, mailer, entry := newEmailOutboxFixture(outboxMaxAttempts)
+
+		for attempt := 1; attempt <= outboxMaxAttempts; attempt++ {
+			assert.NoError(t, service.ProcessDue())
+			entry.NextAttemptAt = time.Now()
+		}
+		assert.Equal(t, domain.OutboxFailed, entry.Status)
+		assert.Equal(t, outboxMaxAttempts, entry.Attempts)
+		assert.Equal(t, "connection refused", entry.LastError)
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, outboxMaxAttempts, mailer.attempts)
+		assert.Empty(t, mailer.Messages())
+	})
+
+	t.Run("one failure does not hold up the batch", func(t *testing.T) {
+		service, repo, mailer, first := newEmailOutboxFixture(1)
+		_ = repo.Enqueue(&email.Message{To: "malee@example.com", Subject: "Reset your password"})
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.OutboxPending, first.Status)
+		assert.Equal(t, domain.OutboxSent, repo.entries[1].Status)
+		if messages := mailer.Messages(); assert.Len(t, messages, 1) {
+			assert.Equal(t, "malee@example.com", messages[0].To)
+		}
+	})
+}
@@ -9,21 +9,27 @@ import (
 	"time"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/PitiNarak/condormhub-backend/pkg/storage"
 	"github.com/PitiNarak/condormhub-backend/pkg/utils"
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
 	"github.com/yokeTH/go-pkg/apperror"
 )
 
 type LeasingHistoryService struct {
-	historyRepo ports.LeasingHistoryRepository
-	dormRepo    ports.DormRepository
-	storage     *storage.Storage
+	historyRepo        ports.LeasingHistoryRepository
+	dormRepo           ports.DormRepository
+	storage            *storage.Storage
+	managerService     ports.DormManagerService
+	webhooks           ports.WebhookPublisher
+	savedSearchService ports.SavedSearchService
 }
 
-func NewLeasingHistoryService(historyRepo ports.LeasingHistoryRepository, dormRepo ports.DormRepository, storage *storage.Storage) ports.LeasingHistoryService {
-	return &LeasingHistoryService{historyRepo: historyRepo, dormRepo: dormRepo, storage: storage}
+func NewLeasingHistoryService(historyRepo ports.LeasingHistoryRepository, dormRepo ports.DormRepository, storage *storage.Storage, managerService ports.DormManagerService, webhooks ports.WebhookPublisher, savedSearchService ports.SavedSearchService) ports.LeasingHistoryService {
+	return &LeasingHistoryService{historyRepo: historyRepo, dormRepo: dormRepo, storage: storage, managerService: managerService, webhooks: webhooks, savedSearchService: savedSearchService}
 }
 
 func (s *LeasingHistoryService) GetImageUrl(reviewImage []domain.ReviewImage) []string {
@@ -34,7 +40,7 @@ func (s *LeasingHistoryService) GetImageUrl(reviewImage []domain.ReviewImage) []
 	return urls
 }
 
-func (s *LeasingHistoryService) Create(userID uuid.UUID, dormID uuid.UUID) (*domain.LeasingHistory, error) {
+func (s *LeasingHistoryService) Create(userID uuid.UUID, dormID uuid.UUID, termMonths int) (*domain.LeasingHistory, error) {
 	_, err := s.dormRepo.GetByID(dormID)
 	if err != nil {
 		return &domain.LeasingHistory{}, err
@@ -45,6 +51,10 @@ func (s *LeasingHistoryService) Create(userID uuid.UUID, dormID uuid.UUID) (*dom
 		return &domain.LeasingHistory{}, err
 	}
 	leasingHistory := &domain.LeasingHistory{DormID: dormID, LesseeID: userID, Start: createTime, Price: dorm.Price}
+	if termMonths > 0 {
+		plannedEnd := createTime.AddDate(0, termMonths, 0)
+		leasingHistory.PlannedEnd = &plannedEnd
+	}
 	err = s.historyRepo.Create(leasingHistory)
 	if err != nil {
 		return &domain.LeasingHistory{}, err
@@ -56,8 +66,16 @@ func (s *LeasingHistoryService) Create(userID uuid.UUID, dormID uuid.UUID) (*dom
 	return leasingHistory, nil
 }
 
-func (s *LeasingHistoryService) Delete(id uuid.UUID) error {
-	err := s.historyRepo.Delete(id)
+func (s *LeasingHistoryService) Delete(user *domain.User, id uuid.UUID) error {
+	history, err := s.historyRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := policy.AuthorizeOwner(user, history.Dorm.OwnerID, domain.PermissionLeasingManage); err != nil {
+		return apperror.ForbiddenError(err, "You do not have permission to delete this leasing history")
+	}
+
+	err = s.historyRepo.Delete(id)
 	if err != nil {
 		return err
 	}
@@ -87,20 +105,45 @@ func (s *LeasingHistoryService) GetByDormID(id uuid.UUID, limit, page int) ([]do
 	return leasingHistory, totalPage, totalRows, nil
 }
 
-func (s *LeasingHistoryService) SetEndTimestamp(id uuid.UUID) error {
+func (s *LeasingHistoryService) SetEndTimestamp(user *domain.User, id uuid.UUID) error {
 	leasingHistory, err := s.historyRepo.GetByID(id)
 	if err != nil {
 		return err
 	}
+	manager, err := s.managerService.Authorize(user, &leasingHistory.Dorm, domain.ScopeContracts, domain.PermissionLeasingManage)
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return err
+		}
+		return apperror.ForbiddenError(err, "You do not have permission to end this lease")
+	}
+	if !leasingHistory.End.IsZero() {
+		return apperror.BadRequestError(errors.New("lease already ended"), "This lease has already ended")
+	}
 	leasingHistory.End = time.Now()
 	err = s.historyRepo.Update(leasingHistory)
 	if err != nil {
 		return err
 	}
-	return nil
+	s.webhooks.Publish(leasingHistory.Dorm.OwnerID, domain.WebhookLeaseEnded, dto.WebhookLeaseEndedData{
+		LeasingHistoryID: leasingHistory.ID,
+		DormID:           leasingHistory.DormID,
+		DormName:         leasingHistory.Dorm.Name,
+		LesseeID:         leasingHistory.LesseeID,
+		Start:            leasingHistory.Start,
+		End:              leasingHistory.End,
+	})
+	// the dorm is available again once its last lease has ended
+	active, err := s.historyRepo.HasActiveLease(leasingHistory.DormID)
+	if err != nil {
+		log.Errorf("leasing history: cannot check the leases of dorm %s: %v", leasingHistory.DormID, err)
+	} else if !active {
+		go s.savedSearchService.MatchAvailableDorm(&leasingHistory.Dorm)
+	}
+	return s.managerService.RecordAction(manager, domain.ScopeContracts, "lease.end", id)
 }
 
-func (s *LeasingHistoryService) CreateReview(user *domain.User, id uuid.UUID, Message string, Rate int) (*domain.Review, error) {
+func (s *LeasingHistoryService) CreateReview(user *domain.User, id uuid.UUID, Message string, Rate int, ratings domain.ReviewRatings) (*domain.Review, error) {
 	history, err := s.historyRepo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -112,6 +155,7 @@ func (s *LeasingHistoryService) CreateReview(user *domain.User, id uuid.UUID, Me
 	review := domain.Review{
 		Message: Message,
 		Rate:    Rate,
+		Ratings: ratings,
 	}
 	history.Review = &review
 	history.ReviewFlag = true
@@ -126,7 +170,7 @@ func (s *LeasingHistoryService) CreateReview(user *domain.User, id uuid.UUID, Me
 	return history.Review, nil
 }
 
-func (s *LeasingHistoryService) UpdateReview(user *domain.User, id uuid.UUID, Message string, Rate int) (*domain.Review, error) {
+func (s *LeasingHistoryService) UpdateReview(user *domain.User, id uuid.UUID, Message string, Rate int, ratings domain.ReviewRatings) (*domain.Review, error) {
 	history, err := s.historyRepo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -138,6 +182,7 @@ func (s *LeasingHistoryService) UpdateReview(user *domain.User, id uuid.UUID, Me
 	review := domain.Review{
 		Message: Message,
 		Rate:    Rate,
+		Ratings: ratings,
 	}
 	history.Review = &review
 	err = s.historyRepo.Update(history)
@@ -168,13 +213,13 @@ func (s *LeasingHistoryService) DeleteReview(user *domain.User, id uuid.UUID) er
 	return nil
 }
 
-func (s *LeasingHistoryService) UploadReviewImage(ctx context.Context, historyID uuid.UUID, filename string, contentType string, fileData io.Reader, userID uuid.UUID, isAdmin bool) (string, error) {
+func (s *LeasingHistoryService) UploadReviewImage(ctx context.Context, historyID uuid.UUID, filename string, contentType string, fileData io.Reader, user *domain.User) (string, error) {
 	history, err := s.historyRepo.GetByID(historyID)
 	if err != nil {
 		return "", err
 	}
 
-	if err = checkPermission(history.Lessee.ID, userID, isAdmin); err != nil {
+	if err = policy.AuthorizeOwner(user, history.Lessee.ID, domain.PermissionReviewModerate); err != nil {
 		return "", apperror.ForbiddenError(err, "You do not have permission to upload image to this dorm")
 	}
 
@@ -196,7 +241,7 @@ func (s *LeasingHistoryService) UploadReviewImage(ctx context.Context, historyID
 	return url, nil
 }
 
-func (s *LeasingHistoryService) DeleteImageByURL(ctx context.Context, imageURL string, userID uuid.UUID, isAdmin bool) error {
+func (s *LeasingHistoryService) DeleteImageByURL(ctx context.Context, imageURL string, user *domain.User) error {
 	imageKey, err := s.storage.GetFileKeyFromPublicUrl(imageURL)
 	if err != nil {
 		return apperror.InternalServerError(err, "Failed to parse URL")
@@ -212,7 +257,7 @@ func (s *LeasingHistoryService) DeleteImageByURL(ctx context.Context, imageURL s
 		return err
 	}
 
-	if err := checkPermission(history.Lessee.ID, userID, isAdmin); err != nil {
+	if err := policy.AuthorizeOwner(user, history.Lessee.ID, domain.PermissionReviewModerate); err != nil {
 		return apperror.ForbiddenError(err, "You do not have permission to delete this review image")
 	}
 
@@ -224,15 +269,15 @@ func (s *LeasingHistoryService) DeleteImageByURL(ctx context.Context, imageURL s
 	return s.historyRepo.DeleteImageByKey(imageKey)
 }
 
-func (s *LeasingHistoryService) GetReportedReviews(limit int, page int) ([]domain.LeasingHistory, int, int, error) {
-	return s.historyRepo.GetReportedReviews(limit, page)
-}
-
 func (s *LeasingHistoryService) GetReviewByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error) {
 	return s.historyRepo.GetReviewByDormID(id, limit, page)
 }
 
-func (s *LeasingHistoryService) ReportReview(id uuid.UUID) (*domain.LeasingHistory, error) {
+func (s *LeasingHistoryService) GetReviewSummaryByDormID(id uuid.UUID) (*domain.ReviewSummary, error) {
+	return s.historyRepo.GetReviewSummaryByDormID(id)
+}
+
+func (s *LeasingHistoryService) ReplyToReview(user *domain.User, id uuid.UUID, message string) (*domain.LeasingHistory, error) {
 	history, err := s.historyRepo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -240,13 +285,61 @@ func (s *LeasingHistoryService) ReportReview(id uuid.UUID) (*domain.LeasingHisto
 	if !history.ReviewFlag {
 		return nil, apperror.NotFoundError(errors.New("review not found"), "review not found")
 	}
-	if history.Review.ReportFlag {
-		return nil, apperror.ConflictError(errors.New("review already reported"), "review already reported")
+	// replies speak for the owner, so staff permissions do not apply
+	manager, err := s.managerService.Authorize(user, &history.Dorm, domain.ScopeReviewReplies, "")
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return nil, err
+		}
+		return nil, apperror.ForbiddenError(err, "Only the dorm owner can reply to this review")
 	}
-	history.Review.ReportFlag = true
-	err = s.historyRepo.Update(history)
+
+	now := time.Now()
+	if err := s.historyRepo.UpdateReviewReply(id, message, &now); err != nil {
+		return nil, err
+	}
+	if err := s.managerService.RecordAction(manager, domain.ScopeReviewReplies, "review.reply", id); err != nil {
+		return nil, err
+	}
+	return s.historyRepo.GetByID(id)
+}
+
+func (s *LeasingHistoryService) DeleteReviewReply(user *domain.User, id uuid.UUID) error {
+	history, err := s.historyRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if !history.ReviewFlag || history.Review.Reply == "" {
+		return apperror.NotFoundError(errors.New("reply not found"), "reply not found")
+	}
+	manager, err := s.managerService.Authorize(user, &history.Dorm, domain.ScopeReviewReplies, domain.PermissionReviewModerate)
 	if err != nil {
+		if apperror.IsAppError(err) {
+			return err
+		}
+		return apperror.ForbiddenError(err, "You do not have permission to delete this reply")
+	}
+
+	if err := s.historyRepo.UpdateReviewReply(id, "", nil); err != nil {
+		return err
+	}
+	return s.managerService.RecordAction(manager, domain.ScopeReviewReplies, "review.reply_delete", id)
+}
+
+func (s *LeasingHistoryService) SetHelpfulVote(user *domain.User, id uuid.UUID, helpful bool) (*domain.LeasingHistory, error) {
+	history, err := s.historyRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !history.ReviewFlag {
+		return nil, apperror.NotFoundError(errors.New("review not found"), "review not found")
+	}
+	if history.LesseeID == user.ID {
+		return nil, apperror.BadRequestError(errors.New("own review"), "You cannot vote on your own review")
+	}
+
+	if err := s.historyRepo.SetHelpfulVote(id, user.ID, helpful); err != nil {
 		return nil, err
 	}
-	return history, nil
+	return s.historyRepo.GetByID(id)
 }
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockReviewRepo stores replies and helpful votes on top of the stored histories.
+type mockReviewRepo struct {
+	*mockLeasingHistoryRepo
+	votes map[uuid.UUID]map[uuid.UUID]bool
+}
+
+func (m *mockReviewRepo) UpdateReviewReply(id uuid.UUID, reply string, replyAt *time.Time) error {
+	history := m.histories[id]
+	history.Review.Reply = reply
+	history.Review.ReplyAt = replyAt
+	return nil
+}
+
+func (m *mockReviewRepo) SetHelpfulVote(historyID uuid.UUID, userID uuid.UUID, helpful bool) error {
+	if m.votes[historyID] == nil {
+		m.votes[historyID] = map[uuid.UUID]bool{}
+	}
+	if helpful {
+		m.votes[historyID][userID] = true
+	} else {
+		delete(m.votes[historyID], userID)
+	}
+	m.histories[historyID].Review.HelpfulCount = len(m.votes[historyID])
+	return nil
+}
+
+type reviewFixture struct {
+	*dormManagerFixture
+	service *LeasingHistoryService
+	repo    *mockReviewRepo
+	lessee  *domain.User
+	history *domain.LeasingHistory
+}
+
+func newReviewFixture() *reviewFixture {
+	f := newDormManagerFixture()
+	lessee := &domain.User{ID: uuid.New(), Role: domain.LesseeRole}
+	history := &domain.LeasingHistory{ID: uuid.New(), DormID: f.dorm.ID, Dorm: *f.dorm, LesseeID: lessee.ID, Lessee: *lessee}
+	repo := &mockReviewRepo{mockLeasingHistoryRepo: newMockLeasingHistoryRepo(history), votes: map[uuid.UUID]map[uuid.UUID]bool{}}
+
+	return &reviewFixture{
+		dormManagerFixture: f,
+		service:            &LeasingHistoryService{historyRepo: repo, managerService: f.service},
+		repo:               repo,
+		lessee:             lessee,
+		history:            history,
+	}
+}
+
+func TestCreateReview(t *testing.T) {
+	f := newReviewFixture()
+	ratings := domain.ReviewRatings{CleanlinessRate: 4, LocationRate: 5}
+
+	_, err := f.service.CreateReview(f.owner, f.history.ID, "Nice", 5, ratings)
+	assert.ErrorContains(t, err, "user is unauthorized")
+	assert.False(t, f.history.ReviewFlag)
+
+	review, err := f.service.CreateReview(f.lessee, f.history.ID, "Nice", 5, ratings)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ratings, review.Ratings)
+	}
+	assert.True(t, f.history.ReviewFlag)
+
+	_, err = f.service.CreateReview(f.lessee, f.history.ID, "Again", 1, domain.ReviewRatings{})
+	assert.ErrorContains(t, err, "review already exist")
+	assert.Equal(t, "Nice", f.history.Review.Message)
+}
+
+func TestReplyToReview(t *testing.T) {
+	t.Run("owner replies", func(t *testing.T) {
+		f := newReviewFixture()
+		_, err := f.service.ReplyToReview(f.owner, f.history.ID, "Thank you")
+		assert.ErrorContains(t, err, "review not found")
+
+		_, _ = f.service.CreateReview(f.lessee, f.history.ID, "Nice", 5, domain.ReviewRatings{})
+		history, err := f.service.ReplyToReview(f.owner, f.history.ID, "Thank you")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Thank you", history.Review.Reply)
+			assert.NotNil(t, history.Review.ReplyAt)
+		}
+		assert.Empty(t, f.managers.actions)
+	})
+
+	t.Run("manager with the reply scope", func(t *testing.T) {
+		f := newReviewFixture()
+		_, _ = f.service.CreateReview(f.lessee, f.history.ID, "Nice", 5, domain.ReviewRatings{})
+		invitation, _ := f.dormManagerFixture.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+		_, _ = f.dormManagerFixture.service.Accept(f.caretaker.ID, invitation.ID)
+
+		_, err := f.service.ReplyToReview(f.caretaker, f.history.ID, "Thank you")
+		assert.ErrorContains(t, err, "Only the dorm owner can reply to this review")
+		assert.Empty(t, f.history.Review.Reply)
+
+		_, _ = f.dormManagerFixture.service.UpdateScopes(f.owner, f.dorm.ID, invitation.ID, domain.ManagerScopes{ReviewReplies: true})
+		_, err = f.service.ReplyToReview(f.caretaker, f.history.ID, "Thank you")
+		assert.NoError(t, err)
+		if assert.Len(t, f.managers.actions, 1) {
+			assert.Equal(t, "review.reply", f.managers.actions[0].Action)
+		}
+	})
+
+	t.Run("staff cannot reply for the owner but can remove a reply", func(t *testing.T) {
+		f := newReviewFixture()
+		admin := &domain.User{ID: uuid.New(), Role: domain.AdminRole, TwoFactorEnabled: true}
+		_, _ = f.service.CreateReview(f.lessee, f.history.ID, "Nice", 5, domain.ReviewRatings{})
+
+		_, err := f.service.ReplyToReview(admin, f.history.ID, "Thank you")
+		assert.ErrorContains(t, err, "Only the dorm owner can reply to this review")
+
+		_, _ = f.service.ReplyToReview(f.owner, f.history.ID, "Thank you")
+		assert.ErrorContains(t, f.service.DeleteReviewReply(f.lessee, f.history.ID), "You do not have permission to delete this reply")
+		assert.NoError(t, f.service.DeleteReviewReply(admin, f.history.ID))
+		assert.Empty(t, f.history.Review.Reply)
+		assert.Nil(t, f.history.Review.ReplyAt)
+
+		assert.ErrorContains(t, f.service.DeleteReviewReply(admin, f.history.ID), "reply not found")
+	})
+}
+
+func TestSetHelpfulVote(t *testing.T) {
+	f := newReviewFixture()
+	_, _ = f.service.CreateReview(f.lessee, f.history.ID, "Nice", 5, domain.ReviewRatings{})
+	other := &domain.User{ID: uuid.New(), Role: domain.LesseeRole}
+
+	_, err := f.service.SetHelpfulVote(f.lessee, f.history.ID, true)
+	assert.ErrorContains(t, err, "You cannot vote on your own review")
+
+	// voting twice counts once
+	_, _ = f.service.SetHelpfulVote(other, f.history.ID, true)
+	history, err := f.service.SetHelpfulVote(other, f.history.ID, true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, history.Review.HelpfulCount)
+	}
+
+	history, err = f.service.SetHelpfulVote(other, f.history.ID, false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, history.Review.HelpfulCount)
+	}
+}
@@ -2,30 +2,37 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/google/uuid"
 	"github.com/yokeTH/go-pkg/apperror"
 )
 
 type LeasingRequestService struct {
-	requestRepo  ports.LeasingRequestRepository
-	dormRepo     ports.DormRepository
-	contractRepo ports.ContractRepository
+	requestRepo    ports.LeasingRequestRepository
+	dormRepo       ports.DormRepository
+	contractRepo   ports.ContractRepository
+	managerService ports.DormManagerService
+	notifier       ports.NotificationPublisher
+	webhooks       ports.WebhookPublisher
 }
 
-func NewLeasingRequestService(requestRepo ports.LeasingRequestRepository, dormRepo ports.DormRepository, contractRepo ports.ContractRepository) ports.LeasingRequestService {
-	return &LeasingRequestService{requestRepo: requestRepo, dormRepo: dormRepo, contractRepo: contractRepo}
+func NewLeasingRequestService(requestRepo ports.LeasingRequestRepository, dormRepo ports.DormRepository, contractRepo ports.ContractRepository, managerService ports.DormManagerService, notifier ports.NotificationPublisher, webhooks ports.WebhookPublisher) ports.LeasingRequestService {
+	return &LeasingRequestService{requestRepo: requestRepo, dormRepo: dormRepo, contractRepo: contractRepo, managerService: managerService, notifier: notifier, webhooks: webhooks}
 }
 
-func (s *LeasingRequestService) Create(leeseeID uuid.UUID, dormID uuid.UUID, message string) (*domain.LeasingRequest, error) {
+func (s *LeasingRequestService) Create(leeseeID uuid.UUID, dormID uuid.UUID, message string, termMonths int) (*domain.LeasingRequest, error) {
 	leasingRequest := &domain.LeasingRequest{
-		Status:   domain.RequestPending,
-		DormID:   dormID,
-		LesseeID: leeseeID,
-		Message:  message,
+		Status:     domain.RequestPending,
+		DormID:     dormID,
+		LesseeID:   leeseeID,
+		Message:    message,
+		TermMonths: termMonths,
 	}
 	err := s.requestRepo.Create(leasingRequest)
 	if err != nil {
@@ -35,6 +42,21 @@ func (s *LeasingRequestService) Create(leeseeID uuid.UUID, dormID uuid.UUID, mes
 	if err != nil {
 		return nil, err
 	}
+	s.notifier.Publish(domain.NotificationEvent{
+		Type:     domain.NotificationLeasingRequestCreated,
+		UserID:   leasingRequest.Dorm.OwnerID,
+		Title:    "New leasing request",
+		Body:     fmt.Sprintf("%s requested to lease %s.", leasingRequest.Lessee.Username, leasingRequest.Dorm.Name),
+		Link:     "/leasing-requests/" + leasingRequest.ID.String(),
+		DormName: leasingRequest.Dorm.Name,
+	})
+	s.webhooks.Publish(leasingRequest.Dorm.OwnerID, domain.WebhookRequestCreated, dto.WebhookRequestCreatedData{
+		RequestID: leasingRequest.ID,
+		DormID:    leasingRequest.DormID,
+		DormName:  leasingRequest.Dorm.Name,
+		LesseeID:  leasingRequest.LesseeID,
+		Message:   leasingRequest.Message,
+	})
 	return leasingRequest, nil
 }
 func (s *LeasingRequestService) Delete(id uuid.UUID) error {
@@ -51,7 +73,7 @@ func (s *LeasingRequestService) GetByUserID(id uuid.UUID, role domain.Role, limi
 	}
 	return leasingRequest, totalPage, totalRows, nil
 }
-func (s *LeasingRequestService) Approve(id, userId uuid.UUID, isAdmin bool) error {
+func (s *LeasingRequestService) Approve(id uuid.UUID, user *domain.User) error {
 	leasingRequest, err := s.requestRepo.GetByID(id)
 	if err != nil {
 		return err
@@ -59,8 +81,12 @@ func (s *LeasingRequestService) Approve(id, userId uuid.UUID, isAdmin bool) erro
 	if leasingRequest.Status != domain.RequestPending {
 		return apperror.BadRequestError(errors.New("request is not in the pending status"), "request is not in the pending status")
 	}
-	if userId != leasingRequest.Dorm.OwnerID && !isAdmin {
-		return apperror.UnauthorizedError(errors.New("user is unauthorized"), "user is unauthorized")
+	manager, err := s.managerService.Authorize(user, &leasingRequest.Dorm, domain.ScopeLeasingRequests, domain.PermissionLeasingManage)
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return err
+		}
+		return apperror.UnauthorizedError(err, "user is unauthorized")
 	}
 	leasingRequest.End = time.Now()
 	leasingRequest.Status = domain.RequestAccepted
@@ -68,15 +94,31 @@ func (s *LeasingRequestService) Approve(id, userId uuid.UUID, isAdmin bool) erro
 	if err != nil {
 		return err
 	}
-	contract := &domain.Contract{LesseeID: leasingRequest.LesseeID, DormID: leasingRequest.DormID}
+	contract := &domain.Contract{LesseeID: leasingRequest.LesseeID, DormID: leasingRequest.DormID, TermMonths: leasingRequest.TermMonths}
 	err = s.contractRepo.Create(contract)
 	if err != nil {
 		return err
 	}
-	return nil
+	s.notifier.Publish(domain.NotificationEvent{
+		Type:     domain.NotificationLeasingRequestApproved,
+		UserID:   leasingRequest.LesseeID,
+		Title:    "Leasing request approved",
+		Body:     fmt.Sprintf("Your request to lease %s was approved.", leasingRequest.Dorm.Name),
+		Link:     "/leasing-requests/" + leasingRequest.ID.String(),
+		DormName: leasingRequest.Dorm.Name,
+	})
+	s.notifier.Publish(domain.NotificationEvent{
+		Type:     domain.NotificationContractAwaitingSignature,
+		UserID:   leasingRequest.LesseeID,
+		Title:    "Contract waiting for your signature",
+		Body:     fmt.Sprintf("The contract for %s is ready. Sign it to start your lease.", leasingRequest.Dorm.Name),
+		Link:     "/contracts/" + contract.ID.String(),
+		DormName: leasingRequest.Dorm.Name,
+	})
+	return s.managerService.RecordAction(manager, domain.ScopeLeasingRequests, "leasing_request.approve", id)
 }
 
-func (s *LeasingRequestService) Reject(id, userId uuid.UUID, isAdmin bool) error {
+func (s *LeasingRequestService) Reject(id uuid.UUID, user *domain.User) error {
 	leasingRequest, err := s.requestRepo.GetByID(id)
 	if err != nil {
 		return err
@@ -84,8 +126,12 @@ func (s *LeasingRequestService) Reject(id, userId uuid.UUID, isAdmin bool) error
 	if leasingRequest.Status != domain.RequestPending {
 		return apperror.BadRequestError(errors.New("request is not in the pending status"), "request is not in the pending status")
 	}
-	if userId != leasingRequest.Dorm.OwnerID && !isAdmin {
-		return apperror.UnauthorizedError(errors.New("user is unauthorized"), "user is unauthorized")
+	manager, err := s.managerService.Authorize(user, &leasingRequest.Dorm, domain.ScopeLeasingRequests, domain.PermissionLeasingManage)
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return err
+		}
+		return apperror.UnauthorizedError(err, "user is unauthorized")
 	}
 	leasingRequest.End = time.Now()
 	leasingRequest.Status = domain.RequestRejected
@@ -93,10 +139,18 @@ func (s *LeasingRequestService) Reject(id, userId uuid.UUID, isAdmin bool) error
 	if err != nil {
 		return err
 	}
-	return nil
+	s.notifier.Publish(domain.NotificationEvent{
+		Type:     domain.NotificationLeasingRequestRejected,
+		UserID:   leasingRequest.LesseeID,
+		Title:    "Leasing request rejected",
+		Body:     fmt.Sprintf("Your request to lease %s was rejected.", leasingRequest.Dorm.Name),
+		Link:     "/leasing-requests/" + leasingRequest.ID.String(),
+		DormName: leasingRequest.Dorm.Name,
+	})
+	return s.managerService.RecordAction(manager, domain.ScopeLeasingRequests, "leasing_request.reject", id)
 }
 
-func (s *LeasingRequestService) Cancel(id, userId uuid.UUID, isAdmin bool) error {
+func (s *LeasingRequestService) Cancel(id uuid.UUID, user *domain.User) error {
 	leasingRequest, err := s.requestRepo.GetByID(id)
 	if err != nil {
 		return err
@@ -104,8 +158,8 @@ func (s *LeasingRequestService) Cancel(id, userId uuid.UUID, isAdmin bool) error
 	if leasingRequest.Status != domain.RequestPending {
 		return apperror.BadRequestError(errors.New("request is not in the pending status"), "request is not in the pending status")
 	}
-	if userId != leasingRequest.LesseeID && !isAdmin {
-		return apperror.UnauthorizedError(errors.New("user is unauthorized"), "user is unauthorized")
+	if err := policy.AuthorizeOwner(user, leasingRequest.LesseeID, domain.PermissionLeasingManage); err != nil {
+		return apperror.UnauthorizedError(err, "user is unauthorized")
 	}
 	leasingRequest.End = time.Now()
 	leasingRequest.Status = domain.RequestCanceled
@@ -116,7 +170,18 @@ func (s *LeasingRequestService) Cancel(id, userId uuid.UUID, isAdmin bool) error
 	return nil
 }
 
-func (s *LeasingRequestService) GetByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingRequest, int, int, error) {
+func (s *LeasingRequestService) GetByDormID(user *domain.User, id uuid.UUID, limit, page int) ([]domain.LeasingRequest, int, int, error) {
+	dorm, err := s.dormRepo.GetByID(id)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if _, err := s.managerService.Authorize(user, dorm, domain.ScopeLeasingRequests, domain.PermissionLeasingManage); err != nil {
+		if apperror.IsAppError(err) {
+			return nil, 0, 0, err
+		}
+		return nil, 0, 0, apperror.ForbiddenError(err, "You do not have permission to view this dorm's requests")
+	}
+
 	leasingRequest, totalPage, totalRows, err := s.requestRepo.GetByDormID(id, limit, page)
 	if err != nil {
 		return nil, totalPage, totalRows, err
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+type LessorReportService struct {
+	reportRepo   ports.LessorReportRepository
+	emailService email.Sender
+}
+
+func NewLessorReportService(reportRepo ports.LessorReportRepository, emailService email.Sender) ports.LessorReportService {
+	return &LessorReportService{reportRepo: reportRepo, emailService: emailService}
+}
+
+// reportWeekStart returns the Monday, at midnight, of the week of t.
+func reportWeekStart(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	year, month, day := t.AddDate(0, 0, -daysSinceMonday).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// SendWeekly emails every lessor the report of the last full week, from Monday
+// to Sunday. It is safe to run often, each lessor gets one report per week.
+func (s *LessorReportService) SendWeekly() error {
+	to := reportWeekStart(time.Now())
+	from := to.AddDate(0, 0, -7)
+
+	owners, err := s.reportRepo.GetRecipients(from)
+	if err != nil {
+		return err
+	}
+
+	for _, owner := range owners {
+		dorms, err := s.reportRepo.GetDormReports(owner.ID, from, to)
+		if err != nil {
+			log.Errorf("lessor report: cannot build report of %s: %v", owner.ID, err)
+			continue
+		}
+		if len(dorms) == 0 {
+			continue
+		}
+
+		claimed, err := s.reportRepo.Claim(&domain.LessorReportLog{OwnerID: owner.ID, WeekStart: from})
+		if err != nil {
+			log.Errorf("lessor report: cannot record report of %s: %v", owner.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		data := weeklyReportData(dorms, from, to)
+		if err := s.emailService.SendWeeklyReportEmail(recipient(&owner), s.emailService.Link("/dashboard"), data); err != nil {
+			log.Errorf("lessor report: cannot send report to %s: %v", owner.ID, err)
+		}
+	}
+	return nil
+}
+
+func weeklyReportData(dorms []domain.DormReport, from time.Time, to time.Time) email.WeeklyReportData {
+	data := email.WeeklyReportData{
+		From:  from,
+		To:    to.AddDate(0, 0, -1),
+		Dorms: make([]email.WeeklyReportDorm, len(dorms)),
+	}
+
+	var responseTime time.Duration
+	for i, dorm := range dorms {
+		data.NewRequests += dorm.NewRequests
+		data.AnsweredRequests += dorm.AnsweredRequests
+		data.PendingRequests += dorm.PendingRequests
+		responseTime += dorm.ResponseTime
+		data.PaidBills += dorm.PaidBills
+		data.PaidAmount += dorm.PaidAmount
+		data.OutstandingBills += dorm.OutstandingBills
+		data.OutstandingAmount += dorm.OutstandingAmount
+		data.NewReviews += dorm.NewReviews
+		data.Views += dorm.Views
+
+		var ratingChange float64
+		// a first review is not a change
+		if dorm.PreviousRating > 0 {
+			ratingChange = dorm.Rating - dorm.PreviousRating
+		}
+		data.Dorms[i] = email.WeeklyReportDorm{
+			Name:         dorm.Name,
+			Occupancy:    int(dorm.Occupancy*100 + 0.5),
+			Rating:       dorm.Rating,
+			RatingChange: ratingChange,
+			NewRequests:  dorm.NewRequests,
+			NewReviews:   dorm.NewReviews,
+			Views:        dorm.Views,
+		}
+	}
+
+	if data.AnsweredRequests > 0 {
+		data.ResponseHours = responseTime.Hours() / float64(data.AnsweredRequests)
+	}
+	return data
+}
+
+func (s *LessorReportService) RecordView(dormID uuid.UUID) {
+	now := time.Now()
+	year, month, day := now.Date()
+	if err := s.reportRepo.AddDormView(dormID, time.Date(year, month, day, 0, 0, 0, 0, now.Location())); err != nil {
+		log.Errorf("lessor report: cannot record view of dorm %s: %v", dormID, err)
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLessorReportRepo struct {
+	ports.LessorReportRepository
+	owners []domain.User
+	dorms  map[uuid.UUID][]domain.DormReport
+	logs   []domain.LessorReportLog
+	// from and to are the period of the last report built.
+	from, to time.Time
+}
+
+// GetRecipients returns every owner, like a list read before another instance
+// claimed the week, so only Claim keeps a lessor from a second report.
+func (m *mockLessorReportRepo) GetRecipients(weekStart time.Time) ([]domain.User, error) {
+	return m.owners, nil
+}
+
+func (m *mockLessorReportRepo) GetDormReports(ownerID uuid.UUID, from time.Time, to time.Time) ([]domain.DormReport, error) {
+	m.from, m.to = from, to
+	dorms, ok := m.dorms[ownerID]
+	if !ok {
+		return nil, errors.New("cannot build report")
+	}
+	return dorms, nil
+}
+
+// Claim refuses a second log of the same owner and week, like the primary key.
+func (m *mockLessorReportRepo) Claim(log *domain.LessorReportLog) (bool, error) {
+	for _, existing := range m.logs {
+		if existing.OwnerID == log.OwnerID && existing.WeekStart.Equal(log.WeekStart) {
+			return false, nil
+		}
+	}
+	m.logs = append(m.logs, *log)
+	return true, nil
+}
+
+func TestReportWeekStart(t *testing.T) {
+	monday := time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, monday, reportWeekStart(monday))
+	assert.Equal(t, monday, reportWeekStart(time.Date(2026, time.October, 14, 9, 30, 0, 0, time.UTC)))
+	assert.Equal(t, monday, reportWeekStart(time.Date(2026, time.October, 18, 23, 59, 0, 0, time.UTC)))
+	// across a month
+	assert.Equal(t, time.Date(2026, time.September, 28, 0, 0, 0, 0, time.UTC), reportWeekStart(time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestWeeklyReportData(t *testing.T) {
+	from := time.Date(2026, time.October, 5, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	data := weeklyReportData([]domain.DormReport{
+		{
+			Name: "Baan Suan", NewRequests: 3, AnsweredRequests: 2, PendingRequests: 1, ResponseTime: 10 * time.Hour,
+			Occupancy: 0.857, PaidBills: 2, PaidAmount: 9000, OutstandingBills: 1, OutstandingAmount: 4500,
+			NewReviews: 1, Rating: 4.5, PreviousRating: 4, Views: 120,
+		},
+		{
+			Name: "Baan Rim Nam", NewRequests: 1, AnsweredRequests: 2, ResponseTime: 30 * time.Hour,
+			Occupancy: 1, PaidBills: 1, PaidAmount: 5000, NewReviews: 1, Rating: 5, Views: 30,
+		},
+	}, from, to)
+
+	assert.Equal(t, from, data.From)
+	// the report ends on Sunday
+	assert.Equal(t, time.Date(2026, time.October, 11, 0, 0, 0, 0, time.UTC), data.To)
+	assert.Equal(t, int64(4), data.NewRequests)
+	assert.Equal(t, int64(4), data.AnsweredRequests)
+	assert.Equal(t, int64(1), data.PendingRequests)
+	assert.Equal(t, 10.0, data.ResponseHours)
+	assert.Equal(t, int64(3), data.PaidBills)
+	assert.Equal(t, int64(14000), data.PaidAmount)
+	assert.Equal(t, int64(1), data.OutstandingBills)
+	assert.Equal(t, int64(4500), data.OutstandingAmount)
+	assert.Equal(t, int64(2), data.NewReviews)
+	assert.Equal(t, int64(150), data.Views)
+
+	if assert.Len(t, data.Dorms, 2) {
+		assert.Equal(t, 86, data.Dorms[0].Occupancy)
+		assert.InDelta(t, 0.5, data.Dorms[0].RatingChange, 1e-9)
+		assert.Equal(t, 100, data.Dorms[1].Occupancy)
+		// a first review is not a change
+		assert.Zero(t, data.Dorms[1].RatingChange)
+	}
+
+	// no answered request, no response time
+	data = weeklyReportData([]domain.DormReport{{Name: "Baan Suan", PendingRequests: 2}}, from, to)
+	assert.Zero(t, data.ResponseHours)
+}
+
+func TestSendWeeklyReport(t *testing.T) {
+	newFixture := func() (*LessorReportService, *mockLessorReportRepo, *mockEmailSender, domain.User) {
+		owner := domain.User{ID: uuid.New(), Email: "somchai@example.com"}
+		repo := &mockLessorReportRepo{
+			owners: []domain.User{owner},
+			dorms:  map[uuid.UUID][]domain.DormReport{owner.ID: {{Name: "Baan Suan", NewRequests: 2, Views: 40}}},
+		}
+		emails := &mockEmailSender{}
+		return &LessorReportService{reportRepo: repo, emailService: emails}, repo, emails, owner
+	}
+
+	t.Run("once per week", func(t *testing.T) {
+		service, repo, emails, owner := newFixture()
+
+		assert.NoError(t, service.SendWeekly())
+		assert.NoError(t, service.SendWeekly())
+
+		if assert.Len(t, emails.sent, 1) {
+			assert.Equal(t, "somchai@example.com", emails.sent[0].to)
+			assert.Equal(t, "https://condormhub.example/dashboard", emails.sent[0].link)
+			assert.Equal(t, int64(2), emails.reports[0].NewRequests)
+		}
+		if assert.Len(t, repo.logs, 1) {
+			assert.Equal(t, owner.ID, repo.logs[0].OwnerID)
+		}
+
+		// the last full week, from Monday to Monday
+		weekStart := reportWeekStart(time.Now())
+		assert.Equal(t, weekStart.AddDate(0, 0, -7), repo.from)
+		assert.Equal(t, weekStart, repo.to)
+		assert.Equal(t, repo.from, repo.logs[0].WeekStart)
+	})
+
+	t.Run("nothing to report", func(t *testing.T) {
+		service, repo, emails, owner := newFixture()
+		repo.dorms[owner.ID] = nil
+
+		assert.NoError(t, service.SendWeekly())
+		assert.Empty(t, emails.sent)
+		// the week is not claimed, a dorm listed later in the week still gets its report
+		assert.Empty(t, repo.logs)
+	})
+
+	t.Run("a failing lessor does not stop the others", func(t *testing.T) {
+		service, repo, emails, _ := newFixture()
+		broken := domain.User{ID: uuid.New(), Email: "malee@example.com"}
+		repo.owners = append([]domain.User{broken}, repo.owners...)
+
+		assert.NoError(t, service.SendWeekly())
+		if assert.Len(t, emails.sent, 1) {
+			assert.Equal(t, "somchai@example.com", emails.sent[0].to)
+		}
+	})
+}
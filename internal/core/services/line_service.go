@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/line"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+const (
+	// LineLinkCodeExpiration is how long a link code can be sent to the bot.
+	LineLinkCodeExpiration = 10 * time.Minute
+
+	lineLinkCodeLength = 8
+	// lineLinkCodeAlphabet leaves out letters and digits that look alike.
+	lineLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+const (
+	lineWelcomeMessage = "Welcome to ConDormHub! To get your notifications here, create a link code in your ConDormHub settings and send it to this chat."
+	lineLinkedMessage  = "Your LINE account is now linked to ConDormHub. Your notifications will be sent here."
+	lineInvalidMessage = "This link code is invalid or expired. Please create a new one in your ConDormHub settings."
+)
+
+// lineLinkCodeStore is the part of redis linking needs, so tests can keep codes in memory.
+type lineLinkCodeStore interface {
+	SetLineLinkCode(ctx context.Context, userID uuid.UUID, code string, ttl time.Duration) error
+	TakeLineLinkCode(ctx context.Context, code string) (uuid.UUID, error)
+}
+
+// LineService links LINE accounts: the user creates a code in the app and
+// sends it to the bot, whose webhook receives it with the LINE user ID.
+type LineService struct {
+	lineRepo      ports.LineAccountRepository
+	codes         lineLinkCodeStore
+	client        line.Client
+	channelSecret string
+}
+
+func NewLineService(lineRepo ports.LineAccountRepository, redis *redis.Redis, client line.Client, channelSecret string) ports.LineService {
+	return &LineService{
+		lineRepo:      lineRepo,
+		codes:         redis,
+		client:        client,
+		channelSecret: channelSecret,
+	}
+}
+
+// CreateLinkCode returns a new code, the previous code of the user stops working.
+func (s *LineService) CreateLinkCode(ctx context.Context, userID uuid.UUID) (string, time.Time, error) {
+	code, err := generateLineLinkCode()
+	if err != nil {
+		return "", time.Time{}, apperror.InternalServerError(err, "Failed to create link code")
+	}
+
+	if err := s.codes.SetLineLinkCode(ctx, userID, code, LineLinkCodeExpiration); err != nil {
+		return "", time.Time{}, apperror.InternalServerError(err, "Failed to create link code")
+	}
+
+	return code, time.Now().Add(LineLinkCodeExpiration), nil
+}
+
+func (s *LineService) GetAccount(userID uuid.UUID) (*domain.LineAccount, error) {
+	return s.lineRepo.GetByUserID(userID)
+}
+
+func (s *LineService) Unlink(userID uuid.UUID) error {
+	return s.lineRepo.DeleteByUserID(userID)
+}
+
+// HandleWebhook processes the events LINE posts to the bot. Only a bad
+// signature or body is an error; an event that fails is logged, as LINE
+// would otherwise send the whole batch again.
+func (s *LineService) HandleWebhook(ctx context.Context, body []byte, signature string) error {
+	if s.channelSecret == "" || !line.VerifySignature(s.channelSecret, body, signature) {
+		return apperror.UnauthorizedError(errors.New("invalid line signature"), "Invalid signature")
+	}
+
+	events, err := line.ParseEvents(body)
+	if err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	for _, event := range events {
+		if event.Source.Type != "user" || event.Source.UserID == "" {
+			continue
+		}
+		if err := s.handleEvent(ctx, event); err != nil {
+			log.Errorf("line: cannot handle %s event of %s: %v", event.Type, event.Source.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (s *LineService) handleEvent(ctx context.Context, event line.Event) error {
+	switch event.Type {
+	case "follow":
+		return s.client.Reply(ctx, event.ReplyToken, line.Message{Text: lineWelcomeMessage})
+	case "unfollow":
+		// the bot was blocked, it cannot send messages anymore
+		return s.lineRepo.DeleteByLineUserID(event.Source.UserID)
+	case "message":
+		if event.Message.Type != "text" {
+			return nil
+		}
+		code := strings.ToUpper(strings.TrimSpace(event.Message.Text))
+		if len(code) != lineLinkCodeLength {
+			return s.client.Reply(ctx, event.ReplyToken, line.Message{Text: lineWelcomeMessage})
+		}
+
+		userID, err := s.codes.TakeLineLinkCode(ctx, code)
+		if errors.Is(err, redis.Nil) {
+			return s.client.Reply(ctx, event.ReplyToken, line.Message{Text: lineInvalidMessage})
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.lineRepo.Link(&domain.LineAccount{UserID: userID, LineUserID: event.Source.UserID}); err != nil {
+			return err
+		}
+		return s.client.Reply(ctx, event.ReplyToken, line.Message{Text: lineLinkedMessage})
+	}
+	return nil
+}
+
+func generateLineLinkCode() (string, error) {
+	code := make([]byte, lineLinkCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(lineLinkCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = lineLinkCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// lineActionLabels are the buttons under the notifications that need the user to act.
+var lineActionLabels = map[domain.NotificationType]string{
+	domain.NotificationLeasingRequestCreated:     "View request",
+	domain.NotificationLeasingRequestPending:     "View request",
+	domain.NotificationContractAwaitingSignature: "Sign contract",
+	domain.NotificationContractUnsigned:          "Sign contract",
+	domain.NotificationOrderCreated:              "Pay now",
+	domain.NotificationPaymentDueSoon:            "Pay now",
+	domain.NotificationPaymentDue:                "Pay now",
+	domain.NotificationPaymentOverdue:            "Pay now",
+	domain.NotificationPaymentCompleted:          "View bill",
+	domain.NotificationAnnouncementPublished:     "Read announcement",
+}
+
+// LineNotificationChannel pushes notifications to the linked LINE account, with
+// a button opening the notification in the app. Users without one are skipped.
+type LineNotificationChannel struct {
+	lineRepo ports.LineAccountRepository
+	client   line.Client
+	link     func(path string) string
+}
+
+// NewLineNotificationChannel creates the channel, link turns the path of a
+// notification into the URL of the web app.
+func NewLineNotificationChannel(lineRepo ports.LineAccountRepository, client line.Client, link func(path string) string) ports.NotificationChannel {
+	return &LineNotificationChannel{lineRepo: lineRepo, client: client, link: link}
+}
+
+func (c *LineNotificationChannel) Name() string {
+	return "line"
+}
+
+func (c *LineNotificationChannel) Enabled(preference domain.NotificationPreference) bool {
+	return preference.Line
+}
+
+func (c *LineNotificationChannel) Send(user *domain.User, event domain.NotificationEvent) error {
+	account, err := c.lineRepo.GetByUserID(user.ID)
+	if err != nil || account == nil {
+		return err
+	}
+
+	message := line.Message{Text: event.Title + "\n" + event.Body}
+	if event.Link != "" {
+		label, ok := lineActionLabels[event.Type]
+		if !ok {
+			label = "Open"
+		}
+		message.Actions = []line.Action{{Label: label, URI: c.link(event.Link)}}
+	}
+
+	return c.client.Push(context.Background(), account.LineUserID, message)
+}
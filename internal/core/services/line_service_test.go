@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/pkg/line"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLineAccountRepo struct {
+	accounts map[uuid.UUID]*domain.LineAccount
+}
+
+func (m *mockLineAccountRepo) GetByUserID(userID uuid.UUID) (*domain.LineAccount, error) {
+	return m.accounts[userID], nil
+}
+
+func (m *mockLineAccountRepo) Link(account *domain.LineAccount) error {
+	m.accounts[account.UserID] = account
+	return nil
+}
+
+func (m *mockLineAccountRepo) DeleteByUserID(userID uuid.UUID) error {
+	delete(m.accounts, userID)
+	return nil
+}
+
+func (m *mockLineAccountRepo) DeleteByLineUserID(lineUserID string) error {
+	for userID, account := range m.accounts {
+		if account.LineUserID == lineUserID {
+			delete(m.accounts, userID)
+		}
+	}
+	return nil
+}
+
+const lineTestSecret = "channel-secret"
+
+// newLineService returns a service that replies through client.
+func newLineService(repo *mockLineAccountRepo, client *line.FakeClient) *LineService {
+	return &LineService{lineRepo: repo, codes: newMockRedis(), client: client, channelSecret: lineTestSecret}
+}
+
+// lineWebhookBody returns a webhook body with a text message from lineUserID and its signature.
+func lineWebhookBody(t *testing.T, lineUserID string, text string) ([]byte, string) {
+	event := map[string]any{
+		"type":       "message",
+		"replyToken": "reply-" + text,
+		"source":     map[string]any{"type": "user", "userId": lineUserID},
+		"message":    map[string]any{"type": "text", "text": text},
+	}
+	body, err := json.Marshal(map[string]any{"events": []any{event}})
+	assert.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte(lineTestSecret))
+	mac.Write(body)
+	return body, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestLineWebhookSignature(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("invalid signature", func(t *testing.T) {
+		client := line.NewFakeClient()
+		service := newLineService(&mockLineAccountRepo{}, client)
+		body, _ := lineWebhookBody(t, "U1", "hello")
+
+		err := service.HandleWebhook(ctx, body, base64.StdEncoding.EncodeToString([]byte("forged")))
+		assert.ErrorContains(t, err, "Invalid signature")
+		assert.Empty(t, client.Messages())
+	})
+
+	t.Run("no channel secret", func(t *testing.T) {
+		client := line.NewFakeClient()
+		service := newLineService(&mockLineAccountRepo{}, client)
+		service.channelSecret = ""
+		body, _ := lineWebhookBody(t, "U1", "hello")
+
+		mac := hmac.New(sha256.New, []byte(""))
+		mac.Write(body)
+		err := service.HandleWebhook(ctx, body, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		assert.ErrorContains(t, err, "Invalid signature")
+		assert.Empty(t, client.Messages())
+	})
+
+	t.Run("valid signature", func(t *testing.T) {
+		client := line.NewFakeClient()
+		service := newLineService(&mockLineAccountRepo{}, client)
+		body, signature := lineWebhookBody(t, "U1", "hello")
+
+		assert.NoError(t, service.HandleWebhook(ctx, body, signature))
+		if messages := client.Messages(); assert.Len(t, messages, 1) {
+			assert.Equal(t, lineWelcomeMessage, messages[0].Text)
+		}
+	})
+}
+
+func TestLineLinkCode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("code links once", func(t *testing.T) {
+		repo, client := &mockLineAccountRepo{accounts: map[uuid.UUID]*domain.LineAccount{}}, line.NewFakeClient()
+		service := newLineService(repo, client)
+		userID := uuid.New()
+		code, _, err := service.CreateLinkCode(ctx, userID)
+		assert.NoError(t, err)
+
+		// users may type the code in lower case with spaces around it
+		body, signature := lineWebhookBody(t, "U1", " "+strings.ToLower(code)+" ")
+		assert.NoError(t, service.HandleWebhook(ctx, body, signature))
+		if assert.Contains(t, repo.accounts, userID) {
+			assert.Equal(t, "U1", repo.accounts[userID].LineUserID)
+		}
+
+		body, signature = lineWebhookBody(t, "U2", code)
+		assert.NoError(t, service.HandleWebhook(ctx, body, signature))
+		assert.Equal(t, "U1", repo.accounts[userID].LineUserID)
+
+		messages := client.Messages()
+		if assert.Len(t, messages, 2) {
+			assert.Equal(t, lineLinkedMessage, messages[0].Text)
+			assert.Equal(t, lineInvalidMessage, messages[1].Text)
+		}
+	})
+
+	t.Run("new code replaces the previous one", func(t *testing.T) {
+		repo, client := &mockLineAccountRepo{accounts: map[uuid.UUID]*domain.LineAccount{}}, line.NewFakeClient()
+		service := newLineService(repo, client)
+		userID := uuid.New()
+		previous, _, err := service.CreateLinkCode(ctx, userID)
+		assert.NoError(t, err)
+		_, _, err = service.CreateLinkCode(ctx, userID)
+		assert.NoError(t, err)
+
+		body, signature := lineWebhookBody(t, "U1", previous)
+		assert.NoError(t, service.HandleWebhook(ctx, body, signature))
+		assert.Empty(t, repo.accounts)
+		if messages := client.Messages(); assert.Len(t, messages, 1) {
+			assert.Equal(t, lineInvalidMessage, messages[0].Text)
+		}
+	})
+}
+
+func TestLineNotificationChannel(t *testing.T) {
+	repo := &mockLineAccountRepo{accounts: map[uuid.UUID]*domain.LineAccount{}}
+	client := line.NewFakeClient()
+	channel := NewLineNotificationChannel(repo, client, func(path string) string {
+		return "https://condormhub.example" + path
+	})
+
+	linked := &domain.User{ID: uuid.New()}
+	repo.accounts[linked.ID] = &domain.LineAccount{UserID: linked.ID, LineUserID: "U1"}
+	event := domain.NotificationEvent{
+		Type:  domain.NotificationPaymentDue,
+		Title: "Payment due",
+		Body:  "Your rent is due today.",
+		Link:  "/orders/1",
+	}
+
+	t.Run("linked user", func(t *testing.T) {
+		assert.NoError(t, channel.Send(linked, event))
+
+		messages := client.Messages()
+		if assert.Len(t, messages, 1) {
+			assert.Equal(t, "U1", messages[0].To)
+			assert.Equal(t, "Payment due\nYour rent is due today.", messages[0].Text)
+			assert.Equal(t, []line.Action{{Label: "Pay now", URI: "https://condormhub.example/orders/1"}}, messages[0].Actions)
+		}
+	})
+
+	t.Run("user without LINE", func(t *testing.T) {
+		client.Clear()
+		assert.NoError(t, channel.Send(&domain.User{ID: uuid.New()}, event))
+		assert.Empty(t, client.Messages())
+	})
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// liveSessionStore is the part of redis that tells whether a session is still
+// valid, so tests can keep sessions in memory.
+type liveSessionStore interface {
+	SessionExists(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error)
+}
+
+// LiveService fans events out through redis pub/sub, so a user connected to
+// another server instance receives them too.
+type LiveService struct {
+	redis    *redis.Redis
+	sessions liveSessionStore
+}
+
+func NewLiveService(redis *redis.Redis) ports.LiveService {
+	return &LiveService{redis: redis, sessions: redis}
+}
+
+func (s *LiveService) Push(userID uuid.UUID, eventType domain.LiveEventType, data interface{}) {
+	payload, err := json.Marshal(dto.LiveEvent{Type: string(eventType), Data: data})
+	if err != nil {
+		log.Errorf("live: cannot encode %s event: %v", eventType, err)
+		return
+	}
+
+	if err := s.redis.PublishUserEvent(context.Background(), userID, string(payload)); err != nil {
+		log.Errorf("live: cannot publish %s event to user %s: %v", eventType, userID, err)
+	}
+}
+
+// Subscribe returns the encoded events of the user until ctx is done.
+func (s *LiveService) Subscribe(ctx context.Context, userID uuid.UUID) (<-chan string, error) {
+	events, err := s.redis.SubscribeUserEvents(ctx, userID)
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to subscribe to live updates")
+	}
+	return events, nil
+}
+
+// Active reports whether the session a stream was opened with still exists.
+// Logging out, revoking the session and banning the user all delete it.
+func (s *LiveService) Active(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error) {
+	active, err := s.sessions.SessionExists(ctx, userID, sessionID)
+	if err != nil {
+		return false, apperror.InternalServerError(err, "Failed to check session")
+	}
+	return active, nil
+}
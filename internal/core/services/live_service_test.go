@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingSessionStore struct{}
+
+func (failingSessionStore) SessionExists(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestLiveActive(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	sessions := newMockRedis()
+	sessions.values[fmt.Sprintf("session:%s:%s", userID, "phone")] = ""
+	service := &LiveService{sessions: sessions}
+
+	active, err := service.Active(ctx, userID, "phone")
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	// revoked, or every session deleted when the user was banned
+	delete(sessions.values, fmt.Sprintf("session:%s:%s", userID, "phone"))
+	active, err = service.Active(ctx, userID, "phone")
+	assert.NoError(t, err)
+	assert.False(t, active)
+
+	active, err = service.Active(ctx, uuid.New(), "phone")
+	assert.NoError(t, err)
+	assert.False(t, active, "session of another user")
+
+	service.sessions = failingSessionStore{}
+	_, err = service.Active(ctx, userID, "phone")
+	assert.ErrorContains(t, err, "Failed to check session")
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// mockUserRepo keeps users in memory. Methods a test does not need panic through the embedded interface.
+// err fails every lookup by email.
+type mockUserRepo struct {
+	ports.UserRepository
+	users []*domain.User
+	err   error
+}
+
+func (m *mockUserRepo) Create(user *domain.User) error {
+	user.ID = uuid.New()
+	m.users = append(m.users, user)
+	return nil
+}
+
+func (m *mockUserRepo) GetUserByID(userID uuid.UUID) (*domain.User, error) {
+	for _, user := range m.users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (m *mockUserRepo) GetUserByEmail(email string) (*domain.User, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (m *mockUserRepo) FindUserByEmail(email string) (*domain.User, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockUserRepo) UpdateUser(user *domain.User) error {
+	return nil
+}
+
+// UpdateInformation only changes the password and phone number, which the services act on.
+func (m *mockUserRepo) UpdateInformation(userID uuid.UUID, data domain.User) error {
+	user, err := m.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if data.Password != "" {
+		user.Password = data.Password
+	}
+	if data.PhoneNumber != "" {
+		user.PhoneNumber = data.PhoneNumber
+	}
+	return nil
+}
+
+func (m *mockUserRepo) DeleteAccount(userID uuid.UUID) error {
+	for i, user := range m.users {
+		if user.ID == userID {
+			m.users = append(m.users[:i], m.users[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+// mockRedis keeps the short lived values services store in redis in memory.
+// Taking a value deletes it, like GETDEL.
+type mockRedis struct {
+	values map[string]string
+}
+
+func newMockRedis() *mockRedis {
+	return &mockRedis{values: map[string]string{}}
+}
+
+func (m *mockRedis) take(key string) (string, error) {
+	value, ok := m.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	delete(m.values, key)
+	return value, nil
+}
+
+func (m *mockRedis) SetOIDCState(ctx context.Context, state string, value string, ttl time.Duration) error {
+	m.values["oidc_state:"+state] = value
+	return nil
+}
+
+func (m *mockRedis) TakeOIDCState(ctx context.Context, state string) (string, error) {
+	return m.take("oidc_state:" + state)
+}
+
+// SetLineLinkCode replaces the previous code of the user, like the redis implementation.
+func (m *mockRedis) SetLineLinkCode(ctx context.Context, userID uuid.UUID, code string, ttl time.Duration) error {
+	for key, value := range m.values {
+		if value == userID.String() && strings.HasPrefix(key, "line_link:") {
+			delete(m.values, key)
+		}
+	}
+	m.values["line_link:"+code] = userID.String()
+	return nil
+}
+
+func (m *mockRedis) TakeLineLinkCode(ctx context.Context, code string) (uuid.UUID, error) {
+	value, err := m.take("line_link:" + code)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(value)
+}
+
+// SetPhoneOTP resets the attempts, like the redis implementation.
+func (m *mockRedis) SetPhoneOTP(ctx context.Context, userID uuid.UUID, value string, ttl time.Duration) error {
+	delete(m.values, "phone_otp_attempts:"+userID.String())
+	m.values["phone_otp:"+userID.String()] = value
+	return nil
+}
+
+func (m *mockRedis) GetPhoneOTP(ctx context.Context, userID uuid.UUID) (string, error) {
+	value, ok := m.values["phone_otp:"+userID.String()]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (m *mockRedis) DeletePhoneOTP(ctx context.Context, userID uuid.UUID) error {
+	delete(m.values, "phone_otp:"+userID.String())
+	delete(m.values, "phone_otp_attempts:"+userID.String())
+	return nil
+}
+
+func (m *mockRedis) IncrPhoneOTPAttempts(ctx context.Context, userID uuid.UUID, ttl time.Duration) (int64, error) {
+	key := "phone_otp_attempts:" + userID.String()
+	var attempts int64
+	fmt.Sscan(m.values[key], &attempts)
+	attempts++
+	m.values[key] = fmt.Sprint(attempts)
+	return attempts, nil
+}
+
+func (m *mockRedis) SessionExists(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error) {
+	_, ok := m.values[fmt.Sprintf("session:%s:%s", userID, sessionID)]
+	return ok, nil
+}
+
+// sentEmail is a sent email, template is empty for free text.
+type sentEmail struct {
+	to       string
+	template string
+	lines    []string
+	link     string
+}
+
+// mockEmailSender records the emails instead of queueing them, err fails every send.
+// Some services send from a goroutine, so sends are guarded.
+type mockEmailSender struct {
+	email.Sender
+	mu   sync.Mutex
+	sent []sentEmail
+	// reports are the data of the weekly reports in sent.
+	reports []email.WeeklyReportData
+	err     error
+}
+
+func (m *mockEmailSender) SendNotificationEmail(to email.Recipient, subject string, lines []string, buttonText, link string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, sentEmail{to: to.Email, lines: lines, link: link})
+	return nil
+}
+
+func (m *mockEmailSender) SendEventEmail(to email.Recipient, template string, link string, data email.EventData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, sentEmail{to: to.Email, template: template, link: link})
+	return nil
+}
+
+func (m *mockEmailSender) SendWeeklyReportEmail(to email.Recipient, link string, data email.WeeklyReportData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, sentEmail{to: to.Email, template: "weekly_report", link: link})
+	m.reports = append(m.reports, data)
+	return nil
+}
+
+func (m *mockEmailSender) SendEmailChangeEmail(to email.Recipient, token string) error {
+	return m.SendNotificationEmail(to, "Confirm your new email", nil, "", m.Link("/verify-email-change?token="+token))
+}
+
+func (m *mockEmailSender) SendEmailChangedEmail(to email.Recipient, newEmail string) error {
+	return m.SendNotificationEmail(to, "Your email was changed", []string{newEmail}, "", m.Link("/support"))
+}
+
+func (m *mockEmailSender) Link(path string) string {
+	return "https://condormhub.example" + path
+}
+
+// mockLeasingHistoryRepo hands out the stored histories, so services change them in place.
+type mockLeasingHistoryRepo struct {
+	ports.LeasingHistoryRepository
+	histories map[uuid.UUID]*domain.LeasingHistory
+}
+
+func newMockLeasingHistoryRepo(histories ...*domain.LeasingHistory) *mockLeasingHistoryRepo {
+	repo := &mockLeasingHistoryRepo{histories: map[uuid.UUID]*domain.LeasingHistory{}}
+	for _, history := range histories {
+		repo.histories[history.ID] = history
+	}
+	return repo
+}
+
+func (m *mockLeasingHistoryRepo) GetByID(id uuid.UUID) (*domain.LeasingHistory, error) {
+	history, ok := m.histories[id]
+	if !ok {
+		return nil, errors.New("history not found")
+	}
+	return history, nil
+}
+
+func (m *mockLeasingHistoryRepo) Update(history *domain.LeasingHistory) error {
+	m.histories[history.ID] = history
+	return nil
+}
+
+// mockDormStore looks up dorms in memory, unlike mockDormRepo which only creates them.
+type mockDormStore struct {
+	ports.DormRepository
+	dorms map[uuid.UUID]*domain.Dorm
+}
+
+func newMockDormStore(dorms ...*domain.Dorm) *mockDormStore {
+	repo := &mockDormStore{dorms: map[uuid.UUID]*domain.Dorm{}}
+	for _, dorm := range dorms {
+		repo.dorms[dorm.ID] = dorm
+	}
+	return repo
+}
+
+func (m *mockDormStore) GetByID(id uuid.UUID) (*domain.Dorm, error) {
+	dorm, ok := m.dorms[id]
+	if !ok {
+		return nil, errors.New("dorm not found")
+	}
+	return dorm, nil
+}
+
+type emailChange struct {
+	token string
+	email string
+}
+
+// mockTokens keeps the sessions of jwt.JWTUtils in memory. Methods a test does not need panic through the embedded interface.
+type mockTokens struct {
+	userTokens
+	sessions map[uuid.UUID][]redis.Session
+	// resets maps a reset password token to its user.
+	resets map[string]uuid.UUID
+	// emailChanges holds the latest email change token of each user and the address it confirms.
+	emailChanges map[uuid.UUID]emailChange
+}
+
+func newMockTokens() *mockTokens {
+	return &mockTokens{sessions: map[uuid.UUID][]redis.Session{}, resets: map[string]uuid.UUID{}, emailChanges: map[uuid.UUID]emailChange{}}
+}
+
+func (m *mockTokens) GenerateKeyPair(ctx context.Context, userID uuid.UUID, userAgent string, ip string) (string, string, error) {
+	now := time.Now()
+	session := redis.Session{ID: uuid.NewString(), UserAgent: userAgent, IP: ip, CreatedAt: now, LastSeenAt: now}
+	m.sessions[userID] = append(m.sessions[userID], session)
+	return "access-" + session.ID, "refresh-" + session.ID, nil
+}
+
+func (m *mockTokens) GetSessions(ctx context.Context, userID uuid.UUID) ([]redis.Session, error) {
+	return m.sessions[userID], nil
+}
+
+// keep drops the sessions of the user that keep returns false for.
+func (m *mockTokens) keep(userID uuid.UUID, keep func(session redis.Session) bool) {
+	kept := []redis.Session{}
+	for _, session := range m.sessions[userID] {
+		if keep(session) {
+			kept = append(kept, session)
+		}
+	}
+	m.sessions[userID] = kept
+}
+
+func (m *mockTokens) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	m.keep(userID, func(session redis.Session) bool { return session.ID != sessionID })
+	return nil
+}
+
+func (m *mockTokens) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keepSessionID string) error {
+	m.keep(userID, func(session redis.Session) bool { return session.ID == keepSessionID })
+	return nil
+}
+
+func (m *mockTokens) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	delete(m.sessions, userID)
+	return nil
+}
+
+func (m *mockTokens) VerifyResetPasswordToken(ctx context.Context, resetToken string) (uuid.UUID, error) {
+	userID, ok := m.resets[resetToken]
+	if !ok {
+		return uuid.Nil, apperror.UnauthorizedError(redis.Nil, "token is expired or token is used")
+	}
+	return userID, nil
+}
+
+func (m *mockTokens) DeleteResetPasswordToken(ctx context.Context, userID uuid.UUID) error {
+	for token, tokenUserID := range m.resets {
+		if tokenUserID == userID {
+			delete(m.resets, token)
+		}
+	}
+	return nil
+}
+
+func (m *mockTokens) GenerateEmailChangeToken(ctx context.Context, userID uuid.UUID, newEmail string) (string, error) {
+	token := uuid.NewString()
+	m.emailChanges[userID] = emailChange{token: token, email: newEmail}
+	return token, nil
+}
+
+func (m *mockTokens) VerifyEmailChangeToken(ctx context.Context, emailChangeToken string) (uuid.UUID, string, error) {
+	for userID, change := range m.emailChanges {
+		if change.token == emailChangeToken {
+			return userID, change.email, nil
+		}
+	}
+	return uuid.Nil, "", apperror.UnauthorizedError(redis.Nil, "token is expired or token is used")
+}
+
+func (m *mockTokens) DeleteEmailChangeToken(ctx context.Context, userID uuid.UUID) error {
+	delete(m.emailChanges, userID)
+	return nil
+}
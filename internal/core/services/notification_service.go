@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type NotificationService struct {
+	notificationRepo ports.NotificationRepository
+	userRepo         ports.UserRepository
+	live             ports.LivePublisher
+	channels         []ports.NotificationChannel
+}
+
+func NewNotificationService(notificationRepo ports.NotificationRepository, userRepo ports.UserRepository, live ports.LivePublisher, channels ...ports.NotificationChannel) ports.NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		live:             live,
+		channels:         channels,
+	}
+}
+
+// Publish delivers the event in-app and on every channel the user turned on for its type.
+func (s *NotificationService) Publish(event domain.NotificationEvent) {
+	preference, err := s.preference(event.UserID, event.Type)
+	if err != nil {
+		log.Errorf("notification: cannot load preferences of user %s: %v", event.UserID, err)
+		return
+	}
+
+	if preference.InApp {
+		notification := &domain.Notification{
+			UserID: event.UserID,
+			Type:   event.Type,
+			Title:  event.Title,
+			Body:   event.Body,
+			Link:   event.Link,
+		}
+		if err := s.notificationRepo.Create(notification); err != nil {
+			log.Errorf("notification: cannot create %s for user %s: %v", event.Type, event.UserID, err)
+		} else {
+			s.live.Push(event.UserID, domain.LiveNotification, notification.ToDTO())
+		}
+	}
+
+	var user *domain.User
+	for _, channel := range s.channels {
+		if !channel.Enabled(preference) {
+			continue
+		}
+		if user == nil {
+			user, err = s.userRepo.GetUserByID(event.UserID)
+			if err != nil {
+				log.Errorf("notification: cannot load user %s: %v", event.UserID, err)
+				return
+			}
+		}
+		if err := channel.Send(user, event); err != nil {
+			log.Errorf("notification: cannot send %s to user %s by %s: %v", event.Type, event.UserID, channel.Name(), err)
+		}
+	}
+}
+
+// EmailNotificationChannel sends the translated email of the event type.
+type EmailNotificationChannel struct {
+	emailService email.Sender
+}
+
+func NewEmailNotificationChannel(emailService email.Sender) ports.NotificationChannel {
+	return &EmailNotificationChannel{emailService: emailService}
+}
+
+func (c *EmailNotificationChannel) Name() string {
+	return "email"
+}
+
+func (c *EmailNotificationChannel) Enabled(preference domain.NotificationPreference) bool {
+	return preference.Email
+}
+
+// notificationEmailTemplates are the translated emails of the event types.
+var notificationEmailTemplates = map[domain.NotificationType]string{
+	domain.NotificationLeasingRequestCreated:     email.TemplateLease,
+	domain.NotificationLeasingRequestApproved:    email.TemplateLease,
+	domain.NotificationLeasingRequestRejected:    email.TemplateLease,
+	domain.NotificationContractAwaitingSignature: email.TemplateContract,
+	domain.NotificationContractSigned:            email.TemplateContract,
+	domain.NotificationOrderCreated:              email.TemplatePayment,
+	domain.NotificationPaymentCompleted:          email.TemplatePayment,
+	domain.NotificationSupportUpdated:            email.TemplateSupport,
+	domain.NotificationPaymentDueSoon:            email.TemplateReminder,
+	domain.NotificationPaymentDue:                email.TemplateReminder,
+	domain.NotificationPaymentOverdue:            email.TemplateReminder,
+	domain.NotificationContractUnsigned:          email.TemplateReminder,
+	domain.NotificationLeasingRequestPending:     email.TemplateReminder,
+	domain.NotificationLeaseEnding:               email.TemplateReminder,
+}
+
+func (c *EmailNotificationChannel) Send(user *domain.User, event domain.NotificationEvent) error {
+	link := c.emailService.Link(event.Link)
+	template, ok := notificationEmailTemplates[event.Type]
+	if !ok {
+		return c.emailService.SendNotificationEmail(recipient(user), event.Title, []string{event.Body}, "Open ConDormHub", link)
+	}
+	return c.emailService.SendEventEmail(recipient(user), template, link, email.EventData{
+		Event:    string(event.Type),
+		DormName: event.DormName,
+		Amount:   event.Amount,
+		Status:   event.Status,
+		Days:     event.Days,
+	})
+}
+
+func (s *NotificationService) GetByUserID(userID uuid.UUID, unreadOnly bool, limit int, page int) ([]domain.Notification, int, int, error) {
+	return s.notificationRepo.GetByUserID(userID, unreadOnly, limit, page)
+}
+
+func (s *NotificationService) CountUnread(userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+func (s *NotificationService) MarkRead(userID uuid.UUID, id uuid.UUID) error {
+	return s.notificationRepo.MarkRead(userID, id)
+}
+
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) error {
+	return s.notificationRepo.MarkAllRead(userID)
+}
+
+// GetPreferences returns the preference of every notification type, defaults included.
+func (s *NotificationService) GetPreferences(userID uuid.UUID) ([]domain.NotificationPreference, error) {
+	saved, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[domain.NotificationType]domain.NotificationPreference, len(saved))
+	for _, p := range saved {
+		byType[p.Type] = p
+	}
+
+	preferences := make([]domain.NotificationPreference, len(domain.NotificationTypes))
+	for i, t := range domain.NotificationTypes {
+		if p, ok := byType[t]; ok {
+			preferences[i] = p
+		} else {
+			preferences[i] = domain.DefaultNotificationPreference(userID, t)
+		}
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences changes the given types only, the others keep their preference.
+func (s *NotificationService) UpdatePreferences(userID uuid.UUID, preferences []domain.NotificationPreference) ([]domain.NotificationPreference, error) {
+	for i, p := range preferences {
+		if !p.Type.IsValid() {
+			return nil, apperror.BadRequestError(errors.New("invalid notification type"), fmt.Sprintf("Unknown notification type %s", p.Type))
+		}
+		preferences[i].UserID = userID
+	}
+
+	if err := s.notificationRepo.SavePreferences(preferences); err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
+
+func (s *NotificationService) preference(userID uuid.UUID, t domain.NotificationType) (domain.NotificationPreference, error) {
+	preferences, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return domain.NotificationPreference{}, err
+	}
+
+	for _, p := range preferences {
+		if p.Type == t {
+			return p, nil
+		}
+	}
+
+	return domain.DefaultNotificationPreference(userID, t), nil
+}
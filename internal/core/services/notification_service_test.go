@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNotificationRepo struct {
+	ports.NotificationRepository
+	notifications []domain.Notification
+	preferences   []domain.NotificationPreference
+}
+
+func (m *mockNotificationRepo) Create(notification *domain.Notification) error {
+	notification.ID = uuid.New()
+	m.notifications = append(m.notifications, *notification)
+	return nil
+}
+
+func (m *mockNotificationRepo) GetPreferences(userID uuid.UUID) ([]domain.NotificationPreference, error) {
+	preferences := []domain.NotificationPreference{}
+	for _, p := range m.preferences {
+		if p.UserID == userID {
+			preferences = append(preferences, p)
+		}
+	}
+	return preferences, nil
+}
+
+// SavePreferences replaces the saved preference of the same user and type, like an upsert.
+func (m *mockNotificationRepo) SavePreferences(preferences []domain.NotificationPreference) error {
+	for _, p := range preferences {
+		saved := false
+		for i, existing := range m.preferences {
+			if existing.UserID == p.UserID && existing.Type == p.Type {
+				m.preferences[i] = p
+				saved = true
+			}
+		}
+		if !saved {
+			m.preferences = append(m.preferences, p)
+		}
+	}
+	return nil
+}
+
+type mockLivePublisher struct {
+	pushed []uuid.UUID
+}
+
+func (m *mockLivePublisher) Push(userID uuid.UUID, eventType domain.LiveEventType, data interface{}) {
+	m.pushed = append(m.pushed, userID)
+}
+
+// mockChannel is turned on by the email preference.
+type mockChannel struct {
+	sent []domain.NotificationEvent
+	err  error
+}
+
+func (m *mockChannel) Name() string {
+	return "mock"
+}
+
+func (m *mockChannel) Enabled(preference domain.NotificationPreference) bool {
+	return preference.Email
+}
+
+func (m *mockChannel) Send(user *domain.User, event domain.NotificationEvent) error {
+	m.sent = append(m.sent, event)
+	return m.err
+}
+
+type notificationFixture struct {
+	service  *NotificationService
+	repo     *mockNotificationRepo
+	live     *mockLivePublisher
+	channels []*mockChannel
+	user     *domain.User
+}
+
+func newNotificationFixture(channels ...*mockChannel) *notificationFixture {
+	user := &domain.User{ID: uuid.New(), Email: "somchai@example.com"}
+	repo := &mockNotificationRepo{}
+	live := &mockLivePublisher{}
+	service := &NotificationService{notificationRepo: repo, userRepo: &mockUserRepo{users: []*domain.User{user}}, live: live}
+	for _, channel := range channels {
+		service.channels = append(service.channels, channel)
+	}
+
+	return &notificationFixture{service: service, repo: repo, live: live, channels: channels, user: user}
+}
+
+func TestPublishNotification(t *testing.T) {
+	t.Run("default preferences", func(t *testing.T) {
+		f := newNotificationFixture(&mockChannel{})
+
+		f.service.Publish(domain.NotificationEvent{Type: domain.NotificationLeasingRequestCreated, UserID: f.user.ID, Title: "New leasing request"})
+		if assert.Len(t, f.repo.notifications, 1) {
+			assert.Equal(t, "New leasing request", f.repo.notifications[0].Title)
+			assert.Nil(t, f.repo.notifications[0].ReadAt)
+		}
+		assert.Equal(t, []uuid.UUID{f.user.ID}, f.live.pushed)
+		assert.Len(t, f.channels[0].sent, 1)
+
+		// a rejection needs no action, so it is not emailed by default
+		f.service.Publish(domain.NotificationEvent{Type: domain.NotificationLeasingRequestRejected, UserID: f.user.ID})
+		assert.Len(t, f.repo.notifications, 2)
+		assert.Len(t, f.channels[0].sent, 1)
+	})
+
+	t.Run("preferences turn delivery off per type", func(t *testing.T) {
+		f := newNotificationFixture(&mockChannel{})
+		_, err := f.service.UpdatePreferences(f.user.ID, []domain.NotificationPreference{
+			{Type: domain.NotificationOrderCreated, InApp: false, Email: true},
+		})
+		assert.NoError(t, err)
+
+		f.service.Publish(domain.NotificationEvent{Type: domain.NotificationOrderCreated, UserID: f.user.ID})
+		assert.Empty(t, f.repo.notifications)
+		assert.Empty(t, f.live.pushed)
+		assert.Len(t, f.channels[0].sent, 1)
+
+		f.service.Publish(domain.NotificationEvent{Type: domain.NotificationPaymentCompleted, UserID: f.user.ID})
+		assert.Len(t, f.repo.notifications, 1)
+	})
+
+	t.Run("a failing channel does not stop the others", func(t *testing.T) {
+		f := newNotificationFixture(&mockChannel{err: errors.New("smtp down")}, &mockChannel{})
+
+		f.service.Publish(domain.NotificationEvent{Type: domain.NotificationOrderCreated, UserID: f.user.ID})
+		assert.Len(t, f.repo.notifications, 1)
+		assert.Len(t, f.channels[0].sent, 1)
+		assert.Len(t, f.channels[1].sent, 1)
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	f := newNotificationFixture()
+
+	preferences, err := f.service.GetPreferences(f.user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, preferences, len(domain.NotificationTypes))
+	for _, p := range preferences {
+		assert.True(t, p.InApp)
+	}
+
+	_, err = f.service.UpdatePreferences(f.user.ID, []domain.NotificationPreference{{Type: "dorm.exploded"}})
+	assert.ErrorContains(t, err, "Unknown notification type dorm.exploded")
+	assert.Empty(t, f.repo.preferences)
+
+	preferences, err = f.service.UpdatePreferences(f.user.ID, []domain.NotificationPreference{{Type: domain.NotificationLeasingRequestCreated, InApp: true}})
+	assert.NoError(t, err)
+	for _, p := range preferences {
+		assert.Equal(t, f.user.ID, p.UserID)
+		if p.Type == domain.NotificationLeasingRequestCreated {
+			assert.False(t, p.Email)
+		} else {
+			assert.Equal(t, domain.DefaultNotificationPreference(f.user.ID, p.Type), p)
+		}
+	}
+}
+
+func TestEmailNotificationChannel(t *testing.T) {
+	emails := &mockEmailSender{}
+	channel := NewEmailNotificationChannel(emails)
+	user := &domain.User{ID: uuid.New(), Email: "somchai@example.com"}
+
+	assert.NoError(t, channel.Send(user, domain.NotificationEvent{Type: domain.NotificationContractSigned, Link: "/contracts/1"}))
+	assert.NoError(t, channel.Send(user, domain.NotificationEvent{Type: domain.NotificationAnnouncementPublished, Title: "Water outage", Body: "No water on Monday", Link: "/announcements/1"}))
+
+	if assert.Len(t, emails.sent, 2) {
+		assert.Equal(t, email.TemplateContract, emails.sent[0].template)
+		assert.Equal(t, "https://condormhub.example/contracts/1", emails.sent[0].link)
+		// types without a translated template are sent as free text
+		assert.Empty(t, emails.sent[1].template)
+		assert.Equal(t, []string{"No water on Monday"}, emails.sent[1].lines)
+	}
+}
+
+type mockLeasingRequestRepo struct {
+	ports.LeasingRequestRepository
+	requests map[uuid.UUID]*domain.LeasingRequest
+}
+
+func (m *mockLeasingRequestRepo) GetByID(id uuid.UUID) (*domain.LeasingRequest, error) {
+	request, ok := m.requests[id]
+	if !ok {
+		return nil, errors.New("request not found")
+	}
+	return request, nil
+}
+
+func (m *mockLeasingRequestRepo) Update(request *domain.LeasingRequest) error {
+	return nil
+}
+
+type mockContractRepo struct {
+	ports.ContractRepository
+	contracts []*domain.Contract
+}
+
+func (m *mockContractRepo) Create(contract *domain.Contract) error {
+	contract.ID = uuid.New()
+	m.contracts = append(m.contracts, contract)
+	return nil
+}
+
+func TestLeasingRequestNotifications(t *testing.T) {
+	newRequestFixture := func() (*LeasingRequestService, *mockNotifier, *domain.LeasingRequest, *domain.User) {
+		f := newDormManagerFixture()
+		request := &domain.LeasingRequest{ID: uuid.New(), Status: domain.RequestPending, DormID: f.dorm.ID, Dorm: *f.dorm, LesseeID: uuid.New()}
+		notifier := &mockNotifier{}
+		service := &LeasingRequestService{
+			requestRepo:    &mockLeasingRequestRepo{requests: map[uuid.UUID]*domain.LeasingRequest{request.ID: request}},
+			contractRepo:   &mockContractRepo{},
+			managerService: f.service,
+			notifier:       notifier,
+		}
+		return service, notifier, request, f.owner
+	}
+
+	t.Run("approval asks the lessee to sign", func(t *testing.T) {
+		service, notifier, request, owner := newRequestFixture()
+
+		assert.NoError(t, service.Approve(request.ID, owner))
+		if assert.Len(t, notifier.events, 2) {
+			assert.Equal(t, domain.NotificationLeasingRequestApproved, notifier.events[0].Type)
+			assert.Equal(t, domain.NotificationContractAwaitingSignature, notifier.events[1].Type)
+			for _, event := range notifier.events {
+				assert.Equal(t, request.LesseeID, event.UserID)
+				assert.Equal(t, "Baan Suan", event.DormName)
+			}
+		}
+	})
+
+	t.Run("rejection", func(t *testing.T) {
+		service, notifier, request, owner := newRequestFixture()
+
+		assert.NoError(t, service.Reject(request.ID, owner))
+		if assert.Len(t, notifier.events, 1) {
+			assert.Equal(t, domain.NotificationLeasingRequestRejected, notifier.events[0].Type)
+			assert.Equal(t, request.LesseeID, notifier.events[0].UserID)
+		}
+	})
+
+	t.Run("nothing is sent when the transition is refused", func(t *testing.T) {
+		service, notifier, request, _ := newRequestFixture()
+
+		assert.Error(t, service.Approve(request.ID, &domain.User{ID: uuid.New(), Role: domain.LessorRole}))
+		assert.Empty(t, notifier.events)
+	})
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
+	"github.com/PitiNarak/condormhub-backend/pkg/oidc"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// oidcStateExpiration is how long the user has to come back from the provider.
+const oidcStateExpiration = 10 * time.Minute
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9._]`)
+
+// oidcState is kept in redis between the redirect to the provider and the callback.
+type oidcState struct {
+	Provider string    `json:"provider"`
+	Verifier string    `json:"verifier"`
+	Nonce    string    `json:"nonce"`
+	UserID   uuid.UUID `json:"userId"`
+}
+
+// oidcStateStore is the part of redis the flow needs, so tests can keep states in memory.
+type oidcStateStore interface {
+	SetOIDCState(ctx context.Context, state string, value string, ttl time.Duration) error
+	TakeOIDCState(ctx context.Context, state string) (string, error)
+}
+
+type OIDCService struct {
+	oidcRepo  ports.OIDCRepository
+	userRepo  ports.UserRepository
+	jwtUtils  *jwt.JWTUtils
+	states    oidcStateStore
+	providers map[string]*oidc.Provider
+}
+
+func NewOIDCService(oidcRepo ports.OIDCRepository, userRepo ports.UserRepository, jwtUtils *jwt.JWTUtils, redis *redis.Redis, providers map[string]*oidc.Provider) ports.OIDCService {
+	return &OIDCService{
+		oidcRepo:  oidcRepo,
+		userRepo:  userRepo,
+		jwtUtils:  jwtUtils,
+		states:    redis,
+		providers: providers,
+	}
+}
+
+// GetAuthorizationURL starts the authorization code flow. linkUserID is uuid.Nil
+// to log in, or the user the provider account will be linked to.
+func (s *OIDCService) GetAuthorizationURL(ctx context.Context, provider string, linkUserID uuid.UUID) (string, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", apperror.InternalServerError(err, "Failed to start login")
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", apperror.InternalServerError(err, "Failed to start login")
+	}
+	verifier, err := oidc.RandomString(32)
+	if err != nil {
+		return "", apperror.InternalServerError(err, "Failed to start login")
+	}
+
+	value, err := json.Marshal(oidcState{Provider: provider, Verifier: verifier, Nonce: nonce, UserID: linkUserID})
+	if err != nil {
+		return "", apperror.InternalServerError(err, "Failed to start login")
+	}
+	if err := s.states.SetOIDCState(ctx, state, string(value), oidcStateExpiration); err != nil {
+		return "", apperror.InternalServerError(err, "Failed to start login")
+	}
+
+	url, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", apperror.InternalServerError(err, "Identity provider is unavailable")
+	}
+
+	return url, nil
+}
+
+// Login finishes the flow. A known provider account logs in its user; otherwise a
+// verified email is linked to the user with that email, or a new user is created.
+func (s *OIDCService) Login(ctx context.Context, provider string, code string, state string, client dto.ClientInfo) (*domain.User, string, string, string, error) {
+	claims, err := s.exchange(ctx, provider, code, state, uuid.Nil)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	user, err := s.findOrCreateUser(provider, claims)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	if user.Banned {
+		return nil, "", "", "", apperror.ForbiddenError(errors.New("account banned"), "account banned")
+	}
+
+	if user.TwoFactorEnabled || user.Role.IsStaff() {
+		twoFactorToken, err := s.jwtUtils.GenerateTwoFactorToken(ctx, user.ID)
+		if err != nil {
+			return nil, "", "", "", err
+		}
+		return user, "", "", twoFactorToken, nil
+	}
+
+	accessToken, refreshToken, err := s.jwtUtils.GenerateKeyPair(ctx, user.ID, client.UserAgent, client.IP)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	return user, accessToken, refreshToken, "", nil
+}
+
+func (s *OIDCService) Link(ctx context.Context, userID uuid.UUID, provider string, code string, state string) (*domain.UserIdentity, error) {
+	claims, err := s.exchange(ctx, provider, code, state, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &domain.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	if err := s.oidcRepo.Create(identity); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (s *OIDCService) Unlink(user *domain.User, provider string) error {
+	identities, err := s.oidcRepo.GetByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	// users created through a provider have no password to fall back to
+	if user.Password == "" && len(identities) <= 1 {
+		return apperror.ConflictError(errors.New("last login method"), "Set a password before unlinking your only login method")
+	}
+
+	return s.oidcRepo.Delete(user.ID, provider)
+}
+
+func (s *OIDCService) GetIdentities(userID uuid.UUID) ([]domain.UserIdentity, error) {
+	return s.oidcRepo.GetByUserID(userID)
+}
+
+func (s *OIDCService) provider(name string) (*oidc.Provider, error) {
+	p, ok := s.providers[name]
+	if !ok || !p.Enabled() {
+		return nil, apperror.NotFoundError(errors.New("unknown provider"), "Login provider not supported")
+	}
+	return p, nil
+}
+
+// exchange checks the state belongs to this flow and returns the verified ID token claims.
+func (s *OIDCService) exchange(ctx context.Context, provider string, code string, state string, linkUserID uuid.UUID) (*oidc.Claims, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := s.states.TakeOIDCState(ctx, state)
+	if errors.Is(err, redis.Nil) {
+		return nil, apperror.UnauthorizedError(err, "Login expired, please try again")
+	} else if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to finish login")
+	}
+
+	var st oidcState
+	if err := json.Unmarshal([]byte(value), &st); err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to finish login")
+	}
+
+	if st.Provider != provider || st.UserID != linkUserID {
+		return nil, apperror.UnauthorizedError(errors.New("state mismatch"), "Login expired, please try again")
+	}
+
+	claims, err := p.Exchange(ctx, code, st.Verifier, st.Nonce)
+	if err != nil {
+		return nil, apperror.UnauthorizedError(err, "Login with provider failed")
+	}
+
+	return claims, nil
+}
+
+func (s *OIDCService) findOrCreateUser(provider string, claims *oidc.Claims) (*domain.User, error) {
+	identity, err := s.oidcRepo.GetByProviderSubject(provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		return s.userRepo.GetUserByID(identity.UserID)
+	}
+
+	if !claims.EmailVerified || claims.Email == "" {
+		return nil, apperror.ForbiddenError(errors.New("email not verified"), "Your email is not verified by the provider")
+	}
+
+	user, err := s.userRepo.FindUserByEmail(claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		// anyone can sign up with an email they do not own, so only link an
+		// account that verified the email itself
+		if !user.IsVerified {
+			return nil, apperror.ConflictError(errors.New("account not verified"), "An account with this email already exists, log in with your password to link this provider")
+		}
+	} else {
+		username, err := usernameFromEmail(claims.Email)
+		if err != nil {
+			return nil, apperror.InternalServerError(err, "Failed to create user")
+		}
+
+		user = &domain.User{
+			Email:      claims.Email,
+			Username:   username,
+			IsVerified: true,
+		}
+		if err := s.userRepo.Create(user); err != nil {
+			return nil, err
+		}
+	}
+
+	identity = &domain.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	if err := s.oidcRepo.Create(identity); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// usernameFromEmail derives a username like "somchai.k-3fa2" from an email.
+func usernameFromEmail(email string) (string, error) {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	local = usernameInvalidChars.ReplaceAllString(local, "")
+	if local == "" {
+		local = "user"
+	}
+
+	suffix := make([]byte, 2)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return local + "-" + hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/oidc"
+	"github.com/PitiNarak/condormhub-backend/pkg/oidc/oidctest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOIDCRepo struct {
+	identities []domain.UserIdentity
+}
+
+func (m *mockOIDCRepo) Create(identity *domain.UserIdentity) error {
+	m.identities = append(m.identities, *identity)
+	return nil
+}
+
+func (m *mockOIDCRepo) GetByProviderSubject(provider string, subject string) (*domain.UserIdentity, error) {
+	for _, identity := range m.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockOIDCRepo) GetByUserID(userID uuid.UUID) ([]domain.UserIdentity, error) {
+	panic("unimplemented")
+}
+
+func (m *mockOIDCRepo) Delete(userID uuid.UUID, provider string) error {
+	panic("unimplemented")
+}
+
+// startOIDCProvider runs the fake provider and returns it as the "google" provider.
+func startOIDCProvider(t *testing.T, user oidctest.User) (*oidctest.Server, map[string]*oidc.Provider) {
+	idp := oidctest.NewServer("condormhub", user)
+	t.Cleanup(idp.Close)
+
+	return idp, map[string]*oidc.Provider{
+		"google": oidc.New(oidc.Config{Issuer: idp.URL, ClientID: idp.ClientID, RedirectURL: "http://localhost/auth/google/callback"}),
+	}
+}
+
+// authorize starts a flow and follows the fake provider back to the callback.
+func authorize(t *testing.T, service *OIDCService, linkUserID uuid.UUID) (string, string) {
+	authURL, err := service.GetAuthorizationURL(context.Background(), "google", linkUserID)
+	assert.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	assert.NoError(t, err)
+
+	return callback.Query().Get("code"), callback.Query().Get("state")
+}
+
+func TestOIDCState(t *testing.T) {
+	ctx := context.Background()
+	_, providers := startOIDCProvider(t, oidctest.User{Subject: "google-1", Email: "somchai@example.com", EmailVerified: true})
+
+	t.Run("PKCE challenge matches the stored verifier", func(t *testing.T) {
+		cache := newMockRedis()
+		service := &OIDCService{states: cache, providers: providers}
+
+		authURL, err := service.GetAuthorizationURL(ctx, "google", uuid.Nil)
+		assert.NoError(t, err)
+		query, err := url.Parse(authURL)
+		assert.NoError(t, err)
+
+		var st oidcState
+		assert.NoError(t, json.Unmarshal([]byte(cache.values["oidc_state:"+query.Query().Get("state")]), &st))
+		assert.Equal(t, "S256", query.Query().Get("code_challenge_method"))
+		assert.Equal(t, oidc.CodeChallenge(st.Verifier), query.Query().Get("code_challenge"))
+		assert.Equal(t, st.Nonce, query.Query().Get("nonce"))
+	})
+
+	t.Run("state is used once", func(t *testing.T) {
+		service := &OIDCService{states: newMockRedis(), providers: providers}
+		code, state := authorize(t, service, uuid.Nil)
+
+		claims, err := service.exchange(ctx, "google", code, state, uuid.Nil)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "google-1", claims.Subject)
+		}
+
+		_, err = service.exchange(ctx, "google", code, state, uuid.Nil)
+		assert.ErrorContains(t, err, "Login expired, please try again")
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		service := &OIDCService{states: newMockRedis(), providers: providers}
+		code, _ := authorize(t, service, uuid.Nil)
+
+		_, err := service.exchange(ctx, "google", code, "forged", uuid.Nil)
+		assert.ErrorContains(t, err, "Login expired, please try again")
+	})
+
+	t.Run("link state cannot log in", func(t *testing.T) {
+		service := &OIDCService{states: newMockRedis(), providers: providers}
+		code, state := authorize(t, service, uuid.New())
+
+		_, err := service.exchange(ctx, "google", code, state, uuid.Nil)
+		assert.ErrorContains(t, err, "Login expired, please try again")
+	})
+
+	t.Run("wrong verifier is refused by the provider", func(t *testing.T) {
+		cache := newMockRedis()
+		service := &OIDCService{states: cache, providers: providers}
+		code, state := authorize(t, service, uuid.Nil)
+
+		var st oidcState
+		assert.NoError(t, json.Unmarshal([]byte(cache.values["oidc_state:"+state]), &st))
+		st.Verifier = "intercepted"
+		value, _ := json.Marshal(st)
+		cache.values["oidc_state:"+state] = string(value)
+
+		_, err := service.exchange(ctx, "google", code, state, uuid.Nil)
+		assert.ErrorContains(t, err, "Login with provider failed")
+	})
+}
+
+func TestOIDCAccountLinking(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("verified email links the existing user", func(t *testing.T) {
+		_, providers := startOIDCProvider(t, oidctest.User{Subject: "google-1", Email: "somchai@example.com", EmailVerified: true})
+		existing := &domain.User{ID: uuid.New(), Email: "somchai@example.com", IsVerified: true}
+		users := &mockUserRepo{users: []*domain.User{existing}}
+		identities := &mockOIDCRepo{}
+		service := &OIDCService{oidcRepo: identities, userRepo: users, states: newMockRedis(), providers: providers}
+
+		code, state := authorize(t, service, uuid.Nil)
+		claims, err := service.exchange(ctx, "google", code, state, uuid.Nil)
+		assert.NoError(t, err)
+
+		user, err := service.findOrCreateUser("google", claims)
+		if assert.NoError(t, err) {
+			assert.Equal(t, existing.ID, user.ID)
+		}
+		assert.Len(t, users.users, 1)
+		if assert.Len(t, identities.identities, 1) {
+			assert.Equal(t, existing.ID, identities.identities[0].UserID)
+			assert.Equal(t, "google-1", identities.identities[0].Subject)
+		}
+	})
+
+	t.Run("unverified account is not linked", func(t *testing.T) {
+		// someone signed up with the email before its owner used the provider
+		_, providers := startOIDCProvider(t, oidctest.User{Subject: "google-1", Email: "somchai@example.com", EmailVerified: true})
+		users := &mockUserRepo{users: []*domain.User{{ID: uuid.New(), Email: "somchai@example.com"}}}
+		identities := &mockOIDCRepo{}
+		service := &OIDCService{oidcRepo: identities, userRepo: users, states: newMockRedis(), providers: providers}
+
+		code, state := authorize(t, service, uuid.Nil)
+		_, _, _, _, err := service.Login(ctx, "google", code, state, dto.ClientInfo{})
+		assert.ErrorContains(t, err, "An account with this email already exists")
+		assert.Empty(t, identities.identities)
+		assert.False(t, users.users[0].IsVerified)
+	})
+
+	t.Run("failed lookup does not create a user", func(t *testing.T) {
+		_, providers := startOIDCProvider(t, oidctest.User{Subject: "google-1", Email: "somchai@example.com", EmailVerified: true})
+		users := &mockUserRepo{err: errors.New("connection refused")}
+		service := &OIDCService{oidcRepo: &mockOIDCRepo{}, userRepo: users, states: newMockRedis(), providers: providers}
+
+		code, state := authorize(t, service, uuid.Nil)
+		_, _, _, _, err := service.Login(ctx, "google", code, state, dto.ClientInfo{})
+		assert.ErrorContains(t, err, "connection refused")
+		assert.Empty(t, users.users)
+	})
+
+	t.Run("email not verified by the provider", func(t *testing.T) {
+		_, providers := startOIDCProvider(t, oidctest.User{Subject: "google-1", Email: "somchai@example.com"})
+		users := &mockUserRepo{users: []*domain.User{{ID: uuid.New(), Email: "somchai@example.com", IsVerified: true}}}
+		identities := &mockOIDCRepo{}
+		service := &OIDCService{oidcRepo: identities, userRepo: users, states: newMockRedis(), providers: providers}
+
+		code, state := authorize(t, service, uuid.Nil)
+		_, _, _, _, err := service.Login(ctx, "google", code, state, dto.ClientInfo{})
+		assert.ErrorContains(t, err, "Your email is not verified by the provider")
+		assert.Empty(t, identities.identities)
+	})
+
+	t.Run("new email creates a user", func(t *testing.T) {
+		_, providers := startOIDCProvider(t, oidctest.User{Subject: "google-1", Email: "Somchai.K@example.com", EmailVerified: true})
+		users := &mockUserRepo{}
+		service := &OIDCService{oidcRepo: &mockOIDCRepo{}, userRepo: users, states: newMockRedis(), providers: providers}
+
+		code, state := authorize(t, service, uuid.Nil)
+		claims, err := service.exchange(ctx, "google", code, state, uuid.Nil)
+		assert.NoError(t, err)
+		user, err := service.findOrCreateUser("google", claims)
+		if assert.NoError(t, err) {
+			assert.True(t, user.IsVerified)
+			assert.Regexp(t, `^somchai\.k-[0-9a-f]{4}$`, user.Username)
+		}
+	})
+
+	t.Run("linked subject logs in its user", func(t *testing.T) {
+		idp, providers := startOIDCProvider(t, oidctest.User{Subject: "google-1", Email: "somchai@example.com", EmailVerified: true})
+		users := &mockUserRepo{}
+		service := &OIDCService{oidcRepo: &mockOIDCRepo{}, userRepo: users, states: newMockRedis(), providers: providers}
+
+		code, state := authorize(t, service, uuid.Nil)
+		claims, err := service.exchange(ctx, "google", code, state, uuid.Nil)
+		assert.NoError(t, err)
+		created, err := service.findOrCreateUser("google", claims)
+		assert.NoError(t, err)
+
+		// the provider account changed its email, the identity still finds the user
+		idp.SetUser(oidctest.User{Subject: "google-1", Email: "somchai@other.example.com", EmailVerified: true})
+		code, state = authorize(t, service, uuid.Nil)
+		claims, err = service.exchange(ctx, "google", code, state, uuid.Nil)
+		assert.NoError(t, err)
+		user, err := service.findOrCreateUser("google", claims)
+		if assert.NoError(t, err) {
+			assert.Equal(t, created.ID, user.ID)
+		}
+		assert.Len(t, users.users, 1)
+	})
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
@@ -9,21 +11,34 @@ import (
 	"github.com/yokeTH/go-pkg/apperror"
 )
 
+// orderPaymentTerm is how long the lessee has to pay a bill.
+const orderPaymentTerm = 7 * 24 * time.Hour
+
 type OrderService struct {
 	orderRepository          ports.OrderRepository
 	leasingHistoryRepository ports.LeasingHistoryRepository
+	managerService           ports.DormManagerService
+	notifier                 ports.NotificationPublisher
 }
 
-func NewOrderService(orderRepository ports.OrderRepository, leasingHistoryRepository ports.LeasingHistoryRepository) ports.OrderService {
-	return &OrderService{orderRepository: orderRepository, leasingHistoryRepository: leasingHistoryRepository}
+func NewOrderService(orderRepository ports.OrderRepository, leasingHistoryRepository ports.LeasingHistoryRepository, managerService ports.DormManagerService, notifier ports.NotificationPublisher) ports.OrderService {
+	return &OrderService{orderRepository: orderRepository, leasingHistoryRepository: leasingHistoryRepository, managerService: managerService, notifier: notifier}
 }
 
-func (s *OrderService) CreateOrder(leasingHistoryID uuid.UUID) (*domain.Order, error) {
+func (s *OrderService) CreateOrder(user *domain.User, leasingHistoryID uuid.UUID) (*domain.Order, error) {
 	leasingHistory, err := s.leasingHistoryRepository.GetByID(leasingHistoryID)
 	if err != nil {
 		return nil, apperror.NotFoundError(err, err.Error())
 	}
 
+	manager, err := s.managerService.Authorize(user, &leasingHistory.Dorm, domain.ScopeMeterReadings, domain.PermissionLeasingManage)
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return nil, err
+		}
+		return nil, apperror.ForbiddenError(err, "You do not have permission to bill this lease")
+	}
+
 	if !leasingHistory.End.IsZero() {
 		return nil, apperror.BadRequestError(errors.New("leasing history has ended"), "leasing history has ended")
 	}
@@ -32,12 +47,27 @@ func (s *OrderService) CreateOrder(leasingHistoryID uuid.UUID) (*domain.Order, e
 		LeasingHistoryID: leasingHistoryID,
 		Price:            int64(leasingHistory.Dorm.Price),
 		Type:             domain.MonthlyBillOrderType,
+		DueAt:            time.Now().Add(orderPaymentTerm),
 	}
 
 	if err := s.orderRepository.Create(order); err != nil {
 		return nil, err
 	}
 
+	s.notifier.Publish(domain.NotificationEvent{
+		Type:     domain.NotificationOrderCreated,
+		UserID:   leasingHistory.LesseeID,
+		Title:    "New bill",
+		Body:     fmt.Sprintf("Your monthly bill of %d THB for %s is ready to pay.", order.Price, leasingHistory.Dorm.Name),
+		Link:     "/orders/" + order.ID.String(),
+		DormName: leasingHistory.Dorm.Name,
+		Amount:   float64(order.Price),
+	})
+
+	if err := s.managerService.RecordAction(manager, domain.ScopeMeterReadings, "order.create", order.ID); err != nil {
+		return nil, err
+	}
+
 	return order, nil
 }
 
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOrderRepo struct {
+	ports.OrderRepository
+	orders []*domain.Order
+}
+
+func (m *mockOrderRepo) Create(order *domain.Order) error {
+	order.ID = uuid.New()
+	m.orders = append(m.orders, order)
+	return nil
+}
+
+func TestCreateOrderScope(t *testing.T) {
+	f := newDormManagerFixture()
+	lease := &domain.LeasingHistory{ID: uuid.New(), LesseeID: uuid.New(), DormID: f.dorm.ID, Dorm: *f.dorm}
+	orders := &mockOrderRepo{}
+	service := NewOrderService(orders, newMockLeasingHistoryRepo(lease), f.service, &mockNotifier{})
+
+	invitation, _ := f.service.Invite(f.owner, f.dorm.ID, f.caretaker.Email, domain.ManagerScopes{Contracts: true})
+	_, _ = f.service.Accept(f.caretaker.ID, invitation.ID)
+
+	_, err := service.CreateOrder(f.caretaker, lease.ID)
+	assert.ErrorContains(t, err, "You do not have permission to bill this lease")
+	assert.Empty(t, orders.orders)
+
+	_, err = f.service.UpdateScopes(f.owner, f.dorm.ID, invitation.ID, domain.ManagerScopes{MeterReadings: true})
+	assert.NoError(t, err)
+
+	order, err := service.CreateOrder(f.caretaker, lease.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, domain.MonthlyBillOrderType, order.Type)
+	}
+	if assert.Len(t, f.managers.actions, 1) {
+		assert.Equal(t, "order.create", f.managers.actions[0].Action)
+		assert.Equal(t, order.ID, f.managers.actions[0].ResourceID)
+	}
+
+	_, err = service.CreateOrder(f.owner, lease.ID)
+	assert.NoError(t, err)
+	assert.Len(t, orders.orders, 2)
+	assert.Len(t, f.managers.actions, 1)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/PitiNarak/condormhub-backend/pkg/sms"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+const (
+	// PhoneOTPExpiration is how long a code sent by SMS can be used.
+	PhoneOTPExpiration = 5 * time.Minute
+
+	// MaxPhoneOTPAttempts is how many wrong codes may be sent before a new code has to be requested.
+	MaxPhoneOTPAttempts = 5
+)
+
+// phoneOTP is kept in redis until the code is confirmed. The phone number is
+// kept with it so a code cannot verify a number the user changed to afterwards.
+type phoneOTP struct {
+	PhoneNumber string `json:"phoneNumber"`
+	CodeHash    string `json:"codeHash"`
+}
+
+// phoneOTPStore is the part of redis that keeps pending codes, so tests can keep them in memory.
+type phoneOTPStore interface {
+	SetPhoneOTP(ctx context.Context, userID uuid.UUID, value string, ttl time.Duration) error
+	GetPhoneOTP(ctx context.Context, userID uuid.UUID) (string, error)
+	DeletePhoneOTP(ctx context.Context, userID uuid.UUID) error
+	IncrPhoneOTPAttempts(ctx context.Context, userID uuid.UUID, ttl time.Duration) (int64, error)
+}
+
+type PhoneVerificationService struct {
+	userRepo ports.UserRepository
+	redis    phoneOTPStore
+	sms      sms.Sender
+}
+
+func NewPhoneVerificationService(userRepo ports.UserRepository, redis *redis.Redis, sms sms.Sender) ports.PhoneVerificationService {
+	return &PhoneVerificationService{
+		userRepo: userRepo,
+		redis:    redis,
+		sms:      sms,
+	}
+}
+
+// SendCode texts a one-time code to the phone number on the user's profile.
+// Requesting another code replaces the previous one.
+func (s *PhoneVerificationService) SendCode(ctx context.Context, user *domain.User) error {
+	if user.PhoneNumber == "" {
+		return apperror.BadRequestError(errors.New("no phone number"), "Add a phone number to your profile first")
+	}
+
+	if user.PhoneVerified {
+		return apperror.ConflictError(errors.New("phone already verified"), "Your phone number is already verified")
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to generate verification code")
+	}
+
+	value, err := json.Marshal(phoneOTP{PhoneNumber: user.PhoneNumber, CodeHash: hashOTP(code)})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to generate verification code")
+	}
+	if err := s.redis.SetPhoneOTP(ctx, user.ID, string(value), PhoneOTPExpiration); err != nil {
+		return apperror.InternalServerError(err, "Failed to generate verification code")
+	}
+
+	message := fmt.Sprintf("Your ConDormHub verification code is %s. It expires in %d minutes.", code, int(PhoneOTPExpiration.Minutes()))
+	if err := s.sms.Send(ctx, user.PhoneNumber, message); err != nil {
+		return apperror.InternalServerError(err, "Failed to send verification code")
+	}
+
+	return nil
+}
+
+func (s *PhoneVerificationService) Verify(ctx context.Context, user *domain.User, code string) (*domain.User, error) {
+	value, err := s.redis.GetPhoneOTP(ctx, user.ID)
+	if errors.Is(err, redis.Nil) {
+		return nil, apperror.BadRequestError(err, "Verification code expired, please request a new one")
+	} else if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to verify phone number")
+	}
+
+	var otp phoneOTP
+	if err := json.Unmarshal([]byte(value), &otp); err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to verify phone number")
+	}
+
+	if otp.PhoneNumber != user.PhoneNumber {
+		return nil, apperror.BadRequestError(errors.New("phone number changed"), "Verification code expired, please request a new one")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(hashOTP(code))) != 1 {
+		attempts, err := s.redis.IncrPhoneOTPAttempts(ctx, user.ID, PhoneOTPExpiration)
+		if err != nil {
+			return nil, apperror.InternalServerError(err, "Failed to verify phone number")
+		}
+		if attempts >= MaxPhoneOTPAttempts {
+			if err := s.redis.DeletePhoneOTP(ctx, user.ID); err != nil {
+				return nil, apperror.InternalServerError(err, "Failed to verify phone number")
+			}
+			return nil, apperror.BadRequestError(errors.New("too many attempts"), "Too many wrong codes, please request a new one")
+		}
+		return nil, apperror.BadRequestError(errors.New("invalid code"), "Invalid verification code")
+	}
+
+	user.PhoneVerified = true
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.redis.DeletePhoneOTP(ctx, user.ID); err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to verify phone number")
+	}
+
+	return user, nil
+}
+
+// generateOTP returns a random 6 digit code.
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashOTP(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSMS struct {
+	messages []string
+}
+
+func (m *mockSMS) Send(ctx context.Context, to string, message string) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+var otpPattern = regexp.MustCompile(`\d{6}`)
+
+// code returns the code of the last message sent.
+func (m *mockSMS) code() string {
+	return otpPattern.FindString(m.messages[len(m.messages)-1])
+}
+
+type phoneVerificationFixture struct {
+	service *PhoneVerificationService
+	cache   *mockRedis
+	sms     *mockSMS
+	user    *domain.User
+}
+
+func newPhoneVerificationFixture() *phoneVerificationFixture {
+	user := &domain.User{ID: uuid.New(), PhoneNumber: "081-234-5678"}
+	cache := newMockRedis()
+	sms := &mockSMS{}
+
+	return &phoneVerificationFixture{
+		service: &PhoneVerificationService{userRepo: &mockUserRepo{users: []*domain.User{user}}, redis: cache, sms: sms},
+		cache:   cache,
+		sms:     sms,
+		user:    user,
+	}
+}
+
+// wrongCode returns a code that is not the one sent.
+func (f *phoneVerificationFixture) wrongCode() string {
+	if f.sms.code() == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestPhoneVerification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the texted code verifies the phone once", func(t *testing.T) {
+		f := newPhoneVerificationFixture()
+		assert.NoError(t, f.service.SendCode(ctx, f.user))
+
+		_, err := f.service.Verify(ctx, f.user, f.wrongCode())
+		assert.ErrorContains(t, err, "Invalid verification code")
+		assert.False(t, f.user.PhoneVerified)
+
+		user, err := f.service.Verify(ctx, f.user, f.sms.code())
+		if assert.NoError(t, err) {
+			assert.True(t, user.PhoneVerified)
+		}
+		assert.Empty(t, f.cache.values)
+
+		assert.ErrorContains(t, f.service.SendCode(ctx, f.user), "Your phone number is already verified")
+	})
+
+	t.Run("too many wrong codes discard the code", func(t *testing.T) {
+		f := newPhoneVerificationFixture()
+		_ = f.service.SendCode(ctx, f.user)
+
+		for i := 1; i < MaxPhoneOTPAttempts; i++ {
+			_, err := f.service.Verify(ctx, f.user, f.wrongCode())
+			assert.ErrorContains(t, err, "Invalid verification code")
+		}
+		_, err := f.service.Verify(ctx, f.user, f.wrongCode())
+		assert.ErrorContains(t, err, "Too many wrong codes, please request a new one")
+
+		_, err = f.service.Verify(ctx, f.user, f.sms.code())
+		assert.ErrorContains(t, err, "Verification code expired, please request a new one")
+		assert.False(t, f.user.PhoneVerified)
+	})
+
+	t.Run("a new code comes with fresh attempts", func(t *testing.T) {
+		f := newPhoneVerificationFixture()
+		_ = f.service.SendCode(ctx, f.user)
+		for i := 1; i < MaxPhoneOTPAttempts; i++ {
+			_, _ = f.service.Verify(ctx, f.user, f.wrongCode())
+		}
+		first := f.sms.code()
+
+		_ = f.service.SendCode(ctx, f.user)
+		_, err := f.service.Verify(ctx, f.user, f.wrongCode())
+		assert.ErrorContains(t, err, "Invalid verification code")
+
+		// the new code replaced the first one
+		if first != f.sms.code() {
+			_, err = f.service.Verify(ctx, f.user, first)
+			assert.ErrorContains(t, err, "Invalid verification code")
+		}
+		_, err = f.service.Verify(ctx, f.user, f.sms.code())
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		f := newPhoneVerificationFixture()
+		_ = f.service.SendCode(ctx, f.user)
+		delete(f.cache.values, "phone_otp:"+f.user.ID.String())
+
+		_, err := f.service.Verify(ctx, f.user, f.sms.code())
+		assert.ErrorContains(t, err, "Verification code expired, please request a new one")
+		assert.False(t, f.user.PhoneVerified)
+	})
+
+	t.Run("code of a number the user changed away from", func(t *testing.T) {
+		f := newPhoneVerificationFixture()
+		_ = f.service.SendCode(ctx, f.user)
+		f.user.PhoneNumber = "089-999-9999"
+
+		_, err := f.service.Verify(ctx, f.user, f.sms.code())
+		assert.ErrorContains(t, err, "Verification code expired, please request a new one")
+		assert.False(t, f.user.PhoneVerified)
+	})
+
+	t.Run("no phone number", func(t *testing.T) {
+		f := newPhoneVerificationFixture()
+		f.user.PhoneNumber = ""
+
+		assert.ErrorContains(t, f.service.SendCode(ctx, f.user), "Add a phone number to your profile first")
+		assert.Empty(t, f.sms.messages)
+	})
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+// ReminderConfig holds the schedule of each reminder in days. The payment
+// reminder is also sent on the due date.
+type ReminderConfig struct {
+	PaymentDueSoonDays        []int `env:"PAYMENT_DUE_SOON_DAYS" envDefault:"3,1"`
+	PaymentOverdueDays        []int `env:"PAYMENT_OVERDUE_DAYS" envDefault:"1,7"`
+	ContractUnsignedDays      []int `env:"CONTRACT_UNSIGNED_DAYS" envDefault:"2,5"`
+	LeasingRequestPendingDays []int `env:"LEASING_REQUEST_PENDING_DAYS" envDefault:"2,5"`
+	LeaseEndingDays           []int `env:"LEASE_ENDING_DAYS" envDefault:"30,7"`
+}
+
+// reminderGrace is how long after its last step a subject is still looked at,
+// so a reminder is not lost when the scheduler skips a run.
+const reminderGrace = 24 * time.Hour
+
+const reminderDay = 24 * time.Hour
+
+// reminderStep is sent Days after the anchor of its subject, negative is before.
+type reminderStep struct {
+	Days int
+	Type domain.NotificationType
+}
+
+// reminderSchedule is sorted by Days.
+type reminderSchedule []reminderStep
+
+func newReminderSchedule(steps ...reminderStep) reminderSchedule {
+	schedule := reminderSchedule(steps)
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i].Days < schedule[j].Days })
+	return schedule
+}
+
+func stepsOf(notificationType domain.NotificationType, sign int, days []int) []reminderStep {
+	steps := make([]reminderStep, len(days))
+	for i, d := range days {
+		steps[i] = reminderStep{Days: sign * d, Type: notificationType}
+	}
+	return steps
+}
+
+// window is the range of anchors that may have a step to send at now. It is
+// false when the schedule has no steps.
+func (s reminderSchedule) window(now time.Time) (time.Time, time.Time, bool) {
+	if len(s) == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	first, last := s[0], s[len(s)-1]
+	return now.Add(-time.Duration(last.Days)*reminderDay - reminderGrace), now.Add(-time.Duration(first.Days) * reminderDay), true
+}
+
+// due returns the latest step whose time has come. Earlier steps that were
+// missed are skipped, the user only needs the most recent reminder.
+func (s reminderSchedule) due(anchor time.Time, now time.Time) (reminderStep, bool) {
+	for i := len(s) - 1; i >= 0; i-- {
+		if !anchor.Add(time.Duration(s[i].Days) * reminderDay).After(now) {
+			return s[i], true
+		}
+	}
+	return reminderStep{}, false
+}
+
+// daysUntil rounds up, so a lease ending in 6.5 days ends "in 7 days".
+func daysUntil(now time.Time, t time.Time) int {
+	return int(math.Ceil(t.Sub(now).Hours() / 24))
+}
+
+// daysSince rounds down, so a bill is "1 day overdue" until the second day is over.
+func daysSince(t time.Time, now time.Time) int {
+	return int(math.Floor(now.Sub(t).Hours() / 24))
+}
+
+type ReminderService struct {
+	reminderRepo ports.ReminderRepository
+	notifier     ports.NotificationPublisher
+	payment      reminderSchedule
+	contract     reminderSchedule
+	request      reminderSchedule
+	lease        reminderSchedule
+}
+
+func NewReminderService(reminderRepo ports.ReminderRepository, notifier ports.NotificationPublisher, config ReminderConfig) ports.ReminderService {
+	payment := append(stepsOf(domain.NotificationPaymentDueSoon, -1, config.PaymentDueSoonDays), reminderStep{Days: 0, Type: domain.NotificationPaymentDue})
+	payment = append(payment, stepsOf(domain.NotificationPaymentOverdue, 1, config.PaymentOverdueDays)...)
+
+	return &ReminderService{
+		reminderRepo: reminderRepo,
+		notifier:     notifier,
+		payment:      newReminderSchedule(payment...),
+		contract:     newReminderSchedule(stepsOf(domain.NotificationContractUnsigned, 1, config.ContractUnsignedDays)...),
+		request:      newReminderSchedule(stepsOf(domain.NotificationLeasingRequestPending, 1, config.LeasingRequestPendingDays)...),
+		lease:        newReminderSchedule(stepsOf(domain.NotificationLeaseEnding, -1, config.LeaseEndingDays)...),
+	}
+}
+
+// SendDue sends the reminders whose time has come. It is safe to run often.
+func (s *ReminderService) SendDue() error {
+	now := time.Now()
+	return errors.Join(
+		s.remindPayments(now),
+		s.remindContracts(now),
+		s.remindLeasingRequests(now),
+		s.remindLeases(now),
+	)
+}
+
+func (s *ReminderService) remindPayments(now time.Time) error {
+	from, to, ok := s.payment.window(now)
+	if !ok {
+		return nil
+	}
+	orders, err := s.reminderRepo.GetUnpaidOrders(from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		step, ok := s.payment.due(order.DueAt, now)
+		if !ok {
+			continue
+		}
+
+		history := order.LeasingHistory
+		event := domain.NotificationEvent{
+			Type:     step.Type,
+			UserID:   history.LesseeID,
+			Link:     "/orders/" + order.ID.String(),
+			DormName: history.Dorm.Name,
+			Amount:   float64(order.Price),
+		}
+		switch step.Type {
+		case domain.NotificationPaymentDueSoon:
+			event.Days = daysUntil(now, order.DueAt)
+			event.Title = "Payment due soon"
+			event.Body = fmt.Sprintf("Your bill of %d THB for %s is due in %d days.", order.Price, history.Dorm.Name, event.Days)
+		case domain.NotificationPaymentDue:
+			event.Title = "Payment due today"
+			event.Body = fmt.Sprintf("Your bill of %d THB for %s is due today.", order.Price, history.Dorm.Name)
+		default:
+			event.Days = daysSince(order.DueAt, now)
+			event.Title = "Payment overdue"
+			event.Body = fmt.Sprintf("Your bill of %d THB for %s is %d days overdue.", order.Price, history.Dorm.Name, event.Days)
+		}
+		s.remind(step, order.ID, event)
+	}
+	return nil
+}
+
+func (s *ReminderService) remindContracts(now time.Time) error {
+	from, to, ok := s.contract.window(now)
+	if !ok {
+		return nil
+	}
+	contracts, err := s.reminderRepo.GetWaitingContracts(from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, contract := range contracts {
+		step, ok := s.contract.due(contract.CreateAt, now)
+		if !ok {
+			continue
+		}
+
+		days := daysSince(contract.CreateAt, now)
+		// only the parties who have not signed yet
+		waiting := []uuid.UUID{}
+		if contract.LesseeStatus == domain.Waiting {
+			waiting = append(waiting, contract.LesseeID)
+		}
+		if contract.LessorStatus == domain.Waiting {
+			waiting = append(waiting, contract.Dorm.OwnerID)
+		}
+		for _, userID := range waiting {
+			s.remind(step, contract.ID, domain.NotificationEvent{
+				Type:     step.Type,
+				UserID:   userID,
+				Title:    "Contract still waiting for your signature",
+				Body:     fmt.Sprintf("The contract for %s has been waiting for your signature for %d days.", contract.Dorm.Name, days),
+				Link:     "/contracts/" + contract.ID.String(),
+				DormName: contract.Dorm.Name,
+				Days:     days,
+			})
+		}
+	}
+	return nil
+}
+
+func (s *ReminderService) remindLeasingRequests(now time.Time) error {
+	from, to, ok := s.request.window(now)
+	if !ok {
+		return nil
+	}
+	requests, err := s.reminderRepo.GetPendingLeasingRequests(from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		step, ok := s.request.due(request.Start, now)
+		if !ok {
+			continue
+		}
+
+		days := daysSince(request.Start, now)
+		s.remind(step, request.ID, domain.NotificationEvent{
+			Type:     step.Type,
+			UserID:   request.Dorm.OwnerID,
+			Title:    "Leasing request waiting for your answer",
+			Body:     fmt.Sprintf("A request to lease %s has been waiting for %d days.", request.Dorm.Name, days),
+			Link:     "/leasing-requests/" + request.ID.String(),
+			DormName: request.Dorm.Name,
+			Days:     days,
+		})
+	}
+	return nil
+}
+
+func (s *ReminderService) remindLeases(now time.Time) error {
+	// a lease that already ended needs no reminder, however late the run is
+	_, to, ok := s.lease.window(now)
+	if !ok {
+		return nil
+	}
+	histories, err := s.reminderRepo.GetEndingLeases(now, to)
+	if err != nil {
+		return err
+	}
+
+	for _, history := range histories {
+		if history.PlannedEnd == nil {
+			continue
+		}
+		step, ok := s.lease.due(*history.PlannedEnd, now)
+		if !ok {
+			continue
+		}
+
+		days := daysUntil(now, *history.PlannedEnd)
+		for _, userID := range []uuid.UUID{history.LesseeID, history.Dorm.OwnerID} {
+			s.remind(step, history.ID, domain.NotificationEvent{
+				Type:     step.Type,
+				UserID:   userID,
+				Title:    "Lease ending soon",
+				Body:     fmt.Sprintf("The lease of %s ends in %d days.", history.Dorm.Name, days),
+				Link:     "/history/" + history.ID.String(),
+				DormName: history.Dorm.Name,
+				Days:     days,
+			})
+		}
+	}
+	return nil
+}
+
+// remind claims the step before publishing, so a reminder is never sent twice.
+func (s *ReminderService) remind(step reminderStep, subjectID uuid.UUID, event domain.NotificationEvent) {
+	claimed, err := s.reminderRepo.Claim(&domain.ReminderLog{
+		Type:      step.Type,
+		SubjectID: subjectID,
+		UserID:    event.UserID,
+		Step:      step.Days,
+	})
+	if err != nil {
+		log.Errorf("reminder: cannot record %s for %s: %v", step.Type, subjectID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	s.notifier.Publish(event)
+}
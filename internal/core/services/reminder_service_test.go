@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReminderRepo struct {
+	leases  []domain.LeasingHistory
+	claimed map[string]bool
+}
+
+func (m *mockReminderRepo) GetUnpaidOrders(from time.Time, to time.Time) ([]domain.Order, error) {
+	return nil, nil
+}
+
+func (m *mockReminderRepo) GetWaitingContracts(from time.Time, to time.Time) ([]domain.Contract, error) {
+	return nil, nil
+}
+
+func (m *mockReminderRepo) GetPendingLeasingRequests(from time.Time, to time.Time) ([]domain.LeasingRequest, error) {
+	return nil, nil
+}
+
+// GetEndingLeases applies the same filters as the SQL query.
+func (m *mockReminderRepo) GetEndingLeases(from time.Time, to time.Time) ([]domain.LeasingHistory, error) {
+	var leases []domain.LeasingHistory
+	for _, l := range m.leases {
+		if !l.End.IsZero() || l.PlannedEnd == nil {
+			continue
+		}
+		if !l.PlannedEnd.Before(from) && l.PlannedEnd.Before(to) {
+			leases = append(leases, l)
+		}
+	}
+	return leases, nil
+}
+
+func (m *mockReminderRepo) Claim(log *domain.ReminderLog) (bool, error) {
+	key := fmt.Sprintf("%s/%s/%s/%d", log.Type, log.SubjectID, log.UserID, log.Step)
+	if m.claimed[key] {
+		return false, nil
+	}
+	m.claimed[key] = true
+	return true, nil
+}
+
+type mockNotifier struct {
+	events []domain.NotificationEvent
+}
+
+func (m *mockNotifier) Publish(event domain.NotificationEvent) {
+	m.events = append(m.events, event)
+}
+
+func newLease(plannedEnd time.Time) domain.LeasingHistory {
+	return domain.LeasingHistory{
+		ID:         uuid.New(),
+		LesseeID:   uuid.New(),
+		Dorm:       domain.Dorm{Name: "SpaceDorm", OwnerID: uuid.New()},
+		Start:      plannedEnd.AddDate(-1, 0, 0),
+		PlannedEnd: &plannedEnd,
+	}
+}
+
+func TestRemindLeases(t *testing.T) {
+	config := ReminderConfig{LeaseEndingDays: []int{30, 7}}
+
+	t.Run("lease ending soon", func(t *testing.T) {
+		lease := newLease(time.Now().Add(6*reminderDay + 12*time.Hour))
+		repo := &mockReminderRepo{leases: []domain.LeasingHistory{lease}, claimed: map[string]bool{}}
+		notifier := &mockNotifier{}
+		service := NewReminderService(repo, notifier, config)
+
+		assert.NoError(t, service.SendDue())
+		if assert.Len(t, notifier.events, 2) {
+			assert.ElementsMatch(t, []uuid.UUID{lease.LesseeID, lease.Dorm.OwnerID}, []uuid.UUID{notifier.events[0].UserID, notifier.events[1].UserID})
+			for _, event := range notifier.events {
+				assert.Equal(t, domain.NotificationLeaseEnding, event.Type)
+				assert.Equal(t, 7, event.Days)
+				assert.Equal(t, "The lease of SpaceDorm ends in 7 days.", event.Body)
+			}
+		}
+
+		// the next run must not send the same step again
+		assert.NoError(t, service.SendDue())
+		assert.Len(t, notifier.events, 2)
+	})
+
+	t.Run("only the latest missed step", func(t *testing.T) {
+		lease := newLease(time.Now().Add(3 * reminderDay))
+		repo := &mockReminderRepo{leases: []domain.LeasingHistory{lease}, claimed: map[string]bool{}}
+		notifier := &mockNotifier{}
+		service := NewReminderService(repo, notifier, config)
+
+		assert.NoError(t, service.SendDue())
+		assert.Len(t, notifier.events, 2)
+		assert.Len(t, repo.claimed, 2)
+		for key := range repo.claimed {
+			assert.Contains(t, key, "/-7")
+		}
+	})
+
+	t.Run("not ending soon", func(t *testing.T) {
+		leases := []domain.LeasingHistory{
+			newLease(time.Now().Add(60 * reminderDay)),
+			{ID: uuid.New(), LesseeID: uuid.New(), Start: time.Now()},
+		}
+		ended := newLease(time.Now().Add(5 * reminderDay))
+		ended.End = time.Now()
+		leases = append(leases, ended)
+
+		repo := &mockReminderRepo{leases: leases, claimed: map[string]bool{}}
+		notifier := &mockNotifier{}
+		service := NewReminderService(repo, notifier, config)
+
+		assert.NoError(t, service.SendDue())
+		assert.Empty(t, notifier.events)
+	})
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type ReviewModerationService struct {
+	moderationRepo ports.ReviewModerationRepository
+	historyRepo    ports.LeasingHistoryRepository
+	emailService   email.Sender
+}
+
+func NewReviewModerationService(moderationRepo ports.ReviewModerationRepository, historyRepo ports.LeasingHistoryRepository, emailService email.Sender) ports.ReviewModerationService {
+	return &ReviewModerationService{moderationRepo: moderationRepo, historyRepo: historyRepo, emailService: emailService}
+}
+
+func (s *ReviewModerationService) Report(user *domain.User, historyID uuid.UUID, category domain.ReportCategory, reason string) (*domain.LeasingHistory, error) {
+	history, err := s.historyRepo.GetByID(historyID)
+	if err != nil {
+		return nil, err
+	}
+	if !history.ReviewFlag || history.Review.Hidden {
+		return nil, apperror.NotFoundError(errors.New("review not found"), "review not found")
+	}
+	if history.LesseeID == user.ID {
+		return nil, apperror.BadRequestError(errors.New("own review"), "You cannot report your own review")
+	}
+
+	reported, err := s.moderationRepo.HasOpenReport(historyID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if reported {
+		return nil, apperror.ConflictError(errors.New("review already reported"), "review already reported")
+	}
+
+	report := &domain.ReviewReport{HistoryID: historyID, ReporterID: user.ID, Category: category, Reason: reason}
+	if err := s.moderationRepo.CreateReport(report); err != nil {
+		return nil, err
+	}
+	return s.historyRepo.GetByID(historyID)
+}
+
+func (s *ReviewModerationService) GetQueue(limit int, page int) ([]domain.LeasingHistory, int, int, error) {
+	return s.moderationRepo.GetQueue(limit, page)
+}
+
+func (s *ReviewModerationService) Moderate(adminID uuid.UUID, historyID uuid.UUID, decision domain.ModerationDecision, reason string) (*domain.ReviewModeration, error) {
+	history, err := s.historyRepo.GetByID(historyID)
+	if err != nil {
+		return nil, err
+	}
+	if !history.ReviewFlag || !history.Review.ReportFlag {
+		return nil, apperror.NotFoundError(errors.New("no open reports"), "This review has no open reports")
+	}
+
+	values := map[string]interface{}{"report_flag": false}
+	switch decision {
+	case domain.ModerationHide:
+		values["hidden"] = true
+	case domain.ModerationRemove:
+		// removed stays set so the reviewer cannot post the review again
+		values["review_flag"] = false
+		values["removed"] = true
+	}
+
+	moderation := &domain.ReviewModeration{HistoryID: historyID, AdminID: adminID, Decision: decision, Reason: reason}
+	if err := s.moderationRepo.CreateModeration(moderation, history, values); err != nil {
+		return nil, err
+	}
+
+	if decision != domain.ModerationDismiss {
+		action := "hidden"
+		if decision == domain.ModerationRemove {
+			action = "removed"
+		}
+		lines := []string{
+			fmt.Sprintf("Your review of %s has been %s by our moderators.", history.Dorm.Name, action),
+			"Reason: " + reason,
+			"If you think this was a mistake, you can appeal this decision once.",
+		}
+		s.notifyReviewer(history, lines, "View review")
+	}
+
+	return moderation, nil
+}
+
+func (s *ReviewModerationService) Appeal(user *domain.User, historyID uuid.UUID, message string) (*domain.ReviewModeration, error) {
+	history, err := s.historyRepo.GetByID(historyID)
+	if err != nil {
+		return nil, err
+	}
+	if history.LesseeID != user.ID {
+		return nil, apperror.ForbiddenError(errors.New("unauthorized action"), "Only the reviewer can appeal this decision")
+	}
+
+	moderation, err := s.moderationRepo.GetLatestModerationByHistoryID(historyID)
+	if err != nil {
+		return nil, err
+	}
+	if moderation.Decision == domain.ModerationDismiss {
+		return nil, apperror.BadRequestError(errors.New("nothing to appeal"), "There is no decision against this review to appeal")
+	}
+
+	appealed, err := s.moderationRepo.HasAppeal(historyID)
+	if err != nil {
+		return nil, err
+	}
+	if appealed {
+		return nil, apperror.ConflictError(errors.New("already appealed"), "You can only appeal once")
+	}
+
+	now := time.Now()
+	moderation.AppealStatus = domain.AppealPending
+	moderation.AppealMessage = message
+	moderation.AppealAt = &now
+	if err := s.moderationRepo.SaveAppeal(moderation); err != nil {
+		return nil, err
+	}
+	return moderation, nil
+}
+
+func (s *ReviewModerationService) GetPendingAppeals(limit int, page int) ([]domain.ReviewModeration, int, int, error) {
+	return s.moderationRepo.GetPendingAppeals(limit, page)
+}
+
+func (s *ReviewModerationService) DecideAppeal(adminID uuid.UUID, moderationID uuid.UUID, status domain.AppealStatus, reason string) (*domain.ReviewModeration, error) {
+	moderation, err := s.moderationRepo.GetModerationByID(moderationID)
+	if err != nil {
+		return nil, err
+	}
+	if moderation.AppealStatus != domain.AppealPending {
+		return nil, apperror.ConflictError(errors.New("appeal not pending"), "appeal not pending")
+	}
+
+	history, err := s.historyRepo.GetByID(moderation.HistoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if status == domain.AppealOverturned {
+		switch moderation.Decision {
+		case domain.ModerationHide:
+			values["hidden"] = false
+		case domain.ModerationRemove:
+			values["review_flag"] = true
+			values["removed"] = false
+		}
+	}
+
+	now := time.Now()
+	moderation.AppealStatus = status
+	moderation.AppealAdminID = &adminID
+	moderation.AppealReason = reason
+	moderation.AppealDecideAt = &now
+	if err := s.moderationRepo.DecideAppeal(moderation, history, values); err != nil {
+		return nil, err
+	}
+
+	result := "upheld, the decision stays in place"
+	if status == domain.AppealOverturned {
+		result = "accepted, your review has been restored"
+	}
+	lines := []string{
+		fmt.Sprintf("Your appeal for your review of %s was %s.", history.Dorm.Name, result),
+		"Reason: " + reason,
+	}
+	s.notifyReviewer(history, lines, "View review")
+
+	return moderation, nil
+}
+
+func (s *ReviewModerationService) notifyReviewer(history *domain.LeasingHistory, lines []string, buttonText string) {
+	link := s.emailService.Link("/history/" + history.ID.String())
+	go func() {
+		if err := s.emailService.SendNotificationEmail(recipient(&history.Lessee), "ConDormHub Review Moderation", lines, buttonText, link); err != nil {
+			log.Errorf("review moderation: cannot notify reviewer of history %s: %v", history.ID, err)
+		}
+	}()
+}
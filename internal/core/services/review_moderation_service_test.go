@@ -0,0 +1,321 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReviewModerationRepo struct {
+	historyRepo *mockLeasingHistoryRepo
+	reports     []*domain.ReviewReport
+	moderations []*domain.ReviewModeration
+}
+
+// apply sets the review columns the service updates on the stored history.
+func (m *mockReviewModerationRepo) apply(history *domain.LeasingHistory, values map[string]interface{}) {
+	for column, value := range values {
+		switch column {
+		case "report_flag":
+			history.Review.ReportFlag = value.(bool)
+		case "hidden":
+			history.Review.Hidden = value.(bool)
+		case "removed":
+			history.Review.Removed = value.(bool)
+		case "review_flag":
+			history.ReviewFlag = value.(bool)
+		}
+	}
+}
+
+func (m *mockReviewModerationRepo) CreateReport(report *domain.ReviewReport) error {
+	m.reports = append(m.reports, report)
+	m.historyRepo.histories[report.HistoryID].Review.ReportFlag = true
+	return nil
+}
+
+func (m *mockReviewModerationRepo) HasOpenReport(historyID uuid.UUID, reporterID uuid.UUID) (bool, error) {
+	for _, report := range m.reports {
+		if report.HistoryID == historyID && report.ReporterID == reporterID && report.ModerationID == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockReviewModerationRepo) GetQueue(limit int, page int) ([]domain.LeasingHistory, int, int, error) {
+	panic("unimplemented")
+}
+
+func (m *mockReviewModerationRepo) CreateModeration(moderation *domain.ReviewModeration, history *domain.LeasingHistory, reviewValues map[string]interface{}) error {
+	moderation.ID = uuid.New()
+	m.moderations = append(m.moderations, moderation)
+	for _, report := range m.reports {
+		if report.HistoryID == moderation.HistoryID && report.ModerationID == nil {
+			report.ModerationID = &moderation.ID
+		}
+	}
+	m.apply(history, reviewValues)
+	return nil
+}
+
+func (m *mockReviewModerationRepo) GetModerationByID(id uuid.UUID) (*domain.ReviewModeration, error) {
+	for _, moderation := range m.moderations {
+		if moderation.ID == id {
+			return moderation, nil
+		}
+	}
+	return nil, errors.New("moderation not found")
+}
+
+func (m *mockReviewModerationRepo) GetLatestModerationByHistoryID(historyID uuid.UUID) (*domain.ReviewModeration, error) {
+	for i := len(m.moderations) - 1; i >= 0; i-- {
+		if m.moderations[i].HistoryID == historyID {
+			return m.moderations[i], nil
+		}
+	}
+	return nil, errors.New("moderation not found")
+}
+
+func (m *mockReviewModerationRepo) HasAppeal(historyID uuid.UUID) (bool, error) {
+	for _, moderation := range m.moderations {
+		if moderation.HistoryID == historyID && moderation.AppealAt != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockReviewModerationRepo) SaveAppeal(moderation *domain.ReviewModeration) error {
+	return nil
+}
+
+func (m *mockReviewModerationRepo) GetPendingAppeals(limit int, page int) ([]domain.ReviewModeration, int, int, error) {
+	panic("unimplemented")
+}
+
+func (m *mockReviewModerationRepo) DecideAppeal(moderation *domain.ReviewModeration, history *domain.LeasingHistory, reviewValues map[string]interface{}) error {
+	m.apply(history, reviewValues)
+	return nil
+}
+
+func newReviewedHistory() *domain.LeasingHistory {
+	lesseeID := uuid.New()
+	return &domain.LeasingHistory{
+		ID:         uuid.New(),
+		DormID:     uuid.New(),
+		Dorm:       domain.Dorm{Name: "SpaceDorm"},
+		LesseeID:   lesseeID,
+		Lessee:     domain.User{ID: lesseeID, Email: "lessee@example.com", Role: domain.LesseeRole},
+		ReviewFlag: true,
+		Review:     &domain.Review{Message: "Noisy at night", Rate: 2},
+	}
+}
+
+// newModerationFixture returns a service for one reported review.
+func newModerationFixture(t *testing.T) (*ReviewModerationService, *mockReviewModerationRepo, *domain.LeasingHistory) {
+	history := newReviewedHistory()
+	historyRepo := newMockLeasingHistoryRepo(history)
+	moderationRepo := &mockReviewModerationRepo{historyRepo: historyRepo}
+	service := &ReviewModerationService{moderationRepo: moderationRepo, historyRepo: historyRepo, emailService: &mockEmailSender{}}
+
+	_, err := service.Report(&domain.User{ID: uuid.New()}, history.ID, domain.ReportHarassment, "insults the staff")
+	assert.NoError(t, err)
+	return service, moderationRepo, history
+}
+
+func TestReviewReport(t *testing.T) {
+	history := newReviewedHistory()
+	historyRepo := newMockLeasingHistoryRepo(history)
+	moderationRepo := &mockReviewModerationRepo{historyRepo: historyRepo}
+	service := &ReviewModerationService{moderationRepo: moderationRepo, historyRepo: historyRepo}
+	reporter := &domain.User{ID: uuid.New()}
+
+	t.Run("own review", func(t *testing.T) {
+		_, err := service.Report(&history.Lessee, history.ID, domain.ReportSpam, "")
+		assert.ErrorContains(t, err, "You cannot report your own review")
+	})
+
+	t.Run("flags the review", func(t *testing.T) {
+		_, err := service.Report(reporter, history.ID, domain.ReportFalseInfo, "never stayed there")
+		assert.NoError(t, err)
+		assert.True(t, history.Review.ReportFlag)
+		assert.Len(t, moderationRepo.reports, 1)
+	})
+
+	t.Run("reported twice by the same user", func(t *testing.T) {
+		_, err := service.Report(reporter, history.ID, domain.ReportSpam, "")
+		assert.ErrorContains(t, err, "review already reported")
+		assert.Len(t, moderationRepo.reports, 1)
+	})
+
+	t.Run("hidden review", func(t *testing.T) {
+		history.Review.Hidden = true
+		_, err := service.Report(&domain.User{ID: uuid.New()}, history.ID, domain.ReportSpam, "")
+		assert.ErrorContains(t, err, "review not found")
+	})
+}
+
+func TestReviewModerate(t *testing.T) {
+	adminID := uuid.New()
+
+	t.Run("hide", func(t *testing.T) {
+		service, repo, history := newModerationFixture(t)
+
+		moderation, err := service.Moderate(adminID, history.ID, domain.ModerationHide, "harassment")
+		assert.NoError(t, err)
+		assert.True(t, history.Review.Hidden)
+		assert.True(t, history.ReviewFlag)
+		assert.False(t, history.Review.ReportFlag)
+		assert.Equal(t, &moderation.ID, repo.reports[0].ModerationID)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		service, _, history := newModerationFixture(t)
+
+		_, err := service.Moderate(adminID, history.ID, domain.ModerationRemove, "harassment")
+		assert.NoError(t, err)
+		assert.False(t, history.ReviewFlag)
+		assert.True(t, history.Review.Removed)
+		assert.False(t, history.Review.ReportFlag)
+	})
+
+	t.Run("removed review cannot be posted again", func(t *testing.T) {
+		service, _, history := newModerationFixture(t)
+		_, err := service.Moderate(adminID, history.ID, domain.ModerationRemove, "harassment")
+		assert.NoError(t, err)
+
+		historyService := &LeasingHistoryService{historyRepo: service.historyRepo}
+		_, err = historyService.CreateReview(&history.Lessee, history.ID, "Noisy at night", 2, domain.ReviewRatings{})
+		assert.ErrorContains(t, err, "Your review was removed by our moderators")
+		assert.False(t, history.ReviewFlag)
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		service, repo, history := newModerationFixture(t)
+
+		_, err := service.Moderate(adminID, history.ID, domain.ModerationDismiss, "no harassment")
+		assert.NoError(t, err)
+		assert.True(t, history.ReviewFlag)
+		assert.False(t, history.Review.Hidden)
+		assert.False(t, history.Review.ReportFlag)
+		assert.NotNil(t, repo.reports[0].ModerationID)
+	})
+
+	t.Run("no open reports", func(t *testing.T) {
+		service, _, history := newModerationFixture(t)
+		_, err := service.Moderate(adminID, history.ID, domain.ModerationDismiss, "")
+		assert.NoError(t, err)
+
+		_, err = service.Moderate(adminID, history.ID, domain.ModerationHide, "")
+		assert.ErrorContains(t, err, "This review has no open reports")
+	})
+}
+
+func TestReviewAppeal(t *testing.T) {
+	adminID := uuid.New()
+
+	t.Run("only the reviewer", func(t *testing.T) {
+		service, _, history := newModerationFixture(t)
+		_, err := service.Moderate(adminID, history.ID, domain.ModerationHide, "harassment")
+		assert.NoError(t, err)
+
+		_, err = service.Appeal(&domain.User{ID: uuid.New()}, history.ID, "please")
+		assert.ErrorContains(t, err, "Only the reviewer can appeal this decision")
+	})
+
+	t.Run("dismissed report", func(t *testing.T) {
+		service, _, history := newModerationFixture(t)
+		_, err := service.Moderate(adminID, history.ID, domain.ModerationDismiss, "")
+		assert.NoError(t, err)
+
+		_, err = service.Appeal(&history.Lessee, history.ID, "please")
+		assert.ErrorContains(t, err, "There is no decision against this review to appeal")
+	})
+
+	t.Run("one appeal per history", func(t *testing.T) {
+		service, _, history := newModerationFixture(t)
+		_, err := service.Moderate(adminID, history.ID, domain.ModerationHide, "harassment")
+		assert.NoError(t, err)
+
+		appeal, err := service.Appeal(&history.Lessee, history.ID, "it was a fair review")
+		if assert.NoError(t, err) {
+			assert.Equal(t, domain.AppealPending, appeal.AppealStatus)
+		}
+
+		_, err = service.Appeal(&history.Lessee, history.ID, "please look again")
+		assert.ErrorContains(t, err, "You can only appeal once")
+	})
+
+	t.Run("second decision cannot be appealed", func(t *testing.T) {
+		service, _, history := newModerationFixture(t)
+		hide, err := service.Moderate(adminID, history.ID, domain.ModerationHide, "harassment")
+		assert.NoError(t, err)
+		_, err = service.Appeal(&history.Lessee, history.ID, "it was a fair review")
+		assert.NoError(t, err)
+		_, err = service.DecideAppeal(adminID, hide.ID, domain.AppealOverturned, "fair review")
+		assert.NoError(t, err)
+
+		// reported again and removed this time
+		_, err = service.Report(&domain.User{ID: uuid.New()}, history.ID, domain.ReportSpam, "")
+		assert.NoError(t, err)
+		_, err = service.Moderate(adminID, history.ID, domain.ModerationRemove, "spam")
+		assert.NoError(t, err)
+
+		_, err = service.Appeal(&history.Lessee, history.ID, "please look again")
+		assert.ErrorContains(t, err, "You can only appeal once")
+	})
+}
+
+func TestReviewDecideAppeal(t *testing.T) {
+	adminID := uuid.New()
+	appeal := func(t *testing.T, decision domain.ModerationDecision) (*ReviewModerationService, *domain.LeasingHistory, *domain.ReviewModeration) {
+		service, _, history := newModerationFixture(t)
+		moderation, err := service.Moderate(adminID, history.ID, decision, "harassment")
+		assert.NoError(t, err)
+		_, err = service.Appeal(&history.Lessee, history.ID, "it was a fair review")
+		assert.NoError(t, err)
+		return service, history, moderation
+	}
+
+	t.Run("overturned remove restores the review", func(t *testing.T) {
+		service, history, moderation := appeal(t, domain.ModerationRemove)
+
+		decided, err := service.DecideAppeal(adminID, moderation.ID, domain.AppealOverturned, "fair review")
+		if assert.NoError(t, err) {
+			assert.Equal(t, domain.AppealOverturned, decided.AppealStatus)
+			assert.Equal(t, &adminID, decided.AppealAdminID)
+		}
+		assert.True(t, history.ReviewFlag)
+		assert.False(t, history.Review.Removed)
+	})
+
+	t.Run("overturned hide shows the review", func(t *testing.T) {
+		service, history, moderation := appeal(t, domain.ModerationHide)
+
+		_, err := service.DecideAppeal(adminID, moderation.ID, domain.AppealOverturned, "fair review")
+		assert.NoError(t, err)
+		assert.False(t, history.Review.Hidden)
+	})
+
+	t.Run("upheld remove stays removed", func(t *testing.T) {
+		service, history, moderation := appeal(t, domain.ModerationRemove)
+
+		_, err := service.DecideAppeal(adminID, moderation.ID, domain.AppealUpheld, "harassment")
+		assert.NoError(t, err)
+		assert.False(t, history.ReviewFlag)
+		assert.True(t, history.Review.Removed)
+	})
+
+	t.Run("decided once", func(t *testing.T) {
+		service, _, moderation := appeal(t, domain.ModerationHide)
+		_, err := service.DecideAppeal(adminID, moderation.ID, domain.AppealUpheld, "harassment")
+		assert.NoError(t, err)
+
+		_, err = service.DecideAppeal(adminID, moderation.ID, domain.AppealOverturned, "fair review")
+		assert.ErrorContains(t, err, "appeal not pending")
+	})
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type SavedSearchService struct {
+	searchRepo   ports.SavedSearchRepository
+	emailService email.Sender
+}
+
+func NewSavedSearchService(searchRepo ports.SavedSearchRepository, emailService email.Sender) ports.SavedSearchService {
+	return &SavedSearchService{searchRepo: searchRepo, emailService: emailService}
+}
+
+func applySavedSearchBody(search *domain.SavedSearch, body dto.SavedSearchRequestBody) error {
+	if body.MinPrice > 0 && body.MaxPrice > 0 && body.MinPrice > body.MaxPrice {
+		return apperror.BadRequestError(errors.New("min price cannot more than max price"), "min price cannot more than max price")
+	}
+
+	search.Name = body.Name
+	search.Search = body.Search
+	search.MinPrice = body.MinPrice
+	search.MaxPrice = body.MaxPrice
+	search.District = body.District
+	search.Subdistrict = body.Subdistrict
+	search.Province = body.Province
+	search.Zipcode = body.Zipcode
+	search.AlertFrequency = domain.AlertImmediate
+	if body.AlertFrequency != "" {
+		search.AlertFrequency = domain.AlertFrequency(body.AlertFrequency)
+	}
+	return nil
+}
+
+func (s *SavedSearchService) Create(user *domain.User, body dto.SavedSearchRequestBody) (*domain.SavedSearch, error) {
+	if err := policy.Authorize(user, domain.PermissionSavedSearch); err != nil {
+		return nil, apperror.ForbiddenError(err, "Only lessees can save a search")
+	}
+
+	search := &domain.SavedSearch{UserID: user.ID}
+	if err := applySavedSearchBody(search, body); err != nil {
+		return nil, err
+	}
+
+	if err := s.searchRepo.Create(search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+func (s *SavedSearchService) GetByID(userID uuid.UUID, id uuid.UUID) (*domain.SavedSearch, error) {
+	search, err := s.searchRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if search.UserID != userID {
+		return nil, apperror.ForbiddenError(errors.New("unauthorized action"), "You do not have permission to access this saved search")
+	}
+	return search, nil
+}
+
+func (s *SavedSearchService) GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.SavedSearch, int, int, error) {
+	return s.searchRepo.GetByUserID(userID, limit, page)
+}
+
+func (s *SavedSearchService) Update(userID uuid.UUID, id uuid.UUID, body dto.SavedSearchRequestBody) (*domain.SavedSearch, error) {
+	search, err := s.GetByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applySavedSearchBody(search, body); err != nil {
+		return nil, err
+	}
+
+	if err := s.searchRepo.Update(search); err != nil {
+		return nil, err
+	}
+	return s.searchRepo.GetByID(id)
+}
+
+func (s *SavedSearchService) Delete(userID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.GetByID(userID, id); err != nil {
+		return err
+	}
+	return s.searchRepo.Delete(id)
+}
+
+// MatchDorm checks a created or updated dorm against the saved searches. When previous is
+// given, only searches the dorm did not already match before the update are alerted.
+func (s *SavedSearchService) MatchDorm(dorm *domain.Dorm, previous *domain.Dorm) {
+	s.matchDorm(dorm, previous, s.searchRepo.CreateMatch)
+}
+
+// MatchAvailableDorm checks a dorm whose last lease has ended against the saved
+// searches. Searches already alerted about it are alerted again.
+func (s *SavedSearchService) MatchAvailableDorm(dorm *domain.Dorm) {
+	s.matchDorm(dorm, nil, s.searchRepo.ReopenMatch)
+}
+
+// matchDorm alerts the searches dorm matches and previous did not, save stores
+// the match and reports whether it has to be announced.
+func (s *SavedSearchService) matchDorm(dorm *domain.Dorm, previous *domain.Dorm, save func(match *domain.SavedSearchMatch) (bool, error)) {
+	searches, err := s.searchRepo.GetCandidatesForDorm(dorm)
+	if err != nil {
+		log.Errorf("saved search: cannot load candidates for dorm %s: %v", dorm.ID, err)
+		return
+	}
+
+	for _, search := range searches {
+		if !search.Matches(dorm) || search.Matches(previous) {
+			continue
+		}
+
+		match := &domain.SavedSearchMatch{SavedSearchID: search.ID, DormID: dorm.ID}
+		created, err := save(match)
+		if err != nil {
+			log.Errorf("saved search: cannot save match for search %s: %v", search.ID, err)
+			continue
+		}
+		if !created || search.AlertFrequency != domain.AlertImmediate {
+			continue
+		}
+
+		if err := s.sendAlert(search, []domain.Dorm{*dorm}); err != nil {
+			log.Errorf("saved search: cannot send alert for search %s: %v", search.ID, err)
+			continue
+		}
+		if err := s.searchRepo.MarkMatchesNotified([]uuid.UUID{match.ID}); err != nil {
+			log.Errorf("saved search: cannot mark match %s as notified: %v", match.ID, err)
+		}
+	}
+}
+
+// SendDailyDigest sends one email per daily search with every dorm matched since its last digest.
+// Each digest is claimed before it is sent, so several instances can run it at once.
+func (s *SavedSearchService) SendDailyDigest() error {
+	now := time.Now()
+	before := now.Add(-24 * time.Hour)
+	matches, err := s.searchRepo.GetPendingDigestMatches(before)
+	if err != nil {
+		return err
+	}
+
+	order := []uuid.UUID{}
+	grouped := map[uuid.UUID][]domain.SavedSearchMatch{}
+	for _, m := range matches {
+		if _, ok := grouped[m.SavedSearchID]; !ok {
+			order = append(order, m.SavedSearchID)
+		}
+		grouped[m.SavedSearchID] = append(grouped[m.SavedSearchID], m)
+	}
+
+	for _, searchID := range order {
+		group := grouped[searchID]
+		dorms := make([]domain.Dorm, len(group))
+		ids := make([]uuid.UUID, len(group))
+		for i, m := range group {
+			dorms[i] = m.Dorm
+			ids[i] = m.ID
+		}
+
+		claimed, err := s.searchRepo.ClaimDigest(searchID, before, now)
+		if err != nil {
+			log.Errorf("saved search: cannot claim digest of search %s: %v", searchID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// unsent matches stay pending for the next digest
+		if err := s.sendAlert(group[0].SavedSearch, dorms); err != nil {
+			log.Errorf("saved search: cannot send digest for search %s: %v", searchID, err)
+			continue
+		}
+		if err := s.searchRepo.MarkMatchesNotified(ids); err != nil {
+			log.Errorf("saved search: cannot mark digest matches as notified: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *SavedSearchService) sendAlert(search domain.SavedSearch, dorms []domain.Dorm) error {
+	lines := []string{fmt.Sprintf("New places matching your saved search \"%s\":", search.Name)}
+	for _, d := range dorms {
+		lines = append(lines, fmt.Sprintf("%s - %s, %s - %.0f THB/month", d.Name, d.Address.District, d.Address.Province, d.Price))
+	}
+
+	link := s.emailService.Link("/dorms")
+	if len(dorms) == 1 {
+		link = s.emailService.Link("/dorms/" + dorms[0].ID.String())
+	}
+
+	return s.emailService.SendNotificationEmail(recipient(&search.User), "ConDormHub New Listing Alert", lines, "View listings", link)
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSavedSearchRepo struct {
+	ports.SavedSearchRepository
+	searches []*domain.SavedSearch
+	dorms    map[uuid.UUID]domain.Dorm
+	matches  []*domain.SavedSearchMatch
+}
+
+func (m *mockSavedSearchRepo) GetCandidatesForDorm(dorm *domain.Dorm) ([]domain.SavedSearch, error) {
+	if m.dorms == nil {
+		m.dorms = map[uuid.UUID]domain.Dorm{}
+	}
+	m.dorms[dorm.ID] = *dorm
+	var searches []domain.SavedSearch
+	for _, search := range m.searches {
+		if search.UserID != dorm.OwnerID {
+			searches = append(searches, *search)
+		}
+	}
+	return searches, nil
+}
+
+func (m *mockSavedSearchRepo) findMatch(match *domain.SavedSearchMatch) *domain.SavedSearchMatch {
+	for _, existing := range m.matches {
+		if existing.SavedSearchID == match.SavedSearchID && existing.DormID == match.DormID {
+			return existing
+		}
+	}
+	return nil
+}
+
+func (m *mockSavedSearchRepo) CreateMatch(match *domain.SavedSearchMatch) (bool, error) {
+	if existing := m.findMatch(match); existing != nil {
+		return false, nil
+	}
+	match.ID = uuid.New()
+	m.matches = append(m.matches, match)
+	return true, nil
+}
+
+func (m *mockSavedSearchRepo) ReopenMatch(match *domain.SavedSearchMatch) (bool, error) {
+	existing := m.findMatch(match)
+	if existing == nil {
+		return m.CreateMatch(match)
+	}
+	if !existing.Notified {
+		return false, nil
+	}
+	existing.Notified = false
+	match.ID = existing.ID
+	return true, nil
+}
+
+func (m *mockSavedSearchRepo) MarkMatchesNotified(ids []uuid.UUID) error {
+	for _, id := range ids {
+		for _, match := range m.matches {
+			if match.ID == id {
+				match.Notified = true
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockSavedSearchRepo) search(id uuid.UUID) *domain.SavedSearch {
+	for _, search := range m.searches {
+		if search.ID == id {
+			return search
+		}
+	}
+	return nil
+}
+
+func (m *mockSavedSearchRepo) GetPendingDigestMatches(before time.Time) ([]domain.SavedSearchMatch, error) {
+	var matches []domain.SavedSearchMatch
+	for _, match := range m.matches {
+		search := m.search(match.SavedSearchID)
+		if match.Notified || search.AlertFrequency != domain.AlertDaily || !search.LastDigestAt.Before(before) {
+			continue
+		}
+		pending := *match
+		pending.SavedSearch = *search
+		pending.Dorm = m.dorms[match.DormID]
+		matches = append(matches, pending)
+	}
+	return matches, nil
+}
+
+func (m *mockSavedSearchRepo) ClaimDigest(id uuid.UUID, before time.Time, at time.Time) (bool, error) {
+	search := m.search(id)
+	if !search.LastDigestAt.Before(before) {
+		return false, nil
+	}
+	search.LastDigestAt = at
+	return true, nil
+}
+
+func newSavedSearch(frequency domain.AlertFrequency) *domain.SavedSearch {
+	userID := uuid.New()
+	return &domain.SavedSearch{
+		ID:             uuid.New(),
+		UserID:         userID,
+		User:           domain.User{ID: userID, Email: "lessee@example.com"},
+		Name:           "Near campus",
+		MaxPrice:       6000,
+		Province:       "Bangkok",
+		AlertFrequency: frequency,
+	}
+}
+
+func newSearchDorm(name string, price float64) *domain.Dorm {
+	return &domain.Dorm{
+		ID:      uuid.New(),
+		OwnerID: uuid.New(),
+		Name:    name,
+		Price:   price,
+		Address: domain.Address{District: "Pathum Wan", Province: "Bangkok"},
+	}
+}
+
+func TestSavedSearchMatchDorm(t *testing.T) {
+	t.Run("new matching dorm", func(t *testing.T) {
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{newSavedSearch(domain.AlertImmediate)}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		dorm := newSearchDorm("SpaceDorm", 5000)
+
+		service.MatchDorm(dorm, nil)
+		if assert.Len(t, sender.sent, 1) {
+			assert.Equal(t, "lessee@example.com", sender.sent[0].to)
+			assert.Equal(t, []string{
+				"New places matching your saved search \"Near campus\":",
+				"SpaceDorm - Pathum Wan, Bangkok - 5000 THB/month",
+			}, sender.sent[0].lines)
+			assert.Equal(t, "https://condormhub.example/dorms/"+dorm.ID.String(), sender.sent[0].link)
+		}
+		if assert.Len(t, repo.matches, 1) {
+			assert.True(t, repo.matches[0].Notified)
+		}
+	})
+
+	t.Run("dorm outside the filters", func(t *testing.T) {
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{newSavedSearch(domain.AlertImmediate)}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		expensive := newSearchDorm("SpaceDorm", 8000)
+		elsewhere := newSearchDorm("SpaceDorm", 5000)
+		elsewhere.Address.Province = "Chiang Mai"
+
+		service.MatchDorm(expensive, nil)
+		service.MatchDorm(elsewhere, nil)
+		assert.Empty(t, sender.sent)
+		assert.Empty(t, repo.matches)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{newSavedSearch(domain.AlertImmediate)}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		dorm := newSearchDorm("SpaceDorm", 8000)
+		service.MatchDorm(dorm, nil)
+
+		// the price drop brings the dorm into the search
+		updated := *dorm
+		updated.Price = 5500
+		service.MatchDorm(&updated, dorm)
+		assert.Len(t, sender.sent, 1)
+
+		// it already matched before this update
+		renamed := updated
+		renamed.Name = "SpaceDorm 2"
+		service.MatchDorm(&renamed, &updated)
+		assert.Len(t, sender.sent, 1)
+	})
+
+	t.Run("dorm announced once", func(t *testing.T) {
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{newSavedSearch(domain.AlertImmediate)}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		dorm := newSearchDorm("SpaceDorm", 5000)
+
+		service.MatchDorm(dorm, nil)
+		service.MatchDorm(dorm, newSearchDorm("SpaceDorm", 9000))
+		assert.Len(t, sender.sent, 1)
+		assert.Len(t, repo.matches, 1)
+	})
+}
+
+func TestSavedSearchMatchAvailableDorm(t *testing.T) {
+	t.Run("announced again", func(t *testing.T) {
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{newSavedSearch(domain.AlertImmediate)}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		dorm := newSearchDorm("SpaceDorm", 5000)
+		service.MatchDorm(dorm, nil)
+
+		service.MatchAvailableDorm(dorm)
+		assert.Len(t, sender.sent, 2)
+		if assert.Len(t, repo.matches, 1) {
+			assert.True(t, repo.matches[0].Notified)
+		}
+	})
+
+	t.Run("first seen when available", func(t *testing.T) {
+		search := newSavedSearch(domain.AlertImmediate)
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{search}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+
+		service.MatchAvailableDorm(newSearchDorm("SpaceDorm", 5000))
+		assert.Len(t, sender.sent, 1)
+		assert.Len(t, repo.matches, 1)
+	})
+
+	t.Run("pending digest match is not duplicated", func(t *testing.T) {
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{newSavedSearch(domain.AlertDaily)}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		dorm := newSearchDorm("SpaceDorm", 5000)
+		service.MatchDorm(dorm, nil)
+
+		service.MatchAvailableDorm(dorm)
+		assert.Empty(t, sender.sent)
+		if assert.Len(t, repo.matches, 1) {
+			assert.False(t, repo.matches[0].Notified)
+		}
+	})
+}
+
+func TestSavedSearchDailyDigest(t *testing.T) {
+	t.Run("one email per search", func(t *testing.T) {
+		daily := newSavedSearch(domain.AlertDaily)
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{daily}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		service.MatchDorm(newSearchDorm("SpaceDorm", 5000), nil)
+		service.MatchDorm(newSearchDorm("MoonDorm", 4500), nil)
+		assert.Empty(t, sender.sent, "daily searches are not alerted right away")
+
+		assert.NoError(t, service.SendDailyDigest())
+		if assert.Len(t, sender.sent, 1) {
+			assert.Len(t, sender.sent[0].lines, 3)
+			assert.Equal(t, "https://condormhub.example/dorms", sender.sent[0].link)
+		}
+		for _, match := range repo.matches {
+			assert.True(t, match.Notified)
+		}
+		assert.WithinDuration(t, time.Now(), daily.LastDigestAt, time.Second)
+	})
+
+	t.Run("at most once a day", func(t *testing.T) {
+		daily := newSavedSearch(domain.AlertDaily)
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{daily}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		service.MatchDorm(newSearchDorm("SpaceDorm", 5000), nil)
+		assert.NoError(t, service.SendDailyDigest())
+
+		service.MatchDorm(newSearchDorm("MoonDorm", 4500), nil)
+		assert.NoError(t, service.SendDailyDigest())
+		assert.Len(t, sender.sent, 1)
+
+		daily.LastDigestAt = time.Now().Add(-25 * time.Hour)
+		assert.NoError(t, service.SendDailyDigest())
+		if assert.Len(t, sender.sent, 2) {
+			assert.Len(t, sender.sent[1].lines, 2)
+		}
+	})
+
+	t.Run("claimed by another instance", func(t *testing.T) {
+		daily := newSavedSearch(domain.AlertDaily)
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{daily}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		service.MatchDorm(newSearchDorm("SpaceDorm", 5000), nil)
+
+		// another instance claims the digest between loading the matches and sending
+		service.searchRepo = &claimingSavedSearchRepo{mockSavedSearchRepo: repo}
+		assert.NoError(t, service.SendDailyDigest())
+		assert.Empty(t, sender.sent)
+		assert.False(t, repo.matches[0].Notified)
+	})
+
+	t.Run("failed email stays pending", func(t *testing.T) {
+		repo := &mockSavedSearchRepo{searches: []*domain.SavedSearch{newSavedSearch(domain.AlertDaily)}}
+		sender := &mockEmailSender{}
+		service := &SavedSearchService{searchRepo: repo, emailService: sender}
+		service.MatchDorm(newSearchDorm("SpaceDorm", 5000), nil)
+		sender.err = errors.New("outbox unavailable")
+
+		assert.NoError(t, service.SendDailyDigest())
+		assert.False(t, repo.matches[0].Notified)
+	})
+}
+
+// claimingSavedSearchRepo loses every digest claim.
+type claimingSavedSearchRepo struct {
+	*mockSavedSearchRepo
+}
+
+func (m *claimingSavedSearchRepo) ClaimDigest(id uuid.UUID, before time.Time, at time.Time) (bool, error) {
+	return false, nil
+}
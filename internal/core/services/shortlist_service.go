@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/PitiNarak/condormhub-backend/pkg/storage"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// MaxCompareDorms is the largest number of dorms that can be compared side by side.
+const MaxCompareDorms = 4
+
+type ShortlistService struct {
+	shortlistRepo      ports.ShortlistRepository
+	dormRepo           ports.DormRepository
+	leasingRequestRepo ports.LeasingRequestRepository
+	emailService       email.Sender
+	storage            *storage.Storage
+}
+
+func NewShortlistService(
+	shortlistRepo ports.ShortlistRepository,
+	dormRepo ports.DormRepository,
+	leasingRequestRepo ports.LeasingRequestRepository,
+	emailService email.Sender,
+	storage *storage.Storage,
+) ports.ShortlistService {
+	return &ShortlistService{
+		shortlistRepo:      shortlistRepo,
+		dormRepo:           dormRepo,
+		leasingRequestRepo: leasingRequestRepo,
+		emailService:       emailService,
+		storage:            storage,
+	}
+}
+
+func (s *ShortlistService) Create(user *domain.User, name string) (*domain.Shortlist, error) {
+	if err := policy.Authorize(user, domain.PermissionShortlist); err != nil {
+		return nil, apperror.ForbiddenError(err, "Only lessees can create a shortlist")
+	}
+
+	shortlist := &domain.Shortlist{UserID: user.ID, Name: name}
+	if err := s.shortlistRepo.Create(shortlist); err != nil {
+		return nil, err
+	}
+	return shortlist, nil
+}
+
+func (s *ShortlistService) GetByID(userID uuid.UUID, id uuid.UUID) (*domain.Shortlist, error) {
+	shortlist, err := s.shortlistRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if shortlist.UserID != userID {
+		return nil, apperror.ForbiddenError(errors.New("unauthorized action"), "You do not have permission to access this shortlist")
+	}
+	return shortlist, nil
+}
+
+func (s *ShortlistService) GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.Shortlist, int, int, error) {
+	return s.shortlistRepo.GetByUserID(userID, limit, page)
+}
+
+func (s *ShortlistService) Rename(userID uuid.UUID, id uuid.UUID, name string) (*domain.Shortlist, error) {
+	if _, err := s.GetByID(userID, id); err != nil {
+		return nil, err
+	}
+	if err := s.shortlistRepo.UpdateName(id, name); err != nil {
+		return nil, err
+	}
+	return s.shortlistRepo.GetByID(id)
+}
+
+func (s *ShortlistService) Delete(userID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.GetByID(userID, id); err != nil {
+		return err
+	}
+	return s.shortlistRepo.Delete(id)
+}
+
+func (s *ShortlistService) AddDorm(userID uuid.UUID, id uuid.UUID, dormID uuid.UUID) (*domain.Shortlist, error) {
+	if _, err := s.GetByID(userID, id); err != nil {
+		return nil, err
+	}
+
+	dorm, err := s.dormRepo.GetByID(dormID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &domain.ShortlistItem{ShortlistID: id, DormID: dormID, PriceAtSave: dorm.Price}
+	if err := s.shortlistRepo.AddItem(item); err != nil {
+		return nil, err
+	}
+	return s.shortlistRepo.GetByID(id)
+}
+
+func (s *ShortlistService) RemoveDorm(userID uuid.UUID, id uuid.UUID, dormID uuid.UUID) error {
+	if _, err := s.GetByID(userID, id); err != nil {
+		return err
+	}
+	return s.shortlistRepo.RemoveItem(id, dormID)
+}
+
+func (s *ShortlistService) Compare(dormIDs []uuid.UUID) ([]dto.DormComparisonResponseBody, error) {
+	ids := make([]uuid.UUID, 0, len(dormIDs))
+	seen := map[uuid.UUID]bool{}
+	for _, id := range dormIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) < 2 || len(ids) > MaxCompareDorms {
+		msg := fmt.Sprintf("Select between 2 and %d dorms to compare", MaxCompareDorms)
+		return nil, apperror.BadRequestError(errors.New(msg), msg)
+	}
+
+	responseHours := map[uuid.UUID]*float64{}
+	res := make([]dto.DormComparisonResponseBody, len(ids))
+	for i, id := range ids {
+		dorm, err := s.dormRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := responseHours[dorm.OwnerID]; !ok {
+			hours, count, err := s.leasingRequestRepo.GetAverageResponseTime(dorm.OwnerID)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				responseHours[dorm.OwnerID] = &hours
+			} else {
+				responseHours[dorm.OwnerID] = nil
+			}
+		}
+
+		images := make([]string, len(dorm.Images))
+		for j, image := range dorm.Images {
+			images[j] = s.storage.GetPublicUrl(image.ImageKey)
+		}
+
+		res[i] = dto.DormComparisonResponseBody{
+			ID:                  dorm.ID,
+			Name:                dorm.Name,
+			Price:               dorm.Price,
+			Size:                dorm.Size,
+			PricePerSize:        dorm.Price / dorm.Size,
+			Bedrooms:            dorm.Bedrooms,
+			Bathrooms:           dorm.Bathrooms,
+			Rating:              dorm.Rating,
+			Address:             dorm.Address.ToDTO(),
+			LessorResponseHours: responseHours[dorm.OwnerID],
+			Images:              images,
+		}
+	}
+	return res, nil
+}
+
+func (s *ShortlistService) GetSavedCount(user *domain.User, dormID uuid.UUID) (int, error) {
+	dorm, err := s.dormRepo.GetByID(dormID)
+	if err != nil {
+		return 0, err
+	}
+	if err := policy.AuthorizeOwner(user, dorm.OwnerID, domain.PermissionDormInsights); err != nil {
+		return 0, apperror.ForbiddenError(err, "You do not have permission to view this dorm's statistics")
+	}
+	return s.shortlistRepo.CountUsersByDormID(dormID)
+}
+
+// NotifyDormUpdated tells users who shortlisted a dorm that its price changed.
+func (s *ShortlistService) NotifyDormUpdated(dorm *domain.Dorm, previous *domain.Dorm) {
+	if previous == nil || dorm.Price == previous.Price {
+		return
+	}
+
+	users, err := s.shortlistRepo.GetUsersByDormID(dorm.ID)
+	if err != nil {
+		log.Errorf("shortlist: cannot load users for dorm %s: %v", dorm.ID, err)
+		return
+	}
+
+	lines := []string{fmt.Sprintf("The price of %s, which is in your shortlist, changed from %.0f to %.0f THB/month.", dorm.Name, previous.Price, dorm.Price)}
+	link := s.emailService.Link("/dorms/" + dorm.ID.String())
+	for _, user := range users {
+		if err := s.emailService.SendNotificationEmail(recipient(&user), "ConDormHub Shortlist Price Change", lines, "View dorm", link); err != nil {
+			log.Errorf("shortlist: cannot notify user %s: %v", user.ID, err)
+		}
+	}
+}
+
+// NotifyDormDeleted tells users who shortlisted a dorm that it was removed, then drops it from their shortlists.
+func (s *ShortlistService) NotifyDormDeleted(dorm *domain.Dorm) {
+	users, err := s.shortlistRepo.GetUsersByDormID(dorm.ID)
+	if err != nil {
+		log.Errorf("shortlist: cannot load users for dorm %s: %v", dorm.ID, err)
+		return
+	}
+
+	lines := []string{fmt.Sprintf("%s, which is in your shortlist, is no longer available and has been removed from your shortlists.", dorm.Name)}
+	link := s.emailService.Link("/dorms")
+	for _, user := range users {
+		if err := s.emailService.SendNotificationEmail(recipient(&user), "ConDormHub Shortlist Update", lines, "Browse dorms", link); err != nil {
+			log.Errorf("shortlist: cannot notify user %s: %v", user.ID, err)
+		}
+	}
+
+	if err := s.shortlistRepo.RemoveItemsByDormID(dorm.ID); err != nil {
+		log.Errorf("shortlist: cannot remove dorm %s from shortlists: %v", dorm.ID, err)
+	}
+}
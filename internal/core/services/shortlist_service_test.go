@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockResponseTimeRepo answers GetAverageResponseTime per owner and counts the lookups.
+type mockResponseTimeRepo struct {
+	ports.LeasingRequestRepository
+	hours   map[uuid.UUID]float64
+	lookups int
+}
+
+func (m *mockResponseTimeRepo) GetAverageResponseTime(ownerID uuid.UUID) (float64, int, error) {
+	m.lookups++
+	hours, ok := m.hours[ownerID]
+	if !ok {
+		return 0, 0, nil
+	}
+	return hours, 3, nil
+}
+
+func newCompareDorms(ownerID uuid.UUID, n int) []*domain.Dorm {
+	dorms := make([]*domain.Dorm, n)
+	for i := range dorms {
+		dorms[i] = &domain.Dorm{ID: uuid.New(), OwnerID: ownerID, Name: "Dorm", Price: 6000, Size: 24}
+	}
+	return dorms
+}
+
+func dormIDs(dorms []*domain.Dorm) []uuid.UUID {
+	res := make([]uuid.UUID, len(dorms))
+	for i, dorm := range dorms {
+		res[i] = dorm.ID
+	}
+	return res
+}
+
+func TestShortlistCompareLimit(t *testing.T) {
+	dorms := newCompareDorms(uuid.New(), MaxCompareDorms+1)
+	service := &ShortlistService{dormRepo: newMockDormStore(dorms...), leasingRequestRepo: &mockResponseTimeRepo{}}
+
+	t.Run("one dorm", func(t *testing.T) {
+		_, err := service.Compare(dormIDs(dorms[:1]))
+		assert.ErrorContains(t, err, "Select between 2 and 4 dorms to compare")
+	})
+
+	t.Run("the same dorm twice", func(t *testing.T) {
+		_, err := service.Compare([]uuid.UUID{dorms[0].ID, dorms[0].ID})
+		assert.ErrorContains(t, err, "Select between 2 and 4 dorms to compare")
+	})
+
+	t.Run("more than the limit", func(t *testing.T) {
+		_, err := service.Compare(dormIDs(dorms))
+		assert.ErrorContains(t, err, "Select between 2 and 4 dorms to compare")
+	})
+
+	t.Run("duplicates do not count toward the limit", func(t *testing.T) {
+		res, err := service.Compare(append(dormIDs(dorms[:MaxCompareDorms]), dorms[0].ID))
+		if assert.NoError(t, err) {
+			assert.Len(t, res, MaxCompareDorms)
+		}
+	})
+}
+
+func TestShortlistCompare(t *testing.T) {
+	responsive, silent := uuid.New(), uuid.New()
+	first := &domain.Dorm{ID: uuid.New(), OwnerID: responsive, Name: "Baan Suan", Price: 6000, Size: 24, Bedrooms: 1, Bathrooms: 1, Rating: 4.5}
+	second := &domain.Dorm{ID: uuid.New(), OwnerID: responsive, Name: "Baan Rim Nam", Price: 9000, Size: 30}
+	third := &domain.Dorm{ID: uuid.New(), OwnerID: silent, Name: "Chula Place", Price: 5000, Size: 20}
+	requests := &mockResponseTimeRepo{hours: map[uuid.UUID]float64{responsive: 5.5}}
+	service := &ShortlistService{dormRepo: newMockDormStore(first, second, third), leasingRequestRepo: requests}
+
+	res, err := service.Compare([]uuid.UUID{third.ID, first.ID, second.ID})
+	if !assert.NoError(t, err) || !assert.Len(t, res, 3) {
+		return
+	}
+
+	// in the order they were asked for
+	assert.Equal(t, []string{"Chula Place", "Baan Suan", "Baan Rim Nam"}, []string{res[0].Name, res[1].Name, res[2].Name})
+
+	assert.Equal(t, 250.0, res[1].PricePerSize)
+	assert.Equal(t, 300.0, res[2].PricePerSize)
+	assert.Equal(t, 4.5, res[1].Rating)
+	assert.Empty(t, res[1].Images)
+
+	assert.Nil(t, res[0].LessorResponseHours)
+	if assert.NotNil(t, res[1].LessorResponseHours) {
+		assert.Equal(t, 5.5, *res[1].LessorResponseHours)
+	}
+	assert.Equal(t, res[1].LessorResponseHours, res[2].LessorResponseHours)
+	assert.Equal(t, 2, requests.lookups, "response time is looked up once per lessor")
+
+	_, err = service.Compare([]uuid.UUID{first.ID, uuid.New()})
+	assert.ErrorContains(t, err, "dorm not found")
+}
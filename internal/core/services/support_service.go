@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
@@ -10,14 +12,25 @@ import (
 )
 
 type SupportService struct {
-	repo ports.SupportRepository
+	repo       ports.SupportRepository
+	threadRepo ports.ThreadRepository
+	notifier   ports.NotificationPublisher
 }
 
-func NewSupportService(repo ports.SupportRepository) ports.SupportService {
-	return &SupportService{repo: repo}
+func NewSupportService(repo ports.SupportRepository, threadRepo ports.ThreadRepository, notifier ports.NotificationPublisher) ports.SupportService {
+	return &SupportService{repo: repo, threadRepo: threadRepo, notifier: notifier}
 }
 
 func (s *SupportService) Create(support *domain.SupportRequest) error {
+	if support.ThreadID != nil {
+		thread, err := s.threadRepo.GetByID(*support.ThreadID)
+		if err != nil {
+			return err
+		}
+		if !thread.IsParticipant(support.UserID) {
+			return apperror.ForbiddenError(errors.New("not a participant"), "You are not a participant of this thread")
+		}
+	}
 	return s.repo.Create(support)
 }
 
@@ -35,7 +48,19 @@ func (s *SupportService) UpdateStatus(id uuid.UUID, status domain.SupportStatus)
 		return nil, apperror.UnprocessableEntityError(errors.New("invalid status value"), "Invalid status value")
 	}
 
+	if err := s.repo.UpdateStatus(id, status); err != nil {
+		return nil, err
+	}
 	support.Status = status
 
-	return support, s.repo.UpdateStatus(id, status)
+	s.notifier.Publish(domain.NotificationEvent{
+		Type:   domain.NotificationSupportUpdated,
+		UserID: support.UserID,
+		Title:  "Support request updated",
+		Body:   fmt.Sprintf("Your support request is now %s.", strings.ToLower(string(status))),
+		Link:   "/support",
+		Status: string(status),
+	})
+
+	return support, nil
 }
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// threadImageURLExpiration is how long the links to message images work.
+const threadImageURLExpiration = time.Hour
+
+type ThreadService struct {
+	threadRepo         ports.ThreadRepository
+	dormRepo           ports.DormRepository
+	leasingRequestRepo ports.LeasingRequestRepository
+	leasingHistoryRepo ports.LeasingHistoryRepository
+	userRepo           ports.UserRepository
+	storage            *storage.Storage
+	live               ports.LivePublisher
+}
+
+func NewThreadService(threadRepo ports.ThreadRepository, dormRepo ports.DormRepository, leasingRequestRepo ports.LeasingRequestRepository, leasingHistoryRepo ports.LeasingHistoryRepository, userRepo ports.UserRepository, storage *storage.Storage, live ports.LivePublisher) ports.ThreadService {
+	return &ThreadService{
+		threadRepo:         threadRepo,
+		dormRepo:           dormRepo,
+		leasingRequestRepo: leasingRequestRepo,
+		leasingHistoryRepo: leasingHistoryRepo,
+		userRepo:           userRepo,
+		storage:            storage,
+		live:               live,
+	}
+}
+
+// Open returns the thread about the subject, creating it on first contact.
+// Anyone may ask the lessor about a dorm; leasing requests and leases can only
+// be discussed by their lessee and lessor.
+func (s *ThreadService) Open(user *domain.User, subject domain.ThreadSubject, subjectID uuid.UUID) (*dto.ThreadResponseBody, error) {
+	thread := &domain.Thread{Subject: subject, SubjectID: subjectID}
+
+	switch subject {
+	case domain.ThreadDorm:
+		dorm, err := s.dormRepo.GetByID(subjectID)
+		if err != nil {
+			return nil, err
+		}
+		thread.DormID, thread.LesseeID, thread.LessorID = dorm.ID, user.ID, dorm.OwnerID
+	case domain.ThreadLeasingRequest:
+		request, err := s.leasingRequestRepo.GetByID(subjectID)
+		if err != nil {
+			return nil, err
+		}
+		thread.DormID, thread.LesseeID, thread.LessorID = request.DormID, request.LesseeID, request.Dorm.OwnerID
+	case domain.ThreadLease:
+		history, err := s.leasingHistoryRepo.GetByID(subjectID)
+		if err != nil {
+			return nil, err
+		}
+		thread.DormID, thread.LesseeID, thread.LessorID = history.DormID, history.LesseeID, history.Dorm.OwnerID
+	default:
+		return nil, apperror.BadRequestError(errors.New("invalid subject"), "Invalid thread subject")
+	}
+
+	if thread.LesseeID == thread.LessorID {
+		return nil, apperror.BadRequestError(errors.New("thread with oneself"), "You cannot message yourself")
+	}
+	if !thread.IsParticipant(user.ID) {
+		return nil, apperror.ForbiddenError(errors.New("not a party"), "You are not a party of this subject")
+	}
+	if err := s.checkNotBlocked(user.ID, thread.Other(user.ID)); err != nil {
+		return nil, err
+	}
+
+	if err := s.threadRepo.Create(thread); err != nil {
+		return nil, err
+	}
+
+	thread, err := s.threadRepo.GetBySubject(thread.Subject, thread.SubjectID, thread.LesseeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toDTO(thread, user.ID)
+}
+
+func (s *ThreadService) GetMine(user *domain.User, limit int, page int) ([]dto.ThreadResponseBody, int, int, error) {
+	threads, totalPages, totalRows, err := s.threadRepo.GetByUserID(user.ID, limit, page)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	resData := make([]dto.ThreadResponseBody, len(threads))
+	for i := range threads {
+		res, err := s.toDTO(&threads[i], user.ID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		resData[i] = *res
+	}
+
+	return resData, totalPages, totalRows, nil
+}
+
+func (s *ThreadService) GetByID(user *domain.User, id uuid.UUID) (*dto.ThreadResponseBody, error) {
+	thread, err := s.authorizeRead(user, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toDTO(thread, user.ID)
+}
+
+func (s *ThreadService) GetMessages(ctx context.Context, user *domain.User, id uuid.UUID, limit int, page int) ([]dto.ThreadMessageResponseBody, int, int, error) {
+	thread, err := s.authorizeRead(user, id)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	messages, totalPages, totalRows, err := s.threadRepo.GetMessages(thread.ID, limit, page)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	reads := make(map[uuid.UUID]*domain.ThreadRead, 2)
+	for _, participantID := range []uuid.UUID{thread.LesseeID, thread.LessorID} {
+		read, err := s.threadRepo.GetRead(thread.ID, participantID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		reads[participantID] = read
+	}
+
+	resData := make([]dto.ThreadMessageResponseBody, len(messages))
+	for i, message := range messages {
+		recipientRead := reads[thread.Other(message.SenderID)]
+		read := recipientRead != nil && !message.CreateAt.After(recipientRead.LastReadAt)
+		res, err := s.messageToDTO(ctx, &message, read)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		resData[i] = res
+	}
+
+	return resData, totalPages, totalRows, nil
+}
+
+// Send posts a text message, an image or both. image is nil for a text only message.
+func (s *ThreadService) Send(ctx context.Context, user *domain.User, id uuid.UUID, body string, filename string, contentType string, image io.Reader) (*dto.ThreadMessageResponseBody, error) {
+	thread, err := s.threadRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !thread.IsParticipant(user.ID) {
+		return nil, apperror.ForbiddenError(errors.New("not a participant"), "You are not a participant of this thread")
+	}
+	recipientID := thread.Other(user.ID)
+	if err := s.checkNotBlocked(user.ID, recipientID); err != nil {
+		return nil, err
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" && image == nil {
+		return nil, apperror.BadRequestError(errors.New("empty message"), "Message must have a text or an image")
+	}
+
+	message := &domain.ThreadMessage{ThreadID: thread.ID, SenderID: user.ID, Body: body}
+	if image != nil {
+		filename = strings.ReplaceAll(filename, " ", "-")
+		message.ImageKey = fmt.Sprintf("threads/%s/%s-%s", thread.ID, uuid.New().String(), filename)
+		if err := s.storage.UploadFile(ctx, message.ImageKey, contentType, image, storage.PrivateBucket); err != nil {
+			return nil, apperror.InternalServerError(err, "error uploading file")
+		}
+	}
+
+	if err := s.threadRepo.CreateMessage(thread, message); err != nil {
+		return nil, err
+	}
+
+	// writing a message means having read the thread up to it
+	if err := s.threadRepo.SaveRead(&domain.ThreadRead{ThreadID: thread.ID, UserID: user.ID, LastReadAt: message.CreateAt}); err != nil {
+		return nil, err
+	}
+
+	res, err := s.messageToDTO(ctx, message, false)
+	if err != nil {
+		return nil, err
+	}
+	s.live.Push(recipientID, domain.LiveMessage, res)
+
+	return &res, nil
+}
+
+func (s *ThreadService) MarkRead(user *domain.User, id uuid.UUID) error {
+	thread, err := s.threadRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if !thread.IsParticipant(user.ID) {
+		return apperror.ForbiddenError(errors.New("not a participant"), "You are not a participant of this thread")
+	}
+
+	return s.threadRepo.SaveRead(&domain.ThreadRead{ThreadID: thread.ID, UserID: user.ID, LastReadAt: time.Now()})
+}
+
+func (s *ThreadService) CountUnread(userID uuid.UUID) (int64, error) {
+	return s.threadRepo.CountAllUnread(userID)
+}
+
+// Report lets staff read the thread, see authorizeRead.
+func (s *ThreadService) Report(user *domain.User, id uuid.UUID, category domain.ReportCategory, reason string) error {
+	thread, err := s.threadRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if !thread.IsParticipant(user.ID) {
+		return apperror.ForbiddenError(errors.New("not a participant"), "You are not a participant of this thread")
+	}
+
+	return s.threadRepo.CreateReport(&domain.ThreadReport{
+		ThreadID:   thread.ID,
+		ReporterID: user.ID,
+		Category:   category,
+		Reason:     reason,
+	})
+}
+
+func (s *ThreadService) Block(user *domain.User, blockedID uuid.UUID) error {
+	if blockedID == user.ID {
+		return apperror.BadRequestError(errors.New("block oneself"), "You cannot block yourself")
+	}
+
+	if _, err := s.userRepo.GetUserByID(blockedID); err != nil {
+		return err
+	}
+
+	return s.threadRepo.Block(&domain.UserBlock{BlockerID: user.ID, BlockedID: blockedID})
+}
+
+func (s *ThreadService) Unblock(user *domain.User, blockedID uuid.UUID) error {
+	return s.threadRepo.Unblock(user.ID, blockedID)
+}
+
+func (s *ThreadService) GetBlocks(userID uuid.UUID) ([]domain.UserBlock, error) {
+	return s.threadRepo.GetBlocks(userID)
+}
+
+// authorizeRead lets the participants read a thread. Staff can only read threads
+// a participant attached to a support request or reported.
+func (s *ThreadService) authorizeRead(user *domain.User, id uuid.UUID) (*domain.Thread, error) {
+	thread, err := s.threadRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if thread.IsParticipant(user.ID) {
+		return thread, nil
+	}
+
+	if err := policy.Authorize(user, domain.PermissionSupportManage); err != nil {
+		return nil, apperror.ForbiddenError(err, "You are not a participant of this thread")
+	}
+
+	attached, err := s.threadRepo.IsAttached(thread.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !attached {
+		return nil, apperror.ForbiddenError(errors.New("thread not attached"), "Staff can only read threads attached to a support request or a report")
+	}
+
+	return thread, nil
+}
+
+func (s *ThreadService) checkNotBlocked(userID uuid.UUID, otherID uuid.UUID) error {
+	blocked, err := s.threadRepo.IsBlocked(userID, otherID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return apperror.ForbiddenError(errors.New("user blocked"), "You cannot message this user")
+	}
+	return nil
+}
+
+func (s *ThreadService) toDTO(thread *domain.Thread, viewerID uuid.UUID) (*dto.ThreadResponseBody, error) {
+	res := thread.ToDTO()
+	if !thread.IsParticipant(viewerID) {
+		return &res, nil
+	}
+
+	unread, err := s.threadRepo.CountUnread(thread.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	res.Unread = unread
+
+	otherRead, err := s.threadRepo.GetRead(thread.ID, thread.Other(viewerID))
+	if err != nil {
+		return nil, err
+	}
+	if otherRead != nil {
+		res.OtherLastReadAt = &otherRead.LastReadAt
+	}
+
+	return &res, nil
+}
+
+func (s *ThreadService) messageToDTO(ctx context.Context, message *domain.ThreadMessage, read bool) (dto.ThreadMessageResponseBody, error) {
+	imageURL := ""
+	if message.ImageKey != "" {
+		url, err := s.storage.GetSignedUrl(ctx, message.ImageKey, threadImageURLExpiration)
+		if err != nil {
+			return dto.ThreadMessageResponseBody{}, apperror.InternalServerError(err, "error getting signed url")
+		}
+		imageURL = url
+	}
+	return message.ToDTO(imageURL, read), nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockThreadRepo struct {
+	ports.ThreadRepository
+	threads  []*domain.Thread
+	messages []*domain.ThreadMessage
+	reads    []domain.ThreadRead
+	blocks   []domain.UserBlock
+	attached map[uuid.UUID]bool
+}
+
+// Create ignores a thread about a subject that already has one, like the unique index.
+func (m *mockThreadRepo) Create(thread *domain.Thread) error {
+	if _, err := m.GetBySubject(thread.Subject, thread.SubjectID, thread.LesseeID); err == nil {
+		return nil
+	}
+	thread.ID = uuid.New()
+	m.threads = append(m.threads, thread)
+	return nil
+}
+
+func (m *mockThreadRepo) GetByID(id uuid.UUID) (*domain.Thread, error) {
+	for _, thread := range m.threads {
+		if thread.ID == id {
+			return thread, nil
+		}
+	}
+	return nil, errors.New("thread not found")
+}
+
+func (m *mockThreadRepo) GetBySubject(subject domain.ThreadSubject, subjectID uuid.UUID, lesseeID uuid.UUID) (*domain.Thread, error) {
+	for _, thread := range m.threads {
+		if thread.Subject == subject && thread.SubjectID == subjectID && thread.LesseeID == lesseeID {
+			return thread, nil
+		}
+	}
+	return nil, errors.New("thread not found")
+}
+
+func (m *mockThreadRepo) CreateMessage(thread *domain.Thread, message *domain.ThreadMessage) error {
+	message.ID, message.CreateAt = uuid.New(), time.Now()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *mockThreadRepo) GetMessages(threadID uuid.UUID, limit int, page int) ([]domain.ThreadMessage, int, int, error) {
+	messages := []domain.ThreadMessage{}
+	for _, message := range m.messages {
+		if message.ThreadID == threadID {
+			messages = append(messages, *message)
+		}
+	}
+	return messages, 1, len(messages), nil
+}
+
+func (m *mockThreadRepo) GetRead(threadID uuid.UUID, userID uuid.UUID) (*domain.ThreadRead, error) {
+	for _, read := range m.reads {
+		if read.ThreadID == threadID && read.UserID == userID {
+			return &read, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockThreadRepo) SaveRead(read *domain.ThreadRead) error {
+	m.reads = append(m.reads, *read)
+	return nil
+}
+
+func (m *mockThreadRepo) CountUnread(threadID uuid.UUID, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockThreadRepo) IsAttached(threadID uuid.UUID) (bool, error) {
+	return m.attached[threadID], nil
+}
+
+func (m *mockThreadRepo) Block(block *domain.UserBlock) error {
+	m.blocks = append(m.blocks, *block)
+	return nil
+}
+
+// IsBlocked looks both ways, like the repository.
+func (m *mockThreadRepo) IsBlocked(userID uuid.UUID, otherID uuid.UUID) (bool, error) {
+	for _, block := range m.blocks {
+		if (block.BlockerID == userID && block.BlockedID == otherID) || (block.BlockerID == otherID && block.BlockedID == userID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type threadFixture struct {
+	service *ThreadService
+	repo    *mockThreadRepo
+	live    *mockLivePublisher
+	dorm    *domain.Dorm
+	owner   *domain.User
+	lessee  *domain.User
+}
+
+func newThreadFixture() *threadFixture {
+	owner := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+	lessee := &domain.User{ID: uuid.New(), Role: domain.LesseeRole}
+	dorm := &domain.Dorm{ID: uuid.New(), Name: "Baan Suan", OwnerID: owner.ID}
+	repo := &mockThreadRepo{attached: map[uuid.UUID]bool{}}
+	live := &mockLivePublisher{}
+
+	return &threadFixture{
+		service: &ThreadService{
+			threadRepo: repo,
+			dormRepo:   newMockDormStore(dorm),
+			userRepo:   &mockUserRepo{users: []*domain.User{owner, lessee}},
+			live:       live,
+		},
+		repo:   repo,
+		live:   live,
+		dorm:   dorm,
+		owner:  owner,
+		lessee: lessee,
+	}
+}
+
+// open starts the lessee's thread about the dorm.
+func (f *threadFixture) open(t *testing.T) uuid.UUID {
+	thread, err := f.service.Open(f.lessee, domain.ThreadDorm, f.dorm.ID)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return thread.ID
+}
+
+func TestOpenThread(t *testing.T) {
+	t.Run("first contact creates the thread and the next one reuses it", func(t *testing.T) {
+		f := newThreadFixture()
+
+		id := f.open(t)
+		assert.Equal(t, id, f.open(t))
+		if assert.Len(t, f.repo.threads, 1) {
+			assert.Equal(t, f.lessee.ID, f.repo.threads[0].LesseeID)
+			assert.Equal(t, f.owner.ID, f.repo.threads[0].LessorID)
+		}
+	})
+
+	t.Run("the owner cannot ask about their own dorm", func(t *testing.T) {
+		f := newThreadFixture()
+
+		_, err := f.service.Open(f.owner, domain.ThreadDorm, f.dorm.ID)
+		assert.ErrorContains(t, err, "You cannot message yourself")
+		assert.Empty(t, f.repo.threads)
+	})
+
+	t.Run("blocked either way", func(t *testing.T) {
+		f := newThreadFixture()
+		assert.NoError(t, f.service.Block(f.owner, f.lessee.ID))
+
+		_, err := f.service.Open(f.lessee, domain.ThreadDorm, f.dorm.ID)
+		assert.ErrorContains(t, err, "You cannot message this user")
+		assert.Empty(t, f.repo.threads)
+	})
+}
+
+func TestSendThreadMessage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the recipient is pushed the message", func(t *testing.T) {
+		f := newThreadFixture()
+		id := f.open(t)
+
+		message, err := f.service.Send(ctx, f.lessee, id, "  Is a room free in June?  ", "", "", nil)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Is a room free in June?", message.Body)
+		}
+		assert.Equal(t, []uuid.UUID{f.owner.ID}, f.live.pushed)
+		if assert.Len(t, f.repo.reads, 1) {
+			assert.Equal(t, f.lessee.ID, f.repo.reads[0].UserID)
+		}
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		f := newThreadFixture()
+		id := f.open(t)
+
+		_, err := f.service.Send(ctx, &domain.User{ID: uuid.New(), Role: domain.LesseeRole}, id, "Hello", "", "", nil)
+		assert.ErrorContains(t, err, "You are not a participant of this thread")
+		_, err = f.service.Send(ctx, f.lessee, id, "   ", "", "", nil)
+		assert.ErrorContains(t, err, "Message must have a text or an image")
+
+		assert.NoError(t, f.service.Block(f.lessee, f.owner.ID))
+		_, err = f.service.Send(ctx, f.owner, id, "Hello", "", "", nil)
+		assert.ErrorContains(t, err, "You cannot message this user")
+
+		assert.Empty(t, f.repo.messages)
+		assert.Empty(t, f.live.pushed)
+	})
+}
+
+func TestThreadReadAuthorization(t *testing.T) {
+	ctx := context.Background()
+	f := newThreadFixture()
+	id := f.open(t)
+	_, _ = f.service.Send(ctx, f.lessee, id, "Is a room free in June?", "", "", nil)
+
+	agent := &domain.User{ID: uuid.New(), Role: domain.SupportAgentRole, TwoFactorEnabled: true}
+	read := func(user *domain.User) error {
+		if _, err := f.service.GetByID(user, id); err != nil {
+			return err
+		}
+		_, _, _, err := f.service.GetMessages(ctx, user, id, 10, 1)
+		return err
+	}
+
+	assert.NoError(t, read(f.lessee))
+	assert.NoError(t, read(f.owner))
+	assert.ErrorContains(t, read(&domain.User{ID: uuid.New(), Role: domain.LesseeRole}), "You are not a participant of this thread")
+	assert.ErrorContains(t, read(&domain.User{ID: uuid.New(), Role: domain.AdminRole}), "You are not a participant of this thread")
+	assert.ErrorContains(t, read(agent), "Staff can only read threads attached to a support request or a report")
+
+	f.repo.attached[id] = true
+	assert.NoError(t, read(agent))
+	messages, _, _, _ := f.service.GetMessages(ctx, agent, id, 10, 1)
+	assert.Len(t, messages, 1)
+}
+
+func TestBlockUser(t *testing.T) {
+	f := newThreadFixture()
+
+	assert.ErrorContains(t, f.service.Block(f.lessee, f.lessee.ID), "You cannot block yourself")
+	assert.Error(t, f.service.Block(f.lessee, uuid.New()))
+	assert.Empty(t, f.repo.blocks)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	stripePkg "github.com/PitiNarak/condormhub-backend/pkg/stripe"
 	"github.com/google/uuid"
 	"github.com/stripe/stripe-go/v81"
@@ -18,15 +19,21 @@ type TransactionService struct {
 	leasingHistoryRepo ports.LeasingHistoryRepository
 	stripe             *stripePkg.Stripe
 	receiptService     ports.ReceiptService
+	live               ports.LivePublisher
+	notifier           ports.NotificationPublisher
+	webhooks           ports.WebhookPublisher
 }
 
-func NewTransactionService(tsxRepo ports.TransactionRepository, orderRepo ports.OrderRepository, stripe *stripePkg.Stripe, leasingHistoryRepo ports.LeasingHistoryRepository, receiptService ports.ReceiptService) ports.TransactionService {
+func NewTransactionService(tsxRepo ports.TransactionRepository, orderRepo ports.OrderRepository, stripe *stripePkg.Stripe, leasingHistoryRepo ports.LeasingHistoryRepository, receiptService ports.ReceiptService, live ports.LivePublisher, notifier ports.NotificationPublisher, webhooks ports.WebhookPublisher) ports.TransactionService {
 	return &TransactionService{
 		tsxRepo:            tsxRepo,
 		orderRepo:          orderRepo,
 		leasingHistoryRepo: leasingHistoryRepo,
 		receiptService:     receiptService,
 		stripe:             stripe,
+		live:               live,
+		notifier:           notifier,
+		webhooks:           webhooks,
 	}
 }
 
@@ -104,6 +111,32 @@ func (s *TransactionService) UpdateTransactionStatus(c context.Context, event st
 			return receiptErr
 		}
 
+		event := dto.PaymentCompletedEventBody{
+			OrderID:       order.ID,
+			TransactionID: tsx.ID,
+			Amount:        tsx.Price,
+		}
+		s.live.Push(history.LesseeID, domain.LivePaymentCompleted, event)
+		s.live.Push(history.Dorm.OwnerID, domain.LivePaymentCompleted, event)
+		s.notifier.Publish(domain.NotificationEvent{
+			Type:     domain.NotificationPaymentCompleted,
+			UserID:   history.LesseeID,
+			Title:    "Payment received",
+			Body:     fmt.Sprintf("We received your payment of %d THB for %s.", tsx.Price, history.Dorm.Name),
+			Link:     "/orders/" + order.ID.String(),
+			DormName: history.Dorm.Name,
+			Amount:   float64(tsx.Price),
+		})
+		s.webhooks.Publish(history.Dorm.OwnerID, domain.WebhookOrderPaid, dto.WebhookOrderPaidData{
+			OrderID:          order.ID,
+			TransactionID:    tsx.ID,
+			LeasingHistoryID: history.ID,
+			DormID:           history.DormID,
+			DormName:         history.Dorm.Name,
+			LesseeID:         history.LesseeID,
+			Amount:           tsx.Price,
+		})
+
 		return nil
 	}
 
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
+	"github.com/PitiNarak/condormhub-backend/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+const (
+	RecoveryCodeCount = 10
+
+	// MaxTwoFactorAttempts is how many wrong codes a pending login may send before the password has to be entered again.
+	MaxTwoFactorAttempts = 5
+)
+
+// pendingLogins is the part of jwt.JWTUtils that completes a pending login, so tests can fake it.
+type pendingLogins interface {
+	VerifyTwoFactorToken(ctx context.Context, twoFactorToken string) (uuid.UUID, error)
+	CountTwoFactorFailure(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteTwoFactorToken(ctx context.Context, userID uuid.UUID) error
+	GenerateKeyPair(ctx context.Context, userID uuid.UUID, userAgent string, ip string) (string, string, error)
+}
+
+type TwoFactorService struct {
+	userRepo      ports.UserRepository
+	twoFactorRepo ports.TwoFactorRepository
+	jwtUtils      pendingLogins
+	issuer        string
+	now           func() time.Time
+}
+
+func NewTwoFactorService(userRepo ports.UserRepository, twoFactorRepo ports.TwoFactorRepository, jwtUtils *jwt.JWTUtils, issuer string) ports.TwoFactorService {
+	return &TwoFactorService{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		jwtUtils:      jwtUtils,
+		issuer:        issuer,
+		now:           time.Now,
+	}
+}
+
+// Setup generates a new secret that becomes active once Enable confirms the first code.
+func (s *TwoFactorService) Setup(user *domain.User) (string, string, error) {
+	if user.TwoFactorEnabled {
+		return "", "", apperror.ConflictError(errors.New("two-factor already enabled"), "Two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", apperror.InternalServerError(err, "Failed to generate two-factor secret")
+	}
+
+	user.TwoFactorSecret = secret
+	user.TwoFactorLastStep = 0
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.ProvisioningURI(s.issuer, user.Email, secret), nil
+}
+
+func (s *TwoFactorService) Enable(user *domain.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, apperror.ConflictError(errors.New("two-factor already enabled"), "Two-factor authentication is already enabled")
+	}
+
+	if user.TwoFactorSecret == "" {
+		return nil, apperror.BadRequestError(errors.New("two-factor not set up"), "Set up two-factor authentication first")
+	}
+
+	// only the authenticator app proves the setup worked, there are no recovery codes yet
+	step, ok := totp.Validate(user.TwoFactorSecret, code, s.now())
+	if !ok {
+		return nil, apperror.BadRequestError(errors.New("invalid two-factor code"), "Invalid two-factor code")
+	}
+
+	recoveryCodes, err := s.replaceRecoveryCodes(user)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TwoFactorEnabled = true
+	user.TwoFactorLastStep = step
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s *TwoFactorService) Disable(user *domain.User, code string) error {
+	if user.Role.IsStaff() {
+		return apperror.ForbiddenError(errors.New("two-factor mandatory for staff"), "Staff cannot disable two-factor authentication")
+	}
+
+	if !user.TwoFactorEnabled {
+		return apperror.ConflictError(errors.New("two-factor not enabled"), "Two-factor authentication is not enabled")
+	}
+
+	if err := s.checkCode(user, code); err != nil {
+		return err
+	}
+
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = ""
+	user.TwoFactorLastStep = 0
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return err
+	}
+
+	return s.twoFactorRepo.DeleteRecoveryCodes(user.ID)
+}
+
+func (s *TwoFactorService) RegenerateRecoveryCodes(user *domain.User, code string) ([]string, error) {
+	if !user.TwoFactorEnabled {
+		return nil, apperror.ConflictError(errors.New("two-factor not enabled"), "Two-factor authentication is not enabled")
+	}
+
+	if err := s.checkCode(user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(user)
+}
+
+// Verify completes a login that UserService.Login left pending on the second factor.
+func (s *TwoFactorService) Verify(ctx context.Context, token string, code string, client dto.ClientInfo) (*domain.User, string, string, error) {
+	user, err := s.pendingUser(ctx, token)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, "", "", apperror.BadRequestError(errors.New("two-factor not enabled"), "Set up two-factor authentication first")
+	}
+
+	if err := s.checkCode(user, code); err != nil {
+		return nil, "", "", s.failAttempt(ctx, user.ID, err)
+	}
+
+	accessToken, refreshToken, err := s.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return user, accessToken, refreshToken, nil
+}
+
+// SetupPending is Setup for staff whose login is pending until they enroll.
+func (s *TwoFactorService) SetupPending(ctx context.Context, token string) (string, string, error) {
+	user, err := s.pendingUser(ctx, token)
+	if err != nil {
+		return "", "", err
+	}
+	return s.Setup(user)
+}
+
+// Enroll enables two-factor authentication for staff whose login is pending until
+// they enroll, then completes the login. The recovery codes are shown only once.
+func (s *TwoFactorService) Enroll(ctx context.Context, token string, code string, client dto.ClientInfo) (*domain.User, []string, string, string, error) {
+	user, err := s.pendingUser(ctx, token)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	recoveryCodes, err := s.Enable(user, code)
+	if err != nil {
+		return nil, nil, "", "", s.failAttempt(ctx, user.ID, err)
+	}
+
+	accessToken, refreshToken, err := s.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	return user, recoveryCodes, accessToken, refreshToken, nil
+}
+
+// pendingUser returns the user of a login pending on the second factor.
+func (s *TwoFactorService) pendingUser(ctx context.Context, token string) (*domain.User, error) {
+	userID, err := s.jwtUtils.VerifyTwoFactorToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Banned {
+		return nil, apperror.ForbiddenError(errors.New("account banned"), "account banned")
+	}
+
+	return user, nil
+}
+
+// failAttempt counts a wrong code of a pending login and ends the login after MaxTwoFactorAttempts.
+func (s *TwoFactorService) failAttempt(ctx context.Context, userID uuid.UUID, err error) error {
+	attempts, countErr := s.jwtUtils.CountTwoFactorFailure(ctx, userID)
+	if countErr != nil {
+		return countErr
+	}
+	if attempts >= MaxTwoFactorAttempts {
+		if err := s.jwtUtils.DeleteTwoFactorToken(ctx, userID); err != nil {
+			return err
+		}
+		return apperror.UnauthorizedError(errors.New("too many two-factor attempts"), "Too many attempts, please log in again")
+	}
+	return err
+}
+
+func (s *TwoFactorService) completeLogin(ctx context.Context, user *domain.User, client dto.ClientInfo) (string, string, error) {
+	if err := s.jwtUtils.DeleteTwoFactorToken(ctx, user.ID); err != nil {
+		return "", "", err
+	}
+	return s.jwtUtils.GenerateKeyPair(ctx, user.ID, client.UserAgent, client.IP)
+}
+
+// checkCode accepts either a code from the authenticator app or an unused recovery code.
+func (s *TwoFactorService) checkCode(user *domain.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.TwoFactorSecret, code, s.now())
+		if !ok || step <= user.TwoFactorLastStep {
+			return apperror.UnauthorizedError(errors.New("invalid two-factor code"), "Invalid two-factor code")
+		}
+
+		user.TwoFactorLastStep = step
+		return s.userRepo.UpdateUser(user)
+	}
+
+	used, err := s.twoFactorRepo.UseRecoveryCode(user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return apperror.UnauthorizedError(errors.New("invalid recovery code"), "Invalid two-factor code")
+	}
+
+	return nil
+}
+
+func (s *TwoFactorService) replaceRecoveryCodes(user *domain.User) ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, apperror.InternalServerError(err, "Failed to generate recovery codes")
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode returns 50 random bits formatted as xxxxx-xxxxx.
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// mockTwoFactorRepo keeps the hashes of unused recovery codes per user.
+type mockTwoFactorRepo struct {
+	ports.TwoFactorRepository
+	codes map[uuid.UUID]map[string]bool
+}
+
+func (m *mockTwoFactorRepo) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	m.codes[userID] = map[string]bool{}
+	for _, hash := range codeHashes {
+		m.codes[userID][hash] = true
+	}
+	return nil
+}
+
+func (m *mockTwoFactorRepo) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	if !m.codes[userID][codeHash] {
+		return false, nil
+	}
+	delete(m.codes[userID], codeHash)
+	return true, nil
+}
+
+func (m *mockTwoFactorRepo) DeleteRecoveryCodes(userID uuid.UUID) error {
+	delete(m.codes, userID)
+	return nil
+}
+
+// mockPendingLogins keeps one pending token per user, like redis.
+type mockPendingLogins struct {
+	tokens   map[uuid.UUID]string
+	failures map[uuid.UUID]int64
+}
+
+func (m *mockPendingLogins) start(userID uuid.UUID) string {
+	token := "pending-" + userID.String()
+	m.tokens[userID] = token
+	return token
+}
+
+func (m *mockPendingLogins) VerifyTwoFactorToken(ctx context.Context, twoFactorToken string) (uuid.UUID, error) {
+	for userID, token := range m.tokens {
+		if token == twoFactorToken {
+			return userID, nil
+		}
+	}
+	return uuid.Nil, apperror.UnauthorizedError(nil, "token is expired or token is used")
+}
+
+func (m *mockPendingLogins) CountTwoFactorFailure(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.failures[userID]++
+	return m.failures[userID], nil
+}
+
+func (m *mockPendingLogins) DeleteTwoFactorToken(ctx context.Context, userID uuid.UUID) error {
+	delete(m.tokens, userID)
+	return nil
+}
+
+func (m *mockPendingLogins) GenerateKeyPair(ctx context.Context, userID uuid.UUID, userAgent string, ip string) (string, string, error) {
+	return "access-" + userID.String(), "refresh-" + userID.String(), nil
+}
+
+type twoFactorFixture struct {
+	service  *TwoFactorService
+	logins   *mockPendingLogins
+	recovery *mockTwoFactorRepo
+	now      time.Time
+}
+
+func newTwoFactorFixture(users ...*domain.User) *twoFactorFixture {
+	f := &twoFactorFixture{
+		logins:   &mockPendingLogins{tokens: map[uuid.UUID]string{}, failures: map[uuid.UUID]int64{}},
+		recovery: &mockTwoFactorRepo{codes: map[uuid.UUID]map[string]bool{}},
+		now:      time.Date(2025, time.May, 15, 9, 0, 10, 0, time.UTC),
+	}
+	f.service = &TwoFactorService{
+		userRepo:      &mockUserRepo{users: users},
+		twoFactorRepo: f.recovery,
+		jwtUtils:      f.logins,
+		issuer:        "ConDormHub",
+		now:           func() time.Time { return f.now },
+	}
+	return f
+}
+
+// code returns the code the authenticator app shows steps periods from now.
+func (f *twoFactorFixture) code(t *testing.T, user *domain.User, steps int64) string {
+	code, err := totp.Code(user.TwoFactorSecret, totp.Step(f.now)+steps)
+	assert.NoError(t, err)
+	return code
+}
+
+// enable sets up two-factor authentication as the user would and returns the recovery codes.
+func (f *twoFactorFixture) enable(t *testing.T, user *domain.User) []string {
+	_, _, err := f.service.Setup(user)
+	assert.NoError(t, err)
+	codes, err := f.service.Enable(user, f.code(t, user, 0))
+	assert.NoError(t, err)
+	// the next login comes a while later
+	f.now = f.now.Add(time.Minute)
+	return codes
+}
+
+func TestTwoFactorEnable(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Email: "somchai@example.com", Role: domain.LessorRole}
+	f := newTwoFactorFixture(user)
+
+	secret, uri, err := f.service.Setup(user)
+	assert.NoError(t, err)
+	assert.Equal(t, secret, user.TwoFactorSecret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/ConDormHub:somchai@example.com?"))
+	assert.False(t, user.TwoFactorEnabled, "enabled only once a code is confirmed")
+
+	_, err = f.service.Enable(user, "000000")
+	assert.ErrorContains(t, err, "Invalid two-factor code")
+	assert.False(t, user.TwoFactorEnabled)
+
+	codes, err := f.service.Enable(user, f.code(t, user, 0))
+	if assert.NoError(t, err) {
+		assert.True(t, user.TwoFactorEnabled)
+		assert.Len(t, codes, RecoveryCodeCount)
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+		assert.Len(t, f.recovery.codes[user.ID], RecoveryCodeCount, "only hashes are stored")
+		assert.NotContains(t, f.recovery.codes[user.ID], codes[0])
+	}
+
+	_, _, err = f.service.Setup(user)
+	assert.ErrorContains(t, err, "Two-factor authentication is already enabled")
+}
+
+func TestTwoFactorCodeWindow(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		steps int64
+		valid bool
+	}{
+		{"current period", 0, true},
+		{"previous period", -1, true},
+		{"next period", 1, true},
+		{"two periods ago", -2, false},
+		{"two periods ahead", 2, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			user := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+			f := newTwoFactorFixture(user)
+			f.enable(t, user)
+
+			err := f.service.checkCode(user, f.code(t, user, tc.steps))
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "Invalid two-factor code")
+			}
+		})
+	}
+}
+
+func TestTwoFactorReplayedCode(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+	f := newTwoFactorFixture(user)
+	f.enable(t, user)
+
+	code := f.code(t, user, 0)
+	assert.NoError(t, f.service.checkCode(user, code))
+	assert.ErrorContains(t, f.service.checkCode(user, code), "Invalid two-factor code")
+
+	// an older code that is still in the window cannot be used after a newer one
+	assert.ErrorContains(t, f.service.checkCode(user, f.code(t, user, -1)), "Invalid two-factor code")
+
+	// the code used to enable cannot log in either
+	other := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+	g := newTwoFactorFixture(other)
+	_, _, _ = g.service.Setup(other)
+	enableCode := g.code(t, other, 0)
+	_, err := g.service.Enable(other, enableCode)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, g.service.checkCode(other, enableCode), "Invalid two-factor code")
+}
+
+func TestTwoFactorRecoveryCodes(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+	f := newTwoFactorFixture(user)
+	codes := f.enable(t, user)
+
+	assert.NoError(t, f.service.checkCode(user, codes[0]))
+	assert.ErrorContains(t, f.service.checkCode(user, codes[0]), "Invalid two-factor code", "each code is used once")
+
+	// typed without the dash, in upper case
+	assert.NoError(t, f.service.checkCode(user, " "+strings.ToUpper(strings.ReplaceAll(codes[1], "-", ""))+" "))
+
+	fresh, err := f.service.RegenerateRecoveryCodes(user, codes[2])
+	if assert.NoError(t, err) {
+		assert.Len(t, fresh, RecoveryCodeCount)
+	}
+	assert.ErrorContains(t, f.service.checkCode(user, codes[3]), "Invalid two-factor code", "old codes are replaced")
+	assert.NoError(t, f.service.checkCode(user, fresh[0]))
+}
+
+func TestTwoFactorVerify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("code completes the login", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+		f := newTwoFactorFixture(user)
+		f.enable(t, user)
+		token := f.logins.start(user.ID)
+
+		_, access, refresh, err := f.service.Verify(ctx, token, f.code(t, user, 0), dto.ClientInfo{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "access-"+user.ID.String(), access)
+			assert.Equal(t, "refresh-"+user.ID.String(), refresh)
+		}
+
+		_, _, _, err = f.service.Verify(ctx, token, f.code(t, user, 1), dto.ClientInfo{})
+		assert.ErrorContains(t, err, "token is expired or token is used")
+	})
+
+	t.Run("too many wrong codes end the login", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+		f := newTwoFactorFixture(user)
+		f.enable(t, user)
+		token := f.logins.start(user.ID)
+
+		for i := 1; i < MaxTwoFactorAttempts; i++ {
+			_, _, _, err := f.service.Verify(ctx, token, "000000", dto.ClientInfo{})
+			assert.ErrorContains(t, err, "Invalid two-factor code")
+		}
+		_, _, _, err := f.service.Verify(ctx, token, "000000", dto.ClientInfo{})
+		assert.ErrorContains(t, err, "Too many attempts, please log in again")
+
+		_, _, _, err = f.service.Verify(ctx, token, f.code(t, user, 0), dto.ClientInfo{})
+		assert.ErrorContains(t, err, "token is expired or token is used")
+	})
+
+	t.Run("banned user", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+		f := newTwoFactorFixture(user)
+		f.enable(t, user)
+		token := f.logins.start(user.ID)
+		user.Banned = true
+
+		_, _, _, err := f.service.Verify(ctx, token, f.code(t, user, 0), dto.ClientInfo{})
+		assert.ErrorContains(t, err, "account banned")
+	})
+
+	t.Run("staff who did not enroll", func(t *testing.T) {
+		admin := &domain.User{ID: uuid.New(), Role: domain.AdminRole}
+		f := newTwoFactorFixture(admin)
+		token := f.logins.start(admin.ID)
+
+		_, _, _, err := f.service.Verify(ctx, token, "000000", dto.ClientInfo{})
+		assert.ErrorContains(t, err, "Set up two-factor authentication first")
+	})
+}
+
+func TestTwoFactorEnroll(t *testing.T) {
+	ctx := context.Background()
+	admin := &domain.User{ID: uuid.New(), Email: "admin@example.com", Role: domain.AdminRole}
+	f := newTwoFactorFixture(admin)
+	token := f.logins.start(admin.ID)
+
+	_, _, _, _, err := f.service.Enroll(ctx, token, "000000", dto.ClientInfo{})
+	assert.ErrorContains(t, err, "Set up two-factor authentication first")
+
+	_, uri, err := f.service.SetupPending(ctx, token)
+	assert.NoError(t, err)
+	assert.Contains(t, uri, "admin@example.com")
+
+	_, _, _, _, err = f.service.Enroll(ctx, token, "000000", dto.ClientInfo{})
+	assert.ErrorContains(t, err, "Invalid two-factor code")
+	assert.Equal(t, int64(2), f.logins.failures[admin.ID], "failed attempts count toward the limit")
+
+	user, codes, access, _, err := f.service.Enroll(ctx, token, f.code(t, admin, 0), dto.ClientInfo{})
+	if assert.NoError(t, err) {
+		assert.True(t, user.TwoFactorEnabled)
+		assert.Len(t, codes, RecoveryCodeCount)
+		assert.Equal(t, "access-"+admin.ID.String(), access)
+	}
+
+	_, _, err = f.service.SetupPending(ctx, token)
+	assert.ErrorContains(t, err, "token is expired or token is used")
+
+	assert.ErrorContains(t, f.service.Disable(admin, codes[0]), "Staff cannot disable two-factor authentication")
+}
@@ -5,28 +5,52 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/PitiNarak/condormhub-backend/pkg/email"
 	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
 	"github.com/PitiNarak/condormhub-backend/pkg/storage"
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
 	"github.com/yokeTH/go-pkg/apperror"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// userTokens is the part of jwt.JWTUtils that manages sessions and one-time tokens, so tests can fake it.
+type userTokens interface {
+	GenerateKeyPair(ctx context.Context, userID uuid.UUID, userAgent string, ip string) (string, string, error)
+	RefreshToken(ctx context.Context, refreshToken string) (string, string, error)
+	GetSessions(ctx context.Context, userID uuid.UUID) ([]redis.Session, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keepSessionID string) error
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+	GenerateTwoFactorToken(ctx context.Context, userID uuid.UUID) (string, error)
+	GenerateVerificationToken(ctx context.Context, userID uuid.UUID) (string, error)
+	VerifyVerificationToken(ctx context.Context, verificationToken string) (uuid.UUID, error)
+	DeleteVerificationToken(ctx context.Context, userID uuid.UUID) error
+	GenerateResetPasswordToken(ctx context.Context, userID uuid.UUID) (string, error)
+	VerifyResetPasswordToken(ctx context.Context, resetToken string) (uuid.UUID, error)
+	DeleteResetPasswordToken(ctx context.Context, userID uuid.UUID) error
+	GenerateEmailChangeToken(ctx context.Context, userID uuid.UUID, newEmail string) (string, error)
+	VerifyEmailChangeToken(ctx context.Context, emailChangeToken string) (uuid.UUID, string, error)
+	DeleteEmailChangeToken(ctx context.Context, userID uuid.UUID) error
+}
+
 type UserService struct {
 	userRepo     ports.UserRepository
-	emailService email.Email
-	jwtUtils     *jwt.JWTUtils
+	emailService email.Sender
+	jwtUtils     userTokens
 	storage      *storage.Storage
 }
 
-func NewUserService(UserRepo ports.UserRepository, EmailService email.Email, jwtUtils *jwt.JWTUtils, storage *storage.Storage) ports.UserService {
+func NewUserService(UserRepo ports.UserRepository, EmailService email.Sender, jwtUtils *jwt.JWTUtils, storage *storage.Storage) ports.UserService {
 	return &UserService{userRepo: UserRepo, emailService: EmailService, jwtUtils: jwtUtils, storage: storage}
 }
 
@@ -55,7 +79,7 @@ func (s *UserService) GetStudentEvidenceDTO(c context.Context, studentEvidence s
 	return res, nil
 }
 
-func (s *UserService) Create(ctx context.Context, user *domain.User) (string, string, error) {
+func (s *UserService) Create(ctx context.Context, user *domain.User, client dto.ClientInfo) (string, string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 
 	if err != nil {
@@ -68,7 +92,7 @@ func (s *UserService) Create(ctx context.Context, user *domain.User) (string, st
 		return "", "", create_err
 	}
 
-	accessToken, refreshToken, err := s.jwtUtils.GenerateKeyPair(ctx, user.ID)
+	accessToken, refreshToken, err := s.jwtUtils.GenerateKeyPair(ctx, user.ID, client.UserAgent, client.IP)
 	if err != nil {
 		return "", "", err
 	}
@@ -78,14 +102,14 @@ func (s *UserService) Create(ctx context.Context, user *domain.User) (string, st
 		return "", "", err
 	}
 
-	err = s.emailService.SendVerificationEmail(user.Email, user.Username, verifyToken)
-	if err != nil {
-		return "", "", err
+	// the user is already created, they can ask for the email again
+	if err := s.emailService.SendVerificationEmail(recipient(user), verifyToken); err != nil {
+		log.Errorf("user: cannot queue verification email of user %s: %v", user.ID, err)
 	}
 	return accessToken, refreshToken, nil
 }
 
-func (s *UserService) VerifyUser(ctx context.Context, token string) (*domain.User, string, string, error) {
+func (s *UserService) VerifyUser(ctx context.Context, token string, client dto.ClientInfo) (*domain.User, string, string, error) {
 	userID, err := s.jwtUtils.VerifyVerificationToken(ctx, token)
 	if err != nil {
 		return nil, "", "", err
@@ -111,7 +135,12 @@ func (s *UserService) VerifyUser(ctx context.Context, token string) (*domain.Use
 		return nil, "", "", err
 	}
 
-	accessToken, refreshToken, err := s.jwtUtils.GenerateKeyPair(ctx, userID)
+	// the email link is not a second factor, log in normally instead
+	if user.TwoFactorEnabled {
+		return user, "", "", nil
+	}
+
+	accessToken, refreshToken, err := s.jwtUtils.GenerateKeyPair(ctx, userID, client.UserAgent, client.IP)
 	if err != nil {
 		return nil, "", "", apperror.InternalServerError(err, "generate key failed")
 	}
@@ -119,26 +148,39 @@ func (s *UserService) VerifyUser(ctx context.Context, token string) (*domain.Use
 	return user, accessToken, refreshToken, nil
 }
 
-func (s *UserService) Login(ctx context.Context, email string, password string) (*domain.User, string, string, error) {
+// Login checks the password. With two-factor authentication enabled it returns a
+// pending token to exchange at TwoFactorService.Verify instead of the key pair.
+func (s *UserService) Login(ctx context.Context, email string, password string, client dto.ClientInfo) (*domain.User, string, string, string, error) {
 	user, getErr := s.userRepo.GetUserByEmail(email)
 	if getErr != nil {
-		return nil, "", "", getErr
+		// do not tell which emails have an account
+		return nil, "", "", "", apperror.UnauthorizedError(getErr, "invalid email or password.")
 	}
 
 	if user.Banned {
-		return nil, "", "", apperror.ForbiddenError(errors.New("account banned"), "account banned")
+		return nil, "", "", "", apperror.ForbiddenError(errors.New("account banned"), "account banned")
 	}
 
 	compareErr := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if compareErr != nil {
-		return nil, "", "", apperror.UnauthorizedError(compareErr, "invalid email or password.")
+		return nil, "", "", "", apperror.UnauthorizedError(compareErr, "invalid email or password.")
+	}
+
+	// staff without two-factor authentication have to enroll before they get a key pair
+	if user.TwoFactorEnabled || user.Role.IsStaff() {
+		twoFactorToken, err := s.jwtUtils.GenerateTwoFactorToken(ctx, user.ID)
+		if err != nil {
+			return nil, "", "", "", err
+		}
+		return user, "", "", twoFactorToken, nil
 	}
-	accessToken, refreshToken, generateErr := s.jwtUtils.GenerateKeyPair(ctx, user.ID)
+
+	accessToken, refreshToken, generateErr := s.jwtUtils.GenerateKeyPair(ctx, user.ID, client.UserAgent, client.IP)
 	if generateErr != nil {
-		return nil, "", "", generateErr
+		return nil, "", "", "", generateErr
 	}
 
-	return user, accessToken, refreshToken, nil
+	return user, accessToken, refreshToken, "", nil
 
 }
 
@@ -150,7 +192,65 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (st
 	return accessToken, newRefreshToken, nil
 }
 
-func (s *UserService) UpdateInformation(userID uuid.UUID, data dto.UserInformationRequestBody) (*domain.User, error) {
+func (s *UserService) GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]domain.Session, error) {
+	redisSessions, err := s.jwtUtils.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]domain.Session, len(redisSessions))
+	for i, v := range redisSessions {
+		sessions[i] = domain.Session{
+			ID:         v.ID,
+			UserAgent:  v.UserAgent,
+			IP:         v.IP,
+			CreatedAt:  v.CreatedAt,
+			LastSeenAt: v.LastSeenAt,
+			Current:    v.ID == currentSessionID,
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (s *UserService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	sessions, err := s.jwtUtils.GetSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range sessions {
+		if v.ID == sessionID {
+			return s.jwtUtils.RevokeSession(ctx, userID, sessionID)
+		}
+	}
+
+	return apperror.NotFoundError(errors.New("session not found"), "Session not found")
+}
+
+func (s *UserService) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) error {
+	return s.jwtUtils.RevokeOtherSessions(ctx, userID, currentSessionID)
+}
+
+func (s *UserService) Logout(ctx context.Context, userID uuid.UUID, sessionID string, all bool) error {
+	if all {
+		return s.jwtUtils.RevokeAllSessions(ctx, userID)
+	}
+	return s.jwtUtils.RevokeSession(ctx, userID, sessionID)
+}
+
+// UpdateInformation logs out the other sessions when the password is changed,
+// sessionID is the session making the change.
+func (s *UserService) UpdateInformation(ctx context.Context, userID uuid.UUID, sessionID string, data dto.UserInformationRequestBody) (*domain.User, error) {
+	current, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
 	if data.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
 		if err != nil {
@@ -174,22 +274,38 @@ func (s *UserService) UpdateInformation(userID uuid.UUID, data dto.UserInformati
 		Lifestyles:      lifestyles,
 		BirthDate:       data.BirthDate,
 		PhoneNumber:     data.PhoneNumber,
+		Language:        data.Language,
 	}
 
-	err := s.userRepo.UpdateInformation(userID, user)
+	err = s.userRepo.UpdateInformation(userID, user)
 	if err != nil {
 		return nil, err
 	}
 
+	// whoever knew the old password must not stay logged in
+	if data.Password != "" {
+		if err := s.jwtUtils.RevokeOtherSessions(ctx, userID, sessionID); err != nil {
+			return nil, err
+		}
+	}
+
 	userInfo, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.resetPhoneVerification(userInfo, current.PhoneNumber); err != nil {
+		return nil, err
+	}
+
 	return userInfo, nil
 }
 
 func (s *UserService) FirstFillInformation(userID uuid.UUID, data dto.UserFirstFillRequestBody) (*domain.User, error) {
+	current, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
 
 	lifestyles := make([]domain.Lifestyle, len(data.Lifestyles))
 	for i, v := range data.Lifestyles {
@@ -208,7 +324,7 @@ func (s *UserService) FirstFillInformation(userID uuid.UUID, data dto.UserFirstF
 		FilledPersonalInfo: true,
 	}
 
-	err := s.userRepo.UpdateInformation(userID, user)
+	err = s.userRepo.UpdateInformation(userID, user)
 	if err != nil {
 		return nil, err
 	}
@@ -218,9 +334,90 @@ func (s *UserService) FirstFillInformation(userID uuid.UUID, data dto.UserFirstF
 		return nil, err
 	}
 
+	if err := s.resetPhoneVerification(userInfo, current.PhoneNumber); err != nil {
+		return nil, err
+	}
+
 	return userInfo, nil
 }
 
+// resetPhoneVerification takes the verified badge away when the phone number changed.
+func (s *UserService) resetPhoneVerification(user *domain.User, oldPhoneNumber string) error {
+	if !user.PhoneVerified || user.PhoneNumber == oldPhoneNumber {
+		return nil
+	}
+
+	user.PhoneVerified = false
+	return s.userRepo.UpdateUser(user)
+}
+
+// RequestEmailChange sends a confirmation link to the new address. The email
+// only changes once the link is opened, see ConfirmEmailChange.
+func (s *UserService) RequestEmailChange(ctx context.Context, user *domain.User, newEmail string, password string) error {
+	// users created through a provider have no password to confirm
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			return apperror.UnauthorizedError(err, "invalid password")
+		}
+	}
+
+	if strings.EqualFold(newEmail, user.Email) {
+		return apperror.BadRequestError(errors.New("same email"), "This is already your email")
+	}
+
+	if _, err := s.userRepo.GetUserByEmail(newEmail); err == nil {
+		return apperror.ConflictError(errors.New("email taken"), "This email is already in use")
+	}
+
+	token, err := s.jwtUtils.GenerateEmailChangeToken(ctx, user.ID, newEmail)
+	if err != nil {
+		return err
+	}
+
+	to := recipient(user)
+	to.Email = newEmail
+	return s.emailService.SendEmailChangeEmail(to, token)
+}
+
+// ConfirmEmailChange switches the account to the address the token was sent to
+// and lets the old address know.
+func (s *UserService) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	userID, newEmail, err := s.jwtUtils.VerifyEmailChangeToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// someone may have signed up with the address since the change was requested
+	if other, err := s.userRepo.GetUserByEmail(newEmail); err == nil && other.ID != user.ID {
+		return nil, apperror.ConflictError(errors.New("email taken"), "This email is already in use")
+	}
+
+	oldEmail := user.Email
+	user.Email = newEmail
+	user.IsVerified = true
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.jwtUtils.DeleteEmailChangeToken(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// the change is done, a lost notification must not undo it
+	to := recipient(user)
+	to.Email = oldEmail
+	if err := s.emailService.SendEmailChangedEmail(to, newEmail); err != nil {
+		log.Errorf("user: cannot notify %s of email change: %v", oldEmail, err)
+	}
+
+	return user, nil
+}
+
 func (s *UserService) GetUserByEmail(email string) (*domain.User, error) {
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
@@ -252,14 +449,14 @@ func (s *UserService) ResetPasswordCreate(ctx context.Context, email string) err
 	if err != nil {
 		return err
 	}
-	err = s.emailService.SendResetPasswordEmail(user.Email, user.Username, token)
+	err = s.emailService.SendResetPasswordEmail(recipient(user), token)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *UserService) ResetPassword(ctx context.Context, token string, password string) (*domain.User, string, string, error) {
+func (s *UserService) ResetPassword(ctx context.Context, token string, password string, client dto.ClientInfo) (*domain.User, string, string, error) {
 	userID, err := s.jwtUtils.VerifyResetPasswordToken(ctx, token)
 	if err != nil {
 		return nil, "", "", err
@@ -289,7 +486,17 @@ func (s *UserService) ResetPassword(ctx context.Context, token string, password
 		return nil, "", "", err
 	}
 
-	accessToken, refreshToken, err := s.jwtUtils.GenerateKeyPair(ctx, userID)
+	// whoever knew the old password must not stay logged in
+	if err := s.jwtUtils.RevokeAllSessions(ctx, userID); err != nil {
+		return nil, "", "", err
+	}
+
+	// the reset link is not a second factor, log in normally instead
+	if user.TwoFactorEnabled {
+		return user, "", "", nil
+	}
+
+	accessToken, refreshToken, err := s.jwtUtils.GenerateKeyPair(ctx, userID, client.UserAgent, client.IP)
 	if err != nil {
 		return nil, "", "", err
 	}
@@ -297,12 +504,12 @@ func (s *UserService) ResetPassword(ctx context.Context, token string, password
 	return user, accessToken, refreshToken, nil
 }
 
-func (s *UserService) DeleteAccount(userID uuid.UUID) error {
+func (s *UserService) DeleteAccount(ctx context.Context, userID uuid.UUID) error {
 	err := s.userRepo.DeleteAccount(userID)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.jwtUtils.RevokeAllSessions(ctx, userID)
 }
 
 func (s *UserService) UploadStudentEvidence(ctx context.Context, filename string, contentType string, fileData io.Reader, userID uuid.UUID) (string, error) {
@@ -343,9 +550,9 @@ func (s *UserService) UploadStudentEvidence(ctx context.Context, filename string
 	return url, nil
 }
 
-func (s *UserService) GetStudentEvidenceByID(ctx context.Context, id uuid.UUID, isSelf bool, isAdmin bool) (*dto.StudentEvidenceUploadResponseBody, error) {
-	if !isSelf && !isAdmin {
-		return nil, apperror.ForbiddenError(errors.New("unauthorized action"), "You do not have permission to view this evidence")
+func (s *UserService) GetStudentEvidenceByID(ctx context.Context, id uuid.UUID, viewer *domain.User) (*dto.StudentEvidenceUploadResponseBody, error) {
+	if err := policy.AuthorizeOwner(viewer, id, domain.PermissionUserVerify); err != nil {
+		return nil, apperror.ForbiddenError(err, "You do not have permission to view this evidence")
 	}
 
 	user, err := s.GetUserByID(id)
@@ -367,7 +574,7 @@ func (s *UserService) ResendVerificationEmailService(ctx context.Context, email
 		return err
 	}
 
-	if err = s.emailService.SendVerificationEmail(user.Email, user.Username, verifyToken); err != nil {
+	if err = s.emailService.SendVerificationEmail(recipient(user), verifyToken); err != nil {
 		return err
 	}
 	return nil
@@ -403,18 +610,39 @@ func (s *UserService) UploadProfilePicture(ctx context.Context, filename string,
 	return url, nil
 }
 
-func (s *UserService) GetLessorIncome(lessorID uuid.UUID, userRole domain.Role) (float64, error) {
-	if userRole != domain.LessorRole {
-		return 0, apperror.ForbiddenError(errors.New("unauthorized action"), "User is not a lessor")
+func (s *UserService) GetLessorIncome(user *domain.User) (float64, error) {
+	if err := policy.Authorize(user, domain.PermissionIncomeRead); err != nil {
+		return 0, apperror.ForbiddenError(err, "User is not a lessor")
+	}
+	return s.userRepo.GetLessorIncome(user.ID)
+}
+
+// UpdateUserRole assigns a role, e.g. to make a user a support agent.
+func (s *UserService) UpdateUserRole(admin *domain.User, id uuid.UUID, role domain.Role) (*domain.User, error) {
+	user, err := s.userRepo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.AuthorizeRoleChange(admin, user, role); err != nil {
+		return nil, apperror.ForbiddenError(err, "You cannot change the role of this user")
+	}
+
+	user.Role = role
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
 	}
-	return s.userRepo.GetLessorIncome(lessorID)
+
+	return user, nil
 }
 
-func (s *UserService) UpdateUserBanStatus(id uuid.UUID, ban bool) (*domain.User, error) {
+func (s *UserService) UpdateUserBanStatus(ctx context.Context, admin *domain.User, id uuid.UUID, ban bool) (*domain.User, error) {
 	user, err := s.userRepo.GetUserByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if err := policy.AuthorizeOverUser(admin, user, domain.PermissionUserBan); err != nil {
+		return nil, apperror.ForbiddenError(err, "You cannot change the ban status of this user")
+	}
 	if user.Banned == ban {
 		msg := "user already banned"
 		if !user.Banned {
@@ -423,7 +651,17 @@ func (s *UserService) UpdateUserBanStatus(id uuid.UUID, ban bool) (*domain.User,
 		return nil, apperror.ConflictError(errors.New(msg), msg)
 	}
 	user.Banned = ban
-	return user, s.userRepo.UpdateUser(user)
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	if ban {
+		if err := s.jwtUtils.RevokeAllSessions(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
 }
 
 func (s *UserService) GetPending(limit int, page int) ([]domain.User, int, int, error) {
@@ -443,3 +681,8 @@ func (s *UserService) UpdateVerificationStatus(lesseeID uuid.UUID, status domain
 	lessee.IsStudentVerified = status
 	return lessee, s.userRepo.UpdateUser(lessee)
 }
+
+// recipient addresses an email to the user in their language.
+func recipient(user *domain.User) email.Recipient {
+	return email.Recipient{Email: user.Email, Name: user.Username, Language: user.Language}
+}
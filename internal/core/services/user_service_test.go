@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserSessions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now()
+
+	t.Run("most recently seen first and the current one marked", func(t *testing.T) {
+		tokens := newMockTokens()
+		tokens.sessions[userID] = []redis.Session{
+			{ID: "laptop", UserAgent: "Firefox", LastSeenAt: now.Add(-time.Hour)},
+			{ID: "phone", UserAgent: "Safari", LastSeenAt: now},
+		}
+		service := &UserService{jwtUtils: tokens}
+
+		sessions, err := service.GetSessions(ctx, userID, "laptop")
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Session{
+			{ID: "phone", UserAgent: "Safari", LastSeenAt: now},
+			{ID: "laptop", UserAgent: "Firefox", LastSeenAt: now.Add(-time.Hour), Current: true},
+		}, sessions)
+	})
+
+	t.Run("only the user's own session can be revoked", func(t *testing.T) {
+		tokens := newMockTokens()
+		other := uuid.New()
+		tokens.sessions[userID] = []redis.Session{{ID: "laptop"}, {ID: "phone"}}
+		tokens.sessions[other] = []redis.Session{{ID: "theirs"}}
+		service := &UserService{jwtUtils: tokens}
+
+		assert.ErrorContains(t, service.RevokeSession(ctx, userID, "theirs"), "Session not found")
+		assert.Len(t, tokens.sessions[other], 1)
+
+		assert.NoError(t, service.RevokeSession(ctx, userID, "phone"))
+		assert.Equal(t, []redis.Session{{ID: "laptop"}}, tokens.sessions[userID])
+	})
+
+	t.Run("revoke the other sessions", func(t *testing.T) {
+		tokens := newMockTokens()
+		tokens.sessions[userID] = []redis.Session{{ID: "laptop"}, {ID: "phone"}, {ID: "tablet"}}
+		service := &UserService{jwtUtils: tokens}
+
+		assert.NoError(t, service.RevokeOtherSessions(ctx, userID, "phone"))
+		assert.Equal(t, []redis.Session{{ID: "phone"}}, tokens.sessions[userID])
+	})
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("current session", func(t *testing.T) {
+		tokens := newMockTokens()
+		tokens.sessions[userID] = []redis.Session{{ID: "laptop"}, {ID: "phone"}}
+		service := &UserService{jwtUtils: tokens}
+
+		assert.NoError(t, service.Logout(ctx, userID, "phone", false))
+		assert.Equal(t, []redis.Session{{ID: "laptop"}}, tokens.sessions[userID])
+	})
+
+	t.Run("every session", func(t *testing.T) {
+		tokens := newMockTokens()
+		tokens.sessions[userID] = []redis.Session{{ID: "laptop"}, {ID: "phone"}}
+		service := &UserService{jwtUtils: tokens}
+
+		assert.NoError(t, service.Logout(ctx, userID, "phone", true))
+		assert.Empty(t, tokens.sessions[userID])
+	})
+}
+
+func TestRevokeSessionsOnAccountChange(t *testing.T) {
+	ctx := context.Background()
+	client := dto.ClientInfo{UserAgent: "Firefox", IP: "10.0.0.1"}
+
+	t.Run("password reset ends every session and starts a new one", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New()}
+		tokens := newMockTokens()
+		tokens.sessions[user.ID] = []redis.Session{{ID: "laptop"}, {ID: "phone"}}
+		tokens.resets["reset"] = user.ID
+		service := &UserService{userRepo: &mockUserRepo{users: []*domain.User{user}}, jwtUtils: tokens}
+
+		_, access, _, err := service.ResetPassword(ctx, "reset", "new-password", client)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, access)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")))
+		if assert.Len(t, tokens.sessions[user.ID], 1) {
+			assert.Equal(t, "Firefox", tokens.sessions[user.ID][0].UserAgent)
+		}
+
+		_, _, _, err = service.ResetPassword(ctx, "reset", "another-password", client)
+		assert.ErrorContains(t, err, "token is expired or token is used")
+	})
+
+	t.Run("password reset of a two-factor user does not log in", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), TwoFactorEnabled: true}
+		tokens := newMockTokens()
+		tokens.sessions[user.ID] = []redis.Session{{ID: "laptop"}}
+		tokens.resets["reset"] = user.ID
+		service := &UserService{userRepo: &mockUserRepo{users: []*domain.User{user}}, jwtUtils: tokens}
+
+		_, access, refresh, err := service.ResetPassword(ctx, "reset", "new-password", client)
+		assert.NoError(t, err)
+		assert.Empty(t, access)
+		assert.Empty(t, refresh)
+		assert.Empty(t, tokens.sessions[user.ID])
+	})
+
+	t.Run("password change keeps only the session making it", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New()}
+		tokens := newMockTokens()
+		tokens.sessions[user.ID] = []redis.Session{{ID: "laptop"}, {ID: "phone"}}
+		service := &UserService{userRepo: &mockUserRepo{users: []*domain.User{user}}, jwtUtils: tokens}
+
+		_, err := service.UpdateInformation(ctx, user.ID, "laptop", dto.UserInformationRequestBody{Firstname: "Somchai"})
+		assert.NoError(t, err)
+		assert.Len(t, tokens.sessions[user.ID], 2)
+
+		_, err = service.UpdateInformation(ctx, user.ID, "laptop", dto.UserInformationRequestBody{Password: "new-password"})
+		assert.NoError(t, err)
+		assert.Equal(t, []redis.Session{{ID: "laptop"}}, tokens.sessions[user.ID])
+	})
+
+	t.Run("ban ends every session", func(t *testing.T) {
+		admin := &domain.User{ID: uuid.New(), Role: domain.AdminRole, TwoFactorEnabled: true}
+		user := &domain.User{ID: uuid.New(), Role: domain.LesseeRole}
+		tokens := newMockTokens()
+		tokens.sessions[user.ID] = []redis.Session{{ID: "laptop"}, {ID: "phone"}}
+		tokens.sessions[admin.ID] = []redis.Session{{ID: "admin"}}
+		service := &UserService{userRepo: &mockUserRepo{users: []*domain.User{admin, user}}, jwtUtils: tokens}
+
+		_, err := service.UpdateUserBanStatus(ctx, user, user.ID, true)
+		assert.ErrorContains(t, err, "You cannot change the ban status of this user")
+		assert.Len(t, tokens.sessions[user.ID], 2)
+
+		banned, err := service.UpdateUserBanStatus(ctx, admin, user.ID, true)
+		if assert.NoError(t, err) {
+			assert.True(t, banned.Banned)
+		}
+		assert.Empty(t, tokens.sessions[user.ID])
+		assert.Len(t, tokens.sessions[admin.ID], 1)
+
+		_, err = service.UpdateUserBanStatus(ctx, admin, user.ID, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("account deletion ends every session", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New()}
+		tokens := newMockTokens()
+		tokens.sessions[user.ID] = []redis.Session{{ID: "laptop"}}
+		users := &mockUserRepo{users: []*domain.User{user}}
+		service := &UserService{userRepo: users, jwtUtils: tokens}
+
+		assert.NoError(t, service.DeleteAccount(ctx, user.ID))
+		assert.Empty(t, users.users)
+		assert.Empty(t, tokens.sessions[user.ID])
+	})
+}
+
+func TestEmailChange(t *testing.T) {
+	ctx := context.Background()
+	password, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+
+	newFixture := func() (*UserService, *domain.User, *mockTokens, *mockEmailSender) {
+		user := &domain.User{ID: uuid.New(), Email: "somchai@example.com", Password: string(password)}
+		taken := &domain.User{ID: uuid.New(), Email: "malee@example.com"}
+		tokens := newMockTokens()
+		emails := &mockEmailSender{}
+		return &UserService{userRepo: &mockUserRepo{users: []*domain.User{user, taken}}, jwtUtils: tokens, emailService: emails}, user, tokens, emails
+	}
+
+	t.Run("the new address confirms the change and the old one is told", func(t *testing.T) {
+		service, user, tokens, emails := newFixture()
+
+		assert.NoError(t, service.RequestEmailChange(ctx, user, "somchai.k@example.com", "password"))
+		assert.Equal(t, "somchai@example.com", user.Email)
+		if assert.Len(t, emails.sent, 1) {
+			assert.Equal(t, "somchai.k@example.com", emails.sent[0].to)
+		}
+
+		token := tokens.emailChanges[user.ID].token
+		changed, err := service.ConfirmEmailChange(ctx, token)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "somchai.k@example.com", changed.Email)
+			assert.True(t, changed.IsVerified)
+		}
+		if assert.Len(t, emails.sent, 2) {
+			assert.Equal(t, "somchai@example.com", emails.sent[1].to)
+			assert.Equal(t, []string{"somchai.k@example.com"}, emails.sent[1].lines)
+		}
+
+		_, err = service.ConfirmEmailChange(ctx, token)
+		assert.ErrorContains(t, err, "token is expired or token is used")
+	})
+
+	t.Run("only the latest request can be confirmed", func(t *testing.T) {
+		service, user, tokens, _ := newFixture()
+		_ = service.RequestEmailChange(ctx, user, "first@example.com", "password")
+		first := tokens.emailChanges[user.ID].token
+		_ = service.RequestEmailChange(ctx, user, "second@example.com", "password")
+
+		_, err := service.ConfirmEmailChange(ctx, first)
+		assert.ErrorContains(t, err, "token is expired or token is used")
+		assert.Equal(t, "somchai@example.com", user.Email)
+	})
+
+	t.Run("request is refused", func(t *testing.T) {
+		service, user, tokens, emails := newFixture()
+
+		assert.ErrorContains(t, service.RequestEmailChange(ctx, user, "somchai.k@example.com", "wrong"), "invalid password")
+		assert.ErrorContains(t, service.RequestEmailChange(ctx, user, "Somchai@Example.com", "password"), "This is already your email")
+		assert.ErrorContains(t, service.RequestEmailChange(ctx, user, "malee@example.com", "password"), "This email is already in use")
+		assert.Empty(t, tokens.emailChanges)
+		assert.Empty(t, emails.sent)
+	})
+
+	t.Run("address taken since the request", func(t *testing.T) {
+		service, user, tokens, _ := newFixture()
+		_ = service.RequestEmailChange(ctx, user, "somchai.k@example.com", "password")
+		_ = service.userRepo.Create(&domain.User{Email: "somchai.k@example.com"})
+
+		_, err := service.ConfirmEmailChange(ctx, tokens.emailChanges[user.ID].token)
+		assert.ErrorContains(t, err, "This email is already in use")
+		assert.Equal(t, "somchai@example.com", user.Email)
+	})
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+const (
+	maxWebhooksPerOwner = 10
+	webhookBatchSize    = 50
+	webhookMaxAttempts  = 8
+	// webhookClaimLease must be longer than delivering a whole batch takes,
+	// every attempt can take up to the client timeout.
+	webhookClaimLease = 15 * time.Minute
+	webhookBaseDelay  = time.Minute
+	webhookMaxDelay   = 6 * time.Hour
+)
+
+type WebhookService struct {
+	webhookRepo ports.WebhookRepository
+	sender      webhook.Sender
+	allowLocal  bool
+}
+
+// NewWebhookService creates the service, allowLocal also accepts http:// URLs
+// to register the local test endpoint.
+func NewWebhookService(webhookRepo ports.WebhookRepository, sender webhook.Sender, allowLocal bool) ports.WebhookService {
+	return &WebhookService{webhookRepo: webhookRepo, sender: sender, allowLocal: allowLocal}
+}
+
+func (s *WebhookService) applyWebhookBody(hook *domain.Webhook, body dto.WebhookRequestBody) error {
+	endpoint, err := url.Parse(body.URL)
+	if err != nil || endpoint.Host == "" {
+		return apperror.BadRequestError(errors.New("invalid webhook url"), "Webhook URL is invalid")
+	}
+	if endpoint.Scheme != "https" && !(s.allowLocal && endpoint.Scheme == "http") {
+		return apperror.BadRequestError(errors.New("webhook url is not https"), "Webhook URL must use HTTPS")
+	}
+
+	// keep the events in their canonical order, without duplicates
+	events := []domain.WebhookEvent{}
+	for _, event := range domain.WebhookEvents {
+		for _, e := range body.Events {
+			if domain.WebhookEvent(e) == event {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+
+	hook.URL = body.URL
+	hook.Events = events
+	hook.Description = body.Description
+	hook.Active = body.Active == nil || *body.Active
+	return nil
+}
+
+func (s *WebhookService) Create(owner *domain.User, body dto.WebhookRequestBody) (*domain.Webhook, error) {
+	if err := policy.Authorize(owner, domain.PermissionWebhookManage); err != nil {
+		return nil, apperror.ForbiddenError(err, "Only lessors can register webhooks")
+	}
+
+	_, _, count, err := s.webhookRepo.GetByOwnerID(owner.ID, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerOwner {
+		return nil, apperror.BadRequestError(errors.New("too many webhooks"), "You cannot register more than 10 webhooks")
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to create webhook")
+	}
+
+	hook := &domain.Webhook{OwnerID: owner.ID, Secret: secret}
+	if err := s.applyWebhookBody(hook, body); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.Create(hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *WebhookService) GetByID(ownerID uuid.UUID, id uuid.UUID) (*domain.Webhook, error) {
+	hook, err := s.webhookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if hook.OwnerID != ownerID {
+		return nil, apperror.ForbiddenError(errors.New("unauthorized action"), "You do not have permission to access this webhook")
+	}
+	return hook, nil
+}
+
+func (s *WebhookService) GetByOwnerID(ownerID uuid.UUID, limit int, page int) ([]domain.Webhook, int, int, error) {
+	return s.webhookRepo.GetByOwnerID(ownerID, limit, page)
+}
+
+func (s *WebhookService) Update(ownerID uuid.UUID, id uuid.UUID, body dto.WebhookRequestBody) (*domain.Webhook, error) {
+	hook, err := s.GetByID(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyWebhookBody(hook, body); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.Update(hook); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.GetByID(id)
+}
+
+func (s *WebhookService) Delete(ownerID uuid.UUID, id uuid.UUID) error {
+	if _, err := s.GetByID(ownerID, id); err != nil {
+		return err
+	}
+	return s.webhookRepo.Delete(id)
+}
+
+func (s *WebhookService) GetDeliveries(ownerID uuid.UUID, id uuid.UUID, limit int, page int) ([]domain.WebhookDelivery, int, int, error) {
+	if _, err := s.GetByID(ownerID, id); err != nil {
+		return nil, 0, 0, err
+	}
+	return s.webhookRepo.GetDeliveriesByWebhookID(id, limit, page)
+}
+
+// Redeliver queues the payload of a finished delivery again, with the same event ID.
+func (s *WebhookService) Redeliver(ownerID uuid.UUID, id uuid.UUID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	hook, err := s.GetByID(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookRepo.GetDeliveryByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != hook.ID {
+		return nil, apperror.NotFoundError(errors.New("delivery of another webhook"), "Webhook delivery not found")
+	}
+	if delivery.Status == domain.WebhookDeliveryPending {
+		return nil, apperror.BadRequestError(errors.New("delivery is pending"), "The delivery is still being attempted")
+	}
+	if !hook.Active {
+		return nil, apperror.BadRequestError(errors.New("webhook is disabled"), "Enable the webhook before redelivering")
+	}
+
+	redelivery := &domain.WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		RedeliveryOf:  &delivery.ID,
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.webhookRepo.CreateDelivery(redelivery); err != nil {
+		return nil, err
+	}
+	return redelivery, nil
+}
+
+// Publish queues the event for every active webhook of the owner subscribed to it.
+func (s *WebhookService) Publish(ownerID uuid.UUID, event domain.WebhookEvent, data any) {
+	hooks, err := s.webhookRepo.GetSubscribers(ownerID, event)
+	if err != nil {
+		log.Errorf("webhook: cannot load webhooks of %s: %v", ownerID, err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload := dto.WebhookPayload{
+		ID:       uuid.New(),
+		Event:    string(event),
+		CreateAt: time.Now(),
+		Data:     data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Errorf("webhook: cannot encode %s event: %v", event, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		err := s.webhookRepo.CreateDelivery(&domain.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       payload.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        domain.WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+		if err != nil {
+			log.Errorf("webhook: cannot queue %s for webhook %s: %v", event, hook.ID, err)
+		}
+	}
+}
+
+// ProcessDue attempts the pending deliveries, like EmailOutboxService.ProcessDue.
+func (s *WebhookService) ProcessDue() error {
+	now := time.Now()
+	deliveries, err := s.webhookRepo.ClaimDeliveries(now, webhookClaimLease, webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	hooks := make(map[uuid.UUID]*domain.Webhook)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		hook, ok := hooks[delivery.WebhookID]
+		if !ok {
+			// left claimed on error, it is retried once the lease is over
+			hook, err = s.webhookRepo.GetByID(delivery.WebhookID)
+			if err != nil {
+				log.Errorf("webhook: cannot load webhook %s: %v", delivery.WebhookID, err)
+				continue
+			}
+			hooks[delivery.WebhookID] = hook
+		}
+
+		if hook.Active {
+			s.attempt(hook, delivery)
+		} else {
+			delivery.Status = domain.WebhookDeliveryFailed
+			delivery.LastError = "webhook is disabled"
+		}
+
+		if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+			log.Errorf("webhook: cannot update delivery %s: %v", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) attempt(hook *domain.Webhook, delivery *domain.WebhookDelivery) {
+	res, err := s.sender.Send(&webhook.Delivery{
+		ID:     delivery.ID.String(),
+		URL:    hook.URL,
+		Secret: hook.Secret,
+		Event:  string(delivery.Event),
+		Body:   []byte(delivery.Payload),
+	})
+
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	if res != nil {
+		delivery.ResponseStatus = res.StatusCode
+		delivery.ResponseBody = res.Body
+	}
+
+	if err == nil {
+		deliveredAt := time.Now()
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &deliveredAt
+		delivery.LastError = ""
+	} else if delivery.Attempts >= webhookMaxAttempts {
+		log.Errorf("webhook: giving up on delivery %s after %d attempts: %v", delivery.ID, delivery.Attempts, err)
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.LastError = err.Error()
+	} else {
+		delivery.NextAttemptAt = time.Now().Add(retryBackoff(webhookBaseDelay, webhookMaxDelay, delivery.Attempts))
+		delivery.LastError = err.Error()
+	}
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook"
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook/webhooktest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockWebhookRepo struct {
+	ports.WebhookRepository
+	hooks      map[uuid.UUID]*domain.Webhook
+	deliveries []*domain.WebhookDelivery
+}
+
+func (m *mockWebhookRepo) GetByID(id uuid.UUID) (*domain.Webhook, error) {
+	hook, ok := m.hooks[id]
+	if !ok {
+		return nil, errors.New("webhook not found")
+	}
+	return hook, nil
+}
+
+func (m *mockWebhookRepo) Create(hook *domain.Webhook) error {
+	hook.ID = uuid.New()
+	m.hooks[hook.ID] = hook
+	return nil
+}
+
+func (m *mockWebhookRepo) GetByOwnerID(ownerID uuid.UUID, limit int, page int) ([]domain.Webhook, int, int, error) {
+	var hooks []domain.Webhook
+	for _, hook := range m.hooks {
+		if hook.OwnerID == ownerID {
+			hooks = append(hooks, *hook)
+		}
+	}
+	return hooks, 1, len(hooks), nil
+}
+
+func (m *mockWebhookRepo) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	delivery.ID = uuid.New()
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *mockWebhookRepo) GetDeliveryByID(id uuid.UUID) (*domain.WebhookDelivery, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.ID == id {
+			return delivery, nil
+		}
+	}
+	return nil, errors.New("delivery not found")
+}
+
+// ClaimDeliveries returns the due pending deliveries and moves them out of reach for lease.
+func (m *mockWebhookRepo) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var claimed []domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status == domain.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			delivery.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, *delivery)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *mockWebhookRepo) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == delivery.ID {
+			*m.deliveries[i] = *delivery
+		}
+	}
+	return nil
+}
+
+// startWebhookReceiver runs a receiver and returns a webhook registered for it.
+func startWebhookReceiver(t *testing.T) (*webhooktest.Server, *domain.Webhook) {
+	receiver := webhooktest.NewServer("whsec_test")
+	t.Cleanup(receiver.Close)
+
+	return receiver, &domain.Webhook{ID: uuid.New(), OwnerID: uuid.New(), URL: receiver.URL, Secret: "whsec_test", Active: true}
+}
+
+func queueDelivery(repo *mockWebhookRepo, hook *domain.Webhook) *domain.WebhookDelivery {
+	delivery := &domain.WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventID:       uuid.New(),
+		Event:         domain.WebhookEvents[0],
+		Payload:       `{"id":"1"}`,
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	_ = repo.CreateDelivery(delivery)
+	return delivery
+}
+
+func TestRetryBackoff(t *testing.T) {
+	expected := []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute,
+		32 * time.Minute, 64 * time.Minute, 128 * time.Minute, 256 * time.Minute, 6 * time.Hour,
+	}
+	for i, delay := range expected {
+		assert.Equal(t, delay, retryBackoff(webhookBaseDelay, webhookMaxDelay, i+1), "attempt %d", i+1)
+	}
+	// the shift overflows long before attempts get this high
+	assert.Equal(t, webhookMaxDelay, retryBackoff(webhookBaseDelay, webhookMaxDelay, 100))
+}
+
+func TestWebhookCreate(t *testing.T) {
+	body := dto.WebhookRequestBody{URL: "https://lessor.example/hooks", Events: []string{string(domain.WebhookEvents[0])}}
+
+	t.Run("lessor", func(t *testing.T) {
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{}}
+		service := &WebhookService{webhookRepo: repo}
+		owner := &domain.User{ID: uuid.New(), Role: domain.LessorRole}
+
+		hook, err := service.Create(owner, body)
+		if assert.NoError(t, err) {
+			assert.Equal(t, owner.ID, hook.OwnerID)
+			assert.NotEmpty(t, hook.Secret)
+			assert.True(t, hook.Active)
+		}
+	})
+
+	t.Run("lessee", func(t *testing.T) {
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{}}
+		service := &WebhookService{webhookRepo: repo}
+
+		_, err := service.Create(&domain.User{ID: uuid.New(), Role: domain.LesseeRole}, body)
+		assert.ErrorContains(t, err, "Only lessors can register webhooks")
+		assert.Empty(t, repo.hooks)
+	})
+}
+
+func TestWebhookProcessDue(t *testing.T) {
+	t.Run("delivered and signed", func(t *testing.T) {
+		receiver, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		delivery := queueDelivery(repo, hook)
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+		assert.NotNil(t, delivery.DeliveredAt)
+
+		received := receiver.Deliveries()
+		if assert.Len(t, received, 1) {
+			assert.True(t, received[0].Valid)
+			assert.Equal(t, delivery.ID.String(), received[0].ID)
+			assert.Equal(t, delivery.Payload, string(received[0].Body))
+		}
+	})
+
+	t.Run("failed attempts back off", func(t *testing.T) {
+		receiver, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		receiver.RespondWith(http.StatusInternalServerError)
+		delivery := queueDelivery(repo, hook)
+
+		for attempt := 1; attempt < webhookMaxAttempts; attempt++ {
+			before := time.Now()
+			assert.NoError(t, service.ProcessDue())
+			assert.Equal(t, domain.WebhookDeliveryPending, delivery.Status)
+			assert.Equal(t, attempt, delivery.Attempts)
+			assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+			assert.WithinDuration(t, before.Add(retryBackoff(webhookBaseDelay, webhookMaxDelay, attempt)), delivery.NextAttemptAt, time.Second)
+
+			// not due yet
+			assert.NoError(t, service.ProcessDue())
+			assert.Equal(t, attempt, delivery.Attempts)
+			delivery.NextAttemptAt = time.Now()
+		}
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.WebhookDeliveryFailed, delivery.Status)
+		assert.Equal(t, webhookMaxAttempts, delivery.Attempts)
+		assert.Len(t, receiver.Deliveries(), webhookMaxAttempts)
+	})
+
+	t.Run("rotated secret is refused", func(t *testing.T) {
+		receiver, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		receiver.SetSecret("whsec_rotated")
+		delivery := queueDelivery(repo, hook)
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, http.StatusUnauthorized, delivery.ResponseStatus)
+	})
+
+	t.Run("private address is refused", func(t *testing.T) {
+		receiver, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		service.sender = webhook.NewClient(webhook.Config{Timeout: time.Second})
+		delivery := queueDelivery(repo, hook)
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.WebhookDeliveryPending, delivery.Status)
+		assert.Contains(t, delivery.LastError, "private address")
+		assert.Zero(t, delivery.ResponseStatus)
+		assert.Empty(t, receiver.Deliveries())
+	})
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	t.Run("finished delivery is sent again", func(t *testing.T) {
+		receiver, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		receiver.RespondWith(http.StatusInternalServerError)
+		delivery := queueDelivery(repo, hook)
+		delivery.Attempts = webhookMaxAttempts - 1
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.WebhookDeliveryFailed, delivery.Status)
+
+		receiver.RespondWith(http.StatusNoContent)
+		redelivery, err := service.Redeliver(hook.OwnerID, hook.ID, delivery.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, delivery.EventID, redelivery.EventID)
+		assert.Equal(t, &delivery.ID, redelivery.RedeliveryOf)
+
+		assert.NoError(t, service.ProcessDue())
+		assert.Equal(t, domain.WebhookDeliverySucceeded, redelivery.Status)
+		assert.Equal(t, domain.WebhookDeliveryFailed, delivery.Status)
+
+		received := receiver.Deliveries()
+		if assert.Len(t, received, 2) {
+			assert.Equal(t, redelivery.ID.String(), received[1].ID)
+			assert.Equal(t, received[0].Body, received[1].Body)
+		}
+	})
+
+	t.Run("pending delivery", func(t *testing.T) {
+		_, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		delivery := queueDelivery(repo, hook)
+
+		_, err := service.Redeliver(hook.OwnerID, hook.ID, delivery.ID)
+		assert.ErrorContains(t, err, "The delivery is still being attempted")
+	})
+
+	t.Run("delivery of another webhook", func(t *testing.T) {
+		_, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		other := &domain.Webhook{ID: uuid.New(), OwnerID: hook.OwnerID, Active: true}
+		repo.hooks[other.ID] = other
+		delivery := queueDelivery(repo, other)
+		delivery.Status = domain.WebhookDeliverySucceeded
+
+		_, err := service.Redeliver(hook.OwnerID, hook.ID, delivery.ID)
+		assert.ErrorContains(t, err, "Webhook delivery not found")
+	})
+
+	t.Run("webhook of another owner", func(t *testing.T) {
+		_, hook := startWebhookReceiver(t)
+		repo := &mockWebhookRepo{hooks: map[uuid.UUID]*domain.Webhook{hook.ID: hook}}
+		service := &WebhookService{webhookRepo: repo, sender: webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})}
+		delivery := queueDelivery(repo, hook)
+		delivery.Status = domain.WebhookDeliverySucceeded
+
+		_, err := service.Redeliver(uuid.New(), hook.ID, delivery.ID)
+		assert.ErrorContains(t, err, "You do not have permission to access this webhook")
+	})
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RentStat struct {
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	Count  int     `json:"count"`
+}
+
+type RentTrendPoint struct {
+	Period  time.Time `json:"period"`
+	Listing RentStat  `json:"listing"`
+	Lease   RentStat  `json:"lease"`
+}
+
+type RentTrendResponseBody struct {
+	Province string           `json:"province"`
+	District string           `json:"district"`
+	Bedrooms *int             `json:"bedrooms"`
+	Interval string           `json:"interval"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Points   []RentTrendPoint `json:"points"`
+}
+
+type MarketPosition string
+
+const (
+	BelowMedian  MarketPosition = "BELOW_MEDIAN"
+	AtMedian     MarketPosition = "AT_MEDIAN"
+	AboveMedian  MarketPosition = "ABOVE_MEDIAN"
+	NoMarketData MarketPosition = "NO_DATA"
+)
+
+type DormMarketPositionResponseBody struct {
+	DormID            uuid.UUID      `json:"dormId"`
+	Name              string         `json:"name"`
+	Price             float64        `json:"price"`
+	AreaMedian        float64        `json:"areaMedian"`
+	AreaSampleSize    int            `json:"areaSampleSize"`
+	DifferencePercent float64        `json:"differencePercent"`
+	Position          MarketPosition `json:"position"`
+}
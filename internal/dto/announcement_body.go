@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnnouncementRequestBody is sent as multipart form data, with the files in "attachments".
+type AnnouncementRequestBody struct {
+	Title string `form:"title" validate:"required,max=200"`
+	Body  string `form:"body" validate:"required,max=5000"`
+	// PublishAt schedules the announcement, in RFC 3339. It is published right away when empty.
+	PublishAt string `form:"publishAt"`
+}
+
+type AnnouncementAttachmentResponseBody struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	URL         string `json:"url"`
+}
+
+type AnnouncementStats struct {
+	Recipients int64 `json:"recipients"`
+	Read       int64 `json:"read"`
+}
+
+type AnnouncementResponseBody struct {
+	ID          uuid.UUID                            `json:"id"`
+	CreateAt    time.Time                            `json:"createAt"`
+	DormID      uuid.UUID                            `json:"dormId"`
+	DormName    string                               `json:"dormName"`
+	Title       string                               `json:"title"`
+	Body        string                               `json:"body"`
+	PublishAt   time.Time                            `json:"publishAt"`
+	PublishedAt *time.Time                           `json:"publishedAt,omitempty"`
+	Published   bool                                 `json:"published"`
+	Attachments []AnnouncementAttachmentResponseBody `json:"attachments"`
+	// Stats is only returned to the lessor.
+	Stats *AnnouncementStats `json:"stats,omitempty"`
+	// Read and ReadAt are only returned to tenants.
+	Read   *bool      `json:"read,omitempty"`
+	ReadAt *time.Time `json:"readAt,omitempty"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type RegisterRequestBody struct {
 	Password string `json:"password" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
@@ -25,3 +27,18 @@ type TokenResponseBody struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 }
+
+// ClientInfo describes the device a session is created for.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type SessionResponseBody struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DormManagerScopes struct {
+	LeasingRequests bool `json:"leasingRequests"`
+	Contracts       bool `json:"contracts"`
+	MeterReadings   bool `json:"meterReadings"`
+	ReviewReplies   bool `json:"reviewReplies"`
+}
+
+type DormManagerInviteRequestBody struct {
+	Email  string            `json:"email" validate:"required,email"`
+	Scopes DormManagerScopes `json:"scopes"`
+}
+
+type DormManagerUpdateRequestBody struct {
+	Scopes DormManagerScopes `json:"scopes"`
+}
+
+type DormManagerResponseBody struct {
+	ID         uuid.UUID         `json:"id"`
+	CreateAt   time.Time         `json:"createAt"`
+	DormID     uuid.UUID         `json:"dormId"`
+	DormName   string            `json:"dormName"`
+	Manager    UserResponse      `json:"manager"`
+	Status     string            `json:"status"`
+	Scopes     DormManagerScopes `json:"scopes"`
+	AcceptedAt *time.Time        `json:"acceptedAt"`
+}
+
+type DelegatedActionResponseBody struct {
+	ID         uuid.UUID    `json:"id"`
+	CreateAt   time.Time    `json:"createAt"`
+	DormID     uuid.UUID    `json:"dormId"`
+	Manager    UserResponse `json:"manager"`
+	Scope      string       `json:"scope"`
+	Action     string       `json:"action"`
+	ResourceID uuid.UUID    `json:"resourceId"`
+}
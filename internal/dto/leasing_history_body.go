@@ -13,6 +13,7 @@ type LeasingHistory struct {
 	Orders     []OrderResponseBody `json:"orders"`
 	Start      time.Time           `json:"start"`
 	End        time.Time           `json:"end"`
+	PlannedEnd *time.Time          `json:"plannedEnd,omitempty"`
 	Price      float64             `json:"price"`
 	Review     Review              `json:"review"`
 	ReviewFlag bool                `json:"reviewFlag"`
@@ -20,25 +21,69 @@ type LeasingHistory struct {
 }
 
 type Review struct {
-	HistoryID  uuid.UUID    `json:"historyId"`
-	Message    string       `json:"message"`
-	Rate       int          `json:"rate"`
-	CreateAt   time.Time    `json:"createAt"`
-	Images     []string     `json:"url"`
-	Reviewer   UserResponse `json:"reviewer"`
-	ReportFlag bool         `json:"reported"`
+	HistoryID    uuid.UUID     `json:"historyId"`
+	Message      string        `json:"message"`
+	Rate         int           `json:"rate"`
+	Ratings      ReviewRatings `json:"ratings"`
+	CreateAt     time.Time     `json:"createAt"`
+	Images       []string      `json:"url"`
+	Reviewer     UserResponse  `json:"reviewer"`
+	ReportFlag   bool          `json:"reported"`
+	Hidden       bool          `json:"hidden"`
+	Reply        *ReviewReply  `json:"reply"`
+	HelpfulCount int           `json:"helpfulCount"`
+}
+
+type ReviewRatings struct {
+	Cleanliness   int `json:"cleanliness" validate:"omitempty,gte=1,lte=5"`
+	Location      int `json:"location" validate:"omitempty,gte=1,lte=5"`
+	Value         int `json:"value" validate:"omitempty,gte=1,lte=5"`
+	Communication int `json:"communication" validate:"omitempty,gte=1,lte=5"`
+	Accuracy      int `json:"accuracy" validate:"omitempty,gte=1,lte=5"`
+}
+
+type ReviewReply struct {
+	Message  string    `json:"message"`
+	CreateAt time.Time `json:"createAt"`
 }
 
 type ReviewCreateRequestBody struct {
-	Message string   `json:"message" validate:"required"`
-	Rate    int      `json:"rate" validate:"required,gte=0,lte=5"`
-	Images  []string `json:"url"`
+	Message string        `json:"message" validate:"required"`
+	Rate    int           `json:"rate" validate:"required,gte=0,lte=5"`
+	Ratings ReviewRatings `json:"ratings"`
+	Images  []string      `json:"url"`
 }
 
 type ReviewUpdateRequestBody struct {
-	Message string   `json:"message" validate:"omitempty"`
-	Rate    int      `json:"rate" validate:"omitempty,gte=0,lte=5"`
-	Images  []string `json:"url"`
+	Message string        `json:"message" validate:"omitempty"`
+	Rate    int           `json:"rate" validate:"omitempty,gte=0,lte=5"`
+	Ratings ReviewRatings `json:"ratings"`
+	Images  []string      `json:"url"`
+}
+
+type ReviewReplyRequestBody struct {
+	Message string `json:"message" validate:"required"`
+}
+
+type ReviewRatingAverages struct {
+	Cleanliness   float64 `json:"cleanliness"`
+	Location      float64 `json:"location"`
+	Value         float64 `json:"value"`
+	Communication float64 `json:"communication"`
+	Accuracy      float64 `json:"accuracy"`
+}
+
+type ReviewSummary struct {
+	Count       int                  `json:"count"`
+	AverageRate float64              `json:"averageRate"`
+	Averages    ReviewRatingAverages `json:"averages"`
+	Histogram   map[int]int          `json:"histogram"`
+}
+
+type ReviewPaginationResponse struct {
+	Data       []Review      `json:"data"`
+	Pagination Pagination    `json:"pagination"`
+	Summary    ReviewSummary `json:"summary"`
 }
 
 type ReviewImageUploadResponseBody struct {
@@ -46,10 +91,52 @@ type ReviewImageUploadResponseBody struct {
 }
 
 type ReportedReview struct {
-	HistoryID uuid.UUID    `json:"historyId"`
-	Message   string       `json:"message"`
-	Rate      int          `json:"rate"`
-	Reviewer  UserResponse `json:"reviewer"`
-	CreateAt  time.Time    `json:"createAt"`
-	Images    []string     `json:"url"`
+	HistoryID  uuid.UUID                  `json:"historyId"`
+	Message    string                     `json:"message"`
+	Rate       int                        `json:"rate"`
+	Reviewer   UserResponse               `json:"reviewer"`
+	CreateAt   time.Time                  `json:"createAt"`
+	Images     []string                   `json:"url"`
+	Categories map[string]int             `json:"categories"`
+	Reports    []ReviewReportResponseBody `json:"reports"`
+}
+
+type ReviewReportRequestBody struct {
+	Category string `json:"category" validate:"required,oneof=SPAM HARASSMENT FALSE_INFO PERSONAL_DATA"`
+	Reason   string `json:"reason" validate:"max=1000"`
+}
+
+type ReviewReportResponseBody struct {
+	ID       uuid.UUID    `json:"id"`
+	CreateAt time.Time    `json:"createAt"`
+	Reporter UserResponse `json:"reporter"`
+	Category string       `json:"category"`
+	Reason   string       `json:"reason"`
+}
+
+type ReviewModerationRequestBody struct {
+	Decision string `json:"decision" validate:"required,oneof=DISMISS HIDE REMOVE"`
+	Reason   string `json:"reason" validate:"required"`
+}
+
+type ReviewAppealRequestBody struct {
+	Message string `json:"message" validate:"required,max=2000"`
+}
+
+type ReviewAppealDecisionRequestBody struct {
+	Decision string `json:"decision" validate:"required,oneof=UPHELD OVERTURNED"`
+	Reason   string `json:"reason" validate:"required"`
+}
+
+type ReviewModerationResponseBody struct {
+	ID             uuid.UUID  `json:"id"`
+	CreateAt       time.Time  `json:"createAt"`
+	HistoryID      uuid.UUID  `json:"historyId"`
+	Decision       string     `json:"decision"`
+	Reason         string     `json:"reason"`
+	AppealStatus   string     `json:"appealStatus"`
+	AppealMessage  string     `json:"appealMessage"`
+	AppealAt       *time.Time `json:"appealAt"`
+	AppealReason   string     `json:"appealReason"`
+	AppealDecideAt *time.Time `json:"appealDecideAt"`
 }
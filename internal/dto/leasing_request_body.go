@@ -16,15 +16,18 @@ const (
 )
 
 type LeasingRequest struct {
-	ID      uuid.UUID        `json:"id"`
-	Status  Status           `json:"status"`
-	Dorm    DormResponseBody `json:"dorm"`
-	Lessee  UserResponse     `json:"lessee"`
-	Start   time.Time        `json:"start"`
-	End     time.Time        `json:"end"`
-	Message string           `json:"message"`
+	ID         uuid.UUID        `json:"id"`
+	Status     Status           `json:"status"`
+	Dorm       DormResponseBody `json:"dorm"`
+	Lessee     UserResponse     `json:"lessee"`
+	Start      time.Time        `json:"start"`
+	End        time.Time        `json:"end"`
+	Message    string           `json:"message"`
+	TermMonths int              `json:"termMonths"`
 }
 
 type LeasingRequestCreateRequestBody struct {
 	Message string `json:"message"`
+	// TermMonths is the length of the lease, leave it out for an open-ended lease.
+	TermMonths int `json:"termMonths" validate:"omitempty,min=1,max=60"`
 }
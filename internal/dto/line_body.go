@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+type LineAccountResponseBody struct {
+	Linked   bool       `json:"linked"`
+	LinkedAt *time.Time `json:"linkedAt,omitempty"`
+}
+
+type LineLinkCodeResponseBody struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// AddFriendURL opens the bot in LINE, the code is sent to it as a message
+	AddFriendURL string `json:"addFriendUrl"`
+}
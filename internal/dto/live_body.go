@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// LiveEvent is sent as the data of a server-sent event.
+type LiveEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+type ContractStatusEventBody struct {
+	ContractID   uuid.UUID `json:"contractId"`
+	Status       string    `json:"status"`
+	LesseeStatus string    `json:"lesseeStatus"`
+	LessorStatus string    `json:"lessorStatus"`
+}
+
+type PaymentCompletedEventBody struct {
+	OrderID       uuid.UUID `json:"orderId"`
+	TransactionID string    `json:"transactionId"`
+	Amount        int64     `json:"amount"`
+}
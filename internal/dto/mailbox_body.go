@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+type MailboxMessageResponseBody struct {
+	ID      int       `json:"id"`
+	SentAt  time.Time `json:"sentAt"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+}
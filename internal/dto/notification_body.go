@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationResponseBody struct {
+	ID       uuid.UUID  `json:"id"`
+	CreateAt time.Time  `json:"createAt"`
+	Type     string     `json:"type"`
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	Link     string     `json:"link"`
+	Read     bool       `json:"read"`
+	ReadAt   *time.Time `json:"readAt"`
+}
+
+// NotificationListResponse is a page of notifications with the number of unread ones in total.
+type NotificationListResponse struct {
+	Data       []NotificationResponseBody `json:"data"`
+	Pagination Pagination                 `json:"pagination"`
+	Unread     int64                      `json:"unread"`
+}
+
+type UnreadCountResponseBody struct {
+	Unread int64 `json:"unread"`
+}
+
+type NotificationPreferenceBody struct {
+	Type  string `json:"type" validate:"required"`
+	InApp bool   `json:"inApp"`
+	Email bool   `json:"email"`
+	Line  bool   `json:"line"`
+}
+
+type NotificationPreferencesRequestBody struct {
+	Preferences []NotificationPreferenceBody `json:"preferences" validate:"required,dive"`
+}
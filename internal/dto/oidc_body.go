@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type OIDCAuthorizationResponseBody struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+type OIDCCallbackRequestBody struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type UserIdentityResponseBody struct {
+	Provider string    `json:"provider"`
+	Email    string    `json:"email"`
+	CreateAt time.Time `json:"createAt"`
+}
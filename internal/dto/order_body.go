@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -12,5 +14,6 @@ type OrderResponseBody struct {
 	ID              uuid.UUID           `json:"id"`
 	Type            string              `json:"type"`
 	Price           int64               `json:"price"`
+	DueAt           time.Time           `json:"dueAt"`
 	PaidTransaction TransactionResponse `json:"paidTransaction"`
 }
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SavedSearchRequestBody struct {
+	Name           string `json:"name" validate:"required"`
+	Search         string `json:"search"`
+	MinPrice       int    `json:"minPrice" validate:"omitempty,gte=0"`
+	MaxPrice       int    `json:"maxPrice" validate:"omitempty,gte=0"`
+	District       string `json:"district"`
+	Subdistrict    string `json:"subdistrict"`
+	Province       string `json:"province"`
+	Zipcode        string `json:"zipcode" validate:"omitempty,numeric,max=5"`
+	AlertFrequency string `json:"alertFrequency" validate:"omitempty,oneof=IMMEDIATE DAILY"`
+}
+
+type SavedSearchResponseBody struct {
+	ID             uuid.UUID `json:"id"`
+	CreateAt       time.Time `json:"createAt"`
+	UpdateAt       time.Time `json:"updateAt"`
+	Name           string    `json:"name"`
+	Search         string    `json:"search"`
+	MinPrice       int       `json:"minPrice"`
+	MaxPrice       int       `json:"maxPrice"`
+	District       string    `json:"district"`
+	Subdistrict    string    `json:"subdistrict"`
+	Province       string    `json:"province"`
+	Zipcode        string    `json:"zipcode"`
+	AlertFrequency string    `json:"alertFrequency"`
+}
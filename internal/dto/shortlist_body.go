@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ShortlistRequestBody struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type ShortlistAddDormRequestBody struct {
+	DormID uuid.UUID `json:"dormId" validate:"required"`
+}
+
+type ShortlistItemResponseBody struct {
+	CreateAt    time.Time        `json:"createAt"`
+	PriceAtSave float64          `json:"priceAtSave"`
+	Dorm        DormResponseBody `json:"dorm"`
+}
+
+type ShortlistResponseBody struct {
+	ID       uuid.UUID                   `json:"id"`
+	CreateAt time.Time                   `json:"createAt"`
+	UpdateAt time.Time                   `json:"updateAt"`
+	Name     string                      `json:"name"`
+	Dorms    []ShortlistItemResponseBody `json:"dorms"`
+}
+
+// DormComparisonResponseBody has no amenities or distance yet, dorms do not
+// store either. LessorResponseHours is null until the lessor answered a request.
+type DormComparisonResponseBody struct {
+	ID                  uuid.UUID `json:"id"`
+	Name                string    `json:"name"`
+	Price               float64   `json:"price"`
+	Size                float64   `json:"size"`
+	PricePerSize        float64   `json:"pricePerSize"`
+	Bedrooms            int       `json:"bedrooms"`
+	Bathrooms           int       `json:"bathrooms"`
+	Rating              float64   `json:"rating"`
+	Address             Address   `json:"address"`
+	LessorResponseHours *float64  `json:"lessorResponseHours"`
+	Images              []string  `json:"imagesUrl"`
+}
+
+type SavedCountResponseBody struct {
+	DormID     uuid.UUID `json:"dormId"`
+	SavedCount int       `json:"savedCount"`
+}
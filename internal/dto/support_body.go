@@ -7,7 +7,8 @@ import (
 )
 
 type SupportRequestBody struct {
-	Message string `json:"message" validate:"required"`
+	Message  string     `json:"message" validate:"required"`
+	ThreadID *uuid.UUID `json:"threadId"`
 }
 
 type UpdateStatusRequestBody struct {
@@ -15,10 +16,11 @@ type UpdateStatusRequestBody struct {
 }
 
 type SupportResponseBody struct {
-	ID       uuid.UUID `json:"id"`
-	CreateAt time.Time `json:"createAt"`
-	UpdateAt time.Time `json:"updateAt"`
-	UserID   uuid.UUID `json:"userID"`
-	Message  string    `json:"message"`
-	Status   string    `json:"status"`
+	ID       uuid.UUID  `json:"id"`
+	CreateAt time.Time  `json:"createAt"`
+	UpdateAt time.Time  `json:"updateAt"`
+	UserID   uuid.UUID  `json:"userID"`
+	Message  string     `json:"message"`
+	Status   string     `json:"status"`
+	ThreadID *uuid.UUID `json:"threadId,omitempty"`
 }
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ThreadCreateRequestBody struct {
+	Subject   string    `json:"subject" validate:"required,oneof=DORM LEASING_REQUEST LEASE"`
+	SubjectID uuid.UUID `json:"subjectId" validate:"required"`
+}
+
+type ThreadReportRequestBody struct {
+	Category string `json:"category" validate:"required,oneof=SPAM HARASSMENT FALSE_INFO PERSONAL_DATA"`
+	Reason   string `json:"reason"`
+}
+
+type ThreadResponseBody struct {
+	ID            uuid.UUID    `json:"id"`
+	CreateAt      time.Time    `json:"createAt"`
+	Subject       string       `json:"subject"`
+	SubjectID     uuid.UUID    `json:"subjectId"`
+	DormID        uuid.UUID    `json:"dormId"`
+	DormName      string       `json:"dormName"`
+	Lessee        UserResponse `json:"lessee"`
+	Lessor        UserResponse `json:"lessor"`
+	LastMessageAt *time.Time   `json:"lastMessageAt"`
+	Unread        int64        `json:"unread"`
+	// OtherLastReadAt is when the other participant last read the thread.
+	OtherLastReadAt *time.Time `json:"otherLastReadAt"`
+}
+
+type ThreadMessageResponseBody struct {
+	ID       uuid.UUID `json:"id"`
+	CreateAt time.Time `json:"createAt"`
+	ThreadID uuid.UUID `json:"threadId"`
+	SenderID uuid.UUID `json:"senderId"`
+	Body     string    `json:"body"`
+	ImageURL string    `json:"imageUrl,omitempty"`
+	// Read is whether the recipient has read the message.
+	Read bool `json:"read"`
+}
+
+type UserBlockResponseBody struct {
+	User     UserResponse `json:"user"`
+	CreateAt time.Time    `json:"createAt"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+type TwoFactorSetupResponseBody struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type TwoFactorCodeRequestBody struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorVerifyRequestBody struct {
+	Token string `json:"token" validate:"required"`
+	Code  string `json:"code" validate:"required"`
+}
+
+type TwoFactorTokenRequestBody struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type RecoveryCodesResponseBody struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TwoFactorPendingResponseBody is returned instead of the key pair until the second
+// factor is checked. TwoFactorEnrollmentRequired is set for staff who have not
+// enabled two-factor authentication yet and have to enroll to log in.
+type TwoFactorPendingResponseBody struct {
+	TwoFactorRequired           bool   `json:"twoFactorRequired"`
+	TwoFactorEnrollmentRequired bool   `json:"twoFactorEnrollmentRequired"`
+	TwoFactorToken              string `json:"twoFactorToken"`
+}
+
+type TwoFactorEnrollResponseBody struct {
+	AccessToken     string       `json:"accessToken"`
+	RefreshToken    string       `json:"refreshToken"`
+	UserInformation UserResponse `json:"userInformation"`
+	RecoveryCodes   []string     `json:"recoveryCodes"`
+}
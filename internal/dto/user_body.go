@@ -12,6 +12,10 @@ const (
 	AdminRole  Role = "ADMIN"
 	LesseeRole Role = "LESSEE"
 	LessorRole Role = "LESSOR"
+
+	// Sub-admin roles with some of the admin permissions.
+	SupportAgentRole Role = "SUPPORT_AGENT"
+	FinanceRole      Role = "FINANCE"
 )
 
 type ResetPasswordCreateRequestBody struct {
@@ -27,6 +31,16 @@ type VerifyRequestBody struct {
 	Token string `json:"token" validate:"required"`
 }
 
+type EmailChangeRequestBody struct {
+	Email string `json:"email" validate:"required,email"`
+	// Password is required unless the account only logs in with a provider.
+	Password string `json:"password"`
+}
+
+type PhoneVerificationRequestBody struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
 type UserInformationRequestBody struct {
 	Username        string    `json:"username,omitempty" validate:"omitempty,min=2"`
 	Password        string    `json:"password,omitempty" validate:"omitempty,min=8"`
@@ -38,6 +52,7 @@ type UserInformationRequestBody struct {
 	StudentEvidence string    `json:"studentEvidence,omitempty"`
 	Lifestyles      []string  `json:"lifestyles,omitempty" validate:"omitempty,lifestyle"`
 	PhoneNumber     string    `json:"phoneNumber,omitempty" validate:"omitempty,phoneNumber"`
+	Language        string    `json:"language,omitempty" validate:"omitempty,oneof=th en"`
 }
 
 type UserFirstFillRequestBody struct {
@@ -51,6 +66,10 @@ type UserFirstFillRequestBody struct {
 	Role        Role      `json:"role" validate:"omitempty,role"`
 }
 
+type UserRoleRequestBody struct {
+	Role Role `json:"role" validate:"required,oneof=ADMIN LESSOR LESSEE SUPPORT_AGENT FINANCE"`
+}
+
 type UserResponse struct {
 	ID                 uuid.UUID `json:"id"`
 	CreateAt           time.Time `json:"createAt"`
@@ -65,12 +84,15 @@ type UserResponse struct {
 	FilledPersonalInfo bool      `json:"filledPersonalInfo"`
 	Lifestyles         []string  `json:"lifestyles"`
 	PhoneNumber        string    `json:"phoneNumber"`
+	PhoneVerified      bool      `json:"phoneVerified"`
 	IsStudentVerified  string    `json:"isStudentVerified"`
 	ProfilePicUrl      string    `json:"profilePicUrl"`
 	ReviewCount        int64     `json:"review_count"`
 	DormsOwned         int64     `json:"dorms_owned"`
 	DormsLeased        int64     `json:"dorms_leased"`
 	Banned             bool      `json:"banned"`
+	TwoFactorEnabled   bool      `json:"twoFactorEnabled"`
+	Language           string    `json:"language"`
 }
 
 type StudentEvidenceUploadResponseBody struct {
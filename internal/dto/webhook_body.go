@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookRequestBody struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=request.created contract.signed order.paid lease.ended"`
+	Description string   `json:"description" validate:"max=200"`
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+type WebhookResponseBody struct {
+	ID          uuid.UUID `json:"id"`
+	CreateAt    time.Time `json:"createAt"`
+	UpdateAt    time.Time `json:"updateAt"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	// Secret is only returned when the webhook is created
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDeliveryResponseBody struct {
+	ID             uuid.UUID  `json:"id"`
+	CreateAt       time.Time  `json:"createAt"`
+	EventID        uuid.UUID  `json:"eventId"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	RedeliveryOf   *uuid.UUID `json:"redeliveryOf,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	ResponseBody   string     `json:"responseBody,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+}
+
+// WebhookPayload is the body posted to webhooks. ID identifies the event, it is
+// the same for every delivery and redelivery of it.
+type WebhookPayload struct {
+	ID       uuid.UUID `json:"id"`
+	Event    string    `json:"event"`
+	CreateAt time.Time `json:"createAt"`
+	Data     any       `json:"data"`
+}
+
+type WebhookRequestCreatedData struct {
+	RequestID uuid.UUID `json:"requestId"`
+	DormID    uuid.UUID `json:"dormId"`
+	DormName  string    `json:"dormName"`
+	LesseeID  uuid.UUID `json:"lesseeId"`
+	Message   string    `json:"message"`
+}
+
+type WebhookContractSignedData struct {
+	ContractID uuid.UUID `json:"contractId"`
+	DormID     uuid.UUID `json:"dormId"`
+	DormName   string    `json:"dormName"`
+	LesseeID   uuid.UUID `json:"lesseeId"`
+}
+
+type WebhookOrderPaidData struct {
+	OrderID          uuid.UUID `json:"orderId"`
+	TransactionID    string    `json:"transactionId"`
+	LeasingHistoryID uuid.UUID `json:"leasingHistoryId"`
+	DormID           uuid.UUID `json:"dormId"`
+	DormName         string    `json:"dormName"`
+	LesseeID         uuid.UUID `json:"lesseeId"`
+	Amount           int64     `json:"amount"`
+}
+
+type WebhookLeaseEndedData struct {
+	LeasingHistoryID uuid.UUID `json:"leasingHistoryId"`
+	DormID           uuid.UUID `json:"dormId"`
+	DormName         string    `json:"dormName"`
+	LesseeID         uuid.UUID `json:"lesseeId"`
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+}
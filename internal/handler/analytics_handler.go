@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type AnalyticsHandler struct {
+	service ports.AnalyticsService
+}
+
+func NewAnalyticsHandler(service ports.AnalyticsService) ports.AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// GetRentTrend godoc
+// @Summary Get rent trend of an area
+// @Description Median and 25th/75th percentile rent per period, from listed dorm prices and from prices actually leased at. A listed price counts in every period until it changes. Province and district match exactly (case insensitive). At most 260 periods.
+// @Tags analytics
+// @Security Bearer
+// @Produce json
+// @Param province query string false "Province"
+// @Param district query string false "District"
+// @Param bedrooms query int false "Bedroom count"
+// @Param interval query string false "week, month (default), quarter or year"
+// @Param from query string false "Start date YYYY-MM-DD (default 12 months ago)"
+// @Param to query string false "End date YYYY-MM-DD (default today)"
+// @Success 200 {object} dto.SuccessResponse[dto.RentTrendResponseBody] "Rent trend retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve rent trend"
+// @Router /analytics/rent [get]
+func (h *AnalyticsHandler) GetRentTrend(c *fiber.Ctx) error {
+	now := time.Now()
+	filter := domain.RentFilter{
+		Province: c.Query("province"),
+		District: c.Query("district"),
+		Bedrooms: c.QueryInt("bedrooms", -1),
+		Interval: c.Query("interval", "month"),
+		From:     now.AddDate(-1, 0, 0),
+		To:       now,
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return apperror.BadRequestError(err, "from must be in YYYY-MM-DD format")
+		}
+		filter.From = t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return apperror.BadRequestError(err, "to must be in YYYY-MM-DD format")
+		}
+		filter.To = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	res, err := h.service.GetRentTrend(filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(res))
+}
+
+// GetMyMarketPosition godoc
+// @Summary Compare my dorms with the area median
+// @Description For each of the lessor's dorms, compare its price with the median price of other dorms with the same bedroom count in the same district
+// @Tags analytics
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Number of dorms to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.DormMarketPositionResponseBody] "Market position retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Only lessors can view their market position"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve market position"
+// @Router /analytics/dorms/me [get]
+func (h *AnalyticsHandler) GetMyMarketPosition(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	user := c.Locals("user").(*domain.User)
+	positions, totalPages, totalRows, err := h.service.GetMarketPositionByOwnerID(user, limit, page)
+	if err != nil {
+		return err
+	}
+
+	res := dto.SuccessPagination(positions, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
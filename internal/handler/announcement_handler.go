@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type AnnouncementHandler struct {
+	service ports.AnnouncementService
+}
+
+func NewAnnouncementHandler(service ports.AnnouncementService) ports.AnnouncementHandler {
+	return &AnnouncementHandler{service: service}
+}
+
+// Create godoc
+// @Summary Post an announcement to a dorm
+// @Description Send an announcement to every current tenant of the dorm through their notification channels. It is published right away, or at publishAt when it is in the future. Up to 5 image or PDF attachments.
+// @Tags announcement
+// @Security Bearer
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "DormID"
+// @Param title formData string true "Title"
+// @Param body formData string true "Body"
+// @Param publishAt formData string false "Publication time, in RFC 3339"
+// @Param attachments formData file false "Image or PDF attachments"
+// @Success 201 {object} dto.SuccessResponse[dto.AnnouncementResponseBody] "Announcement created successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage the announcements of this dorm"
+// @Failure 404 {object} dto.ErrorResponse "Dorm not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to create announcement"
+// @Router /dorms/{id}/announcements [post]
+func (h *AnnouncementHandler) Create(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(dto.AnnouncementRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	var publishAt time.Time
+	if body.PublishAt != "" {
+		publishAt, err = time.Parse(time.RFC3339, body.PublishAt)
+		if err != nil {
+			return apperror.BadRequestError(err, "publishAt must be in RFC 3339 format")
+		}
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return apperror.BadRequestError(err, "Invalid multipart form data")
+	}
+
+	headers := form.File["attachments"]
+	files := make([]domain.AnnouncementFile, 0, len(headers))
+	for _, header := range headers {
+		fileData, err := header.Open()
+		if err != nil {
+			return apperror.InternalServerError(err, "error opening file")
+		}
+		defer fileData.Close()
+
+		files = append(files, domain.AnnouncementFile{
+			Name:        header.Filename,
+			ContentType: header.Header.Get("Content-Type"),
+			Data:        fileData,
+		})
+	}
+
+	user := c.Locals("user").(*domain.User)
+	announcement, err := h.service.Create(c.Context(), user, dormID, *body, publishAt, files)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(announcement))
+}
+
+// GetByID godoc
+// @Summary Get an announcement
+// @Description Get an announcement. The lessor gets how many tenants received and read it, a tenant gets whether they read it.
+// @Tags announcement
+// @Security Bearer
+// @Produce json
+// @Param id path string true "AnnouncementID"
+// @Success 200 {object} dto.SuccessResponse[dto.AnnouncementResponseBody] "Announcement retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to view this announcement"
+// @Failure 404 {object} dto.ErrorResponse "Announcement not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve announcement"
+// @Router /announcements/{id} [get]
+func (h *AnnouncementHandler) GetByID(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	announcement, err := h.service.GetByID(c.Context(), user, id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(announcement))
+}
+
+// GetByDormID godoc
+// @Summary Get the announcements of a dorm
+// @Description Get the announcements of a dorm with their read stats, scheduled ones included, latest first. Only for the lessor.
+// @Tags announcement
+// @Security Bearer
+// @Produce json
+// @Param id path string true "DormID"
+// @Param limit query int false "Number of announcements to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.AnnouncementResponseBody] "Announcements retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage the announcements of this dorm"
+// @Failure 404 {object} dto.ErrorResponse "Dorm not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve announcements"
+// @Router /dorms/{id}/announcements [get]
+func (h *AnnouncementHandler) GetByDormID(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	user := c.Locals("user").(*domain.User)
+	announcements, totalPages, totalRows, err := h.service.GetByDormID(c.Context(), user, dormID, limit, page)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessPagination(announcements, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	}))
+}
+
+// GetByLeasingHistoryID godoc
+// @Summary Get the announcements of a lease
+// @Description Get the announcements the tenant received during the lease, newest first, with whether they read them
+// @Tags announcement
+// @Security Bearer
+// @Produce json
+// @Param id path string true "LeasingHistoryID"
+// @Param limit query int false "Number of announcements to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.AnnouncementResponseBody] "Announcements retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to view the announcements of this lease"
+// @Failure 404 {object} dto.ErrorResponse "leasing history not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve announcements"
+// @Router /history/{id}/announcements [get]
+func (h *AnnouncementHandler) GetByLeasingHistoryID(c *fiber.Ctx) error {
+	historyID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	user := c.Locals("user").(*domain.User)
+	announcements, totalPages, totalRows, err := h.service.GetByLeasingHistoryID(c.Context(), user, historyID, limit, page)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessPagination(announcements, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	}))
+}
+
+// MarkRead godoc
+// @Summary Mark an announcement as read
+// @Description Mark an announcement the current user received as read. The time it was first read is kept.
+// @Tags announcement
+// @Security Bearer
+// @Produce json
+// @Param id path string true "AnnouncementID"
+// @Success 204 "Announcement marked as read"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Announcement not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to mark announcement as read"
+// @Router /announcements/{id}/read [post]
+func (h *AnnouncementHandler) MarkRead(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.MarkRead(user, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Delete godoc
+// @Summary Delete an announcement
+// @Description Cancel a scheduled announcement, or remove a published one from the tenants' lease pages. Only for the lessor.
+// @Tags announcement
+// @Security Bearer
+// @Produce json
+// @Param id path string true "AnnouncementID"
+// @Success 204 "Announcement deleted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage the announcements of this dorm"
+// @Failure 404 {object} dto.ErrorResponse "Announcement not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to delete announcement"
+// @Router /announcements/{id} [delete]
+func (h *AnnouncementHandler) Delete(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.Delete(c.Context(), user, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
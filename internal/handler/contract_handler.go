@@ -208,8 +208,9 @@ func (ct *ContractHandler) GetContractByUserID(c *fiber.Ctx) error {
 // @Success 200 {object} dto.PaginationResponse[dto.ContractResponseBody] "Contracts retrieved successfully"
 // @Failure 400 {object} dto.ErrorResponse "Invalid dorm ID format or query parameters"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to view this dorm's contracts"
 // @Failure 500 {object} dto.ErrorResponse "Failed to retrieve contracts"
-// @Router /contract/{dormID} [get]
+// @Router /contract/bydorm/{dormID} [get]
 func (ct *ContractHandler) GetContractByDormID(c *fiber.Ctx) error {
 	id := c.Params("dormID")
 	dormID, err := uuid.Parse(id)
@@ -227,7 +228,8 @@ func (ct *ContractHandler) GetContractByDormID(c *fiber.Ctx) error {
 	if page <= 0 {
 		page = 1
 	}
-	contracts, totalPage, totalRows, err := ct.contractService.GetByDormID(dormID, limit, page)
+	user := c.Locals("user").(*domain.User)
+	contracts, totalPage, totalRows, err := ct.contractService.GetByDormID(user, dormID, limit, page)
 	if err != nil {
 		return err
 	}
@@ -16,10 +16,11 @@ import (
 
 type DormHandler struct {
 	dormService ports.DormService
+	views       ports.DormViewRecorder
 }
 
-func NewDormHandler(service ports.DormService) ports.DormHandler {
-	return &DormHandler{dormService: service}
+func NewDormHandler(service ports.DormService, views ports.DormViewRecorder) ports.DormHandler {
+	return &DormHandler{dormService: service, views: views}
 }
 
 // Register godoc
@@ -38,8 +39,7 @@ func NewDormHandler(service ports.DormService) ports.DormHandler {
 // @Router /dorms [post]
 func (d *DormHandler) Create(c *fiber.Ctx) error {
 	user := c.Locals("user").(*domain.User)
-	userRole := user.Role
-	if userRole == "" {
+	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
 
@@ -71,7 +71,7 @@ func (d *DormHandler) Create(c *fiber.Ctx) error {
 		Description: reqBody.Description,
 	}
 
-	if err := d.dormService.Create(userRole, dorm); err != nil {
+	if err := d.dormService.Create(user, dorm); err != nil {
 		if apperror.IsAppError(err) {
 			return err
 		}
@@ -105,14 +105,11 @@ func (d *DormHandler) Create(c *fiber.Ctx) error {
 // @Router /dorms/{id} [delete]
 func (d *DormHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
-	userID := c.Locals("userID").(uuid.UUID)
 	user := c.Locals("user").(*domain.User)
 	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
 
-	isAdmin := user.Role == domain.AdminRole
-
 	if err := uuid.Validate(id); err != nil {
 		return apperror.BadRequestError(err, "Incorrect UUID format")
 	}
@@ -122,7 +119,7 @@ func (d *DormHandler) Delete(c *fiber.Ctx) error {
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
 
-	if err := d.dormService.Delete(c.Context(), userID, isAdmin, dormID); err != nil {
+	if err := d.dormService.Delete(c.Context(), user, dormID); err != nil {
 		if apperror.IsAppError(err) {
 			return err
 		}
@@ -194,7 +191,7 @@ func (d *DormHandler) GetAll(c *fiber.Ctx) error {
 
 // GetByID godoc
 // @Summary Get a dorm by ID
-// @Description Retrieve a specific dorm based on its ID
+// @Description Retrieve a specific dorm based on its ID. Every call counts as a view in the weekly report of the owner.
 // @Tags dorms
 // @Produce json
 // @Param id path string true "DormID"
@@ -224,6 +221,8 @@ func (d *DormHandler) GetByID(c *fiber.Ctx) error {
 		return apperror.InternalServerError(err, "get dorm error")
 	}
 
+	d.views.RecordView(dormID)
+
 	return c.Status(fiber.StatusOK).JSON(dto.Success(dorm))
 }
 
@@ -244,15 +243,12 @@ func (d *DormHandler) GetByID(c *fiber.Ctx) error {
 // @Failure 500 {object} dto.ErrorResponse "Server failed to update dorm"
 // @Router /dorms/{id} [patch]
 func (d *DormHandler) Update(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(uuid.UUID)
 	user := c.Locals("user").(*domain.User)
 
 	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
 
-	isAdmin := user.Role == domain.AdminRole
-
 	id := c.Params("id")
 	updateReqBody := new(dto.DormUpdateRequestBody)
 	if err := c.BodyParser(updateReqBody); err != nil {
@@ -273,7 +269,7 @@ func (d *DormHandler) Update(c *fiber.Ctx) error {
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
 
-	updatedDorm, err := d.dormService.Update(userID, isAdmin, dormID, updateReqBody)
+	updatedDorm, err := d.dormService.Update(user, dormID, updateReqBody)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -318,13 +314,10 @@ func (d *DormHandler) UploadDormImage(c *fiber.Ctx) error {
 
 	files := form.File["image"]
 
-	userID := c.Locals("userID").(uuid.UUID)
 	user := c.Locals("user").(*domain.User)
 	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
-	isAdmin := user.Role == domain.AdminRole
-
 	urls := []string{}
 	for _, file := range files {
 		fileData, err := file.Open()
@@ -338,7 +331,7 @@ func (d *DormHandler) UploadDormImage(c *fiber.Ctx) error {
 			return apperror.BadRequestError(errors.New("uploaded file is not an image"), "uploaded file is not an image")
 		}
 
-		url, err := d.dormService.UploadDormImage(c.Context(), dormID, file.Filename, contentType, fileData, userID, isAdmin)
+		url, err := d.dormService.UploadDormImage(c.Context(), dormID, file.Filename, contentType, fileData, user)
 		if err != nil {
 			return err
 		}
@@ -424,15 +417,12 @@ func (d *DormHandler) DeleteDormImageByURL(c *fiber.Ctx) error {
 		return apperror.BadRequestError(err, "Invalid URL")
 	}
 
-	userID := c.Locals("userID").(uuid.UUID)
 	user := c.Locals("user").(*domain.User)
 	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
 
-	isAdmin := user.Role == domain.AdminRole
-
-	if err := d.dormService.DeleteImageByURL(c.Context(), decodedURL, userID, isAdmin); err != nil {
+	if err := d.dormService.DeleteImageByURL(c.Context(), decodedURL, user); err != nil {
 		return err
 	}
 
@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type DormManagerHandler struct {
+	service ports.DormManagerService
+}
+
+func NewDormManagerHandler(service ports.DormManagerService) ports.DormManagerHandler {
+	return &DormManagerHandler{service: service}
+}
+
+// Invite godoc
+// @Summary Invite a dorm manager
+// @Description Invite a user, e.g. a caretaker, to manage the dorm with the given scopes. Payouts are never shared with managers.
+// @Tags dorm-manager
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "DormID"
+// @Param manager body dto.DormManagerInviteRequestBody true "Manager email and scopes"
+// @Success 201 {object} dto.SuccessResponse[dto.DormManagerResponseBody] "Manager invited successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage this dorm's managers"
+// @Failure 404 {object} dto.ErrorResponse "Dorm or user not found"
+// @Failure 409 {object} dto.ErrorResponse "This user is already a manager of this dorm"
+// @Failure 500 {object} dto.ErrorResponse "Failed to invite manager"
+// @Router /dorms/{id}/managers [post]
+func (h *DormManagerHandler) Invite(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(dto.DormManagerInviteRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	manager, err := h.service.Invite(user, dormID, body.Email, domain.NewManagerScopes(body.Scopes))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(manager.ToDTO()))
+}
+
+// GetByDormID godoc
+// @Summary Get the managers of a dorm
+// @Description List the managers and pending invitations of a dorm
+// @Tags dorm-manager
+// @Security Bearer
+// @Produce json
+// @Param id path string true "DormID"
+// @Success 200 {object} dto.SuccessResponse[[]dto.DormManagerResponseBody] "Managers retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage this dorm's managers"
+// @Failure 404 {object} dto.ErrorResponse "Dorm not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve managers"
+// @Router /dorms/{id}/managers [get]
+func (h *DormManagerHandler) GetByDormID(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	managers, err := h.service.GetByDormID(user, dormID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(managerDTOs(managers)))
+}
+
+// GetMine godoc
+// @Summary Get the dorms I manage
+// @Description List the current user's manager invitations and the dorms they manage
+// @Tags dorm-manager
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[[]dto.DormManagerResponseBody] "Managed dorms retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve managed dorms"
+// @Router /managers/me [get]
+func (h *DormManagerHandler) GetMine(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	managers, err := h.service.GetMine(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(managerDTOs(managers)))
+}
+
+// UpdateScopes godoc
+// @Summary Change what a manager may do
+// @Description Replace the scopes of a dorm manager
+// @Tags dorm-manager
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "DormID"
+// @Param managerID path string true "Manager ID, the id of the delegation"
+// @Param manager body dto.DormManagerUpdateRequestBody true "New scopes"
+// @Success 200 {object} dto.SuccessResponse[dto.DormManagerResponseBody] "Manager updated successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage this dorm's managers"
+// @Failure 404 {object} dto.ErrorResponse "Manager not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update manager"
+// @Router /dorms/{id}/managers/{managerID} [patch]
+func (h *DormManagerHandler) UpdateScopes(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("managerID"))
+	if err != nil {
+		return apperror.BadRequestError(err, "Incorrect UUID format")
+	}
+
+	body := new(dto.DormManagerUpdateRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	manager, err := h.service.UpdateScopes(user, dormID, id, domain.NewManagerScopes(body.Scopes))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(manager.ToDTO()))
+}
+
+// Remove godoc
+// @Summary Remove a dorm manager
+// @Description Revoke a manager or cancel an invitation
+// @Tags dorm-manager
+// @Security Bearer
+// @Param id path string true "DormID"
+// @Param managerID path string true "Manager ID, the id of the delegation"
+// @Success 204 "Manager removed successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage this dorm's managers"
+// @Failure 404 {object} dto.ErrorResponse "Manager not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to remove manager"
+// @Router /dorms/{id}/managers/{managerID} [delete]
+func (h *DormManagerHandler) Remove(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("managerID"))
+	if err != nil {
+		return apperror.BadRequestError(err, "Incorrect UUID format")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.Remove(user, dormID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Accept godoc
+// @Summary Accept a manager invitation
+// @Description Start managing a dorm the current user was invited to
+// @Tags dorm-manager
+// @Security Bearer
+// @Produce json
+// @Param id path string true "Manager ID, the id of the invitation"
+// @Success 200 {object} dto.SuccessResponse[dto.DormManagerResponseBody] "Invitation accepted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Manager not found"
+// @Failure 409 {object} dto.ErrorResponse "Invitation already accepted"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update manager"
+// @Router /managers/{id}/accept [post]
+func (h *DormManagerHandler) Accept(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	manager, err := h.service.Accept(userID, id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(manager.ToDTO()))
+}
+
+// Leave godoc
+// @Summary Decline an invitation or stop managing a dorm
+// @Description Decline a manager invitation or give up managing a dorm
+// @Tags dorm-manager
+// @Security Bearer
+// @Param id path string true "Manager ID, the id of the invitation"
+// @Success 204 "Left successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Manager not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to remove manager"
+// @Router /managers/{id} [delete]
+func (h *DormManagerHandler) Leave(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.Leave(userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetActions godoc
+// @Summary Get actions managers took
+// @Description Audit log of the actions managers took on behalf of the owner, newest first
+// @Tags dorm-manager
+// @Security Bearer
+// @Produce json
+// @Param id path string true "DormID"
+// @Param limit query int false "Number of actions to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.DelegatedActionResponseBody] "Actions retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to manage this dorm's managers"
+// @Failure 404 {object} dto.ErrorResponse "Dorm not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve delegated actions"
+// @Router /dorms/{id}/managers/actions [get]
+func (h *DormManagerHandler) GetActions(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	user := c.Locals("user").(*domain.User)
+	actions, totalPages, totalRows, err := h.service.GetActions(user, dormID, limit, page)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.DelegatedActionResponseBody, len(actions))
+	for i, v := range actions {
+		resData[i] = v.ToDTO()
+	}
+
+	res := dto.SuccessPagination(resData, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func managerDTOs(managers []domain.DormManager) []dto.DormManagerResponseBody {
+	resData := make([]dto.DormManagerResponseBody, len(managers))
+	for i, v := range managers {
+		resData[i] = v.ToDTO()
+	}
+	return resData
+}
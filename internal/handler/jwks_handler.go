@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
+	"github.com/gofiber/fiber/v2"
+)
+
+type JWKSHandler struct {
+	jwtUtils *jwt.JWTUtils
+}
+
+func NewJWKSHandler(jwtUtils *jwt.JWTUtils) *JWKSHandler {
+	return &JWKSHandler{jwtUtils: jwtUtils}
+}
+
+// GetJWKS godoc
+// @Summary Get the token signing keys
+// @Description Public keys in JWK format that access and refresh tokens are signed with. Tokens carry the kid of their key.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwt.JWKS "JSON Web Key Set"
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(h.jwtUtils.JWKS())
+}
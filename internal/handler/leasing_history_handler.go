@@ -25,14 +25,16 @@ func NewLeasingHistoryHandler(service ports.LeasingHistoryService, dormService p
 
 // SetEndTimestamp godoc
 // @Summary Set end date of a leasing history
-// @Description Set end date of a leasing history in the database
+// @Description Set end date of a leasing history in the database. When no lease of the dorm is left, saved searches matching it are alerted that it is available again
 // @Tags history
 // @Security Bearer
 // @Produce json
 // @Param id path string true "LeasingHistoryId"
 // @Success 204 "Set end timestamp successfully"
 // @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 400 {object} dto.ErrorResponse "This lease has already ended"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to end this lease"
 // @Failure 404 {object} dto.ErrorResponse "leasing history not found"
 // @Failure 500 {object} dto.ErrorResponse "Can not parse UUID or Failed to update leasing history"
 // @Router /history/{id} [patch]
@@ -41,7 +43,8 @@ func (h *LeasingHistoryHandler) SetEndTimestamp(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	err = h.service.SetEndTimestamp(leasingHistoryID)
+	user := c.Locals("user").(*domain.User)
+	err = h.service.SetEndTimestamp(user, leasingHistoryID)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -193,6 +196,7 @@ func (h *LeasingHistoryHandler) GetByID(c *fiber.Ctx) error {
 // @Success 204 "No Content"
 // @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to delete this leasing history"
 // @Failure 404 {object} dto.ErrorResponse "leasing history not found"
 // @Failure 500 {object} dto.ErrorResponse "Can not parse UUID or Failed to delete leasing history"
 // @Router /history/{id} [delete]
@@ -202,7 +206,8 @@ func (h *LeasingHistoryHandler) Delete(c *fiber.Ctx) error {
 		return err
 	}
 
-	err = h.service.Delete(leasingHistoryID)
+	user := c.Locals("user").(*domain.User)
+	err = h.service.Delete(user, leasingHistoryID)
 	if err != nil {
 		return err
 	}
@@ -229,7 +234,7 @@ func (h *LeasingHistoryHandler) Create(c *fiber.Ctx) error {
 		return err
 	}
 
-	leasingHistory, err := h.service.Create(userID, dormID)
+	leasingHistory, err := h.service.Create(userID, dormID, 0)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -279,7 +284,7 @@ func (h *LeasingHistoryHandler) CreateReview(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	review, err := h.service.CreateReview(user, historyID, body.Message, int(body.Rate))
+	review, err := h.service.CreateReview(user, historyID, body.Message, int(body.Rate), toReviewRatings(body.Ratings))
 	if err != nil {
 		return err
 	}
@@ -290,13 +295,13 @@ func (h *LeasingHistoryHandler) CreateReview(c *fiber.Ctx) error {
 
 // GetReviewByDormID godoc
 // @Summary Get all reviews by dormid
-// @Description Retrieve a list of all reviews by dormid
+// @Description Retrieve a list of all reviews by dormid, with the per-dimension rating averages and the rating histogram of the dorm
 // @Tags history
 // @Produce json
 // @Param id path string true "DormID"
 // @Param limit query int false "Number of reviews to retrieve (default 10, max 50)"
 // @Param page query int false "Page number to retrieve (default 1)"
-// @Success 200 {object} dto.PaginationResponse[dto.Review] "Retrive reviews successfully"
+// @Success 200 {object} dto.ReviewPaginationResponse "Retrive reviews successfully"
 // @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format or limit parameter is incorrect or page parameter is incorrect or page exceeded"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
 // @Failure 404 {object} dto.ErrorResponse "leasing history not found"
@@ -334,12 +339,21 @@ func (h *LeasingHistoryHandler) GetReviewByDormID(c *fiber.Ctx) error {
 		}
 	}
 
-	res := dto.SuccessPagination(resData, dto.Pagination{
-		CurrentPage: page,
-		LastPage:    totalPage,
-		Limit:       limit,
-		Total:       totalRows,
-	})
+	summary, err := h.service.GetReviewSummaryByDormID(dormID)
+	if err != nil {
+		return err
+	}
+
+	res := dto.ReviewPaginationResponse{
+		Data: resData,
+		Pagination: dto.Pagination{
+			CurrentPage: page,
+			LastPage:    totalPage,
+			Limit:       limit,
+			Total:       totalRows,
+		},
+		Summary: summary.ToDTO(),
+	}
 
 	return c.Status(fiber.StatusOK).JSON(res)
 }
@@ -378,13 +392,10 @@ func (h *LeasingHistoryHandler) UploadReviewImage(c *fiber.Ctx) error {
 
 	files := form.File["image"]
 
-	userID := c.Locals("userID").(uuid.UUID)
 	user := c.Locals("user").(*domain.User)
 	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
-	isAdmin := user.Role == domain.AdminRole
-
 	urls := []string{}
 	for _, file := range files {
 		fileData, err := file.Open()
@@ -398,7 +409,7 @@ func (h *LeasingHistoryHandler) UploadReviewImage(c *fiber.Ctx) error {
 			return apperror.BadRequestError(errors.New("uploaded file is not an image"), "uploaded file is not an image")
 		}
 
-		url, err := h.service.UploadReviewImage(c.Context(), historyID, file.Filename, contentType, fileData, userID, isAdmin)
+		url, err := h.service.UploadReviewImage(c.Context(), historyID, file.Filename, contentType, fileData, user)
 		if err != nil {
 			return err
 		}
@@ -429,15 +440,12 @@ func (h *LeasingHistoryHandler) DeleteReviewImageByURL(c *fiber.Ctx) error {
 		return apperror.BadRequestError(err, "Invalid URL")
 	}
 
-	userID := c.Locals("userID").(uuid.UUID)
 	user := c.Locals("user").(*domain.User)
 	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
 
-	isAdmin := user.Role == domain.AdminRole
-
-	if err := h.service.DeleteImageByURL(c.Context(), decodedURL, userID, isAdmin); err != nil {
+	if err := h.service.DeleteImageByURL(c.Context(), decodedURL, user); err != nil {
 		return err
 	}
 
@@ -480,7 +488,7 @@ func (h *LeasingHistoryHandler) UpdateReview(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	review, err := h.service.UpdateReview(user, historyID, body.Message, int(body.Rate))
+	review, err := h.service.UpdateReview(user, historyID, body.Message, int(body.Rate), toReviewRatings(body.Ratings))
 	if err != nil {
 		return err
 	}
@@ -515,83 +523,133 @@ func (h *LeasingHistoryHandler) DeleteReview(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// GetReportedReview godoc
-// @Summary Get all reported reviews
-// @Description Endpoint for admins to get a list of all reported reviews
-// @Tags admin
+// ReplyToReview godoc
+// @Summary Reply to a review
+// @Description Add or replace the dorm owner's public reply to a review
+// @Tags history
 // @Security Bearer
+// @Accept json
 // @Produce json
-// @Param limit query int false "Number of reviews to retrieve (default 10, max 50)"
-// @Param page query int false "Page number to retrieve (default 1)"
-// @Success 200 {object} dto.PaginationResponse[dto.ReportedReview] "Retrieve reported reviews successfully"
-// @Failure 401 {object} dto.ErrorResponse "unauthorized"
-// @Failure 403 {object} dto.ErrorResponse "forbidden"
-// @Failure 500 {object} dto.ErrorResponse "internal server error"
-// @Router /admin/reviews/reported [get]
-func (h *LeasingHistoryHandler) GetReportedReviews(c *fiber.Ctx) error {
-	limit := c.QueryInt("limit", 10)
-	if limit <= 0 {
-		limit = 10
-	} else if limit > 50 {
-		limit = 50
+// @Param id path string true "HistoryID"
+// @Param reply body dto.ReviewReplyRequestBody true "Reply"
+// @Success 200 {object} dto.SuccessResponse[dto.Review] "Reply saved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format or your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Only the dorm owner can reply to this review"
+// @Failure 404 {object} dto.ErrorResponse "review not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save review reply"
+// @Router /history/{id}/review/reply [put]
+func (h *LeasingHistoryHandler) ReplyToReview(c *fiber.Ctx) error {
+	user := c.Locals("user").(*domain.User)
+	historyID, err := parseIdParam(c)
+	if err != nil {
+		return err
 	}
 
-	page := c.QueryInt("page", 1)
-	if page <= 0 {
-		page = 1
+	body := new(dto.ReviewReplyRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
 	}
 
-	leasingHistory, totalPage, totalRows, err := h.service.GetReportedReviews(limit, page)
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	history, err := h.service.ReplyToReview(user, historyID, body.Message)
 	if err != nil {
 		return err
 	}
 
-	resData := make([]dto.ReportedReview, len(leasingHistory))
-	for i, v := range leasingHistory {
-		urls := h.service.GetImageUrl(v.Images)
-		resData[i] = v.Review.ToReportedReviewDTO(urls, v.Lessee.ToDTO(), v.ID)
+	urls := h.service.GetImageUrl(history.Images)
+	return c.Status(fiber.StatusOK).JSON(dto.Success(history.Review.ToDTO(urls, history.Lessee.ToDTO(), history.ID)))
+}
+
+// DeleteReviewReply godoc
+// @Summary Delete a review reply
+// @Description Remove the dorm owner's reply to a review
+// @Tags history
+// @Security Bearer
+// @Produce json
+// @Param id path string true "HistoryID"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to delete this reply"
+// @Failure 404 {object} dto.ErrorResponse "reply not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save review reply"
+// @Router /history/{id}/review/reply [delete]
+func (h *LeasingHistoryHandler) DeleteReviewReply(c *fiber.Ctx) error {
+	user := c.Locals("user").(*domain.User)
+	historyID, err := parseIdParam(c)
+	if err != nil {
+		return err
 	}
 
-	res := dto.SuccessPagination(resData, dto.Pagination{
-		CurrentPage: page,
-		LastPage:    totalPage,
-		Limit:       limit,
-		Total:       totalRows,
-	})
+	if err := h.service.DeleteReviewReply(user, historyID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
 
-	return c.Status(fiber.StatusOK).JSON(res)
+// VoteReviewHelpful godoc
+// @Summary Mark a review as helpful
+// @Description Add the current user's helpful vote to a review. Voting twice has no effect.
+// @Tags history
+// @Security Bearer
+// @Produce json
+// @Param id path string true "HistoryID"
+// @Success 200 {object} dto.SuccessResponse[dto.Review] "Vote saved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format or you cannot vote on your own review"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "review not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save helpful vote"
+// @Router /history/{id}/review/helpful [post]
+func (h *LeasingHistoryHandler) VoteReviewHelpful(c *fiber.Ctx) error {
+	return h.setHelpfulVote(c, true)
 }
 
-// ReportReview godoc
-// @Summary Report a review
-// @Description Mark a review as reported
+// UnvoteReviewHelpful godoc
+// @Summary Remove a helpful vote
+// @Description Remove the current user's helpful vote from a review
 // @Tags history
 // @Security Bearer
 // @Produce json
 // @Param id path string true "HistoryID"
-// @Success 200 {object} dto.SuccessResponse[dto.Review] "Review reported successfully"
-// @Failure 400 {object} dto.ErrorResponse "bad request"
-// @Failure 401 {object} dto.ErrorResponse "unauthorized"
-// @Failure 403 {object} dto.ErrorResponse "forbidden"
-// @Failure 404 {object} dto.ErrorResponse "not found"
-// @Failure 409 {object} dto.ErrorResponse "conflict"
-// @Failure 500 {object} dto.ErrorResponse "internal server error"
-// @Router /history/{id}/review/report [post]
-func (h *LeasingHistoryHandler) ReportReview(c *fiber.Ctx) error {
+// @Success 200 {object} dto.SuccessResponse[dto.Review] "Vote removed successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format or you cannot vote on your own review"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "review not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save helpful vote"
+// @Router /history/{id}/review/helpful [delete]
+func (h *LeasingHistoryHandler) UnvoteReviewHelpful(c *fiber.Ctx) error {
+	return h.setHelpfulVote(c, false)
+}
+
+func (h *LeasingHistoryHandler) setHelpfulVote(c *fiber.Ctx, helpful bool) error {
+	user := c.Locals("user").(*domain.User)
 	historyID, err := parseIdParam(c)
 	if err != nil {
 		return err
 	}
 
-	history, err := h.service.ReportReview(historyID)
+	history, err := h.service.SetHelpfulVote(user, historyID, helpful)
 	if err != nil {
 		return err
 	}
 
 	urls := h.service.GetImageUrl(history.Images)
-	data := history.Review.ToDTO(urls, history.Lessee.ToDTO(), history.ID)
+	return c.Status(fiber.StatusOK).JSON(dto.Success(history.Review.ToDTO(urls, history.Lessee.ToDTO(), history.ID)))
+}
 
-	return c.Status(fiber.StatusOK).JSON(dto.Success(data))
+func toReviewRatings(ratings dto.ReviewRatings) domain.ReviewRatings {
+	return domain.ReviewRatings{
+		CleanlinessRate:   ratings.Cleanliness,
+		LocationRate:      ratings.Location,
+		ValueRate:         ratings.Value,
+		CommunicationRate: ratings.Communication,
+		AccuracyRate:      ratings.Accuracy,
+	}
 }
 
 func parseIdParam(c *fiber.Ctx) (uuid.UUID, error) {
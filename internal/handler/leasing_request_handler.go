@@ -6,6 +6,7 @@ import (
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/yokeTH/go-pkg/apperror"
@@ -52,7 +53,7 @@ func (h *LeasingRequestHandler) Approve(c *fiber.Ctx) error {
 		}
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
-	err = h.service.Approve(leasingRequestID, user.ID, user.Role == domain.AdminRole)
+	err = h.service.Approve(leasingRequestID, user)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -95,7 +96,7 @@ func (h *LeasingRequestHandler) Reject(c *fiber.Ctx) error {
 		}
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
-	err = h.service.Reject(leasingRequestID, user.ID, user.Role == domain.AdminRole)
+	err = h.service.Reject(leasingRequestID, user)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -138,7 +139,7 @@ func (h *LeasingRequestHandler) Cancel(c *fiber.Ctx) error {
 		}
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
-	err = h.service.Cancel(leasingRequestID, user.ID, user.Role == domain.AdminRole)
+	err = h.service.Cancel(leasingRequestID, user)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -204,7 +205,7 @@ func (h *LeasingRequestHandler) GetByUserID(c *fiber.Ctx) error {
 // @Param id path string true "LeasingRequestId"
 // @Success 204 "No Content"
 // @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
-// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized or only admin can delete a request"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized or you cannot manage requests"
 // @Failure 404 {object} dto.ErrorResponse "leasing request not found"
 // @Failure 500 {object} dto.ErrorResponse "Can not parse UUID or Failed to delete leasing request"
 // @Router /request/{id} [delete]
@@ -259,6 +260,11 @@ func (h *LeasingRequestHandler) Create(c *fiber.Ctx) error {
 		return apperror.BadRequestError(err, "your request is invalid")
 	}
 
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
 	dormID, err := uuid.Parse(id)
 	if err != nil {
 		if apperror.IsAppError(err) {
@@ -266,7 +272,7 @@ func (h *LeasingRequestHandler) Create(c *fiber.Ctx) error {
 		}
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
-	leasingRequest, err := h.service.Create(userID, dormID, body.Message)
+	leasingRequest, err := h.service.Create(userID, dormID, body.Message, body.TermMonths)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -291,6 +297,7 @@ func (h *LeasingRequestHandler) Create(c *fiber.Ctx) error {
 // @Success 200 {object} dto.PaginationResponse[dto.LeasingRequest] "Retrieve request successfully"
 // @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format or limit parameter is incorrect or page parameter is incorrect or page exceeded"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to view this dorm's requests"
 // @Failure 404 {object} dto.ErrorResponse "leasing request not found"
 // @Failure 500 {object} dto.ErrorResponse "Can not parse UUID"
 // @Router /request/bydorm/{id} [get]
@@ -312,7 +319,8 @@ func (h *LeasingRequestHandler) GetByDormID(c *fiber.Ctx) error {
 		page = 1
 	}
 
-	leasingRequest, totalPage, totalRows, err := h.service.GetByDormID(dormID, limit, page)
+	user := c.Locals("user").(*domain.User)
+	leasingRequest, totalPage, totalRows, err := h.service.GetByDormID(user, dormID, limit, page)
 	if err != nil {
 		return err
 	}
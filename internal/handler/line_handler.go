@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type LineHandler struct {
+	service      ports.LineService
+	addFriendURL string
+}
+
+func NewLineHandler(service ports.LineService, addFriendURL string) ports.LineHandler {
+	return &LineHandler{service: service, addFriendURL: addFriendURL}
+}
+
+// CreateLinkCode godoc
+// @Summary Create a LINE link code
+// @Description Create a one-time code to link a LINE account. Add the ConDormHub bot as a friend with addFriendUrl and send it the code; notifications turned on for LINE in the preferences are then sent there. The code expires after 10 minutes and creating a new one replaces it.
+// @Tags line
+// @Security Bearer
+// @Produce json
+// @Success 201 {object} dto.SuccessResponse[dto.LineLinkCodeResponseBody] "Link code created successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 429 {object} dto.ErrorResponse "Too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Failed to create link code"
+// @Router /line/account/code [post]
+func (h *LineHandler) CreateLinkCode(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	code, expiresAt, err := h.service.CreateLinkCode(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(dto.LineLinkCodeResponseBody{
+		Code:         code,
+		ExpiresAt:    expiresAt,
+		AddFriendURL: h.addFriendURL,
+	}))
+}
+
+// GetAccount godoc
+// @Summary Get my LINE account
+// @Description Tell whether a LINE account is linked to the current user
+// @Tags line
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[dto.LineAccountResponseBody] "LINE account retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve LINE account"
+// @Router /line/account [get]
+func (h *LineHandler) GetAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	account, err := h.service.GetAccount(userID)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return c.Status(fiber.StatusOK).JSON(dto.Success(dto.LineAccountResponseBody{Linked: false}))
+	}
+	return c.Status(fiber.StatusOK).JSON(dto.Success(account.ToDTO()))
+}
+
+// Unlink godoc
+// @Summary Unlink my LINE account
+// @Description Stop sending notifications to the linked LINE account
+// @Tags line
+// @Security Bearer
+// @Success 204 "LINE account unlinked successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to unlink LINE account"
+// @Router /line/account [delete]
+func (h *LineHandler) Unlink(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.Unlink(userID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Webhook godoc
+// @Summary LINE webhook
+// @Description Receive the events of the ConDormHub bot from LINE, signed with X-Line-Signature. Link codes sent to the bot link the sender's account.
+// @Tags line
+// @Accept json
+// @Param X-Line-Signature header string true "Signature of the body"
+// @Success 200 "Events received"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "Invalid signature"
+// @Router /line/webhook [post]
+func (h *LineHandler) Webhook(c *fiber.Ctx) error {
+	if err := h.service.HandleWebhook(c.Context(), c.Body(), c.Get("X-Line-Signature")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
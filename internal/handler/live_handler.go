@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+// liveHeartbeat keeps idle streams from being closed by proxies and detects clients that went away.
+// The session is checked on every heartbeat, so a revoked session stops receiving events within it.
+const liveHeartbeat = 25 * time.Second
+
+type LiveHandler struct {
+	service ports.LiveService
+}
+
+func NewLiveHandler(service ports.LiveService) ports.LiveHandler {
+	return &LiveHandler{service: service}
+}
+
+// Stream godoc
+// @Summary Stream live updates
+// @Description Server-sent events for the current user: notifications, contract status changes and completed payments. Every event's data is a JSON object with a type and data. EventSource cannot set headers, so the access token may be passed as the access_token query parameter instead. The stream ends once its session is logged out or revoked, or the user is banned.
+// @Tags live
+// @Security Bearer
+// @Produce text/event-stream
+// @Param access_token query string false "Access token, when the Authorization header cannot be set"
+// @Success 200 {object} dto.LiveEvent "stream of events"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to subscribe to live updates"
+// @Router /live/events [get]
+func (h *LiveHandler) Stream(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	sessionID := c.Locals("sessionID").(string)
+
+	// the stream outlives the handler, so it cannot use the request context
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := h.service.Subscribe(ctx, userID)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		ticker := time.NewTicker(liveHeartbeat)
+		defer ticker.Stop()
+
+		fmt.Fprint(w, "retry: 5000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case payload, ok := <-events:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "data: %s\n\n", payload)
+			case <-ticker.C:
+				// the client cannot reconnect with the token of a revoked session either
+				active, err := h.service.Active(ctx, userID, sessionID)
+				if err != nil {
+					log.Errorf("live: cannot check session of user %s: %v", userID, err)
+					return
+				}
+				if !active {
+					return
+				}
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			// flushing fails once the client disconnected
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/gofiber/fiber/v2"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// MailboxHandler shows the emails captured by the file and memory mailers.
+// It is only routed when running locally.
+type MailboxHandler struct {
+	mailbox email.Mailbox
+}
+
+func NewMailboxHandler(mailbox email.Mailbox) *MailboxHandler {
+	return &MailboxHandler{mailbox: mailbox}
+}
+
+// GetAll godoc
+// @Summary Get captured emails
+// @Description Get the emails captured by the local mailer, newest first. Only available when running locally.
+// @Tags dev
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[[]dto.MailboxMessageResponseBody] "Emails retrieved successfully"
+// @Router /dev/mailbox [get]
+func (h *MailboxHandler) GetAll(c *fiber.Ctx) error {
+	messages := h.mailbox.Messages()
+
+	resData := make([]dto.MailboxMessageResponseBody, len(messages))
+	for i, v := range messages {
+		resData[i] = dto.MailboxMessageResponseBody{
+			ID:      v.ID,
+			SentAt:  v.SentAt,
+			To:      v.To,
+			Subject: v.Subject,
+			Text:    v.Text,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(resData))
+}
+
+// GetHTML godoc
+// @Summary Preview a captured email
+// @Description Render the HTML body of a captured email. Only available when running locally.
+// @Tags dev
+// @Produce html
+// @Param id path int true "Email ID"
+// @Success 200 {string} string "HTML body of the email"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect email ID"
+// @Failure 404 {object} dto.ErrorResponse "Email not found"
+// @Router /dev/mailbox/{id} [get]
+func (h *MailboxHandler) GetHTML(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return apperror.BadRequestError(err, "Incorrect email ID")
+	}
+
+	message, ok := h.mailbox.Message(id)
+	if !ok {
+		return apperror.NotFoundError(errors.New("email not found"), "Email not found")
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(fiber.StatusOK).SendString(message.HTML)
+}
+
+// Clear godoc
+// @Summary Delete captured emails
+// @Description Empty the local mailbox. Only available when running locally.
+// @Tags dev
+// @Success 204 "Mailbox cleared"
+// @Router /dev/mailbox [delete]
+func (h *MailboxHandler) Clear(c *fiber.Ctx) error {
+	h.mailbox.Clear()
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type NotificationHandler struct {
+	service ports.NotificationService
+}
+
+func NewNotificationHandler(service ports.NotificationService) ports.NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetAll godoc
+// @Summary Get my notifications
+// @Description Get the current user's notifications, newest first, with the number of unread ones
+// @Tags notification
+// @Security Bearer
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Number of notifications to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.NotificationListResponse "Notifications retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve notifications"
+// @Router /notifications [get]
+func (h *NotificationHandler) GetAll(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	notifications, totalPages, totalRows, err := h.service.GetByUserID(userID, c.QueryBool("unread"), limit, page)
+	if err != nil {
+		return err
+	}
+
+	unread, err := h.service.CountUnread(userID)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.NotificationResponseBody, len(notifications))
+	for i, v := range notifications {
+		resData[i] = v.ToDTO()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NotificationListResponse{
+		Data: resData,
+		Pagination: dto.Pagination{
+			CurrentPage: page,
+			LastPage:    totalPages,
+			Limit:       limit,
+			Total:       totalRows,
+		},
+		Unread: unread,
+	})
+}
+
+// GetUnreadCount godoc
+// @Summary Get my unread notification count
+// @Description Get the number of unread notifications, e.g. for a badge
+// @Tags notification
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[dto.UnreadCountResponseBody] "Unread count retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to count unread notifications"
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	unread, err := h.service.CountUnread(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.UnreadCountResponseBody{Unread: unread}))
+}
+
+// MarkRead godoc
+// @Summary Mark a notification as read
+// @Description Mark one of the current user's notifications as read
+// @Tags notification
+// @Security Bearer
+// @Param id path string true "NotificationID"
+// @Success 204 "Notification marked as read"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Notification not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update notification"
+// @Router /notifications/{id}/read [patch]
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.MarkRead(userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// MarkAllRead godoc
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the current user as read
+// @Tags notification
+// @Security Bearer
+// @Success 204 "Notifications marked as read"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update notifications"
+// @Router /notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.MarkAllRead(userID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetPreferences godoc
+// @Summary Get my notification preferences
+// @Description Get where each notification type is delivered: in-app, by email or on LINE
+// @Tags notification
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[[]dto.NotificationPreferenceBody] "Preferences retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve notification preferences"
+// @Router /notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	preferences, err := h.service.GetPreferences(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(preferenceDTOs(preferences)))
+}
+
+// UpdatePreferences godoc
+// @Summary Update my notification preferences
+// @Description Change where the given notification types are delivered: in-app, by email or on LINE once an account is linked. Types left out keep their preference. Turning off email for report.weekly opts out of the weekly lessor report.
+// @Tags notification
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param preferences body dto.NotificationPreferencesRequestBody true "Preferences to change"
+// @Success 200 {object} dto.SuccessResponse[[]dto.NotificationPreferenceBody] "Preferences updated successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save notification preferences"
+// @Router /notifications/preferences [patch]
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	body := new(dto.NotificationPreferencesRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	preferences := make([]domain.NotificationPreference, len(body.Preferences))
+	for i, p := range body.Preferences {
+		preferences[i] = domain.NotificationPreference{
+			Type:  domain.NotificationType(p.Type),
+			InApp: p.InApp,
+			Email: p.Email,
+			Line:  p.Line,
+		}
+	}
+
+	updated, err := h.service.UpdatePreferences(userID, preferences)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(preferenceDTOs(updated)))
+}
+
+func preferenceDTOs(preferences []domain.NotificationPreference) []dto.NotificationPreferenceBody {
+	resData := make([]dto.NotificationPreferenceBody, len(preferences))
+	for i, v := range preferences {
+		resData[i] = v.ToDTO()
+	}
+	return resData
+}
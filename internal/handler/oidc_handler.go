@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type OIDCHandler struct {
+	service     ports.OIDCService
+	userService ports.UserService
+}
+
+func NewOIDCHandler(service ports.OIDCService, userService ports.UserService) ports.OIDCHandler {
+	return &OIDCHandler{service: service, userService: userService}
+}
+
+// GetLoginURL godoc
+// @Summary Start a social login
+// @Description Get the URL of the provider's login page. The provider redirects back to the frontend with a code and state to send to the callback.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider, e.g. google"
+// @Success 200 {object} dto.SuccessResponse[dto.OIDCAuthorizationResponseBody] "authorization url created successfully"
+// @Failure 404 {object} dto.ErrorResponse "Login provider not supported"
+// @Failure 500 {object} dto.ErrorResponse "Identity provider is unavailable"
+// @Router /auth/oidc/{provider} [get]
+func (h *OIDCHandler) GetLoginURL(c *fiber.Ctx) error {
+	url, err := h.service.GetAuthorizationURL(c.Context(), c.Params("provider"), uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.OIDCAuthorizationResponseBody{AuthorizationURL: url}))
+}
+
+// Callback godoc
+// @Summary Finish a social login
+// @Description Log in with the code and state the provider redirected back with. A new account is created for unknown verified emails, an existing account is only linked if it verified its email. With two-factor authentication enabled, and for staff who still have to enroll, the response is a dto.TwoFactorPendingResponseBody instead.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider, e.g. google"
+// @Param callback body dto.OIDCCallbackRequestBody true "Code and state"
+// @Success 200 {object} dto.SuccessResponse[dto.TokenWithUserInformationResponseBody] "user successfully logged in"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "Login with provider failed"
+// @Failure 403 {object} dto.ErrorResponse "Your email is not verified by the provider"
+// @Failure 404 {object} dto.ErrorResponse "Login provider not supported"
+// @Failure 409 {object} dto.ErrorResponse "An account with this email already exists, log in with your password to link this provider"
+// @Failure 500 {object} dto.ErrorResponse "Failed to finish login"
+// @Router /auth/oidc/{provider}/callback [post]
+func (h *OIDCHandler) Callback(c *fiber.Ctx) error {
+	body := new(dto.OIDCCallbackRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user, accessToken, refreshToken, twoFactorToken, err := h.service.Login(c.Context(), c.Params("provider"), body.Code, body.State, clientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	if twoFactorToken != "" {
+		return c.Status(fiber.StatusOK).JSON(dto.Success(dto.TwoFactorPendingResponseBody{
+			TwoFactorRequired:           true,
+			TwoFactorEnrollmentRequired: !user.TwoFactorEnabled,
+			TwoFactorToken:              twoFactorToken,
+		}))
+	}
+
+	data := dto.TokenWithUserInformationResponseBody{
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		UserInformation: h.userService.ConvertToDTO(*user),
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(data))
+}
+
+// GetLinkURL godoc
+// @Summary Start linking a provider account
+// @Description Get the URL of the provider's login page to link that account to the current user
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Param provider path string true "Provider, e.g. google"
+// @Success 200 {object} dto.SuccessResponse[dto.OIDCAuthorizationResponseBody] "authorization url created successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Login provider not supported"
+// @Failure 500 {object} dto.ErrorResponse "Identity provider is unavailable"
+// @Router /auth/oidc/{provider}/link [get]
+func (h *OIDCHandler) GetLinkURL(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	url, err := h.service.GetAuthorizationURL(c.Context(), c.Params("provider"), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.OIDCAuthorizationResponseBody{AuthorizationURL: url}))
+}
+
+// Link godoc
+// @Summary Link a provider account
+// @Description Link the provider account the user logged in with to the current user
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider, e.g. google"
+// @Param callback body dto.OIDCCallbackRequestBody true "Code and state"
+// @Success 201 {object} dto.SuccessResponse[dto.UserIdentityResponseBody] "account linked successfully"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "Login with provider failed"
+// @Failure 404 {object} dto.ErrorResponse "Login provider not supported"
+// @Failure 409 {object} dto.ErrorResponse "This account is already linked"
+// @Failure 500 {object} dto.ErrorResponse "Failed to link account"
+// @Router /auth/oidc/{provider}/link [post]
+func (h *OIDCHandler) Link(c *fiber.Ctx) error {
+	body := new(dto.OIDCCallbackRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	identity, err := h.service.Link(c.Context(), userID, c.Params("provider"), body.Code, body.State)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(identity.ToDTO()))
+}
+
+// Unlink godoc
+// @Summary Unlink a provider account
+// @Description Remove a linked provider account. The last login method of an account without a password cannot be removed.
+// @Tags auth
+// @Security Bearer
+// @Param provider path string true "Provider, e.g. google"
+// @Success 204 "account unlinked successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Linked account not found"
+// @Failure 409 {object} dto.ErrorResponse "Set a password before unlinking your only login method"
+// @Failure 500 {object} dto.ErrorResponse "Failed to unlink account"
+// @Router /auth/oidc/{provider}/link [delete]
+func (h *OIDCHandler) Unlink(c *fiber.Ctx) error {
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.Unlink(user, c.Params("provider")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetIdentities godoc
+// @Summary Get linked provider accounts
+// @Description List the provider accounts linked to the current user
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[[]dto.UserIdentityResponseBody] "linked accounts retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve linked accounts"
+// @Router /auth/identities [get]
+func (h *OIDCHandler) GetIdentities(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	identities, err := h.service.GetIdentities(userID)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.UserIdentityResponseBody, len(identities))
+	for i, v := range identities {
+		resData[i] = v.ToDTO()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(resData))
+}
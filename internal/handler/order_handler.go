@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/go-playground/validator"
@@ -29,6 +31,7 @@ func NewOrderHandler(service ports.OrderService) ports.OrderHandler {
 // @Success 200 {object} dto.SuccessResponse[dto.OrderResponseBody] "Order created successfully"
 // @Failure 400 {object} dto.ErrorResponse "your request is invalid"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to bill this lease"
 // @Failure 404 {object} dto.ErrorResponse "leasing history not found"
 // @Failure 500 {object} dto.ErrorResponse "cannot parse uuid or cannot delete user"
 func (o *OrderHandler) CreateOrder(c *fiber.Ctx) error {
@@ -42,7 +45,8 @@ func (o *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 		return apperror.BadRequestError(err, "Your request body is invalid")
 	}
 
-	order, err := o.OrderService.CreateOrder(body.LeasingHistoryID)
+	user := c.Locals("user").(*domain.User)
+	order, err := o.OrderService.CreateOrder(user, body.LeasingHistoryID)
 	if err != nil {
 		return err
 	}
@@ -96,6 +100,7 @@ func (o *OrderHandler) GetOrderByID(c *fiber.Ctx) error {
 // @Success 200 {object} dto.PaginationResponse[dto.OrderResponseBody] "Order retrieved successfully"
 // @Failure 400 {object} dto.ErrorResponse "your request is invalid"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to view these orders"
 // @Failure 404 {object} dto.ErrorResponse "leasing history not found"
 // @Failure 500 {object} dto.ErrorResponse "cannot parse uuid or cannot delete user"
 func (o *OrderHandler) GetUnpaidOrderByUserID(c *fiber.Ctx) error {
@@ -104,6 +109,11 @@ func (o *OrderHandler) GetUnpaidOrderByUserID(c *fiber.Ctx) error {
 		return apperror.BadRequestError(err, "Invalid user ID")
 	}
 
+	user := c.Locals("user").(*domain.User)
+	if err := policy.AuthorizeOwner(user, userID, domain.PermissionFinanceRead); err != nil {
+		return apperror.ForbiddenError(err, "You do not have permission to view these orders")
+	}
+
 	limit := c.QueryInt("limit", 10)
 	if limit <= 0 {
 		limit = 10
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type PhoneVerificationHandler struct {
+	service     ports.PhoneVerificationService
+	userService ports.UserService
+}
+
+func NewPhoneVerificationHandler(service ports.PhoneVerificationService, userService ports.UserService) ports.PhoneVerificationHandler {
+	return &PhoneVerificationHandler{service: service, userService: userService}
+}
+
+// SendCode godoc
+// @Summary Send a phone verification code
+// @Description Text a one-time code to the phone number on the profile. The code expires after 5 minutes.
+// @Tags user
+// @Security Bearer
+// @Success 204 "code sent successfully"
+// @Failure 400 {object} dto.ErrorResponse "Add a phone number to your profile first"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Your phone number is already verified"
+// @Failure 429 {object} dto.ErrorResponse "too many requests"
+// @Failure 500 {object} dto.ErrorResponse "Failed to send verification code"
+// @Router /user/phone/code [post]
+func (h *PhoneVerificationHandler) SendCode(c *fiber.Ctx) error {
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.SendCode(c.Context(), user); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Verify godoc
+// @Summary Verify phone number
+// @Description Confirm the code sent by SMS. A verified phone number is shown as a badge on the profile until the number changes.
+// @Tags user
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param code body dto.PhoneVerificationRequestBody true "Code from the SMS"
+// @Success 200 {object} dto.SuccessResponse[dto.UserResponse] "phone number verified successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid verification code"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to verify phone number"
+// @Router /user/phone/verify [post]
+func (h *PhoneVerificationHandler) Verify(c *fiber.Ctx) error {
+	body := new(dto.PhoneVerificationRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	user, err := h.service.Verify(c.Context(), user, body.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(h.userService.ConvertToDTO(*user)))
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type ReviewModerationHandler struct {
+	service        ports.ReviewModerationService
+	historyService ports.LeasingHistoryService
+}
+
+func NewReviewModerationHandler(service ports.ReviewModerationService, historyService ports.LeasingHistoryService) ports.ReviewModerationHandler {
+	return &ReviewModerationHandler{service: service, historyService: historyService}
+}
+
+// Report godoc
+// @Summary Report a review
+// @Description Report a review to the moderators with a category (SPAM, HARASSMENT, FALSE_INFO or PERSONAL_DATA) and an optional reason
+// @Tags history
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "HistoryID"
+// @Param report body dto.ReviewReportRequestBody true "Report"
+// @Success 200 {object} dto.SuccessResponse[dto.Review] "Review reported successfully"
+// @Failure 400 {object} dto.ErrorResponse "bad request"
+// @Failure 401 {object} dto.ErrorResponse "unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "review not found"
+// @Failure 409 {object} dto.ErrorResponse "review already reported"
+// @Failure 500 {object} dto.ErrorResponse "internal server error"
+// @Router /history/{id}/review/report [post]
+func (h *ReviewModerationHandler) Report(c *fiber.Ctx) error {
+	historyID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(dto.ReviewReportRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	history, err := h.service.Report(user, historyID, domain.ReportCategory(body.Category), body.Reason)
+	if err != nil {
+		return err
+	}
+
+	urls := h.historyService.GetImageUrl(history.Images)
+	data := history.Review.ToDTO(urls, history.Lessee.ToDTO(), history.ID)
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(data))
+}
+
+// GetQueue godoc
+// @Summary Get the review moderation queue
+// @Description Endpoint for admins to get reported reviews with their open reports grouped per review, most reported first
+// @Tags admin
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Number of reviews to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.ReportedReview] "Retrieve reported reviews successfully"
+// @Failure 401 {object} dto.ErrorResponse "unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "forbidden"
+// @Failure 500 {object} dto.ErrorResponse "internal server error"
+// @Router /admin/reviews/reported [get]
+func (h *ReviewModerationHandler) GetQueue(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	leasingHistory, totalPage, totalRows, err := h.service.GetQueue(limit, page)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.ReportedReview, len(leasingHistory))
+	for i, v := range leasingHistory {
+		urls := h.historyService.GetImageUrl(v.Images)
+		resData[i] = v.Review.ToReportedReviewDTO(urls, v.Lessee.ToDTO(), v.ID, v.Reports)
+	}
+
+	res := dto.SuccessPagination(resData, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPage,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// Moderate godoc
+// @Summary Moderate a reported review
+// @Description Endpoint for admins to DISMISS the reports, HIDE the review or REMOVE it, with a reason. Hidden and removed reviews do not count towards the dorm rating and the reviewer is notified.
+// @Tags admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "HistoryID"
+// @Param decision body dto.ReviewModerationRequestBody true "Decision"
+// @Success 200 {object} dto.SuccessResponse[dto.ReviewModerationResponseBody] "Decision saved successfully"
+// @Failure 400 {object} dto.ErrorResponse "bad request"
+// @Failure 401 {object} dto.ErrorResponse "unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "forbidden"
+// @Failure 404 {object} dto.ErrorResponse "This review has no open reports"
+// @Failure 500 {object} dto.ErrorResponse "internal server error"
+// @Router /admin/reviews/{id}/moderate [post]
+func (h *ReviewModerationHandler) Moderate(c *fiber.Ctx) error {
+	historyID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(dto.ReviewModerationRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	adminID := c.Locals("userID").(uuid.UUID)
+	moderation, err := h.service.Moderate(adminID, historyID, domain.ModerationDecision(body.Decision), body.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(moderation.ToDTO()))
+}
+
+// Appeal godoc
+// @Summary Appeal a moderation decision
+// @Description The reviewer can appeal a decision to hide or remove their review, once per review
+// @Tags history
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "HistoryID"
+// @Param appeal body dto.ReviewAppealRequestBody true "Appeal"
+// @Success 200 {object} dto.SuccessResponse[dto.ReviewModerationResponseBody] "Appeal submitted successfully"
+// @Failure 400 {object} dto.ErrorResponse "bad request"
+// @Failure 401 {object} dto.ErrorResponse "unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Only the reviewer can appeal this decision"
+// @Failure 404 {object} dto.ErrorResponse "Moderation not found"
+// @Failure 409 {object} dto.ErrorResponse "You can only appeal once"
+// @Failure 500 {object} dto.ErrorResponse "internal server error"
+// @Router /history/{id}/review/appeal [post]
+func (h *ReviewModerationHandler) Appeal(c *fiber.Ctx) error {
+	historyID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(dto.ReviewAppealRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	moderation, err := h.service.Appeal(user, historyID, body.Message)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(moderation.ToDTO()))
+}
+
+// GetPendingAppeals godoc
+// @Summary Get pending review appeals
+// @Description Endpoint for admins to get appeals waiting for a decision, oldest first
+// @Tags admin
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Number of appeals to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.ReviewModerationResponseBody] "Retrieve appeals successfully"
+// @Failure 401 {object} dto.ErrorResponse "unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "forbidden"
+// @Failure 500 {object} dto.ErrorResponse "internal server error"
+// @Router /admin/reviews/appeals [get]
+func (h *ReviewModerationHandler) GetPendingAppeals(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	moderations, totalPage, totalRows, err := h.service.GetPendingAppeals(limit, page)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.ReviewModerationResponseBody, len(moderations))
+	for i, v := range moderations {
+		resData[i] = v.ToDTO()
+	}
+
+	res := dto.SuccessPagination(resData, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPage,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// DecideAppeal godoc
+// @Summary Decide a review appeal
+// @Description Endpoint for admins to uphold the original decision or overturn it, which restores the review
+// @Tags admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "ModerationID"
+// @Param decision body dto.ReviewAppealDecisionRequestBody true "Decision"
+// @Success 200 {object} dto.SuccessResponse[dto.ReviewModerationResponseBody] "Decision saved successfully"
+// @Failure 400 {object} dto.ErrorResponse "bad request"
+// @Failure 401 {object} dto.ErrorResponse "unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "forbidden"
+// @Failure 404 {object} dto.ErrorResponse "Moderation not found"
+// @Failure 409 {object} dto.ErrorResponse "appeal not pending"
+// @Failure 500 {object} dto.ErrorResponse "internal server error"
+// @Router /admin/reviews/appeals/{id} [patch]
+func (h *ReviewModerationHandler) DecideAppeal(c *fiber.Ctx) error {
+	moderationID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(dto.ReviewAppealDecisionRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	adminID := c.Locals("userID").(uuid.UUID)
+	moderation, err := h.service.DecideAppeal(adminID, moderationID, domain.AppealStatus(body.Decision), body.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(moderation.ToDTO()))
+}
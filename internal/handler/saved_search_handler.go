@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type SavedSearchHandler struct {
+	service     ports.SavedSearchService
+	dormService ports.DormService
+}
+
+func NewSavedSearchHandler(service ports.SavedSearchService, dormService ports.DormService) ports.SavedSearchHandler {
+	return &SavedSearchHandler{service: service, dormService: dormService}
+}
+
+// Create godoc
+// @Summary Save a dorm search
+// @Description Save a set of GET /dorms filters and get alerted when a new or newly matching dorm is listed. alertFrequency is IMMEDIATE (default) or DAILY.
+// @Tags saved-search
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param search body dto.SavedSearchRequestBody true "Saved search"
+// @Success 201 {object} dto.SuccessResponse[dto.SavedSearchResponseBody] "Search saved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Only lessees can save a search"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save search"
+// @Router /saved-searches [post]
+func (h *SavedSearchHandler) Create(c *fiber.Ctx) error {
+	reqBody := new(dto.SavedSearchRequestBody)
+	if err := c.BodyParser(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	search, err := h.service.Create(user, *reqBody)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(search.ToDTO()))
+}
+
+// GetMine godoc
+// @Summary Get my saved searches
+// @Description Retrieve the saved searches of the current user
+// @Tags saved-search
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Number of saved searches to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.SavedSearchResponseBody] "Saved searches retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve saved searches"
+// @Router /saved-searches [get]
+func (h *SavedSearchHandler) GetMine(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	searches, totalPages, totalRows, err := h.service.GetByUserID(userID, limit, page)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.SavedSearchResponseBody, len(searches))
+	for i, v := range searches {
+		resData[i] = v.ToDTO()
+	}
+
+	res := dto.SuccessPagination(resData, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// Update godoc
+// @Summary Update a saved search
+// @Description Replace the filters and alert frequency of a saved search
+// @Tags saved-search
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "SavedSearchID"
+// @Param search body dto.SavedSearchRequestBody true "Saved search"
+// @Success 200 {object} dto.SuccessResponse[dto.SavedSearchResponseBody] "Saved search updated successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this saved search"
+// @Failure 404 {object} dto.ErrorResponse "Saved search not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update saved search"
+// @Router /saved-searches/{id} [patch]
+func (h *SavedSearchHandler) Update(c *fiber.Ctx) error {
+	searchID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	reqBody := new(dto.SavedSearchRequestBody)
+	if err := c.BodyParser(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	search, err := h.service.Update(userID, searchID, *reqBody)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(search.ToDTO()))
+}
+
+// Delete godoc
+// @Summary Delete a saved search
+// @Description Delete a saved search and stop its alerts
+// @Tags saved-search
+// @Security Bearer
+// @Produce json
+// @Param id path string true "SavedSearchID"
+// @Success 204 "Saved search deleted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this saved search"
+// @Failure 404 {object} dto.ErrorResponse "Saved search not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to delete saved search"
+// @Router /saved-searches/{id} [delete]
+func (h *SavedSearchHandler) Delete(c *fiber.Ctx) error {
+	searchID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.Delete(userID, searchID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetDorms godoc
+// @Summary Run a saved search
+// @Description Retrieve the dorms currently matching a saved search
+// @Tags saved-search
+// @Security Bearer
+// @Produce json
+// @Param id path string true "SavedSearchID"
+// @Param limit query int false "Number of dorms to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.DormResponseBody] "Dorms retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this saved search"
+// @Failure 404 {object} dto.ErrorResponse "Saved search not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve dorms"
+// @Router /saved-searches/{id}/dorms [get]
+func (h *SavedSearchHandler) GetDorms(c *fiber.Ctx) error {
+	searchID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	search, err := h.service.GetByID(userID, searchID)
+	if err != nil {
+		return err
+	}
+
+	minPrice, maxPrice := search.MinPrice, search.MaxPrice
+	if minPrice == 0 {
+		minPrice = -1
+	}
+	if maxPrice == 0 {
+		maxPrice = -1
+	}
+
+	dorms, totalPages, totalRows, err := h.dormService.GetAll(limit, page, search.Search, minPrice, maxPrice, search.District, search.Subdistrict, search.Province, search.Zipcode)
+	if err != nil {
+		return err
+	}
+
+	res := dto.SuccessPagination(dorms, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
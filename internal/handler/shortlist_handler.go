@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type ShortlistHandler struct {
+	service ports.ShortlistService
+}
+
+func NewShortlistHandler(service ports.ShortlistService) ports.ShortlistHandler {
+	return &ShortlistHandler{service: service}
+}
+
+// Create godoc
+// @Summary Create a shortlist
+// @Description Create a named shortlist of dorms
+// @Tags shortlist
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param shortlist body dto.ShortlistRequestBody true "Shortlist"
+// @Success 201 {object} dto.SuccessResponse[dto.ShortlistResponseBody] "Shortlist created successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Only lessees can create a shortlist"
+// @Failure 500 {object} dto.ErrorResponse "Failed to create shortlist"
+// @Router /shortlists [post]
+func (h *ShortlistHandler) Create(c *fiber.Ctx) error {
+	reqBody := new(dto.ShortlistRequestBody)
+	if err := c.BodyParser(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	shortlist, err := h.service.Create(user, reqBody.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(shortlist.ToDTO()))
+}
+
+// GetMine godoc
+// @Summary Get my shortlists
+// @Description Retrieve the shortlists of the current user with their dorms
+// @Tags shortlist
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Number of shortlists to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.ShortlistResponseBody] "Shortlists retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve shortlists"
+// @Router /shortlists [get]
+func (h *ShortlistHandler) GetMine(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	shortlists, totalPages, totalRows, err := h.service.GetByUserID(userID, limit, page)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.ShortlistResponseBody, len(shortlists))
+	for i, v := range shortlists {
+		resData[i] = v.ToDTO()
+	}
+
+	res := dto.SuccessPagination(resData, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// GetByID godoc
+// @Summary Get a shortlist
+// @Description Retrieve a shortlist with its dorms
+// @Tags shortlist
+// @Security Bearer
+// @Produce json
+// @Param id path string true "ShortlistID"
+// @Success 200 {object} dto.SuccessResponse[dto.ShortlistResponseBody] "Shortlist retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this shortlist"
+// @Failure 404 {object} dto.ErrorResponse "Shortlist not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve shortlist"
+// @Router /shortlists/{id} [get]
+func (h *ShortlistHandler) GetByID(c *fiber.Ctx) error {
+	shortlistID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	shortlist, err := h.service.GetByID(userID, shortlistID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(shortlist.ToDTO()))
+}
+
+// Rename godoc
+// @Summary Rename a shortlist
+// @Description Change the name of a shortlist
+// @Tags shortlist
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "ShortlistID"
+// @Param shortlist body dto.ShortlistRequestBody true "Shortlist"
+// @Success 200 {object} dto.SuccessResponse[dto.ShortlistResponseBody] "Shortlist updated successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this shortlist"
+// @Failure 404 {object} dto.ErrorResponse "Shortlist not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update shortlist"
+// @Router /shortlists/{id} [patch]
+func (h *ShortlistHandler) Rename(c *fiber.Ctx) error {
+	shortlistID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	reqBody := new(dto.ShortlistRequestBody)
+	if err := c.BodyParser(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	shortlist, err := h.service.Rename(userID, shortlistID, reqBody.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(shortlist.ToDTO()))
+}
+
+// Delete godoc
+// @Summary Delete a shortlist
+// @Description Delete a shortlist and everything saved in it
+// @Tags shortlist
+// @Security Bearer
+// @Produce json
+// @Param id path string true "ShortlistID"
+// @Success 204 "Shortlist deleted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this shortlist"
+// @Failure 404 {object} dto.ErrorResponse "Shortlist not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to delete shortlist"
+// @Router /shortlists/{id} [delete]
+func (h *ShortlistHandler) Delete(c *fiber.Ctx) error {
+	shortlistID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.Delete(userID, shortlistID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AddDorm godoc
+// @Summary Add a dorm to a shortlist
+// @Description Save a dorm into a shortlist, remembering its current price
+// @Tags shortlist
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "ShortlistID"
+// @Param dorm body dto.ShortlistAddDormRequestBody true "Dorm to add"
+// @Success 200 {object} dto.SuccessResponse[dto.ShortlistResponseBody] "Dorm added successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this shortlist"
+// @Failure 404 {object} dto.ErrorResponse "Shortlist or dorm not found"
+// @Failure 409 {object} dto.ErrorResponse "Dorm is already in this shortlist"
+// @Failure 500 {object} dto.ErrorResponse "Failed to add dorm to shortlist"
+// @Router /shortlists/{id}/dorms [post]
+func (h *ShortlistHandler) AddDorm(c *fiber.Ctx) error {
+	shortlistID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	reqBody := new(dto.ShortlistAddDormRequestBody)
+	if err := c.BodyParser(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	shortlist, err := h.service.AddDorm(userID, shortlistID, reqBody.DormID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(shortlist.ToDTO()))
+}
+
+// RemoveDorm godoc
+// @Summary Remove a dorm from a shortlist
+// @Description Remove a dorm from a shortlist
+// @Tags shortlist
+// @Security Bearer
+// @Produce json
+// @Param id path string true "ShortlistID"
+// @Param dormID path string true "DormID"
+// @Success 204 "Dorm removed successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this shortlist"
+// @Failure 404 {object} dto.ErrorResponse "Dorm is not in this shortlist"
+// @Failure 500 {object} dto.ErrorResponse "Failed to remove dorm from shortlist"
+// @Router /shortlists/{id}/dorms/{dormID} [delete]
+func (h *ShortlistHandler) RemoveDorm(c *fiber.Ctx) error {
+	shortlistID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	dormID, err := uuid.Parse(c.Params("dormID"))
+	if err != nil {
+		return apperror.BadRequestError(err, "Incorrect UUID format")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.RemoveDorm(userID, shortlistID, dormID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Compare godoc
+// @Summary Compare dorms
+// @Description Side-by-side comparison of 2 to 4 dorms: price, size, rooms, rating, location and the lessor's average response time to leasing requests
+// @Tags shortlist
+// @Security Bearer
+// @Produce json
+// @Param ids query string true "Comma separated DormIDs"
+// @Success 200 {object} dto.SuccessResponse[[]dto.DormComparisonResponseBody] "Dorms compared successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Dorm not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to compare dorms"
+// @Router /shortlists/compare [get]
+func (h *ShortlistHandler) Compare(c *fiber.Ctx) error {
+	var dormIDs []uuid.UUID
+	for _, v := range strings.Split(c.Query("ids"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return apperror.BadRequestError(err, "Incorrect UUID format")
+		}
+		dormIDs = append(dormIDs, id)
+	}
+
+	res, err := h.service.Compare(dormIDs)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(res))
+}
+
+// GetSavedCount godoc
+// @Summary Get how many people saved a dorm
+// @Description Retrieve the number of users who have the dorm in a shortlist. Only the owner or an admin can see it.
+// @Tags dorms
+// @Security Bearer
+// @Produce json
+// @Param id path string true "DormID"
+// @Success 200 {object} dto.SuccessResponse[dto.SavedCountResponseBody] "Saved count retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to view this dorm's statistics"
+// @Failure 404 {object} dto.ErrorResponse "Dorm not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to count saved dorm"
+// @Router /dorms/{id}/saved-count [get]
+func (h *ShortlistHandler) GetSavedCount(c *fiber.Ctx) error {
+	dormID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	count, err := h.service.GetSavedCount(user, dormID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.SavedCountResponseBody{DormID: dormID, SavedCount: count}))
+}
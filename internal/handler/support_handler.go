@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/go-playground/validator"
@@ -31,6 +32,8 @@ func NewSupportHandler(service ports.SupportService) ports.SupportHandler {
 // @Success 201 {object} dto.SuccessResponse[dto.SupportResponseBody] "Support request submitted successfully"
 // @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
 // @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You are not a participant of this thread"
+// @Failure 404 {object} dto.ErrorResponse "Thread not found"
 // @Failure 500 {object} dto.ErrorResponse "Could not submit support request"
 // @Router /support [post]
 func (h *SupportHandler) Create(c *fiber.Ctx) error {
@@ -45,8 +48,9 @@ func (h *SupportHandler) Create(c *fiber.Ctx) error {
 
 	userID := c.Locals("userID").(uuid.UUID)
 	support := &domain.SupportRequest{
-		UserID:  userID,
-		Message: reqBody.Message,
+		UserID:   userID,
+		Message:  reqBody.Message,
+		ThreadID: reqBody.ThreadID,
 	}
 	if err := h.service.Create(support); err != nil {
 		return err
@@ -85,9 +89,10 @@ func (h *SupportHandler) GetAll(c *fiber.Ctx) error {
 	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
-	isAdmin := user.Role == domain.AdminRole
+	// agents see every request, users only their own
+	seeAll := policy.Can(user, domain.PermissionSupportManage)
 
-	supports, totalPages, totalRows, err := h.service.GetAll(limit, page, userID, isAdmin)
+	supports, totalPages, totalRows, err := h.service.GetAll(limit, page, userID, seeAll)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,401 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// maxMessageLength is the longest text a message may have, in characters.
+const maxMessageLength = 4000
+
+type ThreadHandler struct {
+	service ports.ThreadService
+}
+
+func NewThreadHandler(service ports.ThreadService) ports.ThreadHandler {
+	return &ThreadHandler{service: service}
+}
+
+// Open godoc
+// @Summary Open a message thread
+// @Description Start a conversation with the lessor about a dorm, or between the lessee and lessor of a leasing request or lease. Returns the existing thread if there is one.
+// @Tags thread
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param thread body dto.ThreadCreateRequestBody true "Subject of the thread"
+// @Success 200 {object} dto.SuccessResponse[dto.ThreadResponseBody] "Thread opened successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You cannot message this user"
+// @Failure 404 {object} dto.ErrorResponse "Subject not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to create thread"
+// @Router /threads [post]
+func (h *ThreadHandler) Open(c *fiber.Ctx) error {
+	body := new(dto.ThreadCreateRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	thread, err := h.service.Open(user, domain.ThreadSubject(body.Subject), body.SubjectID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(thread))
+}
+
+// GetMine godoc
+// @Summary Get my message threads
+// @Description Get the current user's threads with their unread counts, most recent first
+// @Tags thread
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Number of threads to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.ThreadResponseBody] "Threads retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve threads"
+// @Router /threads [get]
+func (h *ThreadHandler) GetMine(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	user := c.Locals("user").(*domain.User)
+	threads, totalPages, totalRows, err := h.service.GetMine(user, limit, page)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessPagination(threads, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	}))
+}
+
+// GetByID godoc
+// @Summary Get a message thread
+// @Description Get a thread with its unread count and when the other participant last read it
+// @Tags thread
+// @Security Bearer
+// @Produce json
+// @Param id path string true "ThreadID"
+// @Success 200 {object} dto.SuccessResponse[dto.ThreadResponseBody] "Thread retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You are not a participant of this thread"
+// @Failure 404 {object} dto.ErrorResponse "Thread not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve thread"
+// @Router /threads/{id} [get]
+func (h *ThreadHandler) GetByID(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	thread, err := h.service.GetByID(user, id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(thread))
+}
+
+// GetMessages godoc
+// @Summary Get the messages of a thread
+// @Description Get the messages of a thread, newest first, with read receipts. Staff can only read threads attached to a support request or a report.
+// @Tags thread
+// @Security Bearer
+// @Produce json
+// @Param id path string true "ThreadID"
+// @Param limit query int false "Number of messages to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.ThreadMessageResponseBody] "Messages retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You are not a participant of this thread"
+// @Failure 404 {object} dto.ErrorResponse "Thread not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve messages"
+// @Router /threads/{id}/messages [get]
+func (h *ThreadHandler) GetMessages(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	user := c.Locals("user").(*domain.User)
+	messages, totalPages, totalRows, err := h.service.GetMessages(c.Context(), user, id, limit, page)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.SuccessPagination(messages, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	}))
+}
+
+// Send godoc
+// @Summary Send a message
+// @Description Send a text message, an image or both to the other participant of the thread
+// @Tags thread
+// @Security Bearer
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "ThreadID"
+// @Param body formData string false "Text of the message"
+// @Param image formData file false "Image attachment"
+// @Success 201 {object} dto.SuccessResponse[dto.ThreadMessageResponseBody] "Message sent successfully"
+// @Failure 400 {object} dto.ErrorResponse "Message must have a text or an image"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You cannot message this user"
+// @Failure 404 {object} dto.ErrorResponse "Thread not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to send message"
+// @Router /threads/{id}/messages [post]
+func (h *ThreadHandler) Send(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := c.FormValue("body")
+	if len([]rune(body)) > maxMessageLength {
+		return apperror.BadRequestError(errors.New("message too long"), "Message is too long")
+	}
+
+	user := c.Locals("user").(*domain.User)
+
+	file, err := c.FormFile("image")
+	if errors.Is(err, fasthttp.ErrMissingFile) {
+		message, err := h.service.Send(c.Context(), user, id, body, "", "", nil)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusCreated).JSON(dto.Success(message))
+	} else if err != nil {
+		return apperror.BadRequestError(err, "Invalid multipart form data")
+	}
+
+	contentType := file.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return apperror.BadRequestError(errors.New("uploaded file is not an image"), "uploaded file is not an image")
+	}
+
+	fileData, err := file.Open()
+	if err != nil {
+		return apperror.InternalServerError(err, "error opening file")
+	}
+	defer fileData.Close()
+
+	message, err := h.service.Send(c.Context(), user, id, body, file.Filename, contentType, fileData)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(message))
+}
+
+// MarkRead godoc
+// @Summary Mark a thread as read
+// @Description Mark every message of the thread as read by the current user
+// @Tags thread
+// @Security Bearer
+// @Param id path string true "ThreadID"
+// @Success 204 "Thread marked as read"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You are not a participant of this thread"
+// @Failure 404 {object} dto.ErrorResponse "Thread not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to mark thread as read"
+// @Router /threads/{id}/read [post]
+func (h *ThreadHandler) MarkRead(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.MarkRead(user, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetUnreadCount godoc
+// @Summary Get my unread message count
+// @Description Get the number of unread messages over all threads of the current user
+// @Tags thread
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[dto.UnreadCountResponseBody] "Unread count retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to count unread messages"
+// @Router /threads/unread-count [get]
+func (h *ThreadHandler) GetUnreadCount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	unread, err := h.service.CountUnread(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.UnreadCountResponseBody{Unread: unread}))
+}
+
+// Report godoc
+// @Summary Report a thread
+// @Description Report a thread to the staff, who can then read it
+// @Tags thread
+// @Security Bearer
+// @Accept json
+// @Param id path string true "ThreadID"
+// @Param report body dto.ThreadReportRequestBody true "Report"
+// @Success 204 "Thread reported successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You are not a participant of this thread"
+// @Failure 404 {object} dto.ErrorResponse "Thread not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to report thread"
+// @Router /threads/{id}/report [post]
+func (h *ThreadHandler) Report(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := new(dto.ThreadReportRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.Report(user, id, domain.ReportCategory(body.Category), body.Reason); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Block godoc
+// @Summary Block a user
+// @Description Stop a user from messaging the current user, and the other way round
+// @Tags thread
+// @Security Bearer
+// @Param id path string true "UserID"
+// @Success 204 "User blocked successfully"
+// @Failure 400 {object} dto.ErrorResponse "You cannot block yourself"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "user not found"
+// @Failure 409 {object} dto.ErrorResponse "You already blocked this user"
+// @Failure 500 {object} dto.ErrorResponse "Failed to block user"
+// @Router /blocks/{id} [post]
+func (h *ThreadHandler) Block(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.Block(user, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Unblock godoc
+// @Summary Unblock a user
+// @Description Allow a blocked user to message the current user again
+// @Tags thread
+// @Security Bearer
+// @Param id path string true "UserID"
+// @Success 204 "User unblocked successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "You have not blocked this user"
+// @Failure 500 {object} dto.ErrorResponse "Failed to unblock user"
+// @Router /blocks/{id} [delete]
+func (h *ThreadHandler) Unblock(c *fiber.Ctx) error {
+	id, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.Unblock(user, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetBlocks godoc
+// @Summary Get blocked users
+// @Description Get the users the current user blocked
+// @Tags thread
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[[]dto.UserBlockResponseBody] "Blocked users retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve blocked users"
+// @Router /blocks [get]
+func (h *ThreadHandler) GetBlocks(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	blocks, err := h.service.GetBlocks(userID)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.UserBlockResponseBody, len(blocks))
+	for i, v := range blocks {
+		resData[i] = v.ToDTO()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(resData))
+}
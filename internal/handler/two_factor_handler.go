@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type TwoFactorHandler struct {
+	service     ports.TwoFactorService
+	userService ports.UserService
+}
+
+func NewTwoFactorHandler(service ports.TwoFactorService, userService ports.UserService) ports.TwoFactorHandler {
+	return &TwoFactorHandler{service: service, userService: userService}
+}
+
+// Setup godoc
+// @Summary Set up two-factor authentication
+// @Description Generate a TOTP secret. Show the provisioning URI as a QR code to the authenticator app, then confirm a code at /auth/2fa/enable.
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[dto.TwoFactorSetupResponseBody] "secret generated successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication is already enabled"
+// @Failure 500 {object} dto.ErrorResponse "Failed to generate two-factor secret"
+// @Router /auth/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *fiber.Ctx) error {
+	user := c.Locals("user").(*domain.User)
+	secret, uri, err := h.service.Setup(user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.TwoFactorSetupResponseBody{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	}))
+}
+
+// Enable godoc
+// @Summary Enable two-factor authentication
+// @Description Confirm the first code from the authenticator app. The recovery codes in the response are shown only once.
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param code body dto.TwoFactorCodeRequestBody true "Code from the authenticator app"
+// @Success 200 {object} dto.SuccessResponse[dto.RecoveryCodesResponseBody] "two-factor authentication enabled successfully"
+// @Failure 400 {object} dto.ErrorResponse "Invalid two-factor code"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication is already enabled"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save recovery codes"
+// @Router /auth/2fa/enable [post]
+func (h *TwoFactorHandler) Enable(c *fiber.Ctx) error {
+	body := new(dto.TwoFactorCodeRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	codes, err := h.service.Enable(user, body.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.RecoveryCodesResponseBody{RecoveryCodes: codes}))
+}
+
+// Disable godoc
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off with a code from the authenticator app or a recovery code. Admins cannot disable it.
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Param code body dto.TwoFactorCodeRequestBody true "Code from the authenticator app or a recovery code"
+// @Success 204 "two-factor authentication disabled successfully"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "Invalid two-factor code"
+// @Failure 403 {object} dto.ErrorResponse "Admins cannot disable two-factor authentication"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication is not enabled"
+// @Failure 500 {object} dto.ErrorResponse "Failed to delete recovery codes"
+// @Router /auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	body := new(dto.TwoFactorCodeRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.service.Disable(user, body.Code); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes with new ones, e.g. after most have been used
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param code body dto.TwoFactorCodeRequestBody true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} dto.SuccessResponse[dto.RecoveryCodesResponseBody] "recovery codes regenerated successfully"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "Invalid two-factor code"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication is not enabled"
+// @Failure 500 {object} dto.ErrorResponse "Failed to save recovery codes"
+// @Router /auth/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	body := new(dto.TwoFactorCodeRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	codes, err := h.service.RegenerateRecoveryCodes(user, body.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.RecoveryCodesResponseBody{RecoveryCodes: codes}))
+}
+
+// Verify godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the pending token from /auth/login and a code from the authenticator app or a recovery code for the key pair
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param code body dto.TwoFactorVerifyRequestBody true "Pending token and code"
+// @Success 200 {object} dto.SuccessResponse[dto.TokenWithUserInformationResponseBody] "user successfully logged in"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "Invalid two-factor code"
+// @Failure 403 {object} dto.ErrorResponse "account banned"
+// @Failure 500 {object} dto.ErrorResponse "system cannot login user"
+// @Router /auth/2fa/verify [post]
+func (h *TwoFactorHandler) Verify(c *fiber.Ctx) error {
+	body := new(dto.TwoFactorVerifyRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user, accessToken, refreshToken, err := h.service.Verify(c.Context(), body.Token, body.Code, clientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	data := dto.TokenWithUserInformationResponseBody{
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		UserInformation: h.userService.ConvertToDTO(*user),
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(data))
+}
+
+// SetupPending godoc
+// @Summary Set up two-factor authentication to log in
+// @Description Staff must use two-factor authentication. When /auth/login returned twoFactorEnrollmentRequired, generate a TOTP secret with its pending token, then confirm a code at /auth/2fa/enroll.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body dto.TwoFactorTokenRequestBody true "Pending token"
+// @Success 200 {object} dto.SuccessResponse[dto.TwoFactorSetupResponseBody] "secret generated successfully"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "token is expired or token is used"
+// @Failure 403 {object} dto.ErrorResponse "account banned"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication is already enabled"
+// @Failure 500 {object} dto.ErrorResponse "Failed to generate two-factor secret"
+// @Router /auth/2fa/enroll/setup [post]
+func (h *TwoFactorHandler) SetupPending(c *fiber.Ctx) error {
+	body := new(dto.TwoFactorTokenRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	secret, uri, err := h.service.SetupPending(c.Context(), body.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.TwoFactorSetupResponseBody{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	}))
+}
+
+// Enroll godoc
+// @Summary Enable two-factor authentication to log in
+// @Description Confirm the first code from the authenticator app with the pending token and complete the login. The recovery codes in the response are shown only once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param code body dto.TwoFactorVerifyRequestBody true "Pending token and code"
+// @Success 200 {object} dto.SuccessResponse[dto.TwoFactorEnrollResponseBody] "user successfully logged in"
+// @Failure 400 {object} dto.ErrorResponse "Invalid two-factor code"
+// @Failure 401 {object} dto.ErrorResponse "Too many attempts, please log in again"
+// @Failure 403 {object} dto.ErrorResponse "account banned"
+// @Failure 409 {object} dto.ErrorResponse "Two-factor authentication is already enabled"
+// @Failure 500 {object} dto.ErrorResponse "system cannot login user"
+// @Router /auth/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	body := new(dto.TwoFactorVerifyRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user, codes, accessToken, refreshToken, err := h.service.Enroll(c.Context(), body.Token, body.Code, clientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	data := dto.TwoFactorEnrollResponseBody{
+		AccessToken:     accessToken,
+		RefreshToken:    refreshToken,
+		UserInformation: h.userService.ConvertToDTO(*user),
+		RecoveryCodes:   codes,
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(data))
+}
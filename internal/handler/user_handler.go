@@ -46,7 +46,7 @@ func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
 	if err := validate.Struct(body); err != nil {
 		return apperror.BadRequestError(err, "your request body is incorrect")
 	}
-	user, accessToken, refreshToken, err := h.userService.VerifyUser(c.Context(), body.Token)
+	user, accessToken, refreshToken, err := h.userService.VerifyUser(c.Context(), body.Token, clientInfo(c))
 	if err != nil {
 		return err
 	}
@@ -123,7 +123,7 @@ func (h *UserHandler) FirstFillInformation(c *fiber.Ctx) error {
 
 // UpdateUserInformation godoc
 // @Summary Update user information
-// @Description Update user information
+// @Description Update user information. Changing the password logs out every other session.
 // @Tags user
 // @Security Bearer
 // @Accept json
@@ -162,7 +162,8 @@ func (h *UserHandler) UpdateUserInformation(c *fiber.Ctx) error {
 		return apperror.BadRequestError(err, "your request body is incorrect")
 	}
 
-	userInfo, err := h.userService.UpdateInformation(user.ID, *requestBody)
+	sessionID := c.Locals("sessionID").(string)
+	userInfo, err := h.userService.UpdateInformation(c.Context(), user.ID, sessionID, *requestBody)
 
 	if err != nil {
 		return apperror.InternalServerError(err, "system cannot update your account information")
@@ -176,7 +177,7 @@ func (h *UserHandler) UpdateUserInformation(c *fiber.Ctx) error {
 
 // ResetPassword godoc
 // @Summary Reset password
-// @Description Reset password
+// @Description Reset password. Accounts with two-factor authentication get no tokens and have to log in.
 // @Tags user
 // @Accept json
 // @Produce json
@@ -202,7 +203,7 @@ func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
 		return apperror.BadRequestError(errors.New("no token in header"), "your request header is incorrect")
 	}
 
-	user, accessToken, refreshToken, err := h.userService.ResetPassword(c.Context(), tokenString, body.Password)
+	user, accessToken, refreshToken, err := h.userService.ResetPassword(c.Context(), tokenString, body.Password, clientInfo(c))
 	if err != nil {
 		return err
 	}
@@ -278,7 +279,7 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 		Password: user.Password,
 	}
 
-	accessToken, refreshToken, err := h.userService.Create(c.Context(), gormUser)
+	accessToken, refreshToken, err := h.userService.Create(c.Context(), gormUser, clientInfo(c))
 	if err != nil {
 		return err
 	}
@@ -334,9 +335,102 @@ func (h *UserHandler) RefreshToken(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(res)
 }
 
+// GetSessions godoc
+// @Summary Get my sessions
+// @Description List the devices the current user is logged in on with their IP and when they were last seen
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} dto.SuccessResponse[[]dto.SessionResponseBody] "sessions retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "cannot get sessions"
+// @Router /auth/sessions [get]
+func (h *UserHandler) GetSessions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	sessionID := c.Locals("sessionID").(string)
+
+	sessions, err := h.userService.GetSessions(c.Context(), userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.SessionResponseBody, len(sessions))
+	for i, v := range sessions {
+		resData[i] = v.ToDTO()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(resData))
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Log out one of the devices of the current user. Its tokens stop working immediately.
+// @Tags auth
+// @Security Bearer
+// @Param id path string true "SessionID"
+// @Success 204 "session revoked successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 404 {object} dto.ErrorResponse "Session not found"
+// @Failure 500 {object} dto.ErrorResponse "cannot revoke session"
+// @Router /auth/sessions/{id} [delete]
+func (h *UserHandler) RevokeSession(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.userService.RevokeSession(c.Context(), userID, c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Log out every device of the current user except the one making this request
+// @Tags auth
+// @Security Bearer
+// @Success 204 "sessions revoked successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "cannot revoke session"
+// @Router /auth/sessions [delete]
+func (h *UserHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	sessionID := c.Locals("sessionID").(string)
+	if err := h.userService.RevokeOtherSessions(c.Context(), userID, sessionID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Log out the current session, or every session of the user with all=true
+// @Tags auth
+// @Security Bearer
+// @Param all query bool false "Log out of all devices"
+// @Success 204 "logged out successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "cannot revoke session"
+// @Router /auth/logout [post]
+func (h *UserHandler) Logout(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	sessionID := c.Locals("sessionID").(string)
+	if err := h.userService.Logout(c.Context(), userID, sessionID, c.QueryBool("all", false)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func clientInfo(c *fiber.Ctx) dto.ClientInfo {
+	return dto.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	}
+}
+
 // Login godoc
 // @Summary Login user
-// @Description Login user
+// @Description Login user. If two-factor authentication is enabled the response is a dto.TwoFactorPendingResponseBody instead; send its token with a code to /auth/2fa/verify. Staff without two-factor authentication get one with twoFactorEnrollmentRequired set and enroll at /auth/2fa/enroll/setup and /auth/2fa/enroll.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -358,11 +452,19 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 		return apperror.BadRequestError(err, "your request body is incorrect")
 	}
 
-	user, accessToken, refreshToken, loginErr := h.userService.Login(c.Context(), req.Email, req.Password)
+	user, accessToken, refreshToken, twoFactorToken, loginErr := h.userService.Login(c.Context(), req.Email, req.Password, clientInfo(c))
 	if loginErr != nil {
 		return loginErr
 	}
 
+	if twoFactorToken != "" {
+		return c.Status(fiber.StatusOK).JSON(dto.Success(dto.TwoFactorPendingResponseBody{
+			TwoFactorRequired:           true,
+			TwoFactorEnrollmentRequired: !user.TwoFactorEnabled,
+			TwoFactorToken:              twoFactorToken,
+		}))
+	}
+
 	data := dto.TokenWithUserInformationResponseBody{
 		AccessToken:     accessToken,
 		RefreshToken:    refreshToken,
@@ -403,7 +505,7 @@ func (h *UserHandler) GetUserInfo(c *fiber.Ctx) error {
 // @Router /user/ [delete]
 func (h *UserHandler) DeleteAccount(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(uuid.UUID)
-	err := h.userService.DeleteAccount(userID)
+	err := h.userService.DeleteAccount(c.Context(), userID)
 	if err != nil {
 		return err
 	}
@@ -506,10 +608,8 @@ func (h *UserHandler) GetStudentEvidenceByID(c *fiber.Ctx) error {
 	if localUser.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
-	isSelf := localUser.ID == userID
-	isAdmin := localUser.Role == domain.AdminRole
 
-	evidence, err := h.userService.GetStudentEvidenceByID(c.Context(), userID, isSelf, isAdmin)
+	evidence, err := h.userService.GetStudentEvidenceByID(c.Context(), userID, localUser)
 	if err != nil {
 		if apperror.IsAppError(err) {
 			return err
@@ -543,6 +643,71 @@ func (h *UserHandler) ResendVerificationEmailHandler(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// RequestEmailChange godoc
+// @Summary Request an email change
+// @Description Send a confirmation link to the new email. The email changes once the link is opened.
+// @Tags user
+// @Security Bearer
+// @Accept json
+// @Param user body dto.EmailChangeRequestBody true "new email and current password"
+// @Success 204 "confirmation email sent successfully"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "invalid password"
+// @Failure 409 {object} dto.ErrorResponse "This email is already in use"
+// @Failure 429 {object} dto.ErrorResponse "too many requests"
+// @Failure 500 {object} dto.ErrorResponse "cannot sent email"
+// @Router /user/email [post]
+func (h *UserHandler) RequestEmailChange(c *fiber.Ctx) error {
+	body := new(dto.EmailChangeRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	if err := h.userService.RequestEmailChange(c.Context(), user, body.Email, body.Password); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm an email change
+// @Description Switch the account to the new email with the token from the confirmation link. The old email is notified.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param user body dto.VerifyRequestBody true "token"
+// @Success 200 {object} dto.SuccessResponse[dto.UserResponse] "email changed successfully"
+// @Failure 400 {object} dto.ErrorResponse "your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "token is expired or token is used"
+// @Failure 409 {object} dto.ErrorResponse "This email is already in use"
+// @Failure 500 {object} dto.ErrorResponse "failed to update database"
+// @Router /user/email/confirm [post]
+func (h *UserHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	body := new(dto.VerifyRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	user, err := h.userService.ConfirmEmailChange(c.Context(), body.Token)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(h.userService.ConvertToDTO(*user)))
+}
+
 // UploadProfilePicture godoc
 // @Summary Upload user profile picture
 // @Description Upload an profile picture for the current user, by attaching the image as a value for the key field name "image", as a multipart form-data
@@ -598,14 +763,12 @@ func (h *UserHandler) UploadProfilePicture(c *fiber.Ctx) error {
 // @Failure 500 {object} dto.ErrorResponse "system cannot get lessor income"
 // @Router /user/income [get]
 func (h *UserHandler) GetLessorIncome(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(uuid.UUID)
 	user := c.Locals("user").(*domain.User)
-	userRole := user.Role
-	if userRole == "" {
+	if user.Role == "" {
 		return apperror.UnauthorizedError(errors.New("unauthorized"), "user role is missing")
 	}
 
-	income, err := h.userService.GetLessorIncome(userID, userRole)
+	income, err := h.userService.GetLessorIncome(user)
 	if err != nil {
 		return err
 	}
@@ -613,9 +776,50 @@ func (h *UserHandler) GetLessorIncome(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(dto.Success(dto.LessorIncomeResponseBody{Income: income}))
 }
 
+// SetUserRole godoc
+// @Summary Set the role of a user
+// @Description Assign a role, including the sub-admin roles SUPPORT_AGENT and FINANCE. Requires the user:roles permission, the user's role must rank below your own and the new role cannot rank above it
+// @Tags admin
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "userID"
+// @Param role body dto.UserRoleRequestBody true "New role"
+// @Success 200 {object} dto.SuccessResponse[dto.UserResponse] "Role updated"
+// @Failure 400 {object} dto.ErrorResponse "bad request"
+// @Failure 401 {object} dto.ErrorResponse "unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "forbidden"
+// @Failure 404 {object} dto.ErrorResponse "not found"
+// @Failure 500 {object} dto.ErrorResponse "internal server error"
+// @Router /admin/user/{id}/role [patch]
+func (h *UserHandler) SetUserRole(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequestError(err, "Incorrect UUID format")
+	}
+
+	body := new(dto.UserRoleRequestBody)
+	if err := c.BodyParser(body); err != nil {
+		return apperror.BadRequestError(err, "your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(body); err != nil {
+		return apperror.BadRequestError(err, "your request body is incorrect")
+	}
+
+	admin := c.Locals("user").(*domain.User)
+	updatedUser, err := h.userService.UpdateUserRole(admin, userID, domain.Role(body.Role))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(updatedUser.ToDTO()))
+}
+
 // BanUser godoc
 // @Summary Ban a user
-// @Description Ban a user by their ID. Only admins are allowed to use this endpoint
+// @Description Ban a user by their ID. Requires the user:ban permission, and the user's role must rank below your own
 // @Tags admin
 // @Security Bearer
 // @Produce json
@@ -640,7 +844,8 @@ func (h *UserHandler) BanUser(c *fiber.Ctx) error {
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
 
-	updatedUser, err := h.userService.UpdateUserBanStatus(userID, true)
+	admin := c.Locals("user").(*domain.User)
+	updatedUser, err := h.userService.UpdateUserBanStatus(c.Context(), admin, userID, true)
 	if err != nil {
 		return err
 	}
@@ -650,7 +855,7 @@ func (h *UserHandler) BanUser(c *fiber.Ctx) error {
 
 // UnbanUser godoc
 // @Summary Unban a user
-// @Description Unban a user by their ID. Only admins are allowed to use this endpoint
+// @Description Unban a user by their ID. Requires the user:ban permission, and the user's role must rank below your own
 // @Tags admin
 // @Security Bearer
 // @Produce json
@@ -675,7 +880,8 @@ func (h *UserHandler) UnbanUser(c *fiber.Ctx) error {
 		return apperror.InternalServerError(err, "Can not parse UUID")
 	}
 
-	updatedUser, err := h.userService.UpdateUserBanStatus(userID, false)
+	admin := c.Locals("user").(*domain.User)
+	updatedUser, err := h.userService.UpdateUserBanStatus(c.Context(), admin, userID, false)
 	if err != nil {
 		return err
 	}
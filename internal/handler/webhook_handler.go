@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+type WebhookHandler struct {
+	service ports.WebhookService
+}
+
+func NewWebhookHandler(service ports.WebhookService) ports.WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// Create godoc
+// @Summary Register a webhook
+// @Description Register an HTTPS endpoint to receive the events of your dorms: request.created, contract.signed, order.paid and lease.ended. Every delivery is signed: X-Condormhub-Signature is "sha256=" followed by the hex HMAC-SHA256 of "{X-Condormhub-Timestamp}.{body}" with the webhook secret. The secret is only returned here.
+// @Tags webhook
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param webhook body dto.WebhookRequestBody true "Webhook"
+// @Success 201 {object} dto.SuccessResponse[dto.WebhookResponseBody] "Webhook registered successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "Only lessors can register webhooks"
+// @Failure 500 {object} dto.ErrorResponse "Failed to create webhook"
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(c *fiber.Ctx) error {
+	reqBody := new(dto.WebhookRequestBody)
+	if err := c.BodyParser(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	user := c.Locals("user").(*domain.User)
+	webhook, err := h.service.Create(user, *reqBody)
+	if err != nil {
+		return err
+	}
+
+	resData := webhook.ToDTO()
+	resData.Secret = webhook.Secret
+	return c.Status(fiber.StatusCreated).JSON(dto.Success(resData))
+}
+
+// GetMine godoc
+// @Summary Get my webhooks
+// @Description Retrieve the webhooks of the current user
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param limit query int false "Number of webhooks to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.WebhookResponseBody] "Webhooks retrieved successfully"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve webhooks"
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetMine(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	webhooks, totalPages, totalRows, err := h.service.GetByOwnerID(userID, limit, page)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.WebhookResponseBody, len(webhooks))
+	for i, v := range webhooks {
+		resData[i] = v.ToDTO()
+	}
+
+	res := dto.SuccessPagination(resData, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// GetByID godoc
+// @Summary Get a webhook
+// @Description Retrieve a webhook of the current user
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "WebhookID"
+// @Success 200 {object} dto.SuccessResponse[dto.WebhookResponseBody] "Webhook retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this webhook"
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetByID(c *fiber.Ctx) error {
+	webhookID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	webhook, err := h.service.GetByID(userID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(webhook.ToDTO()))
+}
+
+// Update godoc
+// @Summary Update a webhook
+// @Description Replace the URL, events and description of a webhook, or disable it with active false. Pending deliveries of a disabled webhook are dropped.
+// @Tags webhook
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param id path string true "WebhookID"
+// @Param webhook body dto.WebhookRequestBody true "Webhook"
+// @Success 200 {object} dto.SuccessResponse[dto.WebhookResponseBody] "Webhook updated successfully"
+// @Failure 400 {object} dto.ErrorResponse "Your request is invalid"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this webhook"
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update webhook"
+// @Router /webhooks/{id} [patch]
+func (h *WebhookHandler) Update(c *fiber.Ctx) error {
+	webhookID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	reqBody := new(dto.WebhookRequestBody)
+	if err := c.BodyParser(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request is invalid")
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(reqBody); err != nil {
+		return apperror.BadRequestError(err, "Your request body is invalid")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	webhook, err := h.service.Update(userID, webhookID, *reqBody)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.Success(webhook.ToDTO()))
+}
+
+// Delete godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook and its delivery log
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "WebhookID"
+// @Success 204 "Webhook deleted successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this webhook"
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to delete webhook"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
+	webhookID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	if err := h.service.Delete(userID, webhookID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetDeliveries godoc
+// @Summary Get the delivery log of a webhook
+// @Description Retrieve the deliveries of a webhook, newest first, with the response of their last attempt. Failed attempts are retried with exponential backoff, up to 8 attempts.
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "WebhookID"
+// @Param limit query int false "Number of deliveries to retrieve (default 10, max 50)"
+// @Param page query int false "Page number to retrieve (default 1)"
+// @Success 200 {object} dto.PaginationResponse[dto.WebhookDeliveryResponseBody] "Deliveries retrieved successfully"
+// @Failure 400 {object} dto.ErrorResponse "Incorrect UUID format"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this webhook"
+// @Failure 404 {object} dto.ErrorResponse "Webhook not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to retrieve webhook deliveries"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	webhookID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 50 {
+		limit = 50
+	}
+
+	page := c.QueryInt("page", 1)
+	if page <= 0 {
+		page = 1
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	deliveries, totalPages, totalRows, err := h.service.GetDeliveries(userID, webhookID, limit, page)
+	if err != nil {
+		return err
+	}
+
+	resData := make([]dto.WebhookDeliveryResponseBody, len(deliveries))
+	for i, v := range deliveries {
+		resData[i] = v.ToDTO()
+	}
+
+	res := dto.SuccessPagination(resData, dto.Pagination{
+		CurrentPage: page,
+		LastPage:    totalPages,
+		Limit:       limit,
+		Total:       totalRows,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook delivery
+// @Description Queue the payload of a succeeded or failed delivery again. The event ID in the payload stays the same, so receivers can ignore events they already handled.
+// @Tags webhook
+// @Security Bearer
+// @Produce json
+// @Param id path string true "WebhookID"
+// @Param deliveryID path string true "DeliveryID"
+// @Success 202 {object} dto.SuccessResponse[dto.WebhookDeliveryResponseBody] "Delivery queued successfully"
+// @Failure 400 {object} dto.ErrorResponse "The delivery is still being attempted"
+// @Failure 401 {object} dto.ErrorResponse "your request is unauthorized"
+// @Failure 403 {object} dto.ErrorResponse "You do not have permission to access this webhook"
+// @Failure 404 {object} dto.ErrorResponse "Webhook delivery not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to queue webhook delivery"
+// @Router /webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	webhookID, err := parseIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	deliveryID, err := uuid.Parse(c.Params("deliveryID"))
+	if err != nil {
+		return apperror.BadRequestError(err, "Incorrect UUID format")
+	}
+
+	userID := c.Locals("userID").(uuid.UUID)
+	delivery, err := h.service.Redeliver(userID, webhookID, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(dto.Success(delivery.ToDTO()))
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
 	"github.com/gofiber/fiber/v2"
@@ -34,8 +35,26 @@ func (a *AuthMiddleware) Auth(ctx *fiber.Ctx) error {
 		return apperror.UnauthorizedError(errors.New("invalid authorization header"), "Authorization header is invalid")
 	}
 
-	token := authHeader[7:]
-	userID, err := a.jwtUtils.VerifyAccessToken(ctx.Context(), token)
+	return a.authenticate(ctx, authHeader[7:])
+}
+
+// StreamAuth is Auth for streams opened with EventSource, which cannot set headers:
+// the access token may be passed as the access_token query parameter instead.
+func (a *AuthMiddleware) StreamAuth(ctx *fiber.Ctx) error {
+	if ctx.Get("Authorization") != "" {
+		return a.Auth(ctx)
+	}
+
+	token := ctx.Query("access_token")
+	if token == "" {
+		return apperror.UnauthorizedError(errors.New("request without access token"), "Authorization header or access_token is required")
+	}
+
+	return a.authenticate(ctx, token)
+}
+
+func (a *AuthMiddleware) authenticate(ctx *fiber.Ctx, token string) error {
+	userID, sessionID, err := a.jwtUtils.VerifyAccessToken(ctx.Context(), token)
 	if err != nil {
 		return apperror.UnauthorizedError(err, "Invalid token")
 	}
@@ -51,14 +70,22 @@ func (a *AuthMiddleware) Auth(ctx *fiber.Ctx) error {
 
 	ctx.Locals("userID", userID)
 	ctx.Locals("user", user)
+	ctx.Locals("sessionID", sessionID)
 
 	return ctx.Next()
 }
 
-func (a *AuthMiddleware) RequireAdmin(c *fiber.Ctx) error {
-	user := c.Locals("user").(*domain.User)
-	if user.Role != domain.AdminRole {
-		return apperror.ForbiddenError(errors.New("admin access required"), "Admin access required")
+// RequirePermission only lets users whose role grants permission through.
+// Staff accounts have to use two-factor authentication, see policy.Authorize.
+func (a *AuthMiddleware) RequirePermission(permission domain.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := c.Locals("user").(*domain.User)
+		if err := policy.Authorize(user, permission); err != nil {
+			if errors.Is(err, policy.ErrTwoFactorRequired) {
+				return apperror.ForbiddenError(err, "Staff must enable two-factor authentication")
+			}
+			return apperror.ForbiddenError(err, "You do not have permission to do this")
+		}
+		return c.Next()
 	}
-	return c.Next()
 }
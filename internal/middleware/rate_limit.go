@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/dto"
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// LoginLockoutThreshold is the failed login from which an account gets locked for the client IP.
+	LoginLockoutThreshold = 5
+	// LoginLockoutBase is the first lockout, doubled for every further failed login.
+	LoginLockoutBase = time.Minute
+	LoginLockoutMax  = 24 * time.Hour
+	// loginFailureWindow is how long failed logins are remembered after the last one.
+	loginFailureWindow = 24 * time.Hour
+)
+
+// KeyFunc returns the key of the bucket a request is counted in. An empty key skips the limit.
+type KeyFunc func(c *fiber.Ctx) string
+
+// ByIP counts requests per client IP.
+func ByIP(c *fiber.Ctx) string {
+	return c.IP()
+}
+
+// ByAccount counts requests per email in the request body, so attackers cannot
+// get around the limit by spreading requests over many IPs.
+func ByAccount(c *fiber.Ctx) string {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(body.Email))
+}
+
+// ByAccountAndIP counts requests per email in the request body from each client IP.
+func ByAccountAndIP(c *fiber.Ctx) string {
+	account := ByAccount(c)
+	if account == "" {
+		return ""
+	}
+	return account + "|" + ByIP(c)
+}
+
+// ByUser counts requests per logged in user. It has to run after Auth.
+func ByUser(c *fiber.Ctx) string {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ""
+	}
+	return userID.String()
+}
+
+type RateLimit struct {
+	// Name separates the buckets of different limits on the same key.
+	Name   string
+	Max    int
+	Window time.Duration
+	Key    KeyFunc
+}
+
+// rateLimitStore is the part of redis that keeps the counters, so tests can keep them in memory.
+type rateLimitStore interface {
+	HitRateLimit(ctx context.Context, bucket string, key string, window time.Duration) (int64, time.Duration, error)
+	GetLoginLockout(ctx context.Context, account string) (time.Duration, error)
+	RecordLoginFailure(ctx context.Context, account string, threshold int, baseLockout time.Duration, maxLockout time.Duration, window time.Duration) (time.Duration, error)
+	ResetLoginFailures(ctx context.Context, account string) error
+}
+
+// RateLimitMiddleware keeps its counters in redis so the limits hold across replicas.
+type RateLimitMiddleware struct {
+	redis rateLimitStore
+}
+
+func NewRateLimitMiddleware(redis *redis.Redis) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		redis: redis,
+	}
+}
+
+// Limit allows limit.Max requests per limit.Window in each bucket and answers the
+// rest with 429. It sets the RateLimit-* headers of the most restrictive limit.
+func (m *RateLimitMiddleware) Limit(limit RateLimit) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := limit.Key(c)
+		if key == "" {
+			return c.Next()
+		}
+
+		count, reset, err := m.redis.HitRateLimit(c.Context(), limit.Name, key, limit.Window)
+		if err != nil {
+			// rather serve without a limit than not at all
+			log.Printf("rate limit %s unavailable: %v\n", limit.Name, err)
+			return c.Next()
+		}
+
+		remaining := int64(limit.Max) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		if current := c.GetRespHeader("RateLimit-Remaining"); current == "" || parseInt(current) >= remaining {
+			c.Set("RateLimit-Limit", strconv.Itoa(limit.Max))
+			c.Set("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+			c.Set("RateLimit-Reset", strconv.Itoa(seconds(reset)))
+		}
+
+		if count > int64(limit.Max) {
+			return tooManyRequests(c, reset, "Too many requests, please try again later")
+		}
+
+		return c.Next()
+	}
+}
+
+// LoginLockout locks an account after repeated failed logins, see LoginLockoutThreshold.
+// The lock only holds for the IP the failures came from, so nobody can lock
+// others out of their account by knowing their email; the login-account rate
+// limit covers attacks spread over many IPs. It has to wrap the login handler,
+// since it reads the outcome from the response status.
+func (m *RateLimitMiddleware) LoginLockout(c *fiber.Ctx) error {
+	account := ByAccountAndIP(c)
+	if account == "" {
+		return c.Next()
+	}
+
+	lockout, err := m.redis.GetLoginLockout(c.Context(), account)
+	if err != nil {
+		log.Printf("login lockout unavailable: %v\n", err)
+		return c.Next()
+	}
+	if lockout > 0 {
+		return tooManyRequests(c, lockout, "Too many failed logins, please try again later")
+	}
+
+	// answer errors here, as the logger middleware does, to see their status
+	if err := c.Next(); err != nil {
+		if err := c.App().Config().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	switch status := c.Response().StatusCode(); {
+	case status == fiber.StatusUnauthorized:
+		lockout, err := m.redis.RecordLoginFailure(c.Context(), account, LoginLockoutThreshold, LoginLockoutBase, LoginLockoutMax, loginFailureWindow)
+		if err != nil {
+			log.Printf("cannot record failed login: %v\n", err)
+		} else if lockout > 0 {
+			log.Printf("login locked for %s after repeated failures from %s\n", lockout, c.IP())
+		}
+	case status >= 200 && status < 300:
+		if err := m.redis.ResetLoginFailures(c.Context(), account); err != nil {
+			log.Printf("cannot reset failed logins: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+func tooManyRequests(c *fiber.Ctx, retryAfter time.Duration, message string) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds(retryAfter)))
+	return c.Status(fiber.StatusTooManyRequests).JSON(dto.ErrorResponse{Error: message})
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func parseInt(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
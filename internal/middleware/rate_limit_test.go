@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yokeTH/go-pkg/apperror"
+)
+
+// memRateLimitStore counts in memory like the redis scripts, with windows that never expire.
+type memRateLimitStore struct {
+	hits     map[string]int64
+	failures map[string]int
+	lockouts map[string]time.Duration
+}
+
+func newMemRateLimitStore() *memRateLimitStore {
+	return &memRateLimitStore{hits: map[string]int64{}, failures: map[string]int{}, lockouts: map[string]time.Duration{}}
+}
+
+func (m *memRateLimitStore) HitRateLimit(ctx context.Context, bucket string, key string, window time.Duration) (int64, time.Duration, error) {
+	m.hits[bucket+":"+key]++
+	return m.hits[bucket+":"+key], window, nil
+}
+
+func (m *memRateLimitStore) GetLoginLockout(ctx context.Context, account string) (time.Duration, error) {
+	return m.lockouts[account], nil
+}
+
+func (m *memRateLimitStore) RecordLoginFailure(ctx context.Context, account string, threshold int, baseLockout time.Duration, maxLockout time.Duration, window time.Duration) (time.Duration, error) {
+	m.failures[account]++
+	over := m.failures[account] - threshold
+	if over < 0 {
+		return 0, nil
+	}
+	lockout := min(time.Duration(float64(baseLockout)*math.Pow(2, float64(over))), maxLockout)
+	m.lockouts[account] = lockout
+	return lockout, nil
+}
+
+func (m *memRateLimitStore) ResetLoginFailures(ctx context.Context, account string) error {
+	delete(m.failures, account)
+	delete(m.lockouts, account)
+	return nil
+}
+
+// newLoginApp serves a login that only accepts the password "right". The
+// client IP is read from X-Real-IP.
+func newLoginApp(store *memRateLimitStore, limits ...RateLimit) *fiber.App {
+	app := fiber.New(fiber.Config{ProxyHeader: "X-Real-IP", ErrorHandler: apperror.ErrorHandler})
+	m := &RateLimitMiddleware{redis: store}
+
+	handlers := []fiber.Handler{}
+	for _, limit := range limits {
+		handlers = append(handlers, m.Limit(limit))
+	}
+	handlers = append(handlers, m.LoginLockout, func(c *fiber.Ctx) error {
+		var body struct {
+			Password string `json:"password"`
+		}
+		if err := c.BodyParser(&body); err != nil || body.Password != "right" {
+			return apperror.UnauthorizedError(errors.New("wrong password"), "invalid email or password")
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/login", handlers...)
+
+	return app
+}
+
+func login(t *testing.T, app *fiber.App, ip string, email string, password string) *http.Response {
+	req := httptest.NewRequest(fiber.MethodPost, "/login", strings.NewReader(`{"email":"`+email+`","password":"`+password+`"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set("X-Real-IP", ip)
+	res, err := app.Test(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return res
+}
+
+func TestRateLimit(t *testing.T) {
+	store := newMemRateLimitStore()
+	app := newLoginApp(store,
+		RateLimit{Name: "login-ip", Max: 3, Window: time.Minute, Key: ByIP},
+		RateLimit{Name: "login-account", Max: 2, Window: 15 * time.Minute, Key: ByAccount},
+	)
+
+	res := login(t, app, "10.0.0.1", "somchai@example.com", "right")
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	// the account limit is the more restrictive one
+	assert.Equal(t, "2", res.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", res.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "900", res.Header.Get("RateLimit-Reset"))
+
+	// the email is normalized, so changing its case does not get a new bucket
+	res = login(t, app, "10.0.0.2", " Somchai@Example.com", "right")
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	res = login(t, app, "10.0.0.3", "somchai@example.com", "right")
+	assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "900", res.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, "0", res.Header.Get("RateLimit-Remaining"))
+
+	for _, email := range []string{"malee@example.com", "somsak@example.com"} {
+		res = login(t, app, "10.0.0.1", email, "right")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	}
+	res = login(t, app, "10.0.0.1", "somying@example.com", "right")
+	assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "60", res.Header.Get(fiber.HeaderRetryAfter))
+}
+
+func TestLoginLockout(t *testing.T) {
+	email := "somchai@example.com"
+
+	t.Run("progressive lockout from the threshold on", func(t *testing.T) {
+		store := newMemRateLimitStore()
+		app := newLoginApp(store)
+
+		for i := 1; i < LoginLockoutThreshold; i++ {
+			res := login(t, app, "10.0.0.1", email, "wrong")
+			assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+		}
+		res := login(t, app, "10.0.0.1", email, "wrong")
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+
+		// even the right password is refused while locked
+		res = login(t, app, "10.0.0.1", email, "right")
+		assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, "60", res.Header.Get(fiber.HeaderRetryAfter))
+
+		delete(store.lockouts, email+"|10.0.0.1")
+		res = login(t, app, "10.0.0.1", email, "wrong")
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+		res = login(t, app, "10.0.0.1", email, "right")
+		assert.Equal(t, "120", res.Header.Get(fiber.HeaderRetryAfter))
+	})
+
+	t.Run("only the failing IP is locked", func(t *testing.T) {
+		store := newMemRateLimitStore()
+		app := newLoginApp(store)
+
+		for i := 0; i < LoginLockoutThreshold; i++ {
+			login(t, app, "10.0.0.1", email, "wrong")
+		}
+
+		res := login(t, app, "10.0.0.1", email, "right")
+		assert.Equal(t, fiber.StatusTooManyRequests, res.StatusCode)
+		res = login(t, app, "10.0.0.2", email, "right")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+
+	t.Run("a successful login forgets the failures", func(t *testing.T) {
+		store := newMemRateLimitStore()
+		app := newLoginApp(store)
+
+		for i := 1; i < LoginLockoutThreshold; i++ {
+			login(t, app, "10.0.0.1", email, "wrong")
+		}
+		res := login(t, app, "10.0.0.1", email, "right")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+		res = login(t, app, "10.0.0.1", email, "wrong")
+		assert.Equal(t, fiber.StatusUnauthorized, res.StatusCode)
+		res = login(t, app, "10.0.0.1", email, "right")
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	})
+}
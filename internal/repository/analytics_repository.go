@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+)
+
+const rentStatSelect = "COALESCE(percentile_cont(0.25) WITHIN GROUP (ORDER BY %[1]s), 0) AS p25, " +
+	"COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY %[1]s), 0) AS median, " +
+	"COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY %[1]s), 0) AS p75, " +
+	"COUNT(*) AS count"
+
+type AnalyticsRepository struct {
+	db *database.Database
+}
+
+func NewAnalyticsRepository(db *database.Database) ports.AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+func applyAreaFilter(query *gorm.DB, province string, district string, bedrooms int) *gorm.DB {
+	if province != "" {
+		query = query.Where("dorms.province ILIKE ?", province)
+	}
+	if district != "" {
+		query = query.Where("dorms.district ILIKE ?", district)
+	}
+	if bedrooms != -1 {
+		query = query.Where("dorms.bedrooms = ?", bedrooms)
+	}
+	return query
+}
+
+// GetListingPrices returns every price (initial and changed) dorms were listed at
+// until filter.To, including prices set before filter.From so they can be carried
+// forward. Prices are ordered by dorm, then oldest first.
+func (r *AnalyticsRepository) GetListingPrices(filter domain.RentFilter) ([]domain.RentPrice, error) {
+	var prices []domain.RentPrice
+	query := r.db.Table("dorm_price_histories").
+		Select("dorm_price_histories.dorm_id, dorm_price_histories.price, dorm_price_histories.create_at AS at, dorms.deleted_at AS removed_at").
+		Joins("JOIN dorms ON dorms.id = dorm_price_histories.dorm_id").
+		Where("dorm_price_histories.create_at <= ?", filter.To).
+		Where("(dorms.deleted_at IS NULL OR dorms.deleted_at >= ?)", filter.From)
+	query = applyAreaFilter(query, filter.Province, filter.District, filter.Bedrooms)
+
+	if err := query.Order("dorm_price_histories.dorm_id, dorm_price_histories.create_at").Scan(&prices).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve listing rent")
+	}
+	return prices, nil
+}
+
+// GetLeasePrices returns the prices tenants actually leased at, at lease start.
+func (r *AnalyticsRepository) GetLeasePrices(filter domain.RentFilter) ([]domain.RentPrice, error) {
+	var prices []domain.RentPrice
+	query := r.db.Table("leasing_histories").
+		Select("leasing_histories.dorm_id, leasing_histories.price, leasing_histories.start AS at").
+		Joins("JOIN dorms ON dorms.id = leasing_histories.dorm_id").
+		Where("leasing_histories.deleted_at IS NULL").
+		Where("leasing_histories.price > 0").
+		Where("leasing_histories.start BETWEEN ? AND ?", filter.From, filter.To)
+	query = applyAreaFilter(query, filter.Province, filter.District, filter.Bedrooms)
+
+	if err := query.Order("leasing_histories.start").Scan(&prices).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve lease rent")
+	}
+	return prices, nil
+}
+
+// GetAreaListingRent returns the current price distribution of listed dorms in an area, excluding one dorm.
+func (r *AnalyticsRepository) GetAreaListingRent(province string, district string, bedrooms int, excludeDormID uuid.UUID) (*domain.RentStat, error) {
+	stat := new(domain.RentStat)
+	query := r.db.Table("dorms").
+		Select(fmt.Sprintf(rentStatSelect, "dorms.price")).
+		Where("dorms.deleted_at IS NULL").
+		Where("dorms.id <> ?", excludeDormID)
+	query = applyAreaFilter(query, province, district, bedrooms)
+
+	if err := query.Scan(stat).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve area rent")
+	}
+	return stat, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AnnouncementRepository struct {
+	db *database.Database
+}
+
+func NewAnnouncementRepository(db *database.Database) ports.AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+func (r *AnnouncementRepository) Create(announcement *domain.Announcement) error {
+	if err := r.db.Omit("Dorm").Create(announcement).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to create announcement")
+	}
+	return nil
+}
+
+func (r *AnnouncementRepository) GetByID(id uuid.UUID) (*domain.Announcement, error) {
+	announcement := new(domain.Announcement)
+	if err := r.db.Preload("Dorm").Preload("Attachments").First(announcement, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundError(err, "Announcement not found")
+		}
+		return nil, apperror.InternalServerError(err, "Failed to retrieve announcement")
+	}
+	return announcement, nil
+}
+
+func (r *AnnouncementRepository) GetByDormID(dormID uuid.UUID, limit int, page int) ([]domain.Announcement, int, int, error) {
+	var announcements []domain.Announcement
+	query := r.db.Preload("Dorm").Preload("Attachments").Where("dorm_id = ?", dormID)
+
+	totalPages, totalRows, err := r.db.Paginate(&announcements, query, limit, page, "publish_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve announcements")
+	}
+	return announcements, totalPages, totalRows, nil
+}
+
+func (r *AnnouncementRepository) Delete(announcement *domain.Announcement) error {
+	if err := r.db.Delete(announcement).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to delete announcement")
+	}
+	return nil
+}
+
+func (r *AnnouncementRepository) ClaimDue(now time.Time, limit int) ([]domain.Announcement, error) {
+	due := r.db.Model(&domain.Announcement{}).
+		Select("id").
+		Where("published_at IS NULL AND publish_at <= ?", now).
+		Order("publish_at").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var announcements []domain.Announcement
+	err := r.db.Model(&announcements).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Update("published_at", now).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to claim scheduled announcements")
+	}
+	return announcements, nil
+}
+
+func (r *AnnouncementRepository) GetActiveLeases(dormID uuid.UUID, at time.Time) ([]domain.LeasingHistory, error) {
+	var histories []domain.LeasingHistory
+	err := r.db.Where("dorm_id = ?", dormID).
+		Where("start <= ? AND (\"end\" IS NULL OR \"end\" > ?)", at, at).
+		Find(&histories).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve current leases")
+	}
+	return histories, nil
+}
+
+func (r *AnnouncementRepository) CreateRecipients(recipients []domain.AnnouncementRecipient) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	if err := r.db.Omit("Announcement").Clauses(clause.OnConflict{DoNothing: true}).Create(&recipients).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to record announcement recipients")
+	}
+	return nil
+}
+
+func (r *AnnouncementRepository) GetRecipient(announcementID uuid.UUID, userID uuid.UUID) (*domain.AnnouncementRecipient, error) {
+	recipient := new(domain.AnnouncementRecipient)
+	err := r.db.Where("announcement_id = ? AND user_id = ?", announcementID, userID).First(recipient).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve announcement recipient")
+	}
+	return recipient, nil
+}
+
+// GetByLeasingHistoryID returns the announcements sent to the tenant of the lease, newest first.
+func (r *AnnouncementRepository) GetByLeasingHistoryID(historyID uuid.UUID, limit int, page int) ([]domain.AnnouncementRecipient, int, int, error) {
+	var recipients []domain.AnnouncementRecipient
+	query := r.db.Preload("Announcement").
+		Preload("Announcement.Dorm").
+		Preload("Announcement.Attachments").
+		Where("leasing_history_id = ?", historyID)
+
+	totalPages, totalRows, err := r.db.Paginate(&recipients, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve announcements")
+	}
+	return recipients, totalPages, totalRows, nil
+}
+
+// MarkRead keeps the time the announcement was first read.
+func (r *AnnouncementRepository) MarkRead(announcementID uuid.UUID, userID uuid.UUID, at time.Time) error {
+	err := r.db.Model(&domain.AnnouncementRecipient{}).
+		Where("announcement_id = ? AND user_id = ? AND read_at IS NULL", announcementID, userID).
+		Update("read_at", at).Error
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to mark announcement as read")
+	}
+	return nil
+}
+
+func (r *AnnouncementRepository) GetStats(announcementIDs []uuid.UUID) (map[uuid.UUID]domain.AnnouncementStats, error) {
+	stats := make(map[uuid.UUID]domain.AnnouncementStats, len(announcementIDs))
+	if len(announcementIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []struct {
+		AnnouncementID uuid.UUID
+		Recipients     int64
+		Read           int64
+	}
+	err := r.db.Model(&domain.AnnouncementRecipient{}).
+		Select("announcement_id, COUNT(*) AS recipients, COUNT(read_at) AS read").
+		Where("announcement_id IN ?", announcementIDs).
+		Group("announcement_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve announcement stats")
+	}
+
+	for _, row := range rows {
+		stats[row.AnnouncementID] = domain.AnnouncementStats{Recipients: row.Recipients, Read: row.Read}
+	}
+	return stats, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DormManagerRepository struct {
+	db *database.Database
+}
+
+func NewDormManagerRepository(db *database.Database) ports.DormManagerRepository {
+	return &DormManagerRepository{db: db}
+}
+
+func (r *DormManagerRepository) Create(manager *domain.DormManager) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(manager)
+	if result.Error != nil {
+		return apperror.InternalServerError(result.Error, "Failed to invite manager")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.ConflictError(errors.New("manager already invited"), "This user is already a manager of this dorm")
+	}
+	return nil
+}
+
+func (r *DormManagerRepository) GetByID(id uuid.UUID) (*domain.DormManager, error) {
+	manager := new(domain.DormManager)
+	if err := r.db.Preload("Dorm").Preload("Manager").First(manager, id).Error; err != nil {
+		return nil, apperror.NotFoundError(err, "Manager not found")
+	}
+	return manager, nil
+}
+
+func (r *DormManagerRepository) GetByDormID(dormID uuid.UUID) ([]domain.DormManager, error) {
+	var managers []domain.DormManager
+	if err := r.db.Preload("Dorm").Preload("Manager").Where("dorm_id = ?", dormID).Order("create_at").Find(&managers).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve managers")
+	}
+	return managers, nil
+}
+
+func (r *DormManagerRepository) GetByManagerID(managerID uuid.UUID) ([]domain.DormManager, error) {
+	var managers []domain.DormManager
+	if err := r.db.Preload("Dorm").Preload("Manager").Where("manager_id = ?", managerID).Order("create_at").Find(&managers).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve managed dorms")
+	}
+	return managers, nil
+}
+
+// GetAccepted returns nil without an error when the user does not manage the dorm.
+func (r *DormManagerRepository) GetAccepted(dormID uuid.UUID, managerID uuid.UUID) (*domain.DormManager, error) {
+	manager := new(domain.DormManager)
+	err := r.db.Where("dorm_id = ? AND manager_id = ? AND status = ?", dormID, managerID, domain.ManagerAccepted).First(manager).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve manager")
+	}
+	return manager, nil
+}
+
+func (r *DormManagerRepository) Update(manager *domain.DormManager) error {
+	err := r.db.Model(manager).Select("status", "can_leasing_requests", "can_contracts", "can_meter_readings", "can_review_replies", "accepted_at").Updates(manager).Error
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to update manager")
+	}
+	return nil
+}
+
+func (r *DormManagerRepository) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&domain.DormManager{}, id)
+	if result.Error != nil {
+		return apperror.InternalServerError(result.Error, "Failed to remove manager")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFoundError(errors.New("manager not found"), "Manager not found")
+	}
+	return nil
+}
+
+func (r *DormManagerRepository) CreateAction(action *domain.DelegatedAction) error {
+	if err := r.db.Create(action).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to record delegated action")
+	}
+	return nil
+}
+
+func (r *DormManagerRepository) GetActionsByDormID(dormID uuid.UUID, limit int, page int) ([]domain.DelegatedAction, int, int, error) {
+	var actions []domain.DelegatedAction
+	query := r.db.Preload("Manager").Where("dorm_id = ?", dormID)
+	totalPages, totalRows, err := r.db.Paginate(&actions, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve delegated actions")
+	}
+	return actions, totalPages, totalRows, nil
+}
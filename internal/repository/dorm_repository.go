@@ -7,6 +7,7 @@ import (
 	"github.com/PitiNarak/condormhub-backend/internal/dto"
 	"github.com/google/uuid"
 	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
 )
 
 type DormRepository struct {
@@ -100,9 +101,24 @@ func (d *DormRepository) Update(id uuid.UUID, dorm dto.DormUpdateRequestBody) er
 		Description: dorm.Description,
 	}
 
-	res := d.db.Model(&domain.Dorm{}).Where("id = ?", id).Updates(updatedDorm)
-	if res.Error != nil {
-		return apperror.InternalServerError(res.Error, "Failed to update room")
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		var current domain.Dorm
+		if err := tx.Select("price").First(&current, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Dorm{}).Where("id = ?", id).Updates(updatedDorm).Error; err != nil {
+			return err
+		}
+
+		// Keep a record of every price change for market analytics
+		if dorm.Price > 0 && dorm.Price != current.Price {
+			return tx.Create(&domain.DormPriceHistory{DormID: id, Price: dorm.Price}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to update room")
 	}
 
 	return nil
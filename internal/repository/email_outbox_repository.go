@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm/clause"
+)
+
+type EmailOutboxRepository struct {
+	db *database.Database
+}
+
+func NewEmailOutboxRepository(db *database.Database) ports.EmailOutboxRepository {
+	return &EmailOutboxRepository{db: db}
+}
+
+func (r *EmailOutboxRepository) Enqueue(message *email.Message) error {
+	entry := &domain.EmailOutbox{
+		To:            message.To,
+		Subject:       message.Subject,
+		HTML:          message.HTML,
+		Text:          message.Text,
+		Status:        domain.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := r.db.Create(entry).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to queue email")
+	}
+	return nil
+}
+
+// Claim pushes next_attempt_at of the claimed rows past lease in the same
+// statement, so two instances never deliver the same email at once.
+func (r *EmailOutboxRepository) Claim(now time.Time, lease time.Duration, limit int) ([]domain.EmailOutbox, error) {
+	due := r.db.Model(&domain.EmailOutbox{}).
+		Select("id").
+		Where("status = ? AND next_attempt_at <= ?", domain.OutboxPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var entries []domain.EmailOutbox
+	err := r.db.Model(&entries).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Update("next_attempt_at", now.Add(lease)).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to claim queued emails")
+	}
+	return entries, nil
+}
+
+func (r *EmailOutboxRepository) Update(entry *domain.EmailOutbox) error {
+	if err := r.db.Save(entry).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to update queued email")
+	}
+	return nil
+}
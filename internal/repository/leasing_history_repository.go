@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
@@ -9,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/yokeTH/go-pkg/apperror"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LeasingHistoryRepository struct {
@@ -87,13 +89,134 @@ func (d *LeasingHistoryRepository) GetImageByKey(imageKey string) (*domain.Revie
 }
 
 func (d *LeasingHistoryRepository) DeleteReview(leasingHistory *domain.LeasingHistory) error {
-	err := d.db.Model(leasingHistory).Update("review_flag", false).Error
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("history_id = ?", leasingHistory.ID).Delete(&domain.ReviewHelpfulVote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("history_id = ? AND moderation_id IS NULL", leasingHistory.ID).Delete(&domain.ReviewReport{}).Error; err != nil {
+			return err
+		}
+
+		// Clear everything that belongs to the review so a new review starts fresh
+		return tx.Model(leasingHistory).Updates(map[string]interface{}{
+			"review_flag":        false,
+			"cleanliness_rate":   nil,
+			"location_rate":      nil,
+			"value_rate":         nil,
+			"communication_rate": nil,
+			"accuracy_rate":      nil,
+			"reply":              nil,
+			"reply_at":           nil,
+			"helpful_count":      0,
+			"hidden":             false,
+			"report_flag":        false,
+		}).Error
+	})
 	if err != nil {
 		return apperror.InternalServerError(err, "Failed to delete review")
 	}
 	return nil
 }
 
+// UpdateReviewReply sets or, with an empty reply, clears the lessor's reply to a review.
+func (d *LeasingHistoryRepository) UpdateReviewReply(id uuid.UUID, reply string, replyAt *time.Time) error {
+	values := map[string]interface{}{"reply": nil, "reply_at": nil}
+	if reply != "" {
+		values = map[string]interface{}{"reply": reply, "reply_at": replyAt}
+	}
+
+	// UpdateColumns skips the AfterUpdate hook, a reply does not change any rating
+	if err := d.db.Model(&domain.LeasingHistory{}).Where("id = ?", id).UpdateColumns(values).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to save review reply")
+	}
+	return nil
+}
+
+// SetHelpfulVote adds or removes a user's helpful vote and keeps the review's counter in sync.
+func (d *LeasingHistoryRepository) SetHelpfulVote(historyID uuid.UUID, userID uuid.UUID, helpful bool) error {
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		var res *gorm.DB
+		delta := 1
+		if helpful {
+			vote := &domain.ReviewHelpfulVote{HistoryID: historyID, UserID: userID}
+			res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(vote)
+		} else {
+			res = tx.Where("history_id = ? AND user_id = ?", historyID, userID).Delete(&domain.ReviewHelpfulVote{})
+			delta = -1
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		return tx.Model(&domain.LeasingHistory{}).Where("id = ?", historyID).
+			UpdateColumn("helpful_count", gorm.Expr("helpful_count + ?", delta)).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to save helpful vote")
+	}
+	return nil
+}
+
+func (d *LeasingHistoryRepository) GetReviewSummaryByDormID(id uuid.UUID) (*domain.ReviewSummary, error) {
+	var averages struct {
+		Count         int
+		AverageRate   float64
+		Cleanliness   float64
+		Location      float64
+		Value         float64
+		Communication float64
+		Accuracy      float64
+	}
+	reviews := d.db.Model(&domain.LeasingHistory{}).
+		Where("review_flag = ?", true).
+		Where("hidden = ?", false).
+		Where("dorm_id = ?", id)
+
+	err := reviews.Session(&gorm.Session{}).
+		Select("COUNT(*) AS count, " +
+			"COALESCE(AVG(rate), 0) AS average_rate, " +
+			"COALESCE(AVG(NULLIF(cleanliness_rate, 0)), 0) AS cleanliness, " +
+			"COALESCE(AVG(NULLIF(location_rate, 0)), 0) AS location, " +
+			"COALESCE(AVG(NULLIF(value_rate, 0)), 0) AS value, " +
+			"COALESCE(AVG(NULLIF(communication_rate, 0)), 0) AS communication, " +
+			"COALESCE(AVG(NULLIF(accuracy_rate, 0)), 0) AS accuracy").
+		Scan(&averages).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "failed to get review summary")
+	}
+
+	summary := &domain.ReviewSummary{
+		Count:         averages.Count,
+		AverageRate:   averages.AverageRate,
+		Cleanliness:   averages.Cleanliness,
+		Location:      averages.Location,
+		Value:         averages.Value,
+		Communication: averages.Communication,
+		Accuracy:      averages.Accuracy,
+		Histogram:     map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+	}
+
+	var buckets []struct {
+		Rate  int
+		Count int
+	}
+	err = reviews.Session(&gorm.Session{}).
+		Select("rate, COUNT(*) AS count").
+		Group("rate").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "failed to get review summary")
+	}
+	for _, b := range buckets {
+		summary.Histogram[b.Rate] += b.Count
+	}
+
+	return summary, nil
+}
+
 func (d *LeasingHistoryRepository) Delete(id uuid.UUID) error {
 	// TODO: Cascade delete?
 	if err := d.db.Delete(&domain.LeasingHistory{}, id).Error; err != nil {
@@ -147,12 +270,22 @@ func (d *LeasingHistoryRepository) GetByDormID(id uuid.UUID, limit, page int) ([
 	return leasingHistory, totalPage, totalRows, nil
 }
 
+// HasActiveLease reports whether the dorm has a lease that has not ended.
+func (d *LeasingHistoryRepository) HasActiveLease(dormID uuid.UUID) (bool, error) {
+	var count int64
+	if err := d.db.Model(&domain.LeasingHistory{}).Where("dorm_id = ?", dormID).Where("\"end\" IS NULL").Count(&count).Error; err != nil {
+		return false, apperror.InternalServerError(err, "failed to get leasing history")
+	}
+	return count > 0, nil
+}
+
 func (d *LeasingHistoryRepository) GetReviewByDormID(id uuid.UUID, limit, page int) ([]domain.LeasingHistory, int, int, error) {
 	var reviews []domain.LeasingHistory
 	query := d.db.Preload("Lessee").
 		Preload("Dorm").
 		Preload("Images").
 		Where("review_flag = ?", true).
+		Where("hidden = ?", false).
 		Where("dorm_id = ?", id)
 	totalPage, totalRows, err := d.db.Paginate(&reviews, query, limit, page, "start")
 
@@ -168,13 +301,3 @@ func (d *LeasingHistoryRepository) GetReviewByDormID(id uuid.UUID, limit, page i
 
 	return reviews, totalPage, totalRows, nil
 }
-
-func (d *LeasingHistoryRepository) GetReportedReviews(limit int, page int) ([]domain.LeasingHistory, int, int, error) {
-	var reviews []domain.LeasingHistory
-	query := d.db.Preload("Lessee").Preload("Images").Where("report_flag = ?", true).Where("review_flag = ?", true)
-	totalPages, totalRows, err := d.db.Paginate(&reviews, query, limit, page, "id")
-	if err != nil {
-		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve reviews")
-	}
-	return reviews, totalPages, totalRows, nil
-}
@@ -102,3 +102,23 @@ func (d *LeasingRequestRepository) GetByDormID(id uuid.UUID, limit, page int) ([
 
 	return leasingRequest, totalPage, totalRows, nil
 }
+
+// GetAverageResponseTime returns the mean time in hours a lessor took to approve or reject
+// requests on their dorms, and how many answered requests it is based on.
+func (d *LeasingRequestRepository) GetAverageResponseTime(ownerID uuid.UUID) (float64, int, error) {
+	var result struct {
+		Hours float64
+		Count int
+	}
+	err := d.db.Model(&domain.LeasingRequest{}).
+		Select("COALESCE(AVG(EXTRACT(EPOCH FROM (leasing_requests.\"end\" - leasing_requests.start)) / 3600), 0) AS hours, COUNT(*) AS count").
+		Joins("JOIN dorms ON dorms.id = leasing_requests.dorm_id").
+		Where("dorms.owner_id = ?", ownerID).
+		Where("leasing_requests.status IN ?", []domain.Status{domain.RequestAccepted, domain.RequestRejected}).
+		Scan(&result).Error
+	if err != nil {
+		return 0, 0, apperror.InternalServerError(err, "failed to get lessor response time")
+	}
+
+	return result.Hours, result.Count, nil
+}
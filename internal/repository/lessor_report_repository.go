@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LessorReportRepository struct {
+	db *database.Database
+}
+
+func NewLessorReportRepository(db *database.Database) ports.LessorReportRepository {
+	return &LessorReportRepository{db: db}
+}
+
+func (r *LessorReportRepository) GetRecipients(weekStart time.Time) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.Where("role = ? AND dorms_owned > 0 AND banned = ?", domain.LessorRole, false).
+		Where("NOT EXISTS (SELECT 1 FROM notification_preferences WHERE notification_preferences.user_id = users.id AND notification_preferences.type = ? AND notification_preferences.email = ?)", domain.NotificationWeeklyReport, false).
+		Where("NOT EXISTS (SELECT 1 FROM lessor_report_logs WHERE lessor_report_logs.owner_id = users.id AND lessor_report_logs.week_start = ?)", weekStart.Format(time.DateOnly)).
+		Find(&users).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve report recipients")
+	}
+	return users, nil
+}
+
+// GetDormReports returns the activity of every dorm of the owner between from and to.
+func (r *LessorReportRepository) GetDormReports(ownerID uuid.UUID, from time.Time, to time.Time) ([]domain.DormReport, error) {
+	var dorms []domain.Dorm
+	if err := r.db.Where("owner_id = ?", ownerID).Order("create_at").Find(&dorms).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve dorms")
+	}
+	if len(dorms) == 0 {
+		return []domain.DormReport{}, nil
+	}
+
+	reports := make([]domain.DormReport, len(dorms))
+	index := make(map[uuid.UUID]*domain.DormReport, len(dorms))
+	dormIDs := make([]uuid.UUID, len(dorms))
+	for i, dorm := range dorms {
+		reports[i] = domain.DormReport{DormID: dorm.ID, Name: dorm.Name}
+		index[dorm.ID] = &reports[i]
+		dormIDs[i] = dorm.ID
+	}
+
+	// one snapshot, so the figures of the report agree with each other
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var requests []struct {
+			DormID           uuid.UUID
+			NewRequests      int64
+			AnsweredRequests int64
+			PendingRequests  int64
+			ResponseSeconds  float64
+		}
+		err := tx.Model(&domain.LeasingRequest{}).
+			Select("dorm_id, "+
+				"COUNT(*) FILTER (WHERE start >= ? AND start < ?) AS new_requests, "+
+				"COUNT(*) FILTER (WHERE status IN ? AND \"end\" >= ? AND \"end\" < ?) AS answered_requests, "+
+				"COUNT(*) FILTER (WHERE status = ?) AS pending_requests, "+
+				"COALESCE(SUM(EXTRACT(EPOCH FROM \"end\" - start)) FILTER (WHERE status IN ? AND \"end\" >= ? AND \"end\" < ?), 0) AS response_seconds",
+				from, to,
+				[]domain.Status{domain.RequestAccepted, domain.RequestRejected}, from, to,
+				domain.RequestPending,
+				[]domain.Status{domain.RequestAccepted, domain.RequestRejected}, from, to).
+			Where("dorm_id IN ?", dormIDs).
+			Group("dorm_id").
+			Scan(&requests).Error
+		if err != nil {
+			return err
+		}
+		for _, v := range requests {
+			report := index[v.DormID]
+			report.NewRequests = v.NewRequests
+			report.AnsweredRequests = v.AnsweredRequests
+			report.PendingRequests = v.PendingRequests
+			report.ResponseTime = time.Duration(v.ResponseSeconds * float64(time.Second))
+		}
+
+		// the part of every lease inside the period, leases without an end are still running
+		var leases []struct {
+			DormID        uuid.UUID
+			LeasedSeconds float64
+		}
+		err = tx.Model(&domain.LeasingHistory{}).
+			Select("dorm_id, COALESCE(SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(\"end\", ?), ?) - GREATEST(start, ?))), 0) AS leased_seconds", to, to, from).
+			Where("dorm_id IN ?", dormIDs).
+			Where("start < ? AND (\"end\" IS NULL OR \"end\" > ?)", to, from).
+			Group("dorm_id").
+			Scan(&leases).Error
+		if err != nil {
+			return err
+		}
+		for _, v := range leases {
+			// overlapping leases of the same dorm count once
+			index[v.DormID].Occupancy = min(v.LeasedSeconds/to.Sub(from).Seconds(), 1)
+		}
+
+		// bills paid during the period, and the ones due before its end still unpaid
+		var bills []struct {
+			DormID            uuid.UUID
+			PaidBills         int64
+			PaidAmount        int64
+			OutstandingBills  int64
+			OutstandingAmount int64
+		}
+		err = tx.Model(&domain.Order{}).
+			Select("leasing_histories.dorm_id AS dorm_id, "+
+				"COUNT(*) FILTER (WHERE paid.paid_at >= ? AND paid.paid_at < ?) AS paid_bills, "+
+				"COALESCE(SUM(orders.price) FILTER (WHERE paid.paid_at >= ? AND paid.paid_at < ?), 0) AS paid_amount, "+
+				"COUNT(*) FILTER (WHERE paid.paid_at IS NULL AND orders.due_at < ?) AS outstanding_bills, "+
+				"COALESCE(SUM(orders.price) FILTER (WHERE paid.paid_at IS NULL AND orders.due_at < ?), 0) AS outstanding_amount",
+				from, to, from, to, to, to).
+			Joins("JOIN leasing_histories ON leasing_histories.id = orders.leasing_history_id").
+			Joins("LEFT JOIN (SELECT order_id, MIN(update_at) AS paid_at FROM transactions WHERE session_status = ? AND deleted_at IS NULL GROUP BY order_id) AS paid ON paid.order_id = orders.id", domain.StatusComplete).
+			Where("leasing_histories.dorm_id IN ?", dormIDs).
+			Group("leasing_histories.dorm_id").
+			Scan(&bills).Error
+		if err != nil {
+			return err
+		}
+		for _, v := range bills {
+			report := index[v.DormID]
+			report.PaidBills = v.PaidBills
+			report.PaidAmount = v.PaidAmount
+			report.OutstandingBills = v.OutstandingBills
+			report.OutstandingAmount = v.OutstandingAmount
+		}
+
+		var reviews []struct {
+			DormID         uuid.UUID
+			NewReviews     int64
+			Rating         float64
+			PreviousRating float64
+		}
+		err = tx.Model(&domain.LeasingHistory{}).
+			Select("dorm_id, "+
+				"COUNT(*) FILTER (WHERE create_at >= ? AND create_at < ?) AS new_reviews, "+
+				"COALESCE(AVG(rate) FILTER (WHERE create_at < ?), 0) AS rating, "+
+				"COALESCE(AVG(rate) FILTER (WHERE create_at < ?), 0) AS previous_rating",
+				from, to, to, from).
+			Where("dorm_id IN ?", dormIDs).
+			Where("review_flag = ?", true).
+			Where("hidden = ?", false).
+			Group("dorm_id").
+			Scan(&reviews).Error
+		if err != nil {
+			return err
+		}
+		for _, v := range reviews {
+			report := index[v.DormID]
+			report.NewReviews = v.NewReviews
+			report.Rating = v.Rating
+			report.PreviousRating = v.PreviousRating
+		}
+
+		var views []struct {
+			DormID uuid.UUID
+			Views  int64
+		}
+		err = tx.Model(&domain.DormView{}).
+			Select("dorm_id, SUM(count) AS views").
+			Where("dorm_id IN ?", dormIDs).
+			Where("day >= ? AND day < ?", from.Format(time.DateOnly), to.Format(time.DateOnly)).
+			Group("dorm_id").
+			Scan(&views).Error
+		if err != nil {
+			return err
+		}
+		for _, v := range views {
+			index[v.DormID].Views = v.Views
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve dorm reports")
+	}
+
+	return reports, nil
+}
+
+// Claim records the report and tells whether it was not sent yet.
+func (r *LessorReportRepository) Claim(log *domain.LessorReportLog) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(log)
+	if result.Error != nil {
+		return false, apperror.InternalServerError(result.Error, "Failed to record lessor report")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *LessorReportRepository) AddDormView(dormID uuid.UUID, day time.Time) error {
+	view := &domain.DormView{DormID: dormID, Day: day, Count: 1}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dorm_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]any{"count": gorm.Expr("dorm_views.count + 1")}),
+	}).Create(view).Error
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to record dorm view")
+	}
+	return nil
+}
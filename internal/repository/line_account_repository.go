@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+)
+
+type LineAccountRepository struct {
+	db *database.Database
+}
+
+func NewLineAccountRepository(db *database.Database) ports.LineAccountRepository {
+	return &LineAccountRepository{db: db}
+}
+
+func (r *LineAccountRepository) GetByUserID(userID uuid.UUID) (*domain.LineAccount, error) {
+	account := new(domain.LineAccount)
+	err := r.db.Where("user_id = ?", userID).First(account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve LINE account")
+	}
+	return account, nil
+}
+
+func (r *LineAccountRepository) Link(account *domain.LineAccount) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? OR line_user_id = ?", account.UserID, account.LineUserID).Delete(&domain.LineAccount{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(account).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to link LINE account")
+	}
+	return nil
+}
+
+func (r *LineAccountRepository) DeleteByUserID(userID uuid.UUID) error {
+	if err := r.db.Where("user_id = ?", userID).Delete(&domain.LineAccount{}).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to unlink LINE account")
+	}
+	return nil
+}
+
+func (r *LineAccountRepository) DeleteByLineUserID(lineUserID string) error {
+	if err := r.db.Where("line_user_id = ?", lineUserID).Delete(&domain.LineAccount{}).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to unlink LINE account")
+	}
+	return nil
+}
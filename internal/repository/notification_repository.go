@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *database.Database
+}
+
+func NewNotificationRepository(db *database.Database) ports.NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) Create(notification *domain.Notification) error {
+	if err := r.db.Create(notification).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to create notification")
+	}
+	return nil
+}
+
+func (r *NotificationRepository) GetByUserID(userID uuid.UUID, unreadOnly bool, limit int, page int) ([]domain.Notification, int, int, error) {
+	var notifications []domain.Notification
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	totalPages, totalRows, err := r.db.Paginate(&notifications, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve notifications")
+	}
+	return notifications, totalPages, totalRows, nil
+}
+
+func (r *NotificationRepository) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.Model(&domain.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		return 0, apperror.InternalServerError(err, "Failed to count unread notifications")
+	}
+	return count, nil
+}
+
+// MarkRead keeps the first read time when a notification is marked read again.
+func (r *NotificationRepository) MarkRead(userID uuid.UUID, id uuid.UUID) error {
+	result := r.db.Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		return apperror.InternalServerError(result.Error, "Failed to update notification")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFoundError(errors.New("notification not found"), "Notification not found")
+	}
+	return nil
+}
+
+func (r *NotificationRepository) MarkAllRead(userID uuid.UUID) error {
+	err := r.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to update notifications")
+	}
+	return nil
+}
+
+func (r *NotificationRepository) GetPreferences(userID uuid.UUID) ([]domain.NotificationPreference, error) {
+	var preferences []domain.NotificationPreference
+	if err := r.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve notification preferences")
+	}
+	return preferences, nil
+}
+
+func (r *NotificationRepository) SavePreferences(preferences []domain.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "line"}),
+	}).Create(&preferences).Error
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to save notification preferences")
+	}
+	return nil
+}
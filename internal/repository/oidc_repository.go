@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OIDCRepository struct {
+	db *database.Database
+}
+
+func NewOIDCRepository(db *database.Database) ports.OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) Create(identity *domain.UserIdentity) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(identity)
+	if result.Error != nil {
+		return apperror.InternalServerError(result.Error, "Failed to link account")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.ConflictError(errors.New("identity already linked"), "This account is already linked")
+	}
+	return nil
+}
+
+func (r *OIDCRepository) GetByProviderSubject(provider string, subject string) (*domain.UserIdentity, error) {
+	identity := new(domain.UserIdentity)
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, apperror.InternalServerError(err, "Failed to retrieve linked account")
+	}
+	return identity, nil
+}
+
+func (r *OIDCRepository) GetByUserID(userID uuid.UUID) ([]domain.UserIdentity, error) {
+	var identities []domain.UserIdentity
+	if err := r.db.Where("user_id = ?", userID).Order("create_at").Find(&identities).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve linked accounts")
+	}
+	return identities, nil
+}
+
+func (r *OIDCRepository) Delete(userID uuid.UUID, provider string) error {
+	result := r.db.Where("user_id = ? AND provider = ?", userID, provider).Delete(&domain.UserIdentity{})
+	if result.Error != nil {
+		return apperror.InternalServerError(result.Error, "Failed to unlink account")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFoundError(errors.New("identity not found"), "Linked account not found")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm/clause"
+)
+
+type ReminderRepository struct {
+	db *database.Database
+}
+
+func NewReminderRepository(db *database.Database) ports.ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// GetUnpaidOrders returns the orders due in the range without a completed payment.
+func (r *ReminderRepository) GetUnpaidOrders(from time.Time, to time.Time) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := r.db.Preload("LeasingHistory").
+		Preload("LeasingHistory.Dorm").
+		Where("due_at >= ? AND due_at < ?", from, to).
+		Where("NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.order_id = orders.id AND transactions.session_status = ? AND transactions.deleted_at IS NULL)", domain.StatusComplete).
+		Find(&orders).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve unpaid orders")
+	}
+	return orders, nil
+}
+
+func (r *ReminderRepository) GetWaitingContracts(from time.Time, to time.Time) ([]domain.Contract, error) {
+	var contracts []domain.Contract
+	err := r.db.Preload("Dorm").
+		Where("status = ?", domain.Waiting).
+		Where("create_at >= ? AND create_at < ?", from, to).
+		Find(&contracts).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve waiting contracts")
+	}
+	return contracts, nil
+}
+
+func (r *ReminderRepository) GetPendingLeasingRequests(from time.Time, to time.Time) ([]domain.LeasingRequest, error) {
+	var requests []domain.LeasingRequest
+	err := r.db.Preload("Dorm").
+		Where("status = ?", domain.RequestPending).
+		Where("start >= ? AND start < ?", from, to).
+		Find(&requests).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve pending leasing requests")
+	}
+	return requests, nil
+}
+
+// GetEndingLeases returns the running leases whose term is over in the range.
+func (r *ReminderRepository) GetEndingLeases(from time.Time, to time.Time) ([]domain.LeasingHistory, error) {
+	var histories []domain.LeasingHistory
+	err := r.db.Preload("Dorm").
+		Where("\"end\" IS NULL").
+		Where("planned_end >= ? AND planned_end < ?", from, to).
+		Find(&histories).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve ending leases")
+	}
+	return histories, nil
+}
+
+func (r *ReminderRepository) Claim(log *domain.ReminderLog) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(log)
+	if result.Error != nil {
+		return false, apperror.InternalServerError(result.Error, "Failed to record reminder")
+	}
+	return result.RowsAffected == 1, nil
+}
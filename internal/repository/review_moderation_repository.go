@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+)
+
+type ReviewModerationRepository struct {
+	db *database.Database
+}
+
+func NewReviewModerationRepository(db *database.Database) ports.ReviewModerationRepository {
+	return &ReviewModerationRepository{db: db}
+}
+
+func (r *ReviewModerationRepository) CreateReport(report *domain.ReviewReport) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+
+		// A report does not change the rating, so skip the AfterUpdate hook
+		return tx.Model(&domain.LeasingHistory{}).Where("id = ?", report.HistoryID).UpdateColumn("report_flag", true).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to report review")
+	}
+	return nil
+}
+
+func (r *ReviewModerationRepository) HasOpenReport(historyID uuid.UUID, reporterID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.ReviewReport{}).
+		Where("history_id = ? AND reporter_id = ? AND moderation_id IS NULL", historyID, reporterID).
+		Count(&count).Error
+	if err != nil {
+		return false, apperror.InternalServerError(err, "Failed to retrieve review reports")
+	}
+	return count > 0, nil
+}
+
+// GetQueue returns reported reviews with their open reports, most reported first.
+func (r *ReviewModerationRepository) GetQueue(limit int, page int) ([]domain.LeasingHistory, int, int, error) {
+	var reviews []domain.LeasingHistory
+	query := r.db.Preload("Lessee").
+		Preload("Images").
+		Preload("Reports", func(db *gorm.DB) *gorm.DB {
+			return db.Where("moderation_id IS NULL").Order("create_at")
+		}).
+		Preload("Reports.Reporter").
+		Where("report_flag = ?", true).
+		Where("review_flag = ?", true)
+
+	order := "(SELECT COUNT(*) FROM review_reports WHERE review_reports.history_id = leasing_histories.id AND review_reports.moderation_id IS NULL) DESC, id"
+	totalPages, totalRows, err := r.db.Paginate(&reviews, query, limit, page, order)
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve reviews")
+	}
+	return reviews, totalPages, totalRows, nil
+}
+
+// CreateModeration records a decision, closes the open reports it covers and applies the
+// decision to the review. Updating through the history model keeps Dorm.Rating in sync.
+func (r *ReviewModerationRepository) CreateModeration(moderation *domain.ReviewModeration, history *domain.LeasingHistory, reviewValues map[string]interface{}) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(moderation).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&domain.ReviewReport{}).
+			Where("history_id = ? AND moderation_id IS NULL", moderation.HistoryID).
+			Update("moderation_id", moderation.ID).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(history).Updates(reviewValues).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to save moderation decision")
+	}
+	return nil
+}
+
+func (r *ReviewModerationRepository) GetModerationByID(id uuid.UUID) (*domain.ReviewModeration, error) {
+	moderation := new(domain.ReviewModeration)
+	if err := r.db.Preload("History").Preload("History.Lessee").First(moderation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundError(err, "Moderation not found")
+		}
+		return nil, apperror.InternalServerError(err, "Failed to retrieve moderation")
+	}
+	return moderation, nil
+}
+
+func (r *ReviewModerationRepository) GetLatestModerationByHistoryID(historyID uuid.UUID) (*domain.ReviewModeration, error) {
+	moderation := new(domain.ReviewModeration)
+	if err := r.db.Where("history_id = ?", historyID).Order("create_at DESC").First(moderation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundError(err, "Moderation not found")
+		}
+		return nil, apperror.InternalServerError(err, "Failed to retrieve moderation")
+	}
+	return moderation, nil
+}
+
+func (r *ReviewModerationRepository) HasAppeal(historyID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.ReviewModeration{}).
+		Where("history_id = ? AND appeal_at IS NOT NULL", historyID).
+		Count(&count).Error
+	if err != nil {
+		return false, apperror.InternalServerError(err, "Failed to retrieve appeals")
+	}
+	return count > 0, nil
+}
+
+func (r *ReviewModerationRepository) SaveAppeal(moderation *domain.ReviewModeration) error {
+	err := r.db.Model(moderation).Updates(map[string]interface{}{
+		"appeal_status":  moderation.AppealStatus,
+		"appeal_message": moderation.AppealMessage,
+		"appeal_at":      moderation.AppealAt,
+	}).Error
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to save appeal")
+	}
+	return nil
+}
+
+func (r *ReviewModerationRepository) GetPendingAppeals(limit int, page int) ([]domain.ReviewModeration, int, int, error) {
+	var moderations []domain.ReviewModeration
+	query := r.db.Preload("History").
+		Preload("History.Lessee").
+		Where("appeal_status = ?", domain.AppealPending)
+
+	totalPages, totalRows, err := r.db.Paginate(&moderations, query, limit, page, "appeal_at")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve appeals")
+	}
+	return moderations, totalPages, totalRows, nil
+}
+
+func (r *ReviewModerationRepository) DecideAppeal(moderation *domain.ReviewModeration, history *domain.LeasingHistory, reviewValues map[string]interface{}) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(moderation).Updates(map[string]interface{}{
+			"appeal_status":    moderation.AppealStatus,
+			"appeal_admin_id":  moderation.AppealAdminID,
+			"appeal_reason":    moderation.AppealReason,
+			"appeal_decide_at": moderation.AppealDecideAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(reviewValues) == 0 {
+			return nil
+		}
+		return tx.Model(history).Updates(reviewValues).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to save appeal decision")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm/clause"
+)
+
+type SavedSearchRepository struct {
+	db *database.Database
+}
+
+func NewSavedSearchRepository(db *database.Database) ports.SavedSearchRepository {
+	return &SavedSearchRepository{db: db}
+}
+
+func (r *SavedSearchRepository) Create(search *domain.SavedSearch) error {
+	if err := r.db.Create(search).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to save search")
+	}
+	return nil
+}
+
+func (r *SavedSearchRepository) GetByID(id uuid.UUID) (*domain.SavedSearch, error) {
+	search := new(domain.SavedSearch)
+	if err := r.db.First(search, id).Error; err != nil {
+		return nil, apperror.NotFoundError(err, "Saved search not found")
+	}
+	return search, nil
+}
+
+func (r *SavedSearchRepository) GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.SavedSearch, int, int, error) {
+	var searches []domain.SavedSearch
+	query := r.db.Where("user_id = ?", userID)
+
+	totalPages, totalRows, err := r.db.Paginate(&searches, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve saved searches")
+	}
+	return searches, totalPages, totalRows, nil
+}
+
+func (r *SavedSearchRepository) Update(search *domain.SavedSearch) error {
+	if err := r.db.Model(search).Select("*").Omit("id", "create_at", "deleted_at", "user_id", "last_digest_at", clause.Associations).Updates(search).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to update saved search")
+	}
+	return nil
+}
+
+func (r *SavedSearchRepository) Delete(id uuid.UUID) error {
+	if err := r.db.Delete(&domain.SavedSearch{}, id).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to delete saved search")
+	}
+	return nil
+}
+
+// GetCandidatesForDorm narrows saved searches down by price in SQL; the text filters
+// are checked afterwards with SavedSearch.Matches.
+func (r *SavedSearchRepository) GetCandidatesForDorm(dorm *domain.Dorm) ([]domain.SavedSearch, error) {
+	var searches []domain.SavedSearch
+	err := r.db.Preload("User").
+		Where("user_id <> ?", dorm.OwnerID).
+		Where("min_price = 0 OR min_price <= ?", dorm.Price).
+		Where("max_price = 0 OR max_price >= ?", dorm.Price).
+		Find(&searches).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve saved searches")
+	}
+	return searches, nil
+}
+
+// CreateMatch stores a match and reports whether it is new.
+func (r *SavedSearchRepository) CreateMatch(match *domain.SavedSearchMatch) (bool, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(match)
+	if res.Error != nil {
+		return false, apperror.InternalServerError(res.Error, "Failed to save search match")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ReopenMatch stores a match, or makes an already notified one pending again, and
+// reports whether it has to be announced.
+func (r *SavedSearchRepository) ReopenMatch(match *domain.SavedSearchMatch) (bool, error) {
+	res := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "saved_search_id"}, {Name: "dorm_id"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: clause.Column{Table: "saved_search_matches", Name: "notified"}, Value: true}}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"notified": false, "create_at": time.Now()}),
+	}).Create(match)
+	if res.Error != nil {
+		return false, apperror.InternalServerError(res.Error, "Failed to save search match")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *SavedSearchRepository) MarkMatchesNotified(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := r.db.Model(&domain.SavedSearchMatch{}).Where("id IN ?", ids).Update("notified", true).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to update search matches")
+	}
+	return nil
+}
+
+// GetPendingDigestMatches returns unsent matches of daily searches whose last digest was sent before the given time.
+func (r *SavedSearchRepository) GetPendingDigestMatches(before time.Time) ([]domain.SavedSearchMatch, error) {
+	var matches []domain.SavedSearchMatch
+	err := r.db.Preload("SavedSearch").
+		Preload("SavedSearch.User").
+		Preload("Dorm").
+		Joins("JOIN saved_searches ON saved_searches.id = saved_search_matches.saved_search_id").
+		Where("saved_search_matches.notified = ?", false).
+		Where("saved_searches.deleted_at IS NULL").
+		Where("saved_searches.alert_frequency = ?", domain.AlertDaily).
+		Where("saved_searches.last_digest_at IS NULL OR saved_searches.last_digest_at < ?", before).
+		Order("saved_search_matches.create_at").
+		Find(&matches).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve search matches")
+	}
+	return matches, nil
+}
+
+// ClaimDigest moves the last digest of the search to at, unless another run
+// already sent it after before, and reports whether this run may send it.
+func (r *SavedSearchRepository) ClaimDigest(id uuid.UUID, before time.Time, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.SavedSearch{}).
+		Where("id = ?", id).
+		Where("last_digest_at IS NULL OR last_digest_at < ?", before).
+		Update("last_digest_at", at)
+	if result.Error != nil {
+		return false, apperror.InternalServerError(result.Error, "Failed to update saved search")
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShortlistRepository struct {
+	db *database.Database
+}
+
+func NewShortlistRepository(db *database.Database) ports.ShortlistRepository {
+	return &ShortlistRepository{db: db}
+}
+
+func (r *ShortlistRepository) Create(shortlist *domain.Shortlist) error {
+	if err := r.db.Create(shortlist).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to create shortlist")
+	}
+	return nil
+}
+
+func (r *ShortlistRepository) GetByID(id uuid.UUID) (*domain.Shortlist, error) {
+	shortlist := new(domain.Shortlist)
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("shortlist_items.create_at")
+	}).
+		Preload("Items.Dorm").
+		Preload("Items.Dorm.Owner").
+		Preload("Items.Dorm.Images").
+		First(shortlist, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFoundError(err, "Shortlist not found")
+		}
+		return nil, apperror.InternalServerError(err, "Failed to retrieve shortlist")
+	}
+	return shortlist, nil
+}
+
+func (r *ShortlistRepository) GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.Shortlist, int, int, error) {
+	var shortlists []domain.Shortlist
+	query := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("shortlist_items.create_at")
+	}).
+		Preload("Items.Dorm").
+		Preload("Items.Dorm.Owner").
+		Preload("Items.Dorm.Images").
+		Where("user_id = ?", userID)
+
+	totalPages, totalRows, err := r.db.Paginate(&shortlists, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve shortlists")
+	}
+	return shortlists, totalPages, totalRows, nil
+}
+
+func (r *ShortlistRepository) UpdateName(id uuid.UUID, name string) error {
+	if err := r.db.Model(&domain.Shortlist{}).Where("id = ?", id).Update("name", name).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to update shortlist")
+	}
+	return nil
+}
+
+func (r *ShortlistRepository) Delete(id uuid.UUID) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shortlist_id = ?", id).Delete(&domain.ShortlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Shortlist{}, id).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to delete shortlist")
+	}
+	return nil
+}
+
+func (r *ShortlistRepository) AddItem(item *domain.ShortlistItem) error {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(item)
+	if res.Error != nil {
+		return apperror.InternalServerError(res.Error, "Failed to add dorm to shortlist")
+	}
+	if res.RowsAffected == 0 {
+		return apperror.ConflictError(errors.New("shortlist item already exists"), "Dorm is already in this shortlist")
+	}
+	return nil
+}
+
+func (r *ShortlistRepository) RemoveItem(shortlistID uuid.UUID, dormID uuid.UUID) error {
+	res := r.db.Where("shortlist_id = ? AND dorm_id = ?", shortlistID, dormID).Delete(&domain.ShortlistItem{})
+	if res.Error != nil {
+		return apperror.InternalServerError(res.Error, "Failed to remove dorm from shortlist")
+	}
+	if res.RowsAffected == 0 {
+		return apperror.NotFoundError(errors.New("shortlist item not found"), "Dorm is not in this shortlist")
+	}
+	return nil
+}
+
+func (r *ShortlistRepository) RemoveItemsByDormID(dormID uuid.UUID) error {
+	if err := r.db.Where("dorm_id = ?", dormID).Delete(&domain.ShortlistItem{}).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to remove dorm from shortlists")
+	}
+	return nil
+}
+
+// GetUsersByDormID returns every user who has the dorm in at least one shortlist.
+func (r *ShortlistRepository) GetUsersByDormID(dormID uuid.UUID) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.Where("id IN (?)", r.savedBySubquery(dormID)).Find(&users).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve users")
+	}
+	return users, nil
+}
+
+func (r *ShortlistRepository) CountUsersByDormID(dormID uuid.UUID) (int, error) {
+	var count int64
+	err := r.db.Model(&domain.User{}).Where("id IN (?)", r.savedBySubquery(dormID)).Count(&count).Error
+	if err != nil {
+		return 0, apperror.InternalServerError(err, "Failed to count saved dorm")
+	}
+	return int(count), nil
+}
+
+func (r *ShortlistRepository) savedBySubquery(dormID uuid.UUID) *gorm.DB {
+	return r.db.Model(&domain.ShortlistItem{}).
+		Select("shortlists.user_id").
+		Joins("JOIN shortlists ON shortlists.id = shortlist_items.shortlist_id").
+		Where("shortlist_items.dorm_id = ?", dormID).
+		Where("shortlists.deleted_at IS NULL")
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ThreadRepository struct {
+	db *database.Database
+}
+
+func NewThreadRepository(db *database.Database) ports.ThreadRepository {
+	return &ThreadRepository{db: db}
+}
+
+// Create does nothing when the lessee already has a thread about the subject.
+func (r *ThreadRepository) Create(thread *domain.Thread) error {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(thread).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to create thread")
+	}
+	return nil
+}
+
+func (r *ThreadRepository) GetByID(id uuid.UUID) (*domain.Thread, error) {
+	thread := new(domain.Thread)
+	if err := r.db.Preload("Dorm").Preload("Lessee").Preload("Lessor").First(thread, id).Error; err != nil {
+		return nil, apperror.NotFoundError(err, "Thread not found")
+	}
+	return thread, nil
+}
+
+func (r *ThreadRepository) GetBySubject(subject domain.ThreadSubject, subjectID uuid.UUID, lesseeID uuid.UUID) (*domain.Thread, error) {
+	thread := new(domain.Thread)
+	err := r.db.Preload("Dorm").Preload("Lessee").Preload("Lessor").
+		Where("subject = ? AND subject_id = ? AND lessee_id = ?", subject, subjectID, lesseeID).
+		First(thread).Error
+	if err != nil {
+		return nil, apperror.NotFoundError(err, "Thread not found")
+	}
+	return thread, nil
+}
+
+func (r *ThreadRepository) GetByUserID(userID uuid.UUID, limit int, page int) ([]domain.Thread, int, int, error) {
+	var threads []domain.Thread
+	query := r.db.Preload("Dorm").Preload("Lessee").Preload("Lessor").Where("lessee_id = ? OR lessor_id = ?", userID, userID)
+	totalPages, totalRows, err := r.db.Paginate(&threads, query, limit, page, "last_message_at DESC NULLS LAST, create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve threads")
+	}
+	return threads, totalPages, totalRows, nil
+}
+
+// CreateMessage saves the message and moves the thread up in the participants' lists.
+func (r *ThreadRepository) CreateMessage(thread *domain.Thread, message *domain.ThreadMessage) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(thread).Update("last_message_at", message.CreateAt).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to send message")
+	}
+	return nil
+}
+
+func (r *ThreadRepository) GetMessages(threadID uuid.UUID, limit int, page int) ([]domain.ThreadMessage, int, int, error) {
+	var messages []domain.ThreadMessage
+	query := r.db.Where("thread_id = ?", threadID)
+	totalPages, totalRows, err := r.db.Paginate(&messages, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve messages")
+	}
+	return messages, totalPages, totalRows, nil
+}
+
+// GetRead returns nil without an error when the user never read the thread.
+func (r *ThreadRepository) GetRead(threadID uuid.UUID, userID uuid.UUID) (*domain.ThreadRead, error) {
+	read := new(domain.ThreadRead)
+	err := r.db.Where("thread_id = ? AND user_id = ?", threadID, userID).First(read).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve read receipt")
+	}
+	return read, nil
+}
+
+func (r *ThreadRepository) SaveRead(read *domain.ThreadRead) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "thread_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Set{{Column: clause.Column{Name: "last_read_at"}, Value: gorm.Expr("GREATEST(thread_reads.last_read_at, excluded.last_read_at)")}},
+	}).Create(read).Error
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to mark thread as read")
+	}
+	return nil
+}
+
+// unreadMessages are the messages sent to userID after they last read the thread.
+func (r *ThreadRepository) unreadMessages(userID uuid.UUID) *gorm.DB {
+	return r.db.Model(&domain.ThreadMessage{}).
+		Joins("LEFT JOIN thread_reads ON thread_reads.thread_id = thread_messages.thread_id AND thread_reads.user_id = ?", userID).
+		Where("thread_messages.sender_id <> ?", userID).
+		Where("thread_reads.last_read_at IS NULL OR thread_messages.create_at > thread_reads.last_read_at")
+}
+
+func (r *ThreadRepository) CountUnread(threadID uuid.UUID, userID uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.unreadMessages(userID).Where("thread_messages.thread_id = ?", threadID).Count(&count).Error; err != nil {
+		return 0, apperror.InternalServerError(err, "Failed to count unread messages")
+	}
+	return count, nil
+}
+
+func (r *ThreadRepository) CountAllUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.unreadMessages(userID).
+		Joins("JOIN threads ON threads.id = thread_messages.thread_id").
+		Where("threads.lessee_id = ? OR threads.lessor_id = ?", userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, apperror.InternalServerError(err, "Failed to count unread messages")
+	}
+	return count, nil
+}
+
+func (r *ThreadRepository) CreateReport(report *domain.ThreadReport) error {
+	if err := r.db.Create(report).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to report thread")
+	}
+	return nil
+}
+
+// IsAttached reports whether a support request or a report refers to the thread.
+func (r *ThreadRepository) IsAttached(threadID uuid.UUID) (bool, error) {
+	var count int64
+	if err := r.db.Model(&domain.ThreadReport{}).Where("thread_id = ?", threadID).Count(&count).Error; err != nil {
+		return false, apperror.InternalServerError(err, "Failed to retrieve thread reports")
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := r.db.Model(&domain.SupportRequest{}).Where("thread_id = ?", threadID).Count(&count).Error; err != nil {
+		return false, apperror.InternalServerError(err, "Failed to retrieve support requests")
+	}
+	return count > 0, nil
+}
+
+func (r *ThreadRepository) Block(block *domain.UserBlock) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(block)
+	if result.Error != nil {
+		return apperror.InternalServerError(result.Error, "Failed to block user")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.ConflictError(errors.New("user already blocked"), "You already blocked this user")
+	}
+	return nil
+}
+
+func (r *ThreadRepository) Unblock(blockerID uuid.UUID, blockedID uuid.UUID) error {
+	result := r.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&domain.UserBlock{})
+	if result.Error != nil {
+		return apperror.InternalServerError(result.Error, "Failed to unblock user")
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFoundError(errors.New("block not found"), "You have not blocked this user")
+	}
+	return nil
+}
+
+// IsBlocked reports whether either user blocked the other.
+func (r *ThreadRepository) IsBlocked(userID uuid.UUID, otherID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, apperror.InternalServerError(err, "Failed to retrieve blocks")
+	}
+	return count > 0, nil
+}
+
+func (r *ThreadRepository) GetBlocks(blockerID uuid.UUID) ([]domain.UserBlock, error) {
+	var blocks []domain.UserBlock
+	if err := r.db.Preload("Blocked").Where("blocker_id = ?", blockerID).Order("create_at DESC").Find(&blocks).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve blocked users")
+	}
+	return blocks, nil
+}
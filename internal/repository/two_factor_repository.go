@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository struct {
+	db *database.Database
+}
+
+func NewTwoFactorRepository(db *database.Database) ports.TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	codes := make([]domain.RecoveryCode, len(codeHashes))
+	for i, v := range codeHashes {
+		codes[i] = domain.RecoveryCode{UserID: userID, CodeHash: v}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return apperror.InternalServerError(err, "Failed to save recovery codes")
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused code as used and reports whether there was one.
+func (r *TwoFactorRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, apperror.InternalServerError(result.Error, "Failed to use recovery code")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *TwoFactorRepository) DeleteRecoveryCodes(userID uuid.UUID) error {
+	if err := r.db.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to delete recovery codes")
+	}
+	return nil
+}
@@ -1,12 +1,15 @@
 package repository
 
 import (
+	"errors"
+
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/database"
 	"github.com/yokeTH/go-pkg/apperror"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type UserRepo struct {
@@ -44,6 +47,17 @@ func (r *UserRepo) GetUserByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
+func (r *UserRepo) FindUserByEmail(email string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, apperror.InternalServerError(err, "Failed to retrieve user")
+	}
+	return &user, nil
+}
+
 func (r *UserRepo) GetUserByID(userID uuid.UUID) (*domain.User, error) {
 	var user domain.User
 	result := r.db.Where("id = ?", userID).First(&user)
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
+	"github.com/PitiNarak/condormhub-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/yokeTH/go-pkg/apperror"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *database.Database
+}
+
+func NewWebhookRepository(db *database.Database) ports.WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(webhook *domain.Webhook) error {
+	if err := r.db.Create(webhook).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to create webhook")
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(id uuid.UUID) (*domain.Webhook, error) {
+	webhook := new(domain.Webhook)
+	if err := r.db.First(webhook, id).Error; err != nil {
+		return nil, apperror.NotFoundError(err, "Webhook not found")
+	}
+	return webhook, nil
+}
+
+func (r *WebhookRepository) GetByOwnerID(ownerID uuid.UUID, limit int, page int) ([]domain.Webhook, int, int, error) {
+	var webhooks []domain.Webhook
+	query := r.db.Where("owner_id = ?", ownerID)
+
+	totalPages, totalRows, err := r.db.Paginate(&webhooks, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve webhooks")
+	}
+	return webhooks, totalPages, totalRows, nil
+}
+
+// GetSubscribers returns the active webhooks of the owner; events are stored as
+// JSON, so the subscription is checked with Webhook.Subscribes.
+func (r *WebhookRepository) GetSubscribers(ownerID uuid.UUID, event domain.WebhookEvent) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	if err := r.db.Where("owner_id = ? AND active", ownerID).Find(&webhooks).Error; err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to retrieve webhooks")
+	}
+
+	subscribers := make([]domain.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			subscribers = append(subscribers, webhook)
+		}
+	}
+	return subscribers, nil
+}
+
+func (r *WebhookRepository) Update(webhook *domain.Webhook) error {
+	if err := r.db.Model(webhook).Select("url", "events", "description", "active").Updates(webhook).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to update webhook")
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Delete(id uuid.UUID) error {
+	if err := r.db.Delete(&domain.Webhook{ID: id}).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to delete webhook")
+	}
+	return nil
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	if err := r.db.Create(delivery).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to queue webhook delivery")
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetDeliveryByID(id uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery := new(domain.WebhookDelivery)
+	if err := r.db.First(delivery, id).Error; err != nil {
+		return nil, apperror.NotFoundError(err, "Webhook delivery not found")
+	}
+	return delivery, nil
+}
+
+func (r *WebhookRepository) GetDeliveriesByWebhookID(webhookID uuid.UUID, limit int, page int) ([]domain.WebhookDelivery, int, int, error) {
+	var deliveries []domain.WebhookDelivery
+	query := r.db.Where("webhook_id = ?", webhookID)
+
+	totalPages, totalRows, err := r.db.Paginate(&deliveries, query, limit, page, "create_at DESC")
+	if err != nil {
+		return nil, 0, 0, apperror.InternalServerError(err, "Failed to retrieve webhook deliveries")
+	}
+	return deliveries, totalPages, totalRows, nil
+}
+
+// ClaimDeliveries works like EmailOutboxRepository.Claim.
+func (r *WebhookRepository) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	due := r.db.Model(&domain.WebhookDelivery{}).
+		Select("id").
+		Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var deliveries []domain.WebhookDelivery
+	err := r.db.Model(&deliveries).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Update("next_attempt_at", now.Add(lease)).Error
+	if err != nil {
+		return nil, apperror.InternalServerError(err, "Failed to claim webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	if err := r.db.Save(delivery).Error; err != nil {
+		return apperror.InternalServerError(err, "Failed to update webhook delivery")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	handler1 "github.com/PitiNarak/condormhub-backend/internal/handler"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
 )
 
 type handler struct {
@@ -18,13 +19,29 @@ type handler struct {
 	leasingRequest ports.LeasingRequestHandler
 	receipt        ports.ReceiptHandler
 	support        ports.SupportHandler
+	savedSearch    ports.SavedSearchHandler
+	shortlist      ports.ShortlistHandler
+	analytics      ports.AnalyticsHandler
+	moderation     ports.ReviewModerationHandler
+	jwks           *handler1.JWKSHandler
+	twoFactor      ports.TwoFactorHandler
+	oidc           ports.OIDCHandler
+	dormManager    ports.DormManagerHandler
+	phone          ports.PhoneVerificationHandler
+	notification   ports.NotificationHandler
+	live           ports.LiveHandler
+	thread         ports.ThreadHandler
+	mailbox        *handler1.MailboxHandler
+	webhook        ports.WebhookHandler
+	line           ports.LineHandler
+	announcement   ports.AnnouncementHandler
 }
 
 func (s *Server) initHandler() {
 	greeting := handler1.NewGreetingHandler()
 	user := handler1.NewUserHandler(s.service.user)
 	exampleUpload := handler1.NewTestUploadHandler(s.storage)
-	dorm := handler1.NewDormHandler(s.service.dorm)
+	dorm := handler1.NewDormHandler(s.service.dorm, s.service.lessorReport)
 	leasingHistory := handler1.NewLeasingHistoryHandler(s.service.leasingHistory, s.service.dorm)
 	order := handler1.NewOrderHandler(s.service.order)
 	tsx := handler1.NewTransactionHandler(s.service.tsx, s.stripeConfig)
@@ -33,6 +50,23 @@ func (s *Server) initHandler() {
 	leasingRequest := handler1.NewLeasingRequestHandler(s.service.leasingRequest)
 	receipt := handler1.NewReceiptHandler(s.service.receipt)
 	support := handler1.NewSupportHandler(s.service.support)
+	savedSearch := handler1.NewSavedSearchHandler(s.service.savedSearch, s.service.dorm)
+	shortlist := handler1.NewShortlistHandler(s.service.shortlist)
+	analytics := handler1.NewAnalyticsHandler(s.service.analytics)
+	moderation := handler1.NewReviewModerationHandler(s.service.moderation, s.service.leasingHistory)
+	jwks := handler1.NewJWKSHandler(s.jwtUtils)
+	twoFactor := handler1.NewTwoFactorHandler(s.service.twoFactor, s.service.user)
+	oidc := handler1.NewOIDCHandler(s.service.oidc, s.service.user)
+	dormManager := handler1.NewDormManagerHandler(s.service.dormManager)
+	phone := handler1.NewPhoneVerificationHandler(s.service.phone, s.service.user)
+	notification := handler1.NewNotificationHandler(s.service.notification)
+	live := handler1.NewLiveHandler(s.service.live)
+	thread := handler1.NewThreadHandler(s.service.thread)
+	captured, _ := s.mailer.(email.Mailbox)
+	mailbox := handler1.NewMailboxHandler(captured)
+	webhook := handler1.NewWebhookHandler(s.service.webhook)
+	line := handler1.NewLineHandler(s.service.line, s.lineConfig.AddFriendURL)
+	announcement := handler1.NewAnnouncementHandler(s.service.announcement)
 
 	s.handler = &handler{
 		greeting:       greeting,
@@ -47,5 +81,21 @@ func (s *Server) initHandler() {
 		leasingRequest: leasingRequest,
 		receipt:        receipt,
 		support:        support,
+		savedSearch:    savedSearch,
+		shortlist:      shortlist,
+		analytics:      analytics,
+		moderation:     moderation,
+		jwks:           jwks,
+		twoFactor:      twoFactor,
+		oidc:           oidc,
+		dormManager:    dormManager,
+		phone:          phone,
+		notification:   notification,
+		live:           live,
+		thread:         thread,
+		mailbox:        mailbox,
+		webhook:        webhook,
+		line:           line,
+		announcement:   announcement,
 	}
 }
@@ -16,6 +16,21 @@ type repository struct {
 	leasingRequest ports.LeasingRequestRepository
 	receipt        ports.ReceiptRepository
 	support        ports.SupportRepository
+	savedSearch    ports.SavedSearchRepository
+	shortlist      ports.ShortlistRepository
+	analytics      ports.AnalyticsRepository
+	moderation     ports.ReviewModerationRepository
+	twoFactor      ports.TwoFactorRepository
+	oidc           ports.OIDCRepository
+	dormManager    ports.DormManagerRepository
+	notification   ports.NotificationRepository
+	thread         ports.ThreadRepository
+	outbox         ports.EmailOutboxRepository
+	reminder       ports.ReminderRepository
+	webhook        ports.WebhookRepository
+	line           ports.LineAccountRepository
+	lessorReport   ports.LessorReportRepository
+	announcement   ports.AnnouncementRepository
 }
 
 func (s *Server) initRepository() {
@@ -29,6 +44,21 @@ func (s *Server) initRepository() {
 	leasingRequest := repository1.NewLeasingRequestRepository(s.db)
 	receipt := repository1.NewReceiptRepository(s.db)
 	support := repository1.NewSupportRepository(s.db)
+	savedSearch := repository1.NewSavedSearchRepository(s.db)
+	shortlist := repository1.NewShortlistRepository(s.db)
+	analytics := repository1.NewAnalyticsRepository(s.db)
+	moderation := repository1.NewReviewModerationRepository(s.db)
+	twoFactor := repository1.NewTwoFactorRepository(s.db)
+	oidc := repository1.NewOIDCRepository(s.db)
+	dormManager := repository1.NewDormManagerRepository(s.db)
+	notification := repository1.NewNotificationRepository(s.db)
+	thread := repository1.NewThreadRepository(s.db)
+	outbox := repository1.NewEmailOutboxRepository(s.db)
+	reminder := repository1.NewReminderRepository(s.db)
+	webhook := repository1.NewWebhookRepository(s.db)
+	line := repository1.NewLineAccountRepository(s.db)
+	lessorReport := repository1.NewLessorReportRepository(s.db)
+	announcement := repository1.NewAnnouncementRepository(s.db)
 
 	s.repository = &repository{
 		user:           user,
@@ -41,5 +71,20 @@ func (s *Server) initRepository() {
 		leasingRequest: leasingRequest,
 		receipt:        receipt,
 		support:        support,
+		savedSearch:    savedSearch,
+		shortlist:      shortlist,
+		analytics:      analytics,
+		moderation:     moderation,
+		twoFactor:      twoFactor,
+		oidc:           oidc,
+		dormManager:    dormManager,
+		notification:   notification,
+		thread:         thread,
+		outbox:         outbox,
+		reminder:       reminder,
+		webhook:        webhook,
+		line:           line,
+		lessorReport:   lessorReport,
+		announcement:   announcement,
 	}
 }
@@ -1,6 +1,11 @@
 package server
 
 import (
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/middleware"
+	"github.com/PitiNarak/condormhub-backend/pkg/email"
 	"github.com/yokeTH/go-pkg/scalar"
 )
 
@@ -11,6 +16,9 @@ func (s *Server) initRoutes() {
 	// swagger
 	s.app.Get("/swagger/*", scalar.DefaultHandler)
 
+	// token signing keys
+	s.app.Get("/.well-known/jwks.json", s.handler.jwks.GetJWKS)
+
 	s.initExampleUploadRoutes()
 	s.initUserRoutes()
 	s.initAuthRoutes()
@@ -23,7 +31,17 @@ func (s *Server) initRoutes() {
 	s.initReceiptRoutes()
 	s.initContractRoutes()
 	s.initSupportRoutes()
+	s.initSavedSearchRoutes()
+	s.initShortlistRoutes()
+	s.initAnalyticsRoutes()
 	s.initAdminRoutes()
+	s.initNotificationRoutes()
+	s.initLiveRoutes()
+	s.initThreadRoutes()
+	s.initWebhookRoutes()
+	s.initLineRoutes()
+	s.initAnnouncementRoutes()
+	s.initDevRoutes()
 }
 
 func (s *Server) initExampleUploadRoutes() {
@@ -40,9 +58,19 @@ func (s *Server) initUserRoutes() {
 	userRoutes.Get("/:id", s.handler.user.GetUserByID)
 
 	userRoutes.Post("/verify", s.handler.user.VerifyEmail)
-	userRoutes.Post("/resetpassword", s.handler.user.ResetPasswordCreate)
+	// every request sends an email
+	emailIPLimit := s.rateLimit.Limit(middleware.RateLimit{Name: "email-ip", Max: 10, Window: time.Hour, Key: middleware.ByIP})
+	emailAccountLimit := s.rateLimit.Limit(middleware.RateLimit{Name: "email-account", Max: 3, Window: time.Hour, Key: middleware.ByAccount})
+
+	userRoutes.Post("/resetpassword", emailIPLimit, emailAccountLimit, s.handler.user.ResetPasswordCreate)
 	userRoutes.Post("/newpassword", s.handler.user.ResetPassword)
-	userRoutes.Post("/resend", s.handler.user.ResendVerificationEmailHandler)
+	userRoutes.Post("/resend", emailIPLimit, emailAccountLimit, s.handler.user.ResendVerificationEmailHandler)
+
+	userRoutes.Post("/email", s.authMiddleware.Auth, s.rateLimit.Limit(middleware.RateLimit{Name: "email-change", Max: 5, Window: time.Hour, Key: middleware.ByUser}), s.handler.user.RequestEmailChange)
+	userRoutes.Post("/email/confirm", s.handler.user.ConfirmEmailChange)
+
+	userRoutes.Post("/phone/code", s.authMiddleware.Auth, s.rateLimit.Limit(middleware.RateLimit{Name: "phone-otp", Max: 5, Window: time.Hour, Key: middleware.ByUser}), s.handler.phone.SendCode)
+	userRoutes.Post("/phone/verify", s.authMiddleware.Auth, s.handler.phone.Verify)
 
 	userRoutes.Patch("/firstfill", s.authMiddleware.Auth, s.handler.user.FirstFillInformation)
 	userRoutes.Patch("/", s.authMiddleware.Auth, s.handler.user.UpdateUserInformation)
@@ -55,10 +83,35 @@ func (s *Server) initUserRoutes() {
 }
 
 func (s *Server) initAuthRoutes() {
-	authRoutes := s.app.Group("/auth")
-	authRoutes.Post("/register", s.handler.user.Register)
-	authRoutes.Post("/login", s.handler.user.Login)
+	authRoutes := s.app.Group("/auth", s.rateLimit.Limit(middleware.RateLimit{Name: "auth", Max: 120, Window: time.Minute, Key: middleware.ByIP}))
+
+	authRoutes.Post("/register", s.rateLimit.Limit(middleware.RateLimit{Name: "register", Max: 10, Window: time.Hour, Key: middleware.ByIP}), s.handler.user.Register)
+	authRoutes.Post("/login",
+		s.rateLimit.Limit(middleware.RateLimit{Name: "login-ip", Max: 20, Window: 15 * time.Minute, Key: middleware.ByIP}),
+		s.rateLimit.Limit(middleware.RateLimit{Name: "login-account", Max: 10, Window: 15 * time.Minute, Key: middleware.ByAccount}),
+		s.rateLimit.LoginLockout,
+		s.handler.user.Login,
+	)
 	authRoutes.Post("/refresh", s.handler.user.RefreshToken)
+	authRoutes.Post("/logout", s.authMiddleware.Auth, s.handler.user.Logout)
+	authRoutes.Get("/sessions", s.authMiddleware.Auth, s.handler.user.GetSessions)
+	authRoutes.Delete("/sessions", s.authMiddleware.Auth, s.handler.user.RevokeOtherSessions)
+	authRoutes.Delete("/sessions/:id", s.authMiddleware.Auth, s.handler.user.RevokeSession)
+
+	authRoutes.Get("/identities", s.authMiddleware.Auth, s.handler.oidc.GetIdentities)
+	authRoutes.Get("/oidc/:provider", s.handler.oidc.GetLoginURL)
+	authRoutes.Post("/oidc/:provider/callback", s.handler.oidc.Callback)
+	authRoutes.Get("/oidc/:provider/link", s.authMiddleware.Auth, s.handler.oidc.GetLinkURL)
+	authRoutes.Post("/oidc/:provider/link", s.authMiddleware.Auth, s.handler.oidc.Link)
+	authRoutes.Delete("/oidc/:provider/link", s.authMiddleware.Auth, s.handler.oidc.Unlink)
+
+	authRoutes.Post("/2fa/verify", s.rateLimit.Limit(middleware.RateLimit{Name: "2fa-ip", Max: 20, Window: 15 * time.Minute, Key: middleware.ByIP}), s.handler.twoFactor.Verify)
+	authRoutes.Post("/2fa/enroll/setup", s.rateLimit.Limit(middleware.RateLimit{Name: "2fa-ip", Max: 20, Window: 15 * time.Minute, Key: middleware.ByIP}), s.handler.twoFactor.SetupPending)
+	authRoutes.Post("/2fa/enroll", s.rateLimit.Limit(middleware.RateLimit{Name: "2fa-ip", Max: 20, Window: 15 * time.Minute, Key: middleware.ByIP}), s.handler.twoFactor.Enroll)
+	authRoutes.Post("/2fa/setup", s.authMiddleware.Auth, s.handler.twoFactor.Setup)
+	authRoutes.Post("/2fa/enable", s.authMiddleware.Auth, s.handler.twoFactor.Enable)
+	authRoutes.Post("/2fa/disable", s.authMiddleware.Auth, s.handler.twoFactor.Disable)
+	authRoutes.Post("/2fa/recovery-codes", s.authMiddleware.Auth, s.handler.twoFactor.RegenerateRecoveryCodes)
 }
 
 func (s *Server) initDormRoutes() {
@@ -72,6 +125,21 @@ func (s *Server) initDormRoutes() {
 	dormRoutes.Delete("/:id", s.authMiddleware.Auth, s.handler.dorm.Delete)
 	dormRoutes.Post("/:id/images", s.authMiddleware.Auth, s.handler.dorm.UploadDormImage)
 	dormRoutes.Get("/owner/:id", s.handler.dorm.GetByOwnerID)
+
+	// property managers
+	dormRoutes.Get("/:id/managers", s.authMiddleware.Auth, s.handler.dormManager.GetByDormID)
+	dormRoutes.Post("/:id/managers", s.authMiddleware.Auth, s.handler.dormManager.Invite)
+	dormRoutes.Get("/:id/managers/actions", s.authMiddleware.Auth, s.handler.dormManager.GetActions)
+	dormRoutes.Patch("/:id/managers/:managerID", s.authMiddleware.Auth, s.handler.dormManager.UpdateScopes)
+	dormRoutes.Delete("/:id/managers/:managerID", s.authMiddleware.Auth, s.handler.dormManager.Remove)
+
+	dormRoutes.Get("/:id/announcements", s.authMiddleware.Auth, s.handler.announcement.GetByDormID)
+	dormRoutes.Post("/:id/announcements", s.authMiddleware.Auth, s.handler.announcement.Create)
+
+	managerRoutes := s.app.Group("/managers", s.authMiddleware.Auth)
+	managerRoutes.Get("/me", s.handler.dormManager.GetMine)
+	managerRoutes.Post("/:id/accept", s.handler.dormManager.Accept)
+	managerRoutes.Delete("/:id", s.handler.dormManager.Leave)
 }
 
 func (s *Server) initLeasingHistoryRoutes() {
@@ -85,9 +153,15 @@ func (s *Server) initLeasingHistoryRoutes() {
 	historyRoutes.Get("/me", s.handler.leasingHistory.GetByUserID)
 	historyRoutes.Get("/bydorm/:id", s.handler.leasingHistory.GetByDormID)
 	historyRoutes.Get("/:id", s.handler.leasingHistory.GetByID)
+	historyRoutes.Get("/:id/announcements", s.handler.announcement.GetByLeasingHistoryID)
 	historyRoutes.Patch("/:id", s.handler.leasingHistory.SetEndTimestamp)
 	historyRoutes.Delete("/:id", s.handler.leasingHistory.Delete)
-	historyRoutes.Post("/:id/review/report", s.handler.leasingHistory.ReportReview)
+	historyRoutes.Post("/:id/review/report", s.handler.moderation.Report)
+	historyRoutes.Post("/:id/review/appeal", s.handler.moderation.Appeal)
+	historyRoutes.Put("/:id/review/reply", s.handler.leasingHistory.ReplyToReview)
+	historyRoutes.Delete("/:id/review/reply", s.handler.leasingHistory.DeleteReviewReply)
+	historyRoutes.Post("/:id/review/helpful", s.handler.leasingHistory.VoteReviewHelpful)
+	historyRoutes.Delete("/:id/review/helpful", s.handler.leasingHistory.UnvoteReviewHelpful)
 }
 
 func (s *Server) initLeasingRequestRoutes() {
@@ -97,7 +171,7 @@ func (s *Server) initLeasingRequestRoutes() {
 	requestRoutes.Patch("/:id/approve", s.handler.leasingRequest.Approve)
 	requestRoutes.Patch("/:id/reject", s.handler.leasingRequest.Reject)
 	requestRoutes.Patch("/:id/cancel", s.handler.leasingRequest.Cancel)
-	requestRoutes.Delete("/:id", s.authMiddleware.RequireAdmin, s.handler.leasingRequest.Delete)
+	requestRoutes.Delete("/:id", s.authMiddleware.RequirePermission(domain.PermissionLeasingManage), s.handler.leasingRequest.Delete)
 	requestRoutes.Get("/bydorm/:id", s.handler.leasingRequest.GetByDormID)
 }
 
@@ -120,8 +194,8 @@ func (s *Server) initOwnershipProofRoutes() {
 	ownershipRoutes.Post("/:id/upload", s.authMiddleware.Auth, s.handler.ownershipProof.UploadFile)
 	ownershipRoutes.Delete("/:id", s.authMiddleware.Auth, s.handler.ownershipProof.Delete)
 	ownershipRoutes.Get("/:id", s.handler.ownershipProof.GetByDormID)
-	ownershipRoutes.Post("/:id/approve", s.authMiddleware.Auth, s.authMiddleware.RequireAdmin, s.handler.ownershipProof.Approve)
-	ownershipRoutes.Post("/:id/reject", s.authMiddleware.Auth, s.authMiddleware.RequireAdmin, s.handler.ownershipProof.Reject)
+	ownershipRoutes.Post("/:id/approve", s.authMiddleware.Auth, s.authMiddleware.RequirePermission(domain.PermissionOwnershipProof), s.handler.ownershipProof.Approve)
+	ownershipRoutes.Post("/:id/reject", s.authMiddleware.Auth, s.authMiddleware.RequirePermission(domain.PermissionOwnershipProof), s.handler.ownershipProof.Reject)
 
 }
 
@@ -136,7 +210,7 @@ func (s *Server) initContractRoutes() {
 	contractRoutes.Patch("/:contractID/cancel", s.handler.contract.CancelContract)
 	contractRoutes.Get("/:contractID", s.handler.contract.GetContractByContractID)
 	contractRoutes.Get("/", s.handler.contract.GetContractByUserID)
-	contractRoutes.Get("/:dormID", s.handler.contract.GetContractByDormID)
+	contractRoutes.Get("/bydorm/:dormID", s.handler.contract.GetContractByDormID)
 	contractRoutes.Delete("/:contractID", s.handler.contract.Delete)
 
 }
@@ -145,16 +219,124 @@ func (s *Server) initSupportRoutes() {
 	supportRoutes := s.app.Group("/support", s.authMiddleware.Auth)
 	supportRoutes.Post("/", s.handler.support.Create)
 	supportRoutes.Get("/", s.handler.support.GetAll)
-	supportRoutes.Patch("/:id", s.authMiddleware.RequireAdmin, s.handler.support.UpdateStatus)
+	supportRoutes.Patch("/:id", s.authMiddleware.RequirePermission(domain.PermissionSupportManage), s.handler.support.UpdateStatus)
 }
 
 func (s *Server) initAdminRoutes() {
-	adminRoutes := s.app.Group("/admin", s.authMiddleware.Auth, s.authMiddleware.RequireAdmin)
-	adminRoutes.Patch("/user/:id/ban", s.handler.user.BanUser)
-	adminRoutes.Patch("/user/:id/unban", s.handler.user.UnbanUser)
-	adminRoutes.Get("/lessee/pending", s.handler.user.GetPending)
-	adminRoutes.Patch("/lessee/:id/verify", s.handler.user.VerifyStudentVerification)
-	adminRoutes.Patch("/lessee/:id/reject", s.handler.user.RejectStudentVerification)
-	adminRoutes.Get("/reviews/reported", s.handler.leasingHistory.GetReportedReviews)
-	adminRoutes.Delete("/reviews/:id", s.handler.leasingHistory.DeleteReview)
+	adminRoutes := s.app.Group("/admin", s.authMiddleware.Auth)
+
+	canBan := s.authMiddleware.RequirePermission(domain.PermissionUserBan)
+	adminRoutes.Patch("/user/:id/ban", canBan, s.handler.user.BanUser)
+	adminRoutes.Patch("/user/:id/unban", canBan, s.handler.user.UnbanUser)
+	adminRoutes.Patch("/user/:id/role", s.authMiddleware.RequirePermission(domain.PermissionUserRoles), s.handler.user.SetUserRole)
+
+	canVerify := s.authMiddleware.RequirePermission(domain.PermissionUserVerify)
+	adminRoutes.Get("/lessee/pending", canVerify, s.handler.user.GetPending)
+	adminRoutes.Patch("/lessee/:id/verify", canVerify, s.handler.user.VerifyStudentVerification)
+	adminRoutes.Patch("/lessee/:id/reject", canVerify, s.handler.user.RejectStudentVerification)
+
+	canModerate := s.authMiddleware.RequirePermission(domain.PermissionReviewModerate)
+	adminRoutes.Get("/reviews/reported", canModerate, s.handler.moderation.GetQueue)
+	adminRoutes.Post("/reviews/:id/moderate", canModerate, s.handler.moderation.Moderate)
+	adminRoutes.Get("/reviews/appeals", canModerate, s.handler.moderation.GetPendingAppeals)
+	adminRoutes.Patch("/reviews/appeals/:id", canModerate, s.handler.moderation.DecideAppeal)
+	adminRoutes.Delete("/reviews/:id", canModerate, s.handler.leasingHistory.DeleteReview)
+}
+
+func (s *Server) initSavedSearchRoutes() {
+	savedSearchRoutes := s.app.Group("/saved-searches", s.authMiddleware.Auth)
+	savedSearchRoutes.Post("/", s.handler.savedSearch.Create)
+	savedSearchRoutes.Get("/", s.handler.savedSearch.GetMine)
+	savedSearchRoutes.Get("/:id/dorms", s.handler.savedSearch.GetDorms)
+	savedSearchRoutes.Patch("/:id", s.handler.savedSearch.Update)
+	savedSearchRoutes.Delete("/:id", s.handler.savedSearch.Delete)
+}
+
+func (s *Server) initShortlistRoutes() {
+	s.app.Get("/dorms/:id/saved-count", s.authMiddleware.Auth, s.handler.shortlist.GetSavedCount)
+	shortlistRoutes := s.app.Group("/shortlists", s.authMiddleware.Auth)
+	shortlistRoutes.Post("/", s.handler.shortlist.Create)
+	shortlistRoutes.Get("/", s.handler.shortlist.GetMine)
+	shortlistRoutes.Get("/compare", s.handler.shortlist.Compare)
+	shortlistRoutes.Get("/:id", s.handler.shortlist.GetByID)
+	shortlistRoutes.Patch("/:id", s.handler.shortlist.Rename)
+	shortlistRoutes.Delete("/:id", s.handler.shortlist.Delete)
+	shortlistRoutes.Post("/:id/dorms", s.handler.shortlist.AddDorm)
+	shortlistRoutes.Delete("/:id/dorms/:dormID", s.handler.shortlist.RemoveDorm)
+}
+
+func (s *Server) initAnalyticsRoutes() {
+	analyticsRoutes := s.app.Group("/analytics", s.authMiddleware.Auth)
+	analyticsRoutes.Get("/rent", s.handler.analytics.GetRentTrend)
+	analyticsRoutes.Get("/dorms/me", s.handler.analytics.GetMyMarketPosition)
+}
+
+func (s *Server) initNotificationRoutes() {
+	notificationRoutes := s.app.Group("/notifications", s.authMiddleware.Auth)
+	notificationRoutes.Get("/", s.handler.notification.GetAll)
+	notificationRoutes.Get("/unread-count", s.handler.notification.GetUnreadCount)
+	notificationRoutes.Post("/read-all", s.handler.notification.MarkAllRead)
+	notificationRoutes.Get("/preferences", s.handler.notification.GetPreferences)
+	notificationRoutes.Patch("/preferences", s.handler.notification.UpdatePreferences)
+	notificationRoutes.Patch("/:id/read", s.handler.notification.MarkRead)
+}
+
+func (s *Server) initLiveRoutes() {
+	s.app.Get("/live/events", s.authMiddleware.StreamAuth, s.handler.live.Stream)
+}
+
+func (s *Server) initThreadRoutes() {
+	threadRoutes := s.app.Group("/threads", s.authMiddleware.Auth)
+	threadRoutes.Post("/", s.handler.thread.Open)
+	threadRoutes.Get("/", s.handler.thread.GetMine)
+	threadRoutes.Get("/unread-count", s.handler.thread.GetUnreadCount)
+	threadRoutes.Get("/:id", s.handler.thread.GetByID)
+	threadRoutes.Get("/:id/messages", s.handler.thread.GetMessages)
+	threadRoutes.Post("/:id/messages", s.handler.thread.Send)
+	threadRoutes.Post("/:id/read", s.handler.thread.MarkRead)
+	threadRoutes.Post("/:id/report", s.handler.thread.Report)
+
+	blockRoutes := s.app.Group("/blocks", s.authMiddleware.Auth)
+	blockRoutes.Get("/", s.handler.thread.GetBlocks)
+	blockRoutes.Post("/:id", s.handler.thread.Block)
+	blockRoutes.Delete("/:id", s.handler.thread.Unblock)
+}
+
+func (s *Server) initWebhookRoutes() {
+	webhookRoutes := s.app.Group("/webhooks", s.authMiddleware.Auth)
+	webhookRoutes.Post("/", s.handler.webhook.Create)
+	webhookRoutes.Get("/", s.handler.webhook.GetMine)
+	webhookRoutes.Get("/:id", s.handler.webhook.GetByID)
+	webhookRoutes.Patch("/:id", s.handler.webhook.Update)
+	webhookRoutes.Delete("/:id", s.handler.webhook.Delete)
+	webhookRoutes.Get("/:id/deliveries", s.handler.webhook.GetDeliveries)
+	webhookRoutes.Post("/:id/deliveries/:deliveryID/redeliver", s.handler.webhook.Redeliver)
+}
+
+func (s *Server) initLineRoutes() {
+	lineRoutes := s.app.Group("/line")
+	lineRoutes.Post("/webhook", s.handler.line.Webhook)
+	lineRoutes.Get("/account", s.authMiddleware.Auth, s.handler.line.GetAccount)
+	lineRoutes.Post("/account/code", s.authMiddleware.Auth, s.rateLimit.Limit(middleware.RateLimit{Name: "line-code", Max: 10, Window: time.Hour, Key: middleware.ByUser}), s.handler.line.CreateLinkCode)
+	lineRoutes.Delete("/account", s.authMiddleware.Auth, s.handler.line.Unlink)
+}
+
+func (s *Server) initAnnouncementRoutes() {
+	announcementRoutes := s.app.Group("/announcements", s.authMiddleware.Auth)
+	announcementRoutes.Get("/:id", s.handler.announcement.GetByID)
+	announcementRoutes.Delete("/:id", s.handler.announcement.Delete)
+	announcementRoutes.Post("/:id/read", s.handler.announcement.MarkRead)
+}
+
+// initDevRoutes exposes the emails captured by the file and memory mailers
+// when running locally.
+func (s *Server) initDevRoutes() {
+	if _, ok := s.mailer.(email.Mailbox); !ok || s.config.Env != "local" {
+		return
+	}
+
+	devRoutes := s.app.Group("/dev")
+	devRoutes.Get("/mailbox", s.handler.mailbox.GetAll)
+	devRoutes.Get("/mailbox/:id", s.handler.mailbox.GetHTML)
+	devRoutes.Delete("/mailbox", s.handler.mailbox.Clear)
 }
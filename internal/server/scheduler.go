@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// job is a background task that runs every interval until the server stops.
+// Jobs must be safe to run on several instances at once.
+type job struct {
+	// name completes "Failed to ..." in the log.
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+func (s *Server) jobs() []job {
+	return []job{
+		{name: "send saved search digests", interval: time.Hour, run: s.service.savedSearch.SendDailyDigest},
+		{name: "rotate JWT signing keys", interval: time.Hour, run: s.jwtUtils.RotateKeys},
+		{name: "deliver queued emails", interval: 30 * time.Second, run: s.service.outbox.ProcessDue},
+		{name: "send reminders", interval: 15 * time.Minute, run: s.service.reminder.SendDue},
+		{name: "deliver webhooks", interval: 15 * time.Second, run: s.service.webhook.ProcessDue},
+		{name: "send weekly lessor reports", interval: time.Hour, run: s.service.lessorReport.SendWeekly},
+		{name: "publish scheduled announcements", interval: time.Minute, run: s.service.announcement.PublishDue},
+	}
+}
+
+// startScheduler runs every job in the background until ctx is cancelled.
+func (s *Server) startScheduler(ctx context.Context) {
+	for _, j := range s.jobs() {
+		go s.runJob(ctx, j)
+	}
+}
+
+func (s *Server) runJob(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.run(); err != nil {
+				log.Printf("Failed to %s: %v\n", j.name, err)
+			}
+		}
+	}
+}
@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/PitiNarak/condormhub-backend/internal/core/services"
 	"github.com/PitiNarak/condormhub-backend/internal/database"
 	"github.com/PitiNarak/condormhub-backend/internal/middleware"
 	"github.com/PitiNarak/condormhub-backend/pkg/email"
 	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
+	"github.com/PitiNarak/condormhub-backend/pkg/line"
+	"github.com/PitiNarak/condormhub-backend/pkg/oidc"
 	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/PitiNarak/condormhub-backend/pkg/sms"
 	"github.com/PitiNarak/condormhub-backend/pkg/storage"
 	"github.com/PitiNarak/condormhub-backend/pkg/stripe"
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook"
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -29,6 +34,10 @@ type Config struct {
 	CorsAllowMethods     string `env:"CORS_ALLOW_METHODS"`
 	CorsAllowHeaders     string `env:"CORS_ALLOW_HEADERS"`
 	CorsAllowCredentials bool   `env:"CORS_ALLOW_CREDENTIALS"`
+	// ProxyHeader holds the client IP when the server runs behind a load
+	// balancer, it is only read on requests from TrustedProxies.
+	ProxyHeader    string   `env:"PROXY_HEADER"`
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
 }
 
 type Server struct {
@@ -37,17 +46,26 @@ type Server struct {
 	storage        *storage.Storage
 	jwtUtils       *jwt.JWTUtils
 	authMiddleware *middleware.AuthMiddleware
+	rateLimit      *middleware.RateLimitMiddleware
 	redis          *redis.Redis
 	db             *database.Database
 	smtpConfig     *email.SMTPConfig
 	stripeConfig   *stripe.Config
+	googleConfig   *oidc.Config
 	stripe         *stripe.Stripe
+	sms            sms.Sender
+	line           line.Client
+	mailer         email.Mailer
+	webhooks       webhook.Sender
+	reminderConfig services.ReminderConfig
+	webhookConfig  *webhook.Config
+	lineConfig     *line.Config
 	handler        *handler
 	service        *service
 	repository     *repository
 }
 
-func NewServer(config Config, smtpConfig email.SMTPConfig, jwtConfig jwt.JWTConfig, storageConfig storage.Config, stripeConfig stripe.Config, redis *redis.Redis, db *database.Database) *Server {
+func NewServer(config Config, smtpConfig email.SMTPConfig, mailerConfig email.MailerConfig, jwtConfig jwt.JWTConfig, storageConfig storage.Config, stripeConfig stripe.Config, googleConfig oidc.Config, smsConfig sms.Config, lineConfig line.Config, reminderConfig services.ReminderConfig, webhookConfig webhook.Config, redis *redis.Redis, db *database.Database) *Server {
 
 	app := fiber.New(fiber.Config{
 		AppName:               config.Name,
@@ -57,11 +75,29 @@ func NewServer(config Config, smtpConfig email.SMTPConfig, jwtConfig jwt.JWTConf
 		JSONDecoder:           json.Unmarshal,
 		DisableStartupMessage: true,
 		ErrorHandler:          apperror.ErrorHandler,
+		// rate limits and lockouts count per client IP
+		ProxyHeader:             config.ProxyHeader,
+		EnableTrustedProxyCheck: config.ProxyHeader != "",
+		TrustedProxies:          config.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	jwtUtils := jwt.NewJWTUtils(&jwtConfig, redis)
 	storage := storage.NewStorage(storageConfig)
 	stripe := stripe.New(stripeConfig)
+	sms, err := sms.New(smsConfig)
+	if err != nil {
+		log.Fatalf("Unable to create SMS sender: %v", err)
+	}
+	line, err := line.New(lineConfig)
+	if err != nil {
+		log.Fatalf("Unable to create LINE client: %v", err)
+	}
+	mailer, err := email.NewMailer(mailerConfig, &smtpConfig)
+	if err != nil {
+		log.Fatalf("Unable to create mailer: %v", err)
+	}
+	webhooks := webhook.NewClient(webhookConfig)
 
 	return &Server{
 		app:          app,
@@ -72,7 +108,16 @@ func NewServer(config Config, smtpConfig email.SMTPConfig, jwtConfig jwt.JWTConf
 		redis:        redis,
 		smtpConfig:   &smtpConfig,
 		stripeConfig: &stripeConfig,
+		googleConfig: &googleConfig,
 		stripe:       stripe,
+		sms:          sms,
+		line:         line,
+		mailer:       mailer,
+		webhooks:     webhooks,
+
+		reminderConfig: reminderConfig,
+		webhookConfig:  &webhookConfig,
+		lineConfig:     &lineConfig,
 	}
 }
 
@@ -94,6 +139,9 @@ func (s *Server) Start(ctx context.Context, stop context.CancelFunc) {
 		}
 	}()
 
+	// start background jobs
+	s.startScheduler(ctx)
+
 	// shutdown server at the end
 	defer func() {
 		if err := s.app.ShutdownWithContext(ctx); err != nil {
@@ -124,4 +172,5 @@ func (s *Server) initServerMiddleware() {
 
 func (s *Server) initAuthMiddleware() {
 	s.authMiddleware = middleware.NewAuthMiddleware(s.jwtUtils, s.repository.user)
+	s.rateLimit = middleware.NewRateLimitMiddleware(s.redis)
 }
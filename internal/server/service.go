@@ -4,6 +4,7 @@ import (
 	"github.com/PitiNarak/condormhub-backend/internal/core/ports"
 	"github.com/PitiNarak/condormhub-backend/internal/core/services"
 	"github.com/PitiNarak/condormhub-backend/pkg/email"
+	"github.com/PitiNarak/condormhub-backend/pkg/oidc"
 )
 
 type service struct {
@@ -17,20 +18,56 @@ type service struct {
 	leasingRequest ports.LeasingRequestService
 	receipt        ports.ReceiptService
 	support        ports.SupportService
+	savedSearch    ports.SavedSearchService
+	shortlist      ports.ShortlistService
+	analytics      ports.AnalyticsService
+	moderation     ports.ReviewModerationService
+	twoFactor      ports.TwoFactorService
+	dormManager    ports.DormManagerService
+	oidc           ports.OIDCService
+	phone          ports.PhoneVerificationService
+	notification   ports.NotificationService
+	live           ports.LiveService
+	thread         ports.ThreadService
+	outbox         ports.EmailOutboxService
+	reminder       ports.ReminderService
+	webhook        ports.WebhookService
+	line           ports.LineService
+	lessorReport   ports.LessorReportService
+	announcement   ports.AnnouncementService
 }
 
 func (s *Server) initService() {
-	email := email.NewEmailService(s.smtpConfig, s.jwtUtils)
+	email := email.NewEmailService(s.smtpConfig, s.jwtUtils, s.repository.outbox)
 	user := services.NewUserService(s.repository.user, email, s.jwtUtils, s.storage)
-	dorm := services.NewDormService(s.repository.dorm, s.storage)
-	leasingHistory := services.NewLeasingHistoryService(s.repository.leasingHistory, s.repository.dorm, s.storage)
-	order := services.NewOrderService(s.repository.order, s.repository.leasingHistory)
+	live := services.NewLiveService(s.redis)
+	webhook := services.NewWebhookService(s.repository.webhook, s.webhooks, s.webhookConfig.AllowLocal)
+	emailChannel := services.NewEmailNotificationChannel(email)
+	lineChannel := services.NewLineNotificationChannel(s.repository.line, s.line, email.Link)
+	notification := services.NewNotificationService(s.repository.notification, s.repository.user, live, emailChannel, lineChannel)
+	savedSearch := services.NewSavedSearchService(s.repository.savedSearch, email)
+	shortlist := services.NewShortlistService(s.repository.shortlist, s.repository.dorm, s.repository.leasingRequest, email, s.storage)
+	dorm := services.NewDormService(s.repository.dorm, s.storage, savedSearch, shortlist)
+	dormManager := services.NewDormManagerService(s.repository.dormManager, s.repository.dorm, s.repository.user)
+	leasingHistory := services.NewLeasingHistoryService(s.repository.leasingHistory, s.repository.dorm, s.storage, dormManager, webhook, savedSearch)
+	order := services.NewOrderService(s.repository.order, s.repository.leasingHistory, dormManager, notification)
 	ownershipProof := services.NewOwnershipProofService(s.repository.ownershipProof, s.repository.user, s.storage)
-	contract := services.NewContractService(s.repository.contract, s.repository.user, s.repository.dorm, leasingHistory, dorm)
-	leasingRequest := services.NewLeasingRequestService(s.repository.leasingRequest, s.repository.dorm, s.repository.contract)
+	contract := services.NewContractService(s.repository.contract, s.repository.user, s.repository.dorm, leasingHistory, dorm, dormManager, notification, live, webhook)
+	leasingRequest := services.NewLeasingRequestService(s.repository.leasingRequest, s.repository.dorm, s.repository.contract, dormManager, notification, webhook)
 	receipt := services.NewReceiptService(s.repository.receipt, s.repository.user, s.repository.tsx, s.repository.order, s.repository.leasingHistory, s.repository.dorm, s.storage)
-	tsx := services.NewTransactionService(s.repository.tsx, s.repository.order, s.stripe, s.repository.leasingHistory, receipt)
-	support := services.NewSupportService(s.repository.support)
+	tsx := services.NewTransactionService(s.repository.tsx, s.repository.order, s.stripe, s.repository.leasingHistory, receipt, live, notification, webhook)
+	support := services.NewSupportService(s.repository.support, s.repository.thread, notification)
+	analytics := services.NewAnalyticsService(s.repository.analytics, s.repository.dorm)
+	moderation := services.NewReviewModerationService(s.repository.moderation, s.repository.leasingHistory, email)
+	twoFactor := services.NewTwoFactorService(s.repository.user, s.repository.twoFactor, s.jwtUtils, s.config.Name)
+	oidc := services.NewOIDCService(s.repository.oidc, s.repository.user, s.jwtUtils, s.redis, map[string]*oidc.Provider{"google": oidc.New(*s.googleConfig)})
+	phone := services.NewPhoneVerificationService(s.repository.user, s.redis, s.sms)
+	thread := services.NewThreadService(s.repository.thread, s.repository.dorm, s.repository.leasingRequest, s.repository.leasingHistory, s.repository.user, s.storage, live)
+	outbox := services.NewEmailOutboxService(s.repository.outbox, s.mailer)
+	reminder := services.NewReminderService(s.repository.reminder, notification, s.reminderConfig)
+	line := services.NewLineService(s.repository.line, s.redis, s.line, s.lineConfig.ChannelSecret)
+	lessorReport := services.NewLessorReportService(s.repository.lessorReport, email)
+	announcement := services.NewAnnouncementService(s.repository.announcement, s.repository.dorm, s.repository.leasingHistory, s.storage, notification)
 
 	s.service = &service{
 		user:           user,
@@ -43,5 +80,22 @@ func (s *Server) initService() {
 		leasingRequest: leasingRequest,
 		receipt:        receipt,
 		support:        support,
+		savedSearch:    savedSearch,
+		shortlist:      shortlist,
+		analytics:      analytics,
+		moderation:     moderation,
+		twoFactor:      twoFactor,
+		dormManager:    dormManager,
+		oidc:           oidc,
+		phone:          phone,
+		notification:   notification,
+		live:           live,
+		thread:         thread,
+		outbox:         outbox,
+		reminder:       reminder,
+		webhook:        webhook,
+		line:           line,
+		lessorReport:   lessorReport,
+		announcement:   announcement,
 	}
 }
@@ -37,6 +37,6 @@ func main() {
 		log.Fatalf("Redis connection failed: %v", err)
 	}
 
-	s := server.NewServer(config.Server, config.SMTP, config.JWT, config.Storage, config.StripeConfig, redis, db)
+	s := server.NewServer(config.Server, config.SMTP, config.Mailer, config.JWT, config.Storage, config.StripeConfig, config.Google, config.SMS, config.Line, config.Reminder, config.Webhook, redis, db)
 	s.Start(ctx, stop)
 }
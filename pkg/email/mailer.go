@@ -0,0 +1,179 @@
+package email
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-gomail/gomail"
+)
+
+type MailerConfig struct {
+	// Driver selects the Mailer: "smtp", "file", "memory" or "none".
+	Driver string `env:"DRIVER" envDefault:"smtp"`
+	// Dir is where the file driver writes .eml files.
+	Dir string `env:"DIR" envDefault:"tmp/mailbox"`
+}
+
+// Mailer delivers a rendered message.
+type Mailer interface {
+	Send(message *Message) error
+}
+
+// Mailbox is implemented by the mailers that capture messages instead of
+// sending them, for local development.
+type Mailbox interface {
+	Messages() []CapturedMessage
+	Message(id int) (CapturedMessage, bool)
+	Clear()
+}
+
+type CapturedMessage struct {
+	ID     int
+	SentAt time.Time
+	Message
+}
+
+func NewMailer(config MailerConfig, smtpConfig *SMTPConfig) (Mailer, error) {
+	switch config.Driver {
+	case "smtp":
+		return NewSMTPMailer(smtpConfig), nil
+	case "file":
+		return NewFileMailer(config.Dir)
+	case "memory":
+		return NewMemoryMailer(), nil
+	case "none":
+		return NewNopMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", config.Driver)
+	}
+}
+
+func newGomailMessage(message *Message) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", "no-reply@condormhub.xyz")
+	m.SetHeader("To", message.To)
+	m.SetHeader("Subject", message.Subject)
+	m.SetBody("text/plain", message.Text)
+	m.AddAlternative("text/html", message.HTML)
+	return m
+}
+
+type SMTPMailer struct {
+	config *SMTPConfig
+}
+
+func NewSMTPMailer(config *SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{config: config}
+}
+
+func (m *SMTPMailer) Send(message *Message) error {
+	dailer := gomail.NewDialer(m.config.Host, m.config.Port, m.config.Email, m.config.Password)
+	return dailer.DialAndSend(newGomailMessage(message))
+}
+
+// memoryMailboxSize is how many messages MemoryMailer keeps, the oldest are dropped first.
+const memoryMailboxSize = 200
+
+// MemoryMailer keeps the last messages in memory.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	nextID   int
+	messages []CapturedMessage
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{nextID: 1}
+}
+
+func (m *MemoryMailer) Send(message *Message) error {
+	m.capture(message)
+	return nil
+}
+
+func (m *MemoryMailer) capture(message *Message) CapturedMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	captured := CapturedMessage{ID: m.nextID, SentAt: time.Now(), Message: *message}
+	m.nextID++
+	m.messages = append(m.messages, captured)
+	if len(m.messages) > memoryMailboxSize {
+		m.messages = m.messages[len(m.messages)-memoryMailboxSize:]
+	}
+	return captured
+}
+
+// Messages returns the captured messages, newest first.
+func (m *MemoryMailer) Messages() []CapturedMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]CapturedMessage, len(m.messages))
+	for i, message := range m.messages {
+		messages[len(m.messages)-1-i] = message
+	}
+	return messages
+}
+
+func (m *MemoryMailer) Message(id int) (CapturedMessage, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, message := range m.messages {
+		if message.ID == id {
+			return message, true
+		}
+	}
+	return CapturedMessage{}, false
+}
+
+func (m *MemoryMailer) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
+
+// FileMailer writes every message to an .eml file in dir, and keeps it in
+// memory like MemoryMailer.
+type FileMailer struct {
+	*MemoryMailer
+	dir string
+}
+
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{MemoryMailer: NewMemoryMailer(), dir: dir}, nil
+}
+
+func (m *FileMailer) Send(message *Message) error {
+	captured := m.capture(message)
+
+	name := fmt.Sprintf("%s-%d.eml", captured.SentAt.Format("20060102-150405"), captured.ID)
+	file, err := os.Create(filepath.Join(m.dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = newGomailMessage(message).WriteTo(file)
+	return err
+}
+
+// NopMailer drops every message.
+type NopMailer struct{}
+
+func NewNopMailer() *NopMailer {
+	return &NopMailer{}
+}
+
+func (m *NopMailer) Send(message *Message) error {
+	log.Printf("email to %s dropped: %s\n", message.To, message.Subject)
+	return nil
+}
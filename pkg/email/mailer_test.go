@@ -0,0 +1,91 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMailer(t *testing.T) {
+	drivers := map[string]Mailer{
+		"smtp":   &SMTPMailer{},
+		"memory": &MemoryMailer{},
+		"none":   &NopMailer{},
+	}
+	for driver, expected := range drivers {
+		mailer, err := NewMailer(MailerConfig{Driver: driver}, &SMTPConfig{})
+		assert.NoError(t, err)
+		assert.IsType(t, expected, mailer, driver)
+	}
+
+	mailer, err := NewMailer(MailerConfig{Driver: "file", Dir: t.TempDir()}, nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &FileMailer{}, mailer)
+
+	_, err = NewMailer(MailerConfig{Driver: "carrier-pigeon"}, nil)
+	assert.EqualError(t, err, "unknown mailer driver: carrier-pigeon")
+}
+
+func TestMemoryMailer(t *testing.T) {
+	t.Run("newest first and found by id", func(t *testing.T) {
+		mailer := NewMemoryMailer()
+		assert.NoError(t, mailer.Send(&Message{To: "somchai@example.com", Subject: "Verify your email", HTML: "<p>Welcome</p>"}))
+		assert.NoError(t, mailer.Send(&Message{To: "malee@example.com", Subject: "Reset your password"}))
+
+		messages := mailer.Messages()
+		if assert.Len(t, messages, 2) {
+			assert.Equal(t, "malee@example.com", messages[0].To)
+			assert.Equal(t, "somchai@example.com", messages[1].To)
+		}
+
+		message, ok := mailer.Message(messages[1].ID)
+		assert.True(t, ok)
+		assert.Equal(t, "<p>Welcome</p>", message.HTML)
+
+		mailer.Clear()
+		assert.Empty(t, mailer.Messages())
+		_, ok = mailer.Message(messages[1].ID)
+		assert.False(t, ok)
+
+		// ids are not reused after clearing
+		_ = mailer.Send(&Message{To: "somsak@example.com"})
+		assert.Equal(t, 3, mailer.Messages()[0].ID)
+	})
+
+	t.Run("the oldest are dropped when full", func(t *testing.T) {
+		mailer := NewMemoryMailer()
+		for i := 1; i <= memoryMailboxSize+1; i++ {
+			_ = mailer.Send(&Message{To: fmt.Sprintf("user%d@example.com", i)})
+		}
+
+		messages := mailer.Messages()
+		assert.Len(t, messages, memoryMailboxSize)
+		assert.Equal(t, "user2@example.com", messages[len(messages)-1].To)
+		_, ok := mailer.Message(1)
+		assert.False(t, ok)
+	})
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mailbox")
+	mailer, err := NewFileMailer(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, mailer.Send(&Message{To: "somchai@example.com", Subject: "Verify your email", Text: "Welcome", HTML: "<p>Welcome</p>"}))
+	assert.Len(t, mailer.Messages(), 1)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-1.eml"))
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		content, err := os.ReadFile(files[0])
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "To: somchai@example.com")
+		assert.Contains(t, string(content), "Subject: Verify your email")
+		assert.Contains(t, string(content), "<p>Welcome</p>")
+	}
+}
@@ -2,10 +2,9 @@ package email
 
 import (
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/PitiNarak/condormhub-backend/pkg/jwt"
-	"github.com/go-gomail/gomail"
 	"github.com/yokeTH/go-pkg/apperror"
 )
 
@@ -17,62 +16,143 @@ type SMTPConfig struct {
 	LinkHostname string `env:"LINK_HOSTNAME,required"`
 }
 
+// Message is a rendered email with an HTML body and its plain-text alternative.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Recipient is who an email is rendered for. Language picks the template
+// variant and falls back to DefaultLanguage.
+type Recipient struct {
+	Email    string
+	Name     string
+	Language string
+}
+
+// Outbox stores messages until they are delivered, so a failing SMTP server
+// does not fail the request that sent the email.
+type Outbox interface {
+	Enqueue(message *Message) error
+}
+
+// EventData fills the lease, payment, contract and support templates. Event is
+// the notification type, e.g. "contract.signed".
+type EventData struct {
+	Event    string
+	DormName string
+	Amount   float64
+	Status   string
+	Days     int
+}
+
+type NotificationData struct {
+	Subject    string
+	Lines      []string
+	ButtonText string
+}
+
+// WeeklyReportData fills the weekly report of a lessor. To is the last day of
+// the week and amounts are in THB.
+type WeeklyReportData struct {
+	From             time.Time
+	To               time.Time
+	NewRequests      int64
+	AnsweredRequests int64
+	PendingRequests  int64
+	// ResponseHours is the average time to answer a request, 0 when none was answered.
+	ResponseHours     float64
+	PaidBills         int64
+	PaidAmount        int64
+	OutstandingBills  int64
+	OutstandingAmount int64
+	NewReviews        int64
+	Views             int64
+	Dorms             []WeeklyReportDorm
+}
+
+type WeeklyReportDorm struct {
+	Name string
+	// Occupancy is the percentage of the week the dorm was leased.
+	Occupancy    int
+	Rating       float64
+	RatingChange float64
+	NewRequests  int64
+	NewReviews   int64
+	Views        int64
+}
+
+type EmailChangedData struct {
+	NewEmail string
+}
+
+// Sender is what services use to send emails. Email implements it.
+type Sender interface {
+	SendVerificationEmail(to Recipient, token string) error
+	SendResetPasswordEmail(to Recipient, token string) error
+	SendEmailChangeEmail(to Recipient, token string) error
+	SendEmailChangedEmail(to Recipient, newEmail string) error
+	SendNotificationEmail(to Recipient, subject string, lines []string, buttonText, link string) error
+	SendEventEmail(to Recipient, template string, link string, data EventData) error
+	SendWeeklyReportEmail(to Recipient, link string, data WeeklyReportData) error
+	Link(path string) string
+}
+
+// Email renders messages and queues them in the outbox, a Mailer delivers them later.
 type Email struct {
 	emailConfig *SMTPConfig
 	jwtUtils    *jwt.JWTUtils
+	outbox      Outbox
 }
 
-func NewEmailService(emailConfig *SMTPConfig, jwtUtils *jwt.JWTUtils) Email {
-	return Email{emailConfig: emailConfig, jwtUtils: jwtUtils}
+func NewEmailService(emailConfig *SMTPConfig, jwtUtils *jwt.JWTUtils, outbox Outbox) *Email {
+	return &Email{emailConfig: emailConfig, jwtUtils: jwtUtils, outbox: outbox}
 }
 
-func (e *Email) SendVerificationEmail(email, name string, token string) error {
-	message := gomail.NewMessage()
-	message.SetHeader("From", "no-reply@condormhub.xyz")
-	message.SetHeader("To", email)
-	message.SetHeader("Subject", "ConDormHub Email Verification")
-	cwd, _ := os.Getwd()
-	verLink := fmt.Sprintf(e.emailConfig.LinkHostname+"/verify?token=%s", token)
-	html, _ := readTemplate(cwd + "/pkg/email/verify-compress.html")
-	body := fmt.Sprintf(html, name, verLink, verLink)
-	message.SetBody("text/html", body)
+func (e *Email) SendVerificationEmail(to Recipient, token string) error {
+	return e.send(to, templateVerify, e.Link(fmt.Sprintf("/verify?token=%s", token)), nil)
+}
 
-	dailer := gomail.NewDialer(e.emailConfig.Host, e.emailConfig.Port, e.emailConfig.Email, e.emailConfig.Password)
+func (e *Email) SendResetPasswordEmail(to Recipient, token string) error {
+	return e.send(to, templateResetPassword, e.Link(fmt.Sprintf("/newpassword/token=%s", token)), nil)
+}
 
-	return dailer.DialAndSend(message)
+func (e *Email) SendEmailChangeEmail(to Recipient, token string) error {
+	return e.send(to, templateEmailChange, e.Link(fmt.Sprintf("/verify-email-change?token=%s", token)), nil)
 }
 
-func (e *Email) SendResetPasswordEmail(email, name string, token string) error {
-	message := gomail.NewMessage()
-	message.SetHeader("From", "no-reply@condormhub.xyz")
-	message.SetHeader("To", email)
-	message.SetHeader("Subject", "ConDormHub Reset Password")
+func (e *Email) SendEmailChangedEmail(to Recipient, newEmail string) error {
+	return e.send(to, templateEmailChanged, e.Link("/support"), EmailChangedData{NewEmail: newEmail})
+}
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		return apperror.InternalServerError(err, "cannot get current path")
-	}
-	verLink := fmt.Sprintf(e.emailConfig.LinkHostname+"/newpassword/token=%s", token)
-	html, err := readTemplate(cwd + "/pkg/email/reset-compress.html")
-	if err != nil {
-		return apperror.InternalServerError(err, "cannot load html template")
-	}
-	body := fmt.Sprintf(html, name, verLink, verLink)
-	message.SetBody("text/html", body)
+// SendNotificationEmail sends free text. Prefer a dedicated template, which is
+// translated, when there is one.
+func (e *Email) SendNotificationEmail(to Recipient, subject string, lines []string, buttonText, link string) error {
+	return e.send(to, templateNotification, link, NotificationData{Subject: subject, Lines: lines, ButtonText: buttonText})
+}
+
+// SendEventEmail sends one of the lease, payment, contract or support templates.
+func (e *Email) SendEventEmail(to Recipient, template string, link string, data EventData) error {
+	return e.send(to, template, link, data)
+}
+
+func (e *Email) SendWeeklyReportEmail(to Recipient, link string, data WeeklyReportData) error {
+	return e.send(to, templateWeeklyReport, link, data)
+}
 
-	dailer := gomail.NewDialer(e.emailConfig.Host, e.emailConfig.Port, e.emailConfig.Email, e.emailConfig.Password)
-	err = dailer.DialAndSend(message)
+func (e *Email) send(to Recipient, template string, link string, data any) error {
+	message, err := render(to, template, link, data)
 	if err != nil {
-		return apperror.InternalServerError(err, "cannot sent email")
+		return apperror.InternalServerError(err, "cannot render email")
+	}
+	if err := e.outbox.Enqueue(message); err != nil {
+		return apperror.InternalServerError(err, "cannot queue email")
 	}
-
 	return nil
 }
 
-func readTemplate(path string) (string, error) {
-	byteContent, err := os.ReadFile(path)
-	if err != nil { //many people wrap this into a function
-		return "", err
-	}
-	return string(byteContent), nil
+func (e *Email) Link(path string) string {
+	return e.emailConfig.LinkHostname + path
 }
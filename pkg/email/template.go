@@ -0,0 +1,126 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+const (
+	LanguageThai    = "th"
+	LanguageEnglish = "en"
+
+	// DefaultLanguage is used for users who have not picked a language.
+	DefaultLanguage = LanguageEnglish
+)
+
+var Languages = []string{LanguageThai, LanguageEnglish}
+
+// Each email has a <name>.txt defining its "subject", "button" and plain-text
+// "content", and a <name>.html defining its HTML "content", per language.
+const (
+	templateVerify        = "verify"
+	templateResetPassword = "reset_password"
+	templateEmailChange   = "email_change"
+	templateEmailChanged  = "email_changed"
+	templateNotification  = "notification"
+	templateWeeklyReport  = "weekly_report"
+
+	TemplateLease    = "lease"
+	TemplatePayment  = "payment"
+	TemplateContract = "contract"
+	TemplateSupport  = "support"
+	TemplateReminder = "reminder"
+)
+
+var templateNames = []string{
+	templateVerify,
+	templateResetPassword,
+	templateEmailChange,
+	templateEmailChanged,
+	templateNotification,
+	templateWeeklyReport,
+	TemplateLease,
+	TemplatePayment,
+	TemplateContract,
+	TemplateSupport,
+	TemplateReminder,
+}
+
+//go:embed templates
+var templateFS embed.FS
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// templates is keyed by language, then by template name.
+var templates = parseTemplates()
+
+func parseTemplates() map[string]map[string]emailTemplate {
+	result := make(map[string]map[string]emailTemplate, len(Languages))
+	for _, language := range Languages {
+		common := "templates/" + language + "/common.tmpl"
+		result[language] = make(map[string]emailTemplate, len(templateNames))
+		for _, name := range templateNames {
+			base := "templates/" + language + "/" + name
+			result[language][name] = emailTemplate{
+				html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", common, base+".html")),
+				text: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/layout.txt", common, base+".txt")),
+			}
+		}
+	}
+	return result
+}
+
+// templateData is what every template is executed with. Data holds the
+// fields specific to the email.
+type templateData struct {
+	Language string
+	Name     string
+	Subject  string
+	Button   string
+	Link     string
+	Data     any
+}
+
+// render executes a template in the language of the recipient.
+func render(to Recipient, name string, link string, data any) (*Message, error) {
+	language := to.Language
+	if _, ok := templates[language]; !ok {
+		language = DefaultLanguage
+	}
+	tmpl, ok := templates[language][name]
+	if !ok {
+		return nil, fmt.Errorf("email template %q not found", name)
+	}
+
+	values := templateData{Language: language, Name: to.Name, Link: link, Data: data}
+
+	var subject, button, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&button, "button", values); err != nil {
+		return nil, err
+	}
+	values.Subject = subject.String()
+	values.Button = button.String()
+
+	if err := tmpl.text.ExecuteTemplate(&text, "layout.txt", values); err != nil {
+		return nil, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout.html", values); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:      to.Email,
+		Subject: values.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -2,7 +2,9 @@ package jwt
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/PitiNarak/condormhub-backend/pkg/redis"
@@ -12,19 +14,66 @@ import (
 )
 
 type JWTConfig struct {
-	JWTSecretKey           string `env:"SECRET,required"`
+	// KeyStore is redis, shared by every instance, or dir, for a single instance
+	// or instances that all mount KeysDir.
+	KeyStore               string `env:"KEY_STORE" envDefault:"redis"`
+	KeysDir                string `env:"KEYS_DIR" envDefault:"keys"`
+	Algorithm              string `env:"ALGORITHM" envDefault:"EdDSA"`
+	KeyRotationDays        int    `env:"KEY_ROTATION_DAYS" envDefault:"30"`
 	AccessTokenExpiration  int    `env:"EXPIRATION_HOURS,required"`
 	RefreshTokenExpiration int    `env:"REFRESH_EXPIRATION_HOURS,required"`
 }
 
 type JWTUtils struct {
 	Config *JWTConfig
-	Redis  *redis.Redis
+	Redis  tokenStore
+	keys   *keyRing
 }
 
+// tokenStore is the part of redis that keeps sessions and one-time tokens, so tests can keep them in memory.
+type tokenStore interface {
+	CreateSession(ctx context.Context, userID uuid.UUID, session redis.Session, ttl time.Duration) error
+	ExtendSession(ctx context.Context, userID uuid.UUID, sessionID string, ttl time.Duration) error
+	RotateRefreshToken(ctx context.Context, userID uuid.UUID, sessionID string, generation int) (int, error)
+	TouchSession(ctx context.Context, userID uuid.UUID, sessionID string, lastSeenAt time.Time) error
+	GetSessions(ctx context.Context, userID uuid.UUID) ([]redis.Session, error)
+	DeleteSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+	DeleteAllSessions(ctx context.Context, userID uuid.UUID) error
+	SetAccessToken(ctx context.Context, userID uuid.UUID, sessionID string, accessToken string, ttl time.Duration) error
+	GetAccessToken(ctx context.Context, userID uuid.UUID, sessionID string) (string, error)
+	SetVerificationToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error
+	GetVerificationToken(ctx context.Context, userID uuid.UUID) (string, error)
+	DeleteVerificationToken(ctx context.Context, userID uuid.UUID) error
+	SetResetToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error
+	GetResetToken(ctx context.Context, userID uuid.UUID) (string, error)
+	DeleteResetToken(ctx context.Context, userID uuid.UUID) error
+	SetTwoFactorToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error
+	GetTwoFactorToken(ctx context.Context, userID uuid.UUID) (string, error)
+	DeleteTwoFactorToken(ctx context.Context, userID uuid.UUID) error
+	IncrTwoFactorAttempts(ctx context.Context, userID uuid.UUID, ttl time.Duration) (int64, error)
+	SetEmailChangeToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error
+	GetEmailChangeToken(ctx context.Context, userID uuid.UUID) (string, error)
+	DeleteEmailChangeToken(ctx context.Context, userID uuid.UUID) error
+}
+
+const (
+	AccessTokenType      = "access"
+	RefreshTokenType     = "refresh"
+	TwoFactorTokenType   = "2fa_pending"
+	EmailChangeTokenType = "email_change"
+
+	// TwoFactorTokenExpiration is how long a user has to enter their second factor after the password.
+	TwoFactorTokenExpiration = 5 * time.Minute
+)
+
 type JWTClaims struct {
 	jwt.RegisteredClaims
 	UserID string `json:"userID"`
+	Type   string `json:"typ,omitempty"`
+	// Generation is the position of a refresh token in its family, the session it was issued for.
+	Generation int `json:"gen,omitempty"`
+	// Email is the new address of an email change token.
+	Email string `json:"email,omitempty"`
 }
 
 type JWTClaimsInterface interface {
@@ -34,7 +83,22 @@ type JWTClaimsInterface interface {
 }
 
 func NewJWTUtils(config *JWTConfig, redis *redis.Redis) *JWTUtils {
-	return &JWTUtils{Config: config, Redis: redis}
+	// a replaced key stays valid until the longest lived token it signed has expired,
+	// with slack for the hourly rotation and for other instances picking up the new key
+	rotateAfter := time.Hour * 24 * time.Duration(config.KeyRotationDays)
+	retireAfter := time.Hour*time.Duration(max(config.AccessTokenExpiration, config.RefreshTokenExpiration)) + keyRotationSlack
+
+	store, err := newKeyStore(config, redis)
+	if err != nil {
+		log.Fatalf("Unable to open the JWT key store: %v", err)
+	}
+
+	keys, err := newKeyRing(store, config.Algorithm, rotateAfter, retireAfter)
+	if err != nil {
+		log.Fatalf("Unable to load JWT signing keys: %v", err)
+	}
+
+	return &JWTUtils{Config: config, Redis: redis, keys: keys}
 }
 
 func (j *JWTClaims) GetUserID() string {
@@ -50,16 +114,38 @@ func (j *JWTClaims) GetIat() int64 {
 }
 
 func (j *JWTUtils) GenerateJWT(userID uuid.UUID, exp int) (string, error) {
-	claims := &JWTClaims{
+	return j.signClaims(&JWTClaims{
 		UserID: userID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(exp))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
+	})
+}
+
+// generateSessionJWT issues an access or refresh token whose jti is the session it belongs to.
+func (j *JWTUtils) generateSessionJWT(userID uuid.UUID, sessionID string, tokenType string, generation int, exp int) (string, error) {
+	return j.signClaims(&JWTClaims{
+		UserID:     userID.String(),
+		Type:       tokenType,
+		Generation: generation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(exp))),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+}
+
+func (j *JWTUtils) signClaims(claims *JWTClaims) (string, error) {
+	key, err := j.keys.signer()
+	if err != nil {
+		return "", apperror.InternalServerError(err, "cannot generate token")
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := jwtToken.SignedString([]byte(j.Config.JWTSecretKey))
+	jwtToken := jwt.NewWithClaims(key.Method, claims)
+	jwtToken.Header["kid"] = key.ID
+	tokenString, err := jwtToken.SignedString(key.Private)
 	if err != nil {
 		return "", apperror.InternalServerError(err, "cannot generate token")
 	}
@@ -68,10 +154,15 @@ func (j *JWTUtils) GenerateJWT(userID uuid.UUID, exp int) (string, error) {
 
 func (j *JWTUtils) DecodeJWT(inputToken string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(inputToken, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := j.keys.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(j.Config.JWTSecretKey), nil
+		return key.Private.Public(), nil
 	})
 
 	if err != nil || !token.Valid {
@@ -86,90 +177,174 @@ func (j *JWTUtils) DecodeJWT(inputToken string) (*JWTClaims, error) {
 	return claims, nil
 }
 
-func (j *JWTUtils) GenerateKeyPair(ctx context.Context, userID uuid.UUID) (string, string, error) {
-	accessToken, err := j.GenerateJWT(userID, j.Config.AccessTokenExpiration)
+// GenerateKeyPair starts a new session for the device and issues its access and refresh token.
+func (j *JWTUtils) GenerateKeyPair(ctx context.Context, userID uuid.UUID, userAgent string, ip string) (string, string, error) {
+	now := time.Now()
+	session := redis.Session{
+		ID:         uuid.NewString(),
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	err := j.Redis.CreateSession(ctx, userID, session, time.Hour*time.Duration(j.Config.RefreshTokenExpiration))
+	if err != nil {
+		return "", "", apperror.InternalServerError(err, "cannot create session")
+	}
+
+	return j.generateSessionKeyPair(ctx, userID, session.ID, 0)
+}
+
+func (j *JWTUtils) generateSessionKeyPair(ctx context.Context, userID uuid.UUID, sessionID string, generation int) (string, string, error) {
+	accessToken, err := j.generateSessionJWT(userID, sessionID, AccessTokenType, 0, j.Config.AccessTokenExpiration)
 	if err != nil {
 		return "", "", err
 	}
-	err = j.Redis.SetAccessToken(ctx, userID, accessToken, time.Hour*time.Duration(j.Config.AccessTokenExpiration))
+	err = j.Redis.SetAccessToken(ctx, userID, sessionID, accessToken, time.Hour*time.Duration(j.Config.AccessTokenExpiration))
 	if err != nil {
 		return "", "", apperror.InternalServerError(err, "cannot set access token")
 	}
 
-	refreshToken, err := j.GenerateJWT(userID, j.Config.RefreshTokenExpiration)
+	refreshToken, err := j.generateSessionJWT(userID, sessionID, RefreshTokenType, generation, j.Config.RefreshTokenExpiration)
 	if err != nil {
 		return "", "", err
 	}
-	err = j.Redis.SetRefreshToken(ctx, userID, refreshToken, time.Hour*time.Duration(j.Config.RefreshTokenExpiration))
-	if err != nil {
-		return "", "", apperror.InternalServerError(err, "cannot set refresh token")
-	}
 
 	return accessToken, refreshToken, nil
 }
 
-func (j *JWTUtils) VerifyAccessToken(ctx context.Context, accessToken string) (uuid.UUID, error) {
+// VerifyAccessToken returns the user and the session the token belongs to.
+// A token of a revoked session is rejected right away.
+func (j *JWTUtils) VerifyAccessToken(ctx context.Context, accessToken string) (uuid.UUID, string, error) {
 	claims, err := j.DecodeJWT(accessToken)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
+	}
+
+	if claims.Type != AccessTokenType {
+		return uuid.Nil, "", apperror.UnauthorizedError(errors.New("not an access token"), "invalid access token")
 	}
 
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		return uuid.Nil, apperror.InternalServerError(err, "cannot parse user id")
+		return uuid.Nil, "", apperror.InternalServerError(err, "cannot parse user id")
 	}
 
-	token, err := j.Redis.GetAccessToken(ctx, userID)
-	if err != nil {
-		return uuid.Nil, apperror.InternalServerError(err, "cannot get access token")
+	token, err := j.Redis.GetAccessToken(ctx, userID, claims.ID)
+	if errors.Is(err, redis.Nil) {
+		return uuid.Nil, "", apperror.UnauthorizedError(err, "session expired or revoked")
+	} else if err != nil {
+		return uuid.Nil, "", apperror.InternalServerError(err, "cannot get access token")
 	}
 
 	if token != accessToken {
-		return uuid.Nil, apperror.UnauthorizedError(nil, "invalid access token")
+		return uuid.Nil, "", apperror.UnauthorizedError(nil, "invalid access token")
 	}
 
-	return userID, nil
+	if err := j.Redis.TouchSession(ctx, userID, claims.ID, time.Now()); err != nil {
+		return uuid.Nil, "", apperror.InternalServerError(err, "cannot update session")
+	}
+
+	return userID, claims.ID, nil
 }
 
-func (j *JWTUtils) VerifyRefreshToken(ctx context.Context, refreshToken string) (uuid.UUID, error) {
+// RefreshToken exchanges a refresh token for a new key pair. Every refresh token
+// can be used once; presenting one that was already used means it leaked, so the
+// whole family is revoked and the legitimate client has to log in again.
+func (j *JWTUtils) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
 	claims, err := j.DecodeJWT(refreshToken)
 	if err != nil {
-		return uuid.Nil, err
+		return "", "", err
+	}
+
+	if claims.Type != RefreshTokenType {
+		return "", "", apperror.UnauthorizedError(errors.New("not a refresh token"), "invalid refresh token")
 	}
 
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		return uuid.Nil, apperror.InternalServerError(err, "cannot parse user id")
+		return "", "", apperror.InternalServerError(err, "cannot parse user id")
 	}
 
-	token, err := j.Redis.GetRefreshToken(ctx, userID)
+	generation, err := j.Redis.RotateRefreshToken(ctx, userID, claims.ID, claims.Generation)
+	if errors.Is(err, redis.ErrRefreshTokenReused) {
+		log.Printf("security: refresh token reuse detected for user %s, revoking session %s", userID, claims.ID)
+		if err := j.RevokeSession(ctx, userID, claims.ID); err != nil {
+			return "", "", err
+		}
+		return "", "", apperror.UnauthorizedError(err, "refresh token already used, session revoked")
+	} else if errors.Is(err, redis.Nil) {
+		return "", "", apperror.UnauthorizedError(err, "session expired or revoked")
+	} else if err != nil {
+		return "", "", apperror.InternalServerError(err, "cannot rotate refresh token")
+	}
+
+	accessToken, newRefreshToken, err := j.generateSessionKeyPair(ctx, userID, claims.ID, generation)
 	if err != nil {
-		return uuid.Nil, apperror.InternalServerError(err, "cannot get refresh token")
+		return "", "", err
 	}
 
-	if token != refreshToken {
-		return uuid.Nil, apperror.UnauthorizedError(nil, "invalid refresh token")
+	err = j.Redis.ExtendSession(ctx, userID, claims.ID, time.Hour*time.Duration(j.Config.RefreshTokenExpiration))
+	if err != nil {
+		return "", "", apperror.InternalServerError(err, "cannot extend session")
 	}
 
-	return userID, nil
+	return accessToken, newRefreshToken, nil
 }
 
-func (j *JWTUtils) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
-	userID, err := j.VerifyRefreshToken(ctx, refreshToken)
+func (j *JWTUtils) GetSessions(ctx context.Context, userID uuid.UUID) ([]redis.Session, error) {
+	sessions, err := j.Redis.GetSessions(ctx, userID)
 	if err != nil {
-		return "", "", err
+		return nil, apperror.InternalServerError(err, "cannot get sessions")
 	}
+	return sessions, nil
+}
 
-	accessToken, refreshToken, err := j.GenerateKeyPair(ctx, userID)
+func (j *JWTUtils) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	err := j.Redis.DeleteSession(ctx, userID, sessionID)
 	if err != nil {
-		return "", "", err
+		return apperror.InternalServerError(err, "cannot revoke session")
 	}
-	err = j.Redis.SetAccessToken(ctx, userID, accessToken, time.Hour*time.Duration(j.Config.AccessTokenExpiration))
+	return nil
+}
+
+// JWKS returns the public keys that tokens may currently be signed with.
+func (j *JWTUtils) JWKS() JWKS {
+	return j.keys.JWKS()
+}
+
+// RotateKeys starts signing with a new key when the current one is due and drops retired keys.
+func (j *JWTUtils) RotateKeys() error {
+	return j.keys.Rotate(time.Now())
+}
+
+// RevokeAllSessions logs the user out of every device.
+func (j *JWTUtils) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	err := j.Redis.DeleteAllSessions(ctx, userID)
 	if err != nil {
-		return "", "", apperror.InternalServerError(err, "cannot set access token")
+		return apperror.InternalServerError(err, "cannot revoke sessions")
 	}
+	return nil
+}
 
-	return accessToken, refreshToken, nil
+// RevokeOtherSessions revokes every session of the user except keepSessionID.
+func (j *JWTUtils) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, keepSessionID string) error {
+	sessions, err := j.GetSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == keepSessionID {
+			continue
+		}
+		if err := j.RevokeSession(ctx, userID, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (j *JWTUtils) GenerateResetPasswordToken(ctx context.Context, userID uuid.UUID) (string, error) {
@@ -257,3 +432,128 @@ func (j *JWTUtils) DeleteVerificationToken(ctx context.Context, userID uuid.UUID
 	}
 	return nil
 }
+
+// GenerateEmailChangeToken issues the token sent to newEmail to confirm that the user owns it.
+func (j *JWTUtils) GenerateEmailChangeToken(ctx context.Context, userID uuid.UUID, newEmail string) (string, error) {
+	token, err := j.signClaims(&JWTClaims{
+		UserID: userID.String(),
+		Type:   EmailChangeTokenType,
+		Email:  newEmail,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = j.Redis.SetEmailChangeToken(ctx, userID, token, time.Hour*24)
+	if err != nil {
+		return "", apperror.InternalServerError(err, "cannot set email change token")
+	}
+	return token, nil
+}
+
+// VerifyEmailChangeToken returns the user and the new email the token confirms.
+// Only the latest requested change can be confirmed.
+func (j *JWTUtils) VerifyEmailChangeToken(ctx context.Context, emailChangeToken string) (uuid.UUID, string, error) {
+	claims, err := j.DecodeJWT(emailChangeToken)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	if claims.Type != EmailChangeTokenType || claims.Email == "" {
+		return uuid.Nil, "", apperror.UnauthorizedError(errors.New("not an email change token"), "invalid email change token")
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, "", apperror.InternalServerError(err, "cannot parse user id")
+	}
+
+	token, err := j.Redis.GetEmailChangeToken(ctx, userID)
+	if err != nil {
+		return uuid.Nil, "", apperror.UnauthorizedError(err, "token is expired or token is used")
+	}
+
+	if token != emailChangeToken {
+		return uuid.Nil, "", apperror.UnauthorizedError(nil, "invalid email change token")
+	}
+
+	return userID, claims.Email, nil
+}
+
+func (j *JWTUtils) DeleteEmailChangeToken(ctx context.Context, userID uuid.UUID) error {
+	err := j.Redis.DeleteEmailChangeToken(ctx, userID)
+	if err != nil {
+		return apperror.InternalServerError(err, "cannot delete email change token")
+	}
+	return nil
+}
+
+// GenerateTwoFactorToken issues the short lived token that stands for a login
+// whose password was checked but whose second factor is still pending.
+func (j *JWTUtils) GenerateTwoFactorToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := j.signClaims(&JWTClaims{
+		UserID: userID.String(),
+		Type:   TwoFactorTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TwoFactorTokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = j.Redis.SetTwoFactorToken(ctx, userID, token, TwoFactorTokenExpiration)
+	if err != nil {
+		return "", apperror.InternalServerError(err, "cannot set two-factor token")
+	}
+	return token, nil
+}
+
+func (j *JWTUtils) VerifyTwoFactorToken(ctx context.Context, twoFactorToken string) (uuid.UUID, error) {
+	claims, err := j.DecodeJWT(twoFactorToken)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if claims.Type != TwoFactorTokenType {
+		return uuid.Nil, apperror.UnauthorizedError(errors.New("not a two-factor token"), "invalid two-factor token")
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, apperror.InternalServerError(err, "cannot parse user id")
+	}
+
+	token, err := j.Redis.GetTwoFactorToken(ctx, userID)
+	if err != nil {
+		return uuid.Nil, apperror.UnauthorizedError(err, "token is expired or token is used")
+	}
+
+	if token != twoFactorToken {
+		return uuid.Nil, apperror.UnauthorizedError(nil, "invalid two-factor token")
+	}
+
+	return userID, nil
+}
+
+// CountTwoFactorFailure records a wrong second factor and returns how many there were for the pending login.
+func (j *JWTUtils) CountTwoFactorFailure(ctx context.Context, userID uuid.UUID) (int64, error) {
+	attempts, err := j.Redis.IncrTwoFactorAttempts(ctx, userID, TwoFactorTokenExpiration)
+	if err != nil {
+		return 0, apperror.InternalServerError(err, "cannot count two-factor attempts")
+	}
+	return attempts, nil
+}
+
+func (j *JWTUtils) DeleteTwoFactorToken(ctx context.Context, userID uuid.UUID) error {
+	err := j.Redis.DeleteTwoFactorToken(ctx, userID)
+	if err != nil {
+		return apperror.InternalServerError(err, "cannot delete two-factor token")
+	}
+	return nil
+}
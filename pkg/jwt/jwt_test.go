@@ -0,0 +1,352 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type memSession struct {
+	redis.Session
+	generation int
+}
+
+// memStore keeps sessions and tokens in memory. Expiry is not modelled, a test
+// expires a value by deleting it.
+type memStore struct {
+	sessions map[string]*memSession
+	tokens   map[string]string
+	attempts map[string]int64
+}
+
+func newMemStore() *memStore {
+	return &memStore{sessions: map[string]*memSession{}, tokens: map[string]string{}, attempts: map[string]int64{}}
+}
+
+func memKey(kind string, userID uuid.UUID, sessionID string) string {
+	return fmt.Sprintf("%s:%s:%s", kind, userID, sessionID)
+}
+
+func (m *memStore) get(key string) (string, error) {
+	value, ok := m.tokens[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (m *memStore) CreateSession(ctx context.Context, userID uuid.UUID, session redis.Session, ttl time.Duration) error {
+	m.sessions[memKey("session", userID, session.ID)] = &memSession{Session: session}
+	return nil
+}
+
+func (m *memStore) ExtendSession(ctx context.Context, userID uuid.UUID, sessionID string, ttl time.Duration) error {
+	return nil
+}
+
+func (m *memStore) RotateRefreshToken(ctx context.Context, userID uuid.UUID, sessionID string, generation int) (int, error) {
+	session, ok := m.sessions[memKey("session", userID, sessionID)]
+	if !ok {
+		return 0, redis.Nil
+	}
+	if session.generation != generation {
+		return 0, redis.ErrRefreshTokenReused
+	}
+	session.generation++
+	return session.generation, nil
+}
+
+func (m *memStore) TouchSession(ctx context.Context, userID uuid.UUID, sessionID string, lastSeenAt time.Time) error {
+	if session, ok := m.sessions[memKey("session", userID, sessionID)]; ok {
+		session.LastSeenAt = lastSeenAt
+	}
+	return nil
+}
+
+func (m *memStore) GetSessions(ctx context.Context, userID uuid.UUID) ([]redis.Session, error) {
+	var sessions []redis.Session
+	for key, session := range m.sessions {
+		if key == memKey("session", userID, session.ID) {
+			sessions = append(sessions, session.Session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *memStore) DeleteSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	delete(m.sessions, memKey("session", userID, sessionID))
+	delete(m.tokens, memKey("access_token", userID, sessionID))
+	return nil
+}
+
+func (m *memStore) DeleteAllSessions(ctx context.Context, userID uuid.UUID) error {
+	sessions, _ := m.GetSessions(ctx, userID)
+	for _, session := range sessions {
+		_ = m.DeleteSession(ctx, userID, session.ID)
+	}
+	return nil
+}
+
+func (m *memStore) SetAccessToken(ctx context.Context, userID uuid.UUID, sessionID string, accessToken string, ttl time.Duration) error {
+	m.tokens[memKey("access_token", userID, sessionID)] = accessToken
+	return nil
+}
+
+func (m *memStore) GetAccessToken(ctx context.Context, userID uuid.UUID, sessionID string) (string, error) {
+	return m.get(memKey("access_token", userID, sessionID))
+}
+
+func (m *memStore) SetVerificationToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	m.tokens[memKey("verification_token", userID, "")] = token
+	return nil
+}
+
+func (m *memStore) GetVerificationToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	return m.get(memKey("verification_token", userID, ""))
+}
+
+func (m *memStore) DeleteVerificationToken(ctx context.Context, userID uuid.UUID) error {
+	delete(m.tokens, memKey("verification_token", userID, ""))
+	return nil
+}
+
+func (m *memStore) SetResetToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	m.tokens[memKey("reset_token", userID, "")] = token
+	return nil
+}
+
+func (m *memStore) GetResetToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	return m.get(memKey("reset_token", userID, ""))
+}
+
+func (m *memStore) DeleteResetToken(ctx context.Context, userID uuid.UUID) error {
+	delete(m.tokens, memKey("reset_token", userID, ""))
+	return nil
+}
+
+func (m *memStore) SetTwoFactorToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	m.tokens[memKey("two_factor_token", userID, "")] = token
+	delete(m.attempts, userID.String())
+	return nil
+}
+
+func (m *memStore) GetTwoFactorToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	return m.get(memKey("two_factor_token", userID, ""))
+}
+
+func (m *memStore) DeleteTwoFactorToken(ctx context.Context, userID uuid.UUID) error {
+	delete(m.tokens, memKey("two_factor_token", userID, ""))
+	return nil
+}
+
+func (m *memStore) IncrTwoFactorAttempts(ctx context.Context, userID uuid.UUID, ttl time.Duration) (int64, error) {
+	m.attempts[userID.String()]++
+	return m.attempts[userID.String()], nil
+}
+
+func (m *memStore) SetEmailChangeToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	m.tokens[memKey("email_change_token", userID, "")] = token
+	return nil
+}
+
+func (m *memStore) GetEmailChangeToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	return m.get(memKey("email_change_token", userID, ""))
+}
+
+func (m *memStore) DeleteEmailChangeToken(ctx context.Context, userID uuid.UUID) error {
+	delete(m.tokens, memKey("email_change_token", userID, ""))
+	return nil
+}
+
+func newTestJWTUtils(t *testing.T) (*JWTUtils, *memStore) {
+	ring := &keyRing{store: &dirStore{dir: t.TempDir()}, algorithm: AlgorithmEdDSA, rotateAfter: 30 * 24 * time.Hour, retireAfter: 48 * time.Hour}
+	if !assert.NoError(t, ring.Rotate(time.Now())) {
+		t.FailNow()
+	}
+
+	store := newMemStore()
+	return &JWTUtils{Config: &JWTConfig{AccessTokenExpiration: 1, RefreshTokenExpiration: 24}, Redis: store, keys: ring}, store
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("each login is its own session", func(t *testing.T) {
+		j, _ := newTestJWTUtils(t)
+		laptop, _, err := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+		assert.NoError(t, err)
+		phone, _, err := j.GenerateKeyPair(ctx, userID, "Safari", "10.0.0.2")
+		assert.NoError(t, err)
+
+		_, laptopSession, err := j.VerifyAccessToken(ctx, laptop)
+		assert.NoError(t, err)
+		_, phoneSession, err := j.VerifyAccessToken(ctx, phone)
+		assert.NoError(t, err)
+		assert.NotEqual(t, laptopSession, phoneSession)
+
+		sessions, err := j.GetSessions(ctx, userID)
+		assert.NoError(t, err)
+		devices := []string{}
+		for _, session := range sessions {
+			devices = append(devices, session.UserAgent)
+		}
+		assert.ElementsMatch(t, []string{"Firefox", "Safari"}, devices)
+	})
+
+	t.Run("revoking a session logs out only that device", func(t *testing.T) {
+		j, _ := newTestJWTUtils(t)
+		laptop, _, _ := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+		phone, _, _ := j.GenerateKeyPair(ctx, userID, "Safari", "10.0.0.2")
+		_, phoneSession, _ := j.VerifyAccessToken(ctx, phone)
+
+		assert.NoError(t, j.RevokeSession(ctx, userID, phoneSession))
+
+		_, _, err := j.VerifyAccessToken(ctx, phone)
+		assert.ErrorContains(t, err, "session expired or revoked")
+		_, _, err = j.VerifyAccessToken(ctx, laptop)
+		assert.NoError(t, err)
+	})
+
+	t.Run("revoking the other sessions keeps the current one", func(t *testing.T) {
+		j, _ := newTestJWTUtils(t)
+		laptop, _, _ := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+		phone, _, _ := j.GenerateKeyPair(ctx, userID, "Safari", "10.0.0.2")
+		tablet, _, _ := j.GenerateKeyPair(ctx, userID, "Chrome", "10.0.0.3")
+		_, laptopSession, _ := j.VerifyAccessToken(ctx, laptop)
+
+		assert.NoError(t, j.RevokeOtherSessions(ctx, userID, laptopSession))
+
+		_, _, err := j.VerifyAccessToken(ctx, laptop)
+		assert.NoError(t, err)
+		for _, token := range []string{phone, tablet} {
+			_, _, err := j.VerifyAccessToken(ctx, token)
+			assert.ErrorContains(t, err, "session expired or revoked")
+		}
+		sessions, _ := j.GetSessions(ctx, userID)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("sessions of another user are untouched", func(t *testing.T) {
+		j, _ := newTestJWTUtils(t)
+		other := uuid.New()
+		mine, _, _ := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+		theirs, _, _ := j.GenerateKeyPair(ctx, other, "Firefox", "10.0.0.9")
+
+		assert.NoError(t, j.RevokeAllSessions(ctx, userID))
+
+		_, _, err := j.VerifyAccessToken(ctx, mine)
+		assert.Error(t, err)
+		verifiedUser, _, err := j.VerifyAccessToken(ctx, theirs)
+		if assert.NoError(t, err) {
+			assert.Equal(t, other, verifiedUser)
+		}
+	})
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("a refresh token is used once", func(t *testing.T) {
+		j, _ := newTestJWTUtils(t)
+		_, refresh, _ := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+
+		access, next, err := j.RefreshToken(ctx, refresh)
+		assert.NoError(t, err)
+		assert.NotEqual(t, refresh, next)
+		_, _, err = j.VerifyAccessToken(ctx, access)
+		assert.NoError(t, err)
+
+		_, _, err = j.RefreshToken(ctx, next)
+		assert.NoError(t, err)
+	})
+
+	t.Run("a reused refresh token revokes its family", func(t *testing.T) {
+		j, _ := newTestJWTUtils(t)
+		_, stolen, _ := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+		other, _, _ := j.GenerateKeyPair(ctx, userID, "Safari", "10.0.0.2")
+
+		access, next, err := j.RefreshToken(ctx, stolen)
+		assert.NoError(t, err)
+
+		_, _, err = j.RefreshToken(ctx, stolen)
+		assert.ErrorContains(t, err, "refresh token already used, session revoked")
+
+		// the legitimate client has to log in again
+		_, _, err = j.VerifyAccessToken(ctx, access)
+		assert.ErrorContains(t, err, "session expired or revoked")
+		_, _, err = j.RefreshToken(ctx, next)
+		assert.ErrorContains(t, err, "session expired or revoked")
+
+		// other devices are not part of the family
+		_, _, err = j.VerifyAccessToken(ctx, other)
+		assert.NoError(t, err)
+	})
+
+	t.Run("refreshing replaces the access token", func(t *testing.T) {
+		j, _ := newTestJWTUtils(t)
+		access, refresh, _ := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+		// tokens issued within the same second would be identical
+		time.Sleep(time.Second)
+
+		_, _, err := j.RefreshToken(ctx, refresh)
+		assert.NoError(t, err)
+		_, _, err = j.VerifyAccessToken(ctx, access)
+		assert.ErrorContains(t, err, "invalid access token")
+	})
+}
+
+func TestTokenType(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	j, _ := newTestJWTUtils(t)
+	access, refresh, _ := j.GenerateKeyPair(ctx, userID, "Firefox", "10.0.0.1")
+
+	_, _, err := j.VerifyAccessToken(ctx, refresh)
+	assert.ErrorContains(t, err, "invalid access token")
+
+	_, _, err = j.RefreshToken(ctx, access)
+	assert.ErrorContains(t, err, "invalid refresh token")
+
+	pending, err := j.GenerateTwoFactorToken(ctx, userID)
+	assert.NoError(t, err)
+	_, _, err = j.VerifyAccessToken(ctx, pending)
+	assert.ErrorContains(t, err, "invalid access token")
+	_, _, err = j.RefreshToken(ctx, pending)
+	assert.ErrorContains(t, err, "invalid refresh token")
+}
+
+func TestEmailChangeToken(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	j, _ := newTestJWTUtils(t)
+
+	first, err := j.GenerateEmailChangeToken(ctx, userID, "first@example.com")
+	assert.NoError(t, err)
+	second, err := j.GenerateEmailChangeToken(ctx, userID, "second@example.com")
+	assert.NoError(t, err)
+
+	_, _, err = j.VerifyEmailChangeToken(ctx, first)
+	assert.ErrorContains(t, err, "invalid email change token")
+
+	verifiedUser, email, err := j.VerifyEmailChangeToken(ctx, second)
+	if assert.NoError(t, err) {
+		assert.Equal(t, userID, verifiedUser)
+		assert.Equal(t, "second@example.com", email)
+	}
+
+	assert.NoError(t, j.DeleteEmailChangeToken(ctx, userID))
+	_, _, err = j.VerifyEmailChangeToken(ctx, second)
+	assert.ErrorContains(t, err, "token is expired or token is used")
+
+	// a verification token of the same user does not carry an address to change to
+	verification, _ := j.GenerateVerificationToken(ctx, userID)
+	_, _, err = j.VerifyEmailChangeToken(ctx, verification)
+	assert.ErrorContains(t, err, "invalid email change token")
+}
@@ -0,0 +1,440 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/pkg/redis"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	KeyStoreRedis = "redis"
+	KeyStoreDir   = "dir"
+
+	// reloadInterval limits how often an unknown kid makes the key ring re-read
+	// its store, e.g. when another instance has just rotated the keys.
+	reloadInterval = time.Minute
+
+	// keyRotationSlack is added to the token lifetime before a replaced key is retired.
+	keyRotationSlack = 24 * time.Hour
+
+	// rotationLockTTL is how long other instances wait for the instance creating
+	// the next key before they may create one themselves.
+	rotationLockTTL = time.Minute
+)
+
+type signingKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// JWK is a public key in the JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// keyStore keeps the PEM encoded signing keys by kid. Every instance has to
+// use the same store, or the tokens one signs fail on the others.
+type keyStore interface {
+	load() (map[string][]byte, error)
+	save(kid string, data []byte) error
+	remove(kid string) error
+	// lockRotation reports whether this instance may create the next key.
+	lockRotation() (bool, error)
+}
+
+// redisStore shares the keys between instances through redis.
+type redisStore struct {
+	redis *redis.Redis
+}
+
+func (s *redisStore) load() (map[string][]byte, error) {
+	stored, err := s.redis.GetSigningKeys(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string][]byte, len(stored))
+	for kid, data := range stored {
+		keys[kid] = []byte(data)
+	}
+	return keys, nil
+}
+
+func (s *redisStore) save(kid string, data []byte) error {
+	return s.redis.SaveSigningKey(context.Background(), kid, string(data))
+}
+
+func (s *redisStore) remove(kid string) error {
+	return s.redis.DeleteSigningKey(context.Background(), kid)
+}
+
+func (s *redisStore) lockRotation() (bool, error) {
+	return s.redis.LockSigningKeyRotation(context.Background(), rotationLockTTL)
+}
+
+// dirStore keeps one PEM file per key in dir. It only works for a single
+// instance, or when every instance mounts the same directory.
+type dirStore struct {
+	dir string
+}
+
+func (s *dirStore) load() (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys[strings.TrimSuffix(filepath.Base(path), ".pem")] = data
+	}
+	return keys, nil
+}
+
+func (s *dirStore) save(kid string, data []byte) error {
+	return os.WriteFile(filepath.Join(s.dir, kid+".pem"), data, 0600)
+}
+
+func (s *dirStore) remove(kid string) error {
+	if err := os.Remove(filepath.Join(s.dir, kid+".pem")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *dirStore) lockRotation() (bool, error) {
+	return true, nil
+}
+
+func newKeyStore(config *JWTConfig, redis *redis.Redis) (keyStore, error) {
+	switch config.KeyStore {
+	case KeyStoreRedis:
+		return &redisStore{redis: redis}, nil
+	case KeyStoreDir:
+		if err := os.MkdirAll(config.KeysDir, 0700); err != nil {
+			return nil, err
+		}
+		return &dirStore{dir: config.KeysDir}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key store: %s", config.KeyStore)
+	}
+}
+
+// keyRing holds the signing keys. The newest key signs new tokens. A new key
+// is created once the newest one is older than rotateAfter. A replaced key is
+// retired, i.e. no longer accepted, retireAfter after the key replacing it was
+// created, which leaves time for the tokens it signed to expire.
+type keyRing struct {
+	mu          sync.RWMutex
+	store       keyStore
+	algorithm   string
+	rotateAfter time.Duration
+	retireAfter time.Duration
+	keys        []*signingKey
+	loadedAt    time.Time
+}
+
+func newKeyRing(store keyStore, algorithm string, rotateAfter time.Duration, retireAfter time.Duration) (*keyRing, error) {
+	if algorithm != AlgorithmRS256 && algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+
+	ring := &keyRing{
+		store:       store,
+		algorithm:   algorithm,
+		rotateAfter: rotateAfter,
+		retireAfter: retireAfter,
+	}
+
+	// another instance starting at the same time may be creating the first key
+	for deadline := time.Now().Add(rotationLockTTL); ; time.Sleep(time.Second) {
+		if err := ring.Rotate(time.Now()); err != nil {
+			return nil, err
+		}
+		if len(ring.keys) > 0 || time.Now().After(deadline) {
+			break
+		}
+	}
+	if len(ring.keys) == 0 {
+		return nil, errors.New("no signing key")
+	}
+
+	return ring, nil
+}
+
+func (r *keyRing) load() error {
+	stored, err := r.store.load()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*signingKey, 0, len(stored))
+	for kid, data := range stored {
+		key, err := parseKey(kid, data)
+		if err != nil {
+			return fmt.Errorf("cannot read signing key %s: %w", kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	r.keys = keys
+	r.loadedAt = time.Now()
+
+	return nil
+}
+
+// retired reports whether keys[i] was replaced more than retireAfter ago. keys is sorted.
+func (r *keyRing) retired(keys []*signingKey, i int, now time.Time) bool {
+	return i < len(keys)-1 && now.Sub(keys[i+1].CreatedAt) >= r.retireAfter
+}
+
+// Rotate creates a new signing key when the current one is due and deletes the
+// retired keys. It also picks up the keys other instances created.
+func (r *keyRing) Rotate(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+
+	keys := make([]*signingKey, 0, len(r.keys)+1)
+	for i, key := range r.keys {
+		if !r.retired(r.keys, i, now) {
+			keys = append(keys, key)
+			continue
+		}
+		if err := r.store.remove(key.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(keys) == 0 || now.Sub(keys[len(keys)-1].CreatedAt) >= r.rotateAfter {
+		locked, err := r.store.lockRotation()
+		if err != nil {
+			return err
+		}
+		// otherwise the next rotation loads the key of the instance holding the lock
+		if locked {
+			key, err := r.generate(now)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	r.keys = keys
+
+	return nil
+}
+
+func (r *keyRing) generate(now time.Time) (*signingKey, error) {
+	var private crypto.Signer
+	var err error
+	switch r.algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newSigningKey(private, now)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{"Created": now.UTC().Format(time.RFC3339)},
+		Bytes:   der,
+	}
+	if err := r.store.save(key.ID, pem.EncodeToMemory(block)); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// signer returns the newest key, which is never retired.
+func (r *keyRing) signer() (*signingKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.keys) == 0 {
+		return nil, errors.New("no signing key")
+	}
+
+	return r.keys[len(r.keys)-1], nil
+}
+
+// lookup returns the non-retired key with the given kid.
+func (r *keyRing) lookup(kid string) (*signingKey, error) {
+	if key := r.find(kid); key != nil {
+		return key, nil
+	}
+
+	r.mu.Lock()
+	if time.Since(r.loadedAt) >= reloadInterval {
+		if err := r.load(); err != nil {
+			r.mu.Unlock()
+			return nil, err
+		}
+	}
+	r.mu.Unlock()
+
+	if key := r.find(kid); key != nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown or retired key: %s", kid)
+}
+
+func (r *keyRing) find(kid string) *signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for i, key := range r.keys {
+		if key.ID == kid && !r.retired(r.keys, i, now) {
+			return key
+		}
+	}
+
+	return nil
+}
+
+func (r *keyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	jwks := JWKS{Keys: make([]JWK, 0, len(r.keys))}
+	for i, key := range r.keys {
+		if r.retired(r.keys, i, now) {
+			continue
+		}
+
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+func parseKey(kid string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, block.Headers["Created"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid Created header: %w", err)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported key type")
+	}
+
+	key, err := newSigningKey(private, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if kid != key.ID {
+		return nil, fmt.Errorf("stored kid does not match kid %s", key.ID)
+	}
+
+	return key, nil
+}
+
+// newSigningKey derives the kid from the public key so every instance agrees on it.
+func newSigningKey(private crypto.Signer, createdAt time.Time) (*signingKey, error) {
+	var method jwt.SigningMethod
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+
+	return &signingKey{
+		ID:        base64.RawURLEncoding.EncodeToString(sum[:12]),
+		Method:    method,
+		Private:   private,
+		CreatedAt: createdAt,
+	}, nil
+}
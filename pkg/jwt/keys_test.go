@@ -0,0 +1,51 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotation(t *testing.T) {
+	store := &dirStore{dir: t.TempDir()}
+	rotateAfter := 30 * 24 * time.Hour
+	retireAfter := 2 * 24 * time.Hour
+	start := time.Now().Add(-60 * 24 * time.Hour)
+
+	ring := &keyRing{store: store, algorithm: AlgorithmEdDSA, rotateAfter: rotateAfter, retireAfter: retireAfter}
+	assert.NoError(t, ring.Rotate(start))
+	assert.Len(t, ring.keys, 1)
+	first := ring.keys[0].ID
+
+	t.Run("not due", func(t *testing.T) {
+		assert.NoError(t, ring.Rotate(start.Add(rotateAfter-time.Hour)))
+		assert.Len(t, ring.keys, 1)
+	})
+
+	t.Run("replaced key is kept until its tokens expire", func(t *testing.T) {
+		assert.NoError(t, ring.Rotate(start.Add(rotateAfter)))
+		assert.Len(t, ring.keys, 2)
+
+		// a key that is older than rotateAfter+retireAfter but was replaced recently stays
+		assert.NoError(t, ring.Rotate(start.Add(rotateAfter+retireAfter-time.Hour)))
+		assert.Len(t, ring.keys, 2)
+		assert.Equal(t, first, ring.keys[0].ID)
+
+		signer, err := ring.signer()
+		assert.NoError(t, err)
+		assert.NotEqual(t, first, signer.ID)
+	})
+
+	t.Run("retired key is removed from the store", func(t *testing.T) {
+		assert.NoError(t, ring.Rotate(start.Add(rotateAfter+retireAfter)))
+		if assert.Len(t, ring.keys, 1) {
+			assert.NotEqual(t, first, ring.keys[0].ID)
+		}
+
+		stored, err := store.load()
+		assert.NoError(t, err)
+		assert.NotContains(t, stored, first)
+		assert.Len(t, stored, 1)
+	})
+}
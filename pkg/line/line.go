@@ -0,0 +1,255 @@
+// Package line sends messages through the LINE Messaging API and reads the
+// events LINE posts to the bot's webhook.
+package line
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type Config struct {
+	// Provider selects the Client: "api" calls the Messaging API, "fake" logs
+	// messages and keeps them in memory.
+	Provider           string `env:"PROVIDER" envDefault:"fake"`
+	ChannelSecret      string `env:"CHANNEL_SECRET"`
+	ChannelAccessToken string `env:"CHANNEL_ACCESS_TOKEN"`
+	// AddFriendURL is shown to users linking their account, e.g. https://line.me/R/ti/p/@condormhub.
+	AddFriendURL string `env:"ADD_FRIEND_URL"`
+}
+
+// Message is a text, with up to 4 buttons opening a link under it.
+type Message struct {
+	Text    string
+	Actions []Action
+}
+
+type Action struct {
+	Label string
+	URI   string
+}
+
+// Client sends messages to LINE users.
+type Client interface {
+	// Push sends messages to a user at any time.
+	Push(ctx context.Context, to string, messages ...Message) error
+	// Reply answers an event with its reply token, which can be used once.
+	Reply(ctx context.Context, replyToken string, messages ...Message) error
+}
+
+func New(config Config) (Client, error) {
+	switch config.Provider {
+	case "api":
+		return NewAPIClient(config.ChannelAccessToken), nil
+	case "fake":
+		return NewFakeClient(), nil
+	default:
+		return nil, fmt.Errorf("unknown line provider: %s", config.Provider)
+	}
+}
+
+const apiURL = "https://api.line.me/v2/bot/message"
+
+// limits of the Messaging API
+const (
+	maxButtonsText  = 160
+	maxAltText      = 400
+	maxActionLabel  = 20
+	maxActions      = 4
+	maxTextLength   = 5000
+	maxMessageCount = 5
+)
+
+type APIClient struct {
+	accessToken string
+	http        *http.Client
+}
+
+func NewAPIClient(accessToken string) *APIClient {
+	return &APIClient{accessToken: accessToken, http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (c *APIClient) Push(ctx context.Context, to string, messages ...Message) error {
+	return c.post(ctx, "/push", map[string]any{"to": to, "messages": encodeMessages(messages)})
+}
+
+func (c *APIClient) Reply(ctx context.Context, replyToken string, messages ...Message) error {
+	return c.post(ctx, "/reply", map[string]any{"replyToken": replyToken, "messages": encodeMessages(messages)})
+}
+
+func (c *APIClient) post(ctx context.Context, path string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("line %s responded with status %d: %s", path, res.StatusCode, detail)
+	}
+	return nil
+}
+
+// encodeMessages turns a message with actions into a buttons template, whose
+// text is shorter; altText keeps the whole text for notifications and old clients.
+func encodeMessages(messages []Message) []map[string]any {
+	if len(messages) > maxMessageCount {
+		messages = messages[:maxMessageCount]
+	}
+
+	encoded := make([]map[string]any, len(messages))
+	for i, m := range messages {
+		if len(m.Actions) == 0 {
+			encoded[i] = map[string]any{"type": "text", "text": truncate(m.Text, maxTextLength)}
+			continue
+		}
+
+		actions := m.Actions
+		if len(actions) > maxActions {
+			actions = actions[:maxActions]
+		}
+		uriActions := make([]map[string]any, len(actions))
+		for j, a := range actions {
+			uriActions[j] = map[string]any{"type": "uri", "label": truncate(a.Label, maxActionLabel), "uri": a.URI}
+		}
+
+		encoded[i] = map[string]any{
+			"type":    "template",
+			"altText": truncate(m.Text, maxAltText),
+			"template": map[string]any{
+				"type":    "buttons",
+				"text":    truncate(m.Text, maxButtonsText),
+				"actions": uriActions,
+			},
+		}
+	}
+	return encoded
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+// fakeClientSize is how many messages FakeClient keeps, the oldest are dropped first.
+const fakeClientSize = 200
+
+// SentMessage is a message received by FakeClient. To is empty for replies.
+type SentMessage struct {
+	SentAt     time.Time
+	To         string
+	ReplyToken string
+	Message
+}
+
+// FakeClient logs messages and keeps the last ones in memory, for local
+// development and tests.
+type FakeClient struct {
+	mu       sync.Mutex
+	messages []SentMessage
+}
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{}
+}
+
+func (c *FakeClient) Push(ctx context.Context, to string, messages ...Message) error {
+	for _, m := range messages {
+		log.Printf("line message to %s: %s\n", to, m.Text)
+		c.record(SentMessage{SentAt: time.Now(), To: to, Message: m})
+	}
+	return nil
+}
+
+func (c *FakeClient) Reply(ctx context.Context, replyToken string, messages ...Message) error {
+	for _, m := range messages {
+		log.Printf("line reply to %s: %s\n", replyToken, m.Text)
+		c.record(SentMessage{SentAt: time.Now(), ReplyToken: replyToken, Message: m})
+	}
+	return nil
+}
+
+func (c *FakeClient) record(message SentMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = append(c.messages, message)
+	if len(c.messages) > fakeClientSize {
+		c.messages = c.messages[len(c.messages)-fakeClientSize:]
+	}
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (c *FakeClient) Messages() []SentMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]SentMessage(nil), c.messages...)
+}
+
+func (c *FakeClient) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = nil
+}
+
+// VerifySignature checks the X-Line-Signature header of a webhook request:
+// the base64 HMAC-SHA256 of the body with the channel secret.
+func VerifySignature(channelSecret string, body []byte, signature string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
+
+// Event is the part of a webhook event the app uses.
+type Event struct {
+	Type       string `json:"type"`
+	ReplyToken string `json:"replyToken"`
+	Source     struct {
+		Type   string `json:"type"`
+		UserID string `json:"userId"`
+	} `json:"source"`
+	Message struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+func ParseEvents(body []byte) ([]Event, error) {
+	var payload struct {
+		Events []Event `json:"events"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Events, nil
+}
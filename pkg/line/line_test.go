@@ -0,0 +1,77 @@
+package line
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"events":[]}`)
+	signature := sign("channel-secret", body)
+
+	assert.True(t, VerifySignature("channel-secret", body, signature))
+	assert.False(t, VerifySignature("other-secret", body, signature))
+	assert.False(t, VerifySignature("channel-secret", []byte(`{"events":[{}]}`), signature))
+	assert.False(t, VerifySignature("channel-secret", body, ""))
+	assert.False(t, VerifySignature("channel-secret", body, "not base64!"))
+}
+
+func TestFakeClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("push and reply", func(t *testing.T) {
+		client := NewFakeClient()
+		assert.NoError(t, client.Push(ctx, "U1", Message{Text: "first"}, Message{Text: "second", Actions: []Action{{Label: "Open", URI: "https://condormhub.example/"}}}))
+		assert.NoError(t, client.Reply(ctx, "reply-token", Message{Text: "hello"}))
+
+		messages := client.Messages()
+		if assert.Len(t, messages, 3) {
+			assert.Equal(t, "U1", messages[0].To)
+			assert.Equal(t, "first", messages[0].Text)
+			assert.Equal(t, "Open", messages[1].Actions[0].Label)
+			assert.Empty(t, messages[2].To)
+			assert.Equal(t, "reply-token", messages[2].ReplyToken)
+		}
+
+		client.Clear()
+		assert.Empty(t, client.Messages())
+	})
+
+	t.Run("keeps the last messages", func(t *testing.T) {
+		client := NewFakeClient()
+		for i := 0; i < fakeClientSize+10; i++ {
+			assert.NoError(t, client.Push(ctx, "U1", Message{Text: fmt.Sprint(i)}))
+		}
+
+		messages := client.Messages()
+		assert.Len(t, messages, fakeClientSize)
+		assert.Equal(t, "10", messages[0].Text)
+	})
+}
+
+func TestEncodeMessages(t *testing.T) {
+	text := strings.Repeat("ก", maxButtonsText+1)
+	encoded := encodeMessages([]Message{
+		{Text: "plain"},
+		{Text: text, Actions: []Action{{Label: "Open", URI: "https://condormhub.example/"}}},
+	})
+
+	assert.Equal(t, "text", encoded[0]["type"])
+	assert.Equal(t, "template", encoded[1]["type"])
+	assert.Equal(t, text, encoded[1]["altText"])
+	template := encoded[1]["template"].(map[string]any)
+	assert.Equal(t, maxButtonsText, len([]rune(template["text"].(string))))
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+func userEventsChannel(userID uuid.UUID) string {
+	return fmt.Sprintf("user_events:%s", userID)
+}
+
+// PublishUserEvent sends payload to every subscription of the user on any server instance.
+func (r *Redis) PublishUserEvent(ctx context.Context, userID uuid.UUID, payload string) error {
+	return r.client.Publish(ctx, userEventsChannel(userID), payload).Err()
+}
+
+// SubscribeUserEvents returns the payloads published for the user until ctx is done.
+func (r *Redis) SubscribeUserEvents(ctx context.Context, userID uuid.UUID) (<-chan string, error) {
+	pubsub := r.client.Subscribe(ctx, userEventsChannel(userID))
+
+	// wait for the confirmation so no event published after returning is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan string)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case events <- message.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
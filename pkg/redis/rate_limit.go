@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// hitRateLimit counts a request in a fixed window and returns the count and the
+// milliseconds until the window resets. The window starts with the first request.
+var hitRateLimit = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {count, redis.call("PTTL", KEYS[1])}
+`)
+
+// recordLoginFailure counts a failed login of an account. From the threshold-th
+// failure on the account is locked, twice as long for every further failure up to
+// a maximum. It returns the lock duration in milliseconds, or 0 when not locked.
+var recordLoginFailure = redis.NewScript(`
+local failures = redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+local over = failures - tonumber(ARGV[1])
+if over < 0 then
+	return 0
+end
+local lock = math.min(tonumber(ARGV[2]) * 2 ^ over, tonumber(ARGV[3]))
+lock = math.floor(lock)
+redis.call("SET", KEYS[2], failures, "PX", lock)
+return lock
+`)
+
+func rateLimitKey(bucket string, key string) string {
+	return fmt.Sprintf("rate_limit:%s:%s", bucket, key)
+}
+
+func loginFailuresKey(account string) string {
+	return fmt.Sprintf("login_failures:%s", account)
+}
+
+func loginLockoutKey(account string) string {
+	return fmt.Sprintf("login_lockout:%s", account)
+}
+
+// HitRateLimit counts a request against key in bucket and returns how many requests
+// the window has seen and when it resets.
+func (r *Redis) HitRateLimit(ctx context.Context, bucket string, key string, window time.Duration) (int64, time.Duration, error) {
+	res, err := hitRateLimit.Run(ctx, r.client, []string{rateLimitKey(bucket, key)}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return res[0], time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// GetLoginLockout returns how long the account stays locked, or 0 when it is not.
+func (r *Redis) GetLoginLockout(ctx context.Context, account string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, loginLockoutKey(account)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// a missing key has a negative ttl
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+// RecordLoginFailure counts a failed login and returns how long the account is now locked.
+// Failures are forgotten once there has been none for window.
+func (r *Redis) RecordLoginFailure(ctx context.Context, account string, threshold int, baseLockout time.Duration, maxLockout time.Duration, window time.Duration) (time.Duration, error) {
+	keys := []string{loginFailuresKey(account), loginLockoutKey(account)}
+	lock, err := recordLoginFailure.Run(ctx, r.client, keys, threshold, baseLockout.Milliseconds(), maxLockout.Milliseconds(), window.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(lock) * time.Millisecond, nil
+}
+
+// ResetLoginFailures forgets the failed logins of an account after it logged in successfully.
+func (r *Redis) ResetLoginFailures(ctx context.Context, account string) error {
+	return r.client.Del(ctx, loginFailuresKey(account), loginLockoutKey(account)).Err()
+}
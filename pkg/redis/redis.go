@@ -10,6 +10,9 @@ type Config struct {
 	RefreshTokenExpireHrs int    `env:"REFRESH_TOKEN_EXPIRE_HRS"`
 }
 
+// Nil is returned when a key does not exist, e.g. because the token expired or was revoked.
+const Nil = redis.Nil
+
 type Redis struct {
 	client *redis.Client
 }
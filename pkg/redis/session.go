@@ -0,0 +1,189 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Session is the metadata of one logged in device. Its ID is the jti of the
+// access and refresh tokens issued to that device, so a session is also the
+// family of its refresh tokens.
+type Session struct {
+	ID         string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+}
+
+// touchSession only updates a session that still exists, so a request racing
+// with a revocation cannot bring the session back without a ttl.
+var touchSession = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], "lastSeenAt", ARGV[1])
+end
+return 0
+`)
+
+// rotateRefreshToken advances the refresh token generation of a session when the
+// presented generation is the current one. A session that no longer exists returns
+// -1 and an older generation, i.e. a token that was already used, returns -2.
+var rotateRefreshToken = redis.NewScript(`
+local generation = redis.call("HGET", KEYS[1], "generation")
+if not generation then
+	return -1
+end
+if tonumber(generation) ~= tonumber(ARGV[1]) then
+	return -2
+end
+return redis.call("HINCRBY", KEYS[1], "generation", 1)
+`)
+
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+func sessionKey(userID uuid.UUID, sessionID string) string {
+	return fmt.Sprintf("session:%s:%s", userID, sessionID)
+}
+
+func sessionSetKey(userID uuid.UUID) string {
+	return fmt.Sprintf("sessions:%s", userID)
+}
+
+func (r *Redis) CreateSession(ctx context.Context, userID uuid.UUID, session Session, ttl time.Duration) error {
+	key := sessionKey(userID, session.ID)
+	setKey := sessionSetKey(userID)
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, map[string]interface{}{
+			"userAgent":  session.UserAgent,
+			"ip":         session.IP,
+			"createdAt":  session.CreatedAt.Unix(),
+			"lastSeenAt": session.LastSeenAt.Unix(),
+			"generation": 0,
+		})
+		pipe.Expire(ctx, key, ttl)
+		pipe.SAdd(ctx, setKey, session.ID)
+		pipe.Expire(ctx, setKey, ttl)
+		return nil
+	})
+
+	return err
+}
+
+// ExtendSession pushes the expiry of a session forward, e.g. after its tokens were refreshed.
+func (r *Redis) ExtendSession(ctx context.Context, userID uuid.UUID, sessionID string, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Expire(ctx, sessionKey(userID, sessionID), ttl)
+		pipe.Expire(ctx, sessionSetKey(userID), ttl)
+		return nil
+	})
+
+	return err
+}
+
+// RotateRefreshToken marks the refresh token of the given generation as used and
+// returns the generation of the next one.
+func (r *Redis) RotateRefreshToken(ctx context.Context, userID uuid.UUID, sessionID string, generation int) (int, error) {
+	next, err := rotateRefreshToken.Run(ctx, r.client, []string{sessionKey(userID, sessionID)}, generation).Int()
+	if err != nil {
+		return 0, err
+	}
+
+	switch next {
+	case -1:
+		return 0, Nil
+	case -2:
+		return 0, ErrRefreshTokenReused
+	}
+
+	return next, nil
+}
+
+func (r *Redis) TouchSession(ctx context.Context, userID uuid.UUID, sessionID string, lastSeenAt time.Time) error {
+	return touchSession.Run(ctx, r.client, []string{sessionKey(userID, sessionID)}, lastSeenAt.Unix()).Err()
+}
+
+func (r *Redis) GetSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	setKey := sessionSetKey(userID)
+
+	ids, err := r.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		values, err := r.client.HGetAll(ctx, sessionKey(userID, id)).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		// the session has expired, forget about it
+		if len(values) == 0 {
+			if err := r.client.SRem(ctx, setKey, id).Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var createdAt, lastSeenAt int64
+		fmt.Sscan(values["createdAt"], &createdAt)
+		fmt.Sscan(values["lastSeenAt"], &lastSeenAt)
+
+		sessions = append(sessions, Session{
+			ID:         id,
+			UserAgent:  values["userAgent"],
+			IP:         values["ip"],
+			CreatedAt:  time.Unix(createdAt, 0),
+			LastSeenAt: time.Unix(lastSeenAt, 0),
+		})
+	}
+
+	return sessions, nil
+}
+
+// SessionExists reports whether a session has neither expired nor been revoked.
+func (r *Redis) SessionExists(ctx context.Context, userID uuid.UUID, sessionID string) (bool, error) {
+	count, err := r.client.Exists(ctx, sessionKey(userID, sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count == 1, nil
+}
+
+// DeleteSession removes a session together with its access token, which ends its refresh token family.
+func (r *Redis) DeleteSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx,
+			sessionKey(userID, sessionID),
+			fmt.Sprintf("access_token:%s:%s", userID, sessionID),
+		)
+		pipe.SRem(ctx, sessionSetKey(userID), sessionID)
+		return nil
+	})
+
+	return err
+}
+
+// DeleteAllSessions ends every session of the user.
+func (r *Redis) DeleteAllSessions(ctx context.Context, userID uuid.UUID) error {
+	setKey := sessionSetKey(userID)
+
+	ids, err := r.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(ids)*2+1)
+	for _, id := range ids {
+		keys = append(keys, sessionKey(userID, id), fmt.Sprintf("access_token:%s:%s", userID, id))
+	}
+	keys = append(keys, setKey)
+
+	return r.client.Del(ctx, keys...).Err()
+}
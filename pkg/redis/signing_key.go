@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	signingKeysKey       = "jwt_signing_keys"
+	signingKeyRotateLock = "jwt_signing_keys:rotate"
+)
+
+// GetSigningKeys returns the PEM encoded JWT signing keys by kid.
+func (r *Redis) GetSigningKeys(ctx context.Context) (map[string]string, error) {
+	return r.client.HGetAll(ctx, signingKeysKey).Result()
+}
+
+func (r *Redis) SaveSigningKey(ctx context.Context, kid string, key string) error {
+	return r.client.HSet(ctx, signingKeysKey, kid, key).Err()
+}
+
+func (r *Redis) DeleteSigningKey(ctx context.Context, kid string) error {
+	return r.client.HDel(ctx, signingKeysKey, kid).Err()
+}
+
+// LockSigningKeyRotation reports whether the caller may create the next signing key,
+// so instances rotating at the same time do not each create one.
+func (r *Redis) LockSigningKeyRotation(ctx context.Context, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, signingKeyRotateLock, 1, ttl).Result()
+}
@@ -8,8 +8,8 @@ import (
 	"github.com/google/uuid"
 )
 
-func (r *Redis) SetAccessToken(ctx context.Context, userId uuid.UUID, accessToken string, ttl time.Duration) error {
-	accessTokenKey := fmt.Sprintf("access_token:%s", userId)
+func (r *Redis) SetAccessToken(ctx context.Context, userId uuid.UUID, sessionID string, accessToken string, ttl time.Duration) error {
+	accessTokenKey := fmt.Sprintf("access_token:%s:%s", userId, sessionID)
 
 	err := r.client.Set(ctx, accessTokenKey, accessToken, ttl).Err()
 	if err != nil {
@@ -19,10 +19,21 @@ func (r *Redis) SetAccessToken(ctx context.Context, userId uuid.UUID, accessToke
 	return nil
 }
 
-func (r *Redis) SetRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, ttl time.Duration) error {
-	refreshTokenKey := fmt.Sprintf("refresh_token:%s", userId)
+func (r *Redis) GetAccessToken(ctx context.Context, userId uuid.UUID, sessionID string) (string, error) {
+	accessTokenKey := fmt.Sprintf("access_token:%s:%s", userId, sessionID)
 
-	err := r.client.Set(ctx, refreshTokenKey, refreshToken, ttl).Err()
+	accessToken, err := r.client.Get(ctx, accessTokenKey).Result()
+	if err != nil {
+		return "", err
+	}
+
+	return accessToken, nil
+}
+
+func (r *Redis) SetVerificationToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	verificationTokenKey := fmt.Sprintf("verification_token:%s", userID)
+
+	err := r.client.Set(ctx, verificationTokenKey, token, ttl).Err()
 	if err != nil {
 		return err
 	}
@@ -30,38 +41,54 @@ func (r *Redis) SetRefreshToken(ctx context.Context, userId uuid.UUID, refreshTo
 	return nil
 }
 
-func (r *Redis) GetAccessToken(ctx context.Context, userId uuid.UUID) (string, error) {
-	accessTokenKey := fmt.Sprintf("access_token:%s", userId)
+func (r *Redis) GetVerificationToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	verificationTokenKey := fmt.Sprintf("verification_token:%s", userID)
 
-	accessToken, err := r.client.Get(ctx, accessTokenKey).Result()
+	token, err := r.client.Get(ctx, verificationTokenKey).Result()
 	if err != nil {
 		return "", err
 	}
 
-	return accessToken, nil
+	return token, nil
 }
 
-func (r *Redis) GetRefreshToken(ctx context.Context, userId uuid.UUID) (string, error) {
-	refreshTokenKey := fmt.Sprintf("refresh_token:%s", userId)
+func (r *Redis) DeleteVerificationToken(ctx context.Context, userID uuid.UUID) error {
+	verificationTokenKey := fmt.Sprintf("verification_token:%s", userID)
 
-	refreshToken, err := r.client.Get(ctx, refreshTokenKey).Result()
+	err := r.client.Del(ctx, verificationTokenKey).Err()
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return refreshToken, nil
+	return nil
 }
 
-func (r *Redis) DeleteAccessTokenAndRefreshToken(ctx context.Context, userId uuid.UUID) error {
-	accessTokenKey := fmt.Sprintf("access_token:%s", userId)
+func (r *Redis) SetResetToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	resetTokenKey := fmt.Sprintf("reset_token:%s", userID)
 
-	err := r.client.Del(ctx, accessTokenKey).Err()
+	err := r.client.Set(ctx, resetTokenKey, token, ttl).Err()
 	if err != nil {
 		return err
 	}
 
-	refreshTokenKey := fmt.Sprintf("refresh_token:%s", userId)
-	err = r.client.Del(ctx, refreshTokenKey).Err()
+	return nil
+}
+
+func (r *Redis) GetResetToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	resetTokenKey := fmt.Sprintf("reset_token:%s", userID)
+
+	token, err := r.client.Get(ctx, resetTokenKey).Result()
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (r *Redis) DeleteResetToken(ctx context.Context, userID uuid.UUID) error {
+	resetTokenKey := fmt.Sprintf("reset_token:%s", userID)
+
+	err := r.client.Del(ctx, resetTokenKey).Err()
 	if err != nil {
 		return err
 	}
@@ -69,10 +96,10 @@ func (r *Redis) DeleteAccessTokenAndRefreshToken(ctx context.Context, userId uui
 	return nil
 }
 
-func (r *Redis) SetVerificationToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
-	verificationTokenKey := fmt.Sprintf("verification_token:%s", userID)
+func (r *Redis) SetTwoFactorToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	twoFactorTokenKey := fmt.Sprintf("two_factor_token:%s", userID)
 
-	err := r.client.Set(ctx, verificationTokenKey, token, ttl).Err()
+	err := r.client.Set(ctx, twoFactorTokenKey, token, ttl).Err()
 	if err != nil {
 		return err
 	}
@@ -80,10 +107,10 @@ func (r *Redis) SetVerificationToken(ctx context.Context, userID uuid.UUID, toke
 	return nil
 }
 
-func (r *Redis) GetVerificationToken(ctx context.Context, userID uuid.UUID) (string, error) {
-	verificationTokenKey := fmt.Sprintf("verification_token:%s", userID)
+func (r *Redis) GetTwoFactorToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	twoFactorTokenKey := fmt.Sprintf("two_factor_token:%s", userID)
 
-	token, err := r.client.Get(ctx, verificationTokenKey).Result()
+	token, err := r.client.Get(ctx, twoFactorTokenKey).Result()
 	if err != nil {
 		return "", err
 	}
@@ -91,10 +118,11 @@ func (r *Redis) GetVerificationToken(ctx context.Context, userID uuid.UUID) (str
 	return token, nil
 }
 
-func (r *Redis) DeleteVerificationToken(ctx context.Context, userID uuid.UUID) error {
-	verificationTokenKey := fmt.Sprintf("verification_token:%s", userID)
+func (r *Redis) DeleteTwoFactorToken(ctx context.Context, userID uuid.UUID) error {
+	twoFactorTokenKey := fmt.Sprintf("two_factor_token:%s", userID)
+	twoFactorAttemptsKey := fmt.Sprintf("two_factor_attempts:%s", userID)
 
-	err := r.client.Del(ctx, verificationTokenKey).Err()
+	err := r.client.Del(ctx, twoFactorTokenKey, twoFactorAttemptsKey).Err()
 	if err != nil {
 		return err
 	}
@@ -102,10 +130,28 @@ func (r *Redis) DeleteVerificationToken(ctx context.Context, userID uuid.UUID) e
 	return nil
 }
 
-func (r *Redis) SetResetToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
-	resetTokenKey := fmt.Sprintf("reset_token:%s", userID)
+// IncrTwoFactorAttempts counts the failed second factor checks of the pending login.
+func (r *Redis) IncrTwoFactorAttempts(ctx context.Context, userID uuid.UUID, ttl time.Duration) (int64, error) {
+	twoFactorAttemptsKey := fmt.Sprintf("two_factor_attempts:%s", userID)
 
-	err := r.client.Set(ctx, resetTokenKey, token, ttl).Err()
+	attempts, err := r.client.Incr(ctx, twoFactorAttemptsKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if attempts == 1 {
+		if err := r.client.Expire(ctx, twoFactorAttemptsKey, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return attempts, nil
+}
+
+func (r *Redis) SetOIDCState(ctx context.Context, state string, value string, ttl time.Duration) error {
+	oidcStateKey := fmt.Sprintf("oidc_state:%s", state)
+
+	err := r.client.Set(ctx, oidcStateKey, value, ttl).Err()
 	if err != nil {
 		return err
 	}
@@ -113,10 +159,33 @@ func (r *Redis) SetResetToken(ctx context.Context, userID uuid.UUID, token strin
 	return nil
 }
 
-func (r *Redis) GetResetToken(ctx context.Context, userID uuid.UUID) (string, error) {
-	resetTokenKey := fmt.Sprintf("reset_token:%s", userID)
+// TakeOIDCState returns the value of a state and deletes it, so a state can only be used once.
+func (r *Redis) TakeOIDCState(ctx context.Context, state string) (string, error) {
+	oidcStateKey := fmt.Sprintf("oidc_state:%s", state)
 
-	token, err := r.client.Get(ctx, resetTokenKey).Result()
+	value, err := r.client.GetDel(ctx, oidcStateKey).Result()
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+func (r *Redis) SetEmailChangeToken(ctx context.Context, userID uuid.UUID, token string, ttl time.Duration) error {
+	emailChangeTokenKey := fmt.Sprintf("email_change_token:%s", userID)
+
+	err := r.client.Set(ctx, emailChangeTokenKey, token, ttl).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Redis) GetEmailChangeToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	emailChangeTokenKey := fmt.Sprintf("email_change_token:%s", userID)
+
+	token, err := r.client.Get(ctx, emailChangeTokenKey).Result()
 	if err != nil {
 		return "", err
 	}
@@ -124,13 +193,114 @@ func (r *Redis) GetResetToken(ctx context.Context, userID uuid.UUID) (string, er
 	return token, nil
 }
 
-func (r *Redis) DeleteResetToken(ctx context.Context, userID uuid.UUID) error {
-	resetTokenKey := fmt.Sprintf("reset_token:%s", userID)
+func (r *Redis) DeleteEmailChangeToken(ctx context.Context, userID uuid.UUID) error {
+	emailChangeTokenKey := fmt.Sprintf("email_change_token:%s", userID)
 
-	err := r.client.Del(ctx, resetTokenKey).Err()
+	err := r.client.Del(ctx, emailChangeTokenKey).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Redis) SetPhoneOTP(ctx context.Context, userID uuid.UUID, value string, ttl time.Duration) error {
+	phoneOTPKey := fmt.Sprintf("phone_otp:%s", userID)
+	phoneOTPAttemptsKey := fmt.Sprintf("phone_otp_attempts:%s", userID)
+
+	// a new code comes with a fresh number of attempts
+	err := r.client.Del(ctx, phoneOTPAttemptsKey).Err()
+	if err != nil {
+		return err
+	}
+
+	err = r.client.Set(ctx, phoneOTPKey, value, ttl).Err()
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (r *Redis) GetPhoneOTP(ctx context.Context, userID uuid.UUID) (string, error) {
+	phoneOTPKey := fmt.Sprintf("phone_otp:%s", userID)
+
+	value, err := r.client.Get(ctx, phoneOTPKey).Result()
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+func (r *Redis) DeletePhoneOTP(ctx context.Context, userID uuid.UUID) error {
+	phoneOTPKey := fmt.Sprintf("phone_otp:%s", userID)
+	phoneOTPAttemptsKey := fmt.Sprintf("phone_otp_attempts:%s", userID)
+
+	err := r.client.Del(ctx, phoneOTPKey, phoneOTPAttemptsKey).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IncrPhoneOTPAttempts counts the wrong codes sent for the pending phone verification.
+func (r *Redis) IncrPhoneOTPAttempts(ctx context.Context, userID uuid.UUID, ttl time.Duration) (int64, error) {
+	phoneOTPAttemptsKey := fmt.Sprintf("phone_otp_attempts:%s", userID)
+
+	attempts, err := r.client.Incr(ctx, phoneOTPAttemptsKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if attempts == 1 {
+		if err := r.client.Expire(ctx, phoneOTPAttemptsKey, ttl).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return attempts, nil
+}
+
+// SetLineLinkCode stores a code that links the LINE user who sends it to the
+// bot to userID. A new code replaces the previous one of the user.
+func (r *Redis) SetLineLinkCode(ctx context.Context, userID uuid.UUID, code string, ttl time.Duration) error {
+	userKey := fmt.Sprintf("line_link_user:%s", userID)
+
+	previous, err := r.client.Get(ctx, userKey).Result()
+	if err != nil && err != Nil {
+		return err
+	}
+	if err == nil {
+		if err := r.client.Del(ctx, fmt.Sprintf("line_link:%s", previous)).Err(); err != nil {
+			return err
+		}
+	}
+
+	err = r.client.Set(ctx, fmt.Sprintf("line_link:%s", code), userID.String(), ttl).Err()
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, userKey, code, ttl).Err()
+}
+
+// TakeLineLinkCode returns the user of a code and deletes the code, so it is only used once.
+func (r *Redis) TakeLineLinkCode(ctx context.Context, code string) (uuid.UUID, error) {
+	value, err := r.client.GetDel(ctx, fmt.Sprintf("line_link:%s", code)).Result()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := r.client.Del(ctx, fmt.Sprintf("line_link_user:%s", userID)).Err(); err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
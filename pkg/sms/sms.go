@@ -0,0 +1,39 @@
+// Package sms sends text messages, e.g. one-time codes, through a configurable provider.
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+type Config struct {
+	// Provider selects the Sender. Only "log" exists so far, which prints messages instead of sending them.
+	Provider string `env:"PROVIDER" envDefault:"log"`
+}
+
+// Sender sends a text message to a phone number.
+type Sender interface {
+	Send(ctx context.Context, to string, message string) error
+}
+
+func New(config Config) (Sender, error) {
+	switch config.Provider {
+	case "log":
+		return NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider: %s", config.Provider)
+	}
+}
+
+// LogSender writes messages to the log, for local development.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, to string, message string) error {
+	log.Printf("sms to %s: %s\n", to, message)
+	return nil
+}
@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 key of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// the last 6 digits of the 8 digit RFC 6238 test vectors
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+
+	_, err := Code("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	for offset := int64(-skew); offset <= skew; offset++ {
+		code, _ := Code(rfcSecret, current+offset)
+		step, ok := Validate(rfcSecret, code, now)
+		assert.True(t, ok, offset)
+		assert.Equal(t, current+offset, step)
+	}
+
+	for _, offset := range []int64{-skew - 1, skew + 1} {
+		code, _ := Code(rfcSecret, current+offset)
+		_, ok := Validate(rfcSecret, code, now)
+		assert.False(t, ok, offset)
+	}
+
+	_, ok := Validate(rfcSecret, "050 471", now)
+	assert.True(t, ok, "spaces are ignored")
+	_, ok = Validate(rfcSecret, "50471", now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(ProvisioningURI("ConDormHub", "somchai@example.com", "JBSWY3DPEHPK3PXP"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "totp", uri.Host)
+		assert.Equal(t, "/ConDormHub:somchai@example.com", uri.Path)
+		assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+		assert.Equal(t, "6", uri.Query().Get("digits"))
+		assert.Equal(t, "30", uri.Query().Get("period"))
+	}
+}
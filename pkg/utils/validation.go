@@ -5,6 +5,7 @@ import (
 	"regexp"
 
 	"github.com/PitiNarak/condormhub-backend/internal/core/domain"
+	"github.com/PitiNarak/condormhub-backend/internal/core/policy"
 	"github.com/go-playground/validator/v10"
 	"github.com/yokeTH/go-pkg/apperror"
 )
@@ -42,14 +43,16 @@ func ValidateRole(fl validator.FieldLevel) bool {
 func ValidateUserForReview(user *domain.User, history *domain.LeasingHistory, create bool) error {
 	if create && history.ReviewFlag {
 		return apperror.BadRequestError(errors.New("review already exist"), "review already exist")
+	} else if create && history.Review != nil && history.Review.Removed {
+		return apperror.ForbiddenError(errors.New("review removed"), "Your review was removed by our moderators")
 	} else if !create && !history.ReviewFlag {
 		return apperror.BadRequestError(errors.New("review not exist"), "review not exist")
 	}
 	if user.Role == domain.LessorRole {
 		return apperror.UnauthorizedError(errors.New("user is unauthorized"), "user is unauthorized")
 	}
-	if history.LesseeID != user.ID && user.Role != domain.AdminRole {
-		return apperror.UnauthorizedError(errors.New("user is unauthorized"), "user is unauthorized")
+	if err := policy.AuthorizeOwner(user, history.LesseeID, domain.PermissionReviewModerate); err != nil {
+		return apperror.UnauthorizedError(err, "user is unauthorized")
 	}
 	return nil
 }
@@ -0,0 +1,148 @@
+// Package webhook signs and posts event payloads to endpoints registered by users.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type Config struct {
+	// Timeout bounds a single delivery attempt, including reading the response.
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"10s"`
+	// AllowLocal accepts http:// endpoints and private addresses, for the local test receiver.
+	AllowLocal bool `env:"ALLOW_LOCAL" envDefault:"false"`
+}
+
+const (
+	HeaderEvent     = "X-Condormhub-Event"
+	HeaderDelivery  = "X-Condormhub-Delivery"
+	HeaderTimestamp = "X-Condormhub-Timestamp"
+	HeaderSignature = "X-Condormhub-Signature"
+)
+
+// maxResponseBody is how much of the response is kept for the delivery log.
+const maxResponseBody = 1024
+
+var errPrivateAddress = errors.New("endpoint resolves to a private address")
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns "sha256=" followed by the hex HMAC-SHA256 of "timestamp.body".
+// Signing the timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Delivery is a payload to post to one endpoint.
+type Delivery struct {
+	ID     string
+	URL    string
+	Secret string
+	Event  string
+	Body   []byte
+}
+
+type Response struct {
+	StatusCode int
+	Body       string
+}
+
+// Sender posts a delivery. The response is returned when the endpoint answered,
+// even if the error is not nil because the status was not 2xx.
+type Sender interface {
+	Send(delivery *Delivery) (*Response, error)
+}
+
+// Client posts deliveries over HTTP. Redirects are not followed and, unless
+// AllowLocal is set, private addresses are refused when dialing so a hostname
+// cannot be pointed at the internal network after it was registered.
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(config Config) *Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowLocal {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	return &Client{
+		http: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (c *Client) Send(delivery *Delivery) (*Response, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ConDormHub-Webhook/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Body))
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	// the body is stored in a text column, which cannot hold invalid UTF-8 or NUL
+	response := &Response{StatusCode: res.StatusCode, Body: strings.ReplaceAll(strings.ToValidUTF8(string(body), ""), "\x00", "")}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return response, fmt.Errorf("endpoint responded with status %d", res.StatusCode)
+	}
+	return response, nil
+}
+
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
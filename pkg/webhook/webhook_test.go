@@ -0,0 +1,89 @@
+package webhook_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook"
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook/webhooktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"contract.signed"}`)
+	signature := webhook.Sign("whsec_test", 1700000000, body)
+
+	assert.True(t, strings.HasPrefix(signature, "sha256="))
+	assert.True(t, webhook.Verify("whsec_test", 1700000000, body, signature))
+	assert.False(t, webhook.Verify("whsec_other", 1700000000, body, signature))
+	assert.False(t, webhook.Verify("whsec_test", 1700000001, body, signature), "a replayed delivery with a new timestamp must not verify")
+	assert.False(t, webhook.Verify("whsec_test", 1700000000, []byte(`{"event":"order.paid"}`), signature))
+}
+
+func TestClient(t *testing.T) {
+	delivery := func(url string, secret string) *webhook.Delivery {
+		return &webhook.Delivery{ID: "delivery-1", URL: url, Secret: secret, Event: "contract.signed", Body: []byte(`{"id":"1"}`)}
+	}
+
+	t.Run("signed delivery", func(t *testing.T) {
+		receiver := webhooktest.NewServer("whsec_test")
+		defer receiver.Close()
+
+		client := webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})
+		res, err := client.Send(delivery(receiver.URL, "whsec_test"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		deliveries := receiver.Deliveries()
+		if assert.Len(t, deliveries, 1) {
+			assert.True(t, deliveries[0].Valid)
+			assert.Equal(t, "delivery-1", deliveries[0].ID)
+			assert.Equal(t, "contract.signed", deliveries[0].Event)
+			assert.Equal(t, `{"id":"1"}`, string(deliveries[0].Body))
+			assert.WithinDuration(t, time.Now(), time.Unix(deliveries[0].Timestamp, 0), time.Minute)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		receiver := webhooktest.NewServer("whsec_test")
+		defer receiver.Close()
+
+		client := webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})
+		res, err := client.Send(delivery(receiver.URL, "whsec_old"))
+		assert.Error(t, err)
+		if assert.NotNil(t, res) {
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		}
+	})
+
+	t.Run("private address", func(t *testing.T) {
+		receiver := webhooktest.NewServer("")
+		defer receiver.Close()
+
+		client := webhook.NewClient(webhook.Config{Timeout: time.Second})
+		for _, url := range []string{receiver.URL, strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1)} {
+			res, err := client.Send(delivery(url, "whsec_test"))
+			assert.ErrorContains(t, err, "private address", url)
+			assert.Nil(t, res)
+		}
+		assert.Empty(t, receiver.Deliveries())
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		receiver := webhooktest.NewServer("")
+		defer receiver.Close()
+		redirector := httptest.NewServer(http.RedirectHandler(receiver.URL, http.StatusTemporaryRedirect))
+		defer redirector.Close()
+
+		client := webhook.NewClient(webhook.Config{Timeout: time.Second, AllowLocal: true})
+		res, err := client.Send(delivery(redirector.URL, "whsec_test"))
+		assert.Error(t, err)
+		if assert.NotNil(t, res) {
+			assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+		}
+		assert.Empty(t, receiver.Deliveries())
+	})
+}
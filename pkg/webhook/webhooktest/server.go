@@ -0,0 +1,123 @@
+// Package webhooktest is a local webhook endpoint for development and tests.
+// It records every delivery it receives and checks its signature.
+package webhooktest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PitiNarak/condormhub-backend/pkg/webhook"
+)
+
+// Delivery is a request received by the server.
+type Delivery struct {
+	ReceivedAt time.Time
+	ID         string
+	Event      string
+	Timestamp  int64
+	Signature  string
+	Body       []byte
+	// Valid is whether the signature matched the secret of the server, it is
+	// always true when the server has no secret.
+	Valid bool
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	secret     string
+	status     int
+	deliveries []Delivery
+	onDelivery func(Delivery)
+}
+
+// NewServer starts an endpoint on a random local port. Deliveries signed with
+// another secret are answered with 401, an empty secret accepts every delivery.
+func NewServer(secret string) *Server {
+	s := NewUnstartedServer(secret)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns an endpoint that is not listening yet, so its Listener can be replaced.
+func NewUnstartedServer(secret string) *Server {
+	s := &Server{secret: secret, status: http.StatusNoContent}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.handleDelivery))
+	return s
+}
+
+// SetSecret changes the secret the next deliveries are checked with.
+func (s *Server) SetSecret(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secret = secret
+}
+
+// RespondWith changes the status of the next answers, e.g. 500 to exercise retries.
+func (s *Server) RespondWith(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// OnDelivery calls f with every delivery received from now on.
+func (s *Server) OnDelivery(f func(Delivery)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDelivery = f
+}
+
+// Deliveries returns the deliveries received so far, oldest first.
+func (s *Server) Deliveries() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Delivery(nil), s.deliveries...)
+}
+
+func (s *Server) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = nil
+}
+
+func (s *Server) handleDelivery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+
+	s.mu.Lock()
+	delivery := Delivery{
+		ReceivedAt: time.Now(),
+		ID:         r.Header.Get(webhook.HeaderDelivery),
+		Event:      r.Header.Get(webhook.HeaderEvent),
+		Timestamp:  timestamp,
+		Signature:  r.Header.Get(webhook.HeaderSignature),
+		Body:       body,
+	}
+	delivery.Valid = s.secret == "" || webhook.Verify(s.secret, timestamp, body, delivery.Signature)
+	s.deliveries = append(s.deliveries, delivery)
+	status, onDelivery := s.status, s.onDelivery
+	s.mu.Unlock()
+
+	if onDelivery != nil {
+		onDelivery(delivery)
+	}
+	if !delivery.Valid {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(status)
+}